@@ -3,14 +3,20 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/accelerator"
 	DSCtx "github.com/nano-gpu/nano-gpu-scheduler/pkg/context"
 	"github.com/nano-gpu/nano-gpu-scheduler/pkg/controller"
 	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/metrics"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/prometheus"
 	"github.com/nano-gpu/nano-gpu-scheduler/pkg/routes"
 	"github.com/nano-gpu/nano-gpu-scheduler/pkg/scheduler"
 	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
@@ -18,8 +24,14 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	log "k8s.io/klog/v2"
 )
@@ -28,15 +40,69 @@ const RecommendedKubeConfigPathEnv = "KUBECONFIG"
 
 var (
 	clientset         *kubernetes.Clientset
+	restConfig        *rest.Config
 	resyncPeriod      = 30 * time.Second
 	PriorityAlgorithm string
+	UsagePushTokenFile string
+	FederationTokenFile string
+	StatusPort         string
+	AllocationObjects  bool
+	Predictor         string
 	PolicyConfigPath  string
 	DefaultPolicyConfigPath = "/data/policy.yaml"
 	PrometheusUrl     string
 	InstancePort      string
 	SyncPeriod        time.Duration
 	isLoadSchedule    bool
-
+	enableElasticQuota bool
+	QuotaReclaimPeriod time.Duration
+	ReservedPercent       int
+	ReservedPriorityClass string
+	PriorityAware         bool
+	StarvationTimeout     time.Duration
+	OverloadThreshold     float64
+	OverloadDuration      time.Duration
+	OverloadAction        string
+	DefragMode            string
+	DefragPeriod          time.Duration
+	InterferenceWeight    int
+	UsageSmoothingAlpha   float64
+	UsageSpikeThreshold   float64
+	StalenessWindow       time.Duration
+	StalePolicy           string
+	ThermalThreshold      float64
+	RTCRShape             string
+	NamespaceRaters       string
+	HealthSyncPeriod      time.Duration
+	HealthMetrics         controller.HealthMetrics
+	RemediationPeriod     time.Duration
+	RecreateBarePods      bool
+	MIGReconfigurePeriod  time.Duration
+	AutoscalerPeriod      time.Duration
+	KueueUsagePeriod      time.Duration
+	ElasticPeriod         time.Duration
+	WorkloadProfiles      bool
+	ExtenderProfilesPath  string
+	SchedulerNames        string
+	FederationHub         bool
+	ShardOptions          controller.ShardOptions
+	ShardSelector         string
+	ShadowPolicyConfigPath string
+	ShadowPriority        string
+	ExperimentPolicyConfigPath string
+	ExperimentPriority    string
+	ExperimentNamespaces  string
+	ExperimentPercent     int
+	AutoscalerOptions     controller.AutoscalerOptions
+	AutoscalerResource    string
+	StuckPodTimeout       time.Duration
+	ReleaseStuckPods      bool
+	RequeuePeriod         time.Duration
+	RequeueAnnotate       bool
+	OrphanGCPeriod        time.Duration
+	ServerOptions         routes.ServerOptions
+	KubeAPIQPS            float64
+	KubeAPIBurst          int
 )
 
 func initKubeClient() {
@@ -48,10 +114,13 @@ func initKubeClient() {
 	}
 
 	// Get kubernetes config.
-	restConfig, err := clientcmd.BuildConfigFromFlags("", kubeConfig)
+	var err error
+	restConfig, err = clientcmd.BuildConfigFromFlags("", kubeConfig)
 	if err != nil {
 		log.Fatalf("Error building kubeconfig: %s", err.Error())
 	}
+	restConfig.QPS = float32(KubeAPIQPS)
+	restConfig.Burst = KubeAPIBurst
 
 	// create the clientset
 	clientset, err = kubernetes.NewForConfig(restConfig)
@@ -61,13 +130,126 @@ func initKubeClient() {
 }
 
 func InitFlag() {
-	flag.StringVar(&PriorityAlgorithm, "priority", "binpack", "priority algorithm, binpack/spread")
+	flag.StringVar(&PriorityAlgorithm, "priority", "binpack", "priority algorithm, binpack/spread/thermal-spread/requested-to-capacity-ratio/consolidation/balanced")
+	flag.StringVar(&Predictor, "predictor", dealer.PredictorPassthrough, "predictor of the gpu usage the load-aware scheduling decides on")
+	flag.DurationVar(&dealer.PredictionHorizon, "predictionHorizon", 0, "how far ahead the gpu usage is predicted")
+	flag.StringVar(&NamespaceRaters, "namespaceRaters", "", "priority algorithm by namespace overriding priority, like ml=card-spread,batch=binpack")
+	flag.StringVar(&RTCRShape, "rtcrShape", "0:0,100:100", "utilization:score points of the requested-to-capacity-ratio priority, both in [0, 100]")
+	flag.Float64Var(&ThermalThreshold, "thermalThreshold", 0.85, "normalized gpu temperature above which thermal-spread avoids a card")
 	flag.StringVar(&PolicyConfigPath, "policyConfigPath", DefaultPolicyConfigPath, "Policy Config Path")
 	flag.StringVar(&PrometheusUrl, "prometheusUrl", "http://thanos-prometheus.kube-system:80",
 		"The prometheus url, default: http://thanos-prometheus.kube-system:80.")
 	flag.StringVar(&InstancePort, "instancePort",  "9100", "The instance port, default: 9100")
 	flag.DurationVar(&SyncPeriod, "sync-period",  time.Second * 5, "sync period")
 	flag.BoolVar(&isLoadSchedule, "isLoadSchedule",  false, "Is load scheduling enabled")
+	flag.BoolVar(&enableElasticQuota, "enableElasticQuota", false, "Is ElasticGPUQuota borrowing and reclaim enabled")
+	flag.DurationVar(&QuotaReclaimPeriod, "quotaReclaimPeriod", time.Second*30, "elastic quota reclaim period")
+	flag.IntVar(&ReservedPercent, "reservedPercent", 0, "gpu percent of every card reserved for high priority pods")
+	flag.StringVar(&ReservedPriorityClass, "reservedPriorityClass", "", "minimal PriorityClass allowed to consume the reserved gpu percent")
+	flag.BoolVar(&PriorityAware, "priorityAware", false, "keep the best fitting cards for pending pods with higher priority")
+	flag.DurationVar(&StarvationTimeout, "starvationTimeout", 5*time.Minute, "pending time after which a pod is treated as highest priority")
+	flag.Float64Var(&OverloadThreshold, "overloadThreshold", 0, "measured gpu usage in (0, 1] above which a card is overloaded, 0 disables the overload controller")
+	flag.DurationVar(&OverloadDuration, "overloadDuration", 5*time.Minute, "how long a card must stay overloaded before acting")
+	flag.StringVar(&OverloadAction, "overloadAction", controller.OverloadActionFlag, "action on pods of overloaded cards, flag/evict")
+	flag.StringVar(&DefragMode, "defragMode", "", "defragmentation of free gpu share, propose/evict, empty disables it")
+	flag.DurationVar(&DefragPeriod, "defragPeriod", time.Minute, "defragmentation period")
+	flag.StringVar(&StatusPort, "statusPort", "", "port of a read-only listener serving the status and usage endpoints to callers allowed by a SubjectAccessReview, empty disables it")
+	flag.StringVar(&UsagePushTokenFile, "usagePushTokenFile", "", "file holding the bearer token node agents push their gpu usage with, empty disables the push endpoint")
+	flag.StringVar(&dealer.RemoteWriteURL, "remoteWriteURL", "", "prometheus remote-write endpoint the reported gpu usage is forwarded to, empty disables it")
+	flag.DurationVar(&dealer.RemoteWriteInterval, "remoteWriteInterval", 15*time.Second, "period of forwarding the reported gpu usage")
+	flag.BoolVar(&AllocationObjects, "allocationObjects", false, "record every allocation as a NanoGPUAllocation object and bind pods without writing their plan to them")
+	flag.IntVar(&dealer.AllocationHistorySize, "allocationHistorySize", 200, "allocations and releases kept by card for the history api, 0 disables it")
+	flag.IntVar(&dealer.UsageHistorySize, "usageHistorySize", 0, "gpu usage samples kept by card and metric for the history api, 0 disables it")
+	flag.Float64Var(&UsageSmoothingAlpha, "usageSmoothingAlpha", 1, "weight in (0, 1] of the newest gpu usage sample in the moving average, 1 disables smoothing")
+	flag.Float64Var(&UsageSpikeThreshold, "usageSpikeThreshold", 0, "gpu usage samples deviating more than this from the recent median are rejected, 0 disables it")
+	flag.DurationVar(&StalenessWindow, "stalenessWindow", dealer.ExtenderAtivePeriod, "how long gpu usage stays valid beyond its sync period")
+	flag.StringVar(&dealer.ScoreWebhookURL, "scoreWebhookURL", "", "url receiving the feasible plans of every prioritize call and returning score adjustments by node, empty disables it")
+	flag.DurationVar(&dealer.ScoreWebhookTimeout, "scoreWebhookTimeout", 500*time.Millisecond, "timeout of the score webhook, scores are kept unadjusted when it fails")
+	flag.BoolVar(&dealer.NormalizeScores, "normalizeScores", false, "scale the scores of every prioritize call to the score range")
+	flag.IntVar(&dealer.ScoreRangeMin, "scoreRangeMin", 0, "score of the worst node when scores are normalized")
+	flag.IntVar(&dealer.ScoreRangeMax, "scoreRangeMax", 100, "score of the best node when scores are normalized")
+	flag.IntVar(&dealer.ThrottleThreshold, "throttleThreshold", 0, "consecutive throttled or timed out apiserver calls switching to a degraded mode deferring non-critical writes, 0 disables it")
+	flag.DurationVar(&dealer.DegradedPeriod, "degradedPeriod", time.Minute, "how long the degraded mode lasts after the last throttled apiserver call")
+	flag.IntVar(&dealer.UsageBreakerThreshold, "usageBreakerThreshold", 0, "consecutive failed usage syncs of a metric scheduling the node by request for a cooldown, 0 disables it")
+	flag.DurationVar(&dealer.UsageBreakerCooldown, "usageBreakerCooldown", 5*time.Minute, "how long a node with untrusted usage is scheduled by request")
+	flag.StringVar(&StalePolicy, "stalePolicy", dealer.StalePolicyFailOpen, "load of cards with stale usage, fail-open/fail-closed/request")
+	flag.Float64Var(&dealer.LatencySensitiveUsage, "latencySensitiveUsage", 0.5, "measured core usage in (0, 1] of a card from which latency sensitive pods stay off it when scheduling by load, 0 disables it")
+	flag.IntVar(&InterferenceWeight, "interferenceWeight", 100, "score penalty per unit of core usage deviation on shared cards for latency sensitive pods, 0 disables it")
+	flag.DurationVar(&HealthSyncPeriod, "healthSyncPeriod", 0, "gpu health sync period, 0 disables health filtering")
+	flag.StringVar(&HealthMetrics.XID, "healthXIDMetric", "DCGM_FI_DEV_XID_ERRORS", "prometheus metric of the last xid error of a card")
+	flag.StringVar(&HealthMetrics.RetiredPages, "healthRetiredPagesMetric", "DCGM_FI_DEV_RETIRED_DBE", "prometheus metric of the retired pages of a card")
+	flag.StringVar(&HealthMetrics.DoubleBitECC, "healthECCMetric", "DCGM_FI_DEV_ECC_DBE_VOL_TOTAL", "prometheus metric of the double bit ecc errors of a card")
+	flag.DurationVar(&RemediationPeriod, "remediationPeriod", 0, "period of moving pods off unhealthy gpus, 0 disables it")
+	flag.BoolVar(&RecreateBarePods, "recreateBarePods", false, "recreate pods without controller on unhealthy gpus instead of only reporting them")
+	flag.StringVar(&accelerator.AMDMetricPrefix, "amdMetricPrefix", "amd_", "prefix of the usage metrics of nodes labeled with the amd gpu vendor")
+	flag.IntVar(&dealer.MPSScoreBonus, "mpsScoreBonus", 0, "score added to MPS nodes for pods whose containers take a small share, 0 disables it")
+	flag.IntVar(&dealer.CapabilityScoreBonus, "capabilityScoreBonus", 10, "score added to nodes meeting the preferred compute capability of a pod, 0 disables it")
+	flag.IntVar(&dealer.SpreadWeight, "spreadWeight", 0, "score of the least crowded topology domain of pods with topology spread constraints, 0 disables it")
+	flag.IntVar(&dealer.PreferredCardBonus, "preferredCardBonus", 50, "score added to the node of the preferred card of a pod when the card is free for it")
+	flag.StringVar(&dealer.SystemReserved, "systemReserved", "", "share of every card kept off the schedulable capacity of nodes without the system reserved annotation, as core=5,memory=1Gi")
+	flag.DurationVar(&dealer.MaintenanceLeadTime, "maintenanceLeadTime", 24*time.Hour, "how long before a maintenance window of a node long-running pods stop being placed on it")
+	flag.IntVar(&dealer.SpotScoreWeight, "spotScoreWeight", 0, "score added to spot nodes for fault-tolerant pods and taken off them for long-running pods, 0 disables it")
+	flag.IntVar(&dealer.MPSSmallPercent, "mpsSmallPercent", 25, "largest container gpu percent of a pod preferring MPS nodes")
+	flag.BoolVar(&dealer.QoSReservation, "qosReservation", false, "reserve only the core request of burstable and best-effort pods, requires isLoadSchedule")
+	flag.DurationVar(&ServerOptions.ReadTimeout, "serverReadTimeout", 30*time.Second, "timeout of reading an extender request")
+	flag.DurationVar(&ServerOptions.WriteTimeout, "serverWriteTimeout", 60*time.Second, "timeout of handling and writing an extender request")
+	flag.DurationVar(&ServerOptions.IdleTimeout, "serverIdleTimeout", 120*time.Second, "how long an idle keep-alive connection stays open")
+	flag.BoolVar(&ServerOptions.KeepAlives, "serverKeepAlives", true, "reuse connections of the extender endpoints")
+	flag.IntVar(&ServerOptions.MaxConcurrentRequests, "maxConcurrentRequests", 0, "extender requests handled at once, 0 leaves them unbounded")
+	flag.BoolVar(&routes.CompressResponses, "compressResponses", false, "gzip the filter and prioritize responses for clients accepting it")
+	flag.Float64Var(&KubeAPIQPS, "kubeAPIQPS", 20, "queries per second of the kubernetes client")
+	flag.IntVar(&KubeAPIBurst, "kubeAPIBurst", 30, "burst of the kubernetes client")
+	flag.DurationVar(&dealer.APICallTimeout, "apiCallTimeout", 10*time.Second, "timeout of the apiserver calls made when binding and auditing, 0 disables it")
+	flag.Int64Var(&dealer.StartupListPageSize, "startupListPageSize", 500, "number of pods listed per request when the cache is built on startup")
+	flag.StringVar(&dealer.CheckpointPath, "checkpointPath", "", "file the state is flushed to on shutdown and warm started from, empty disables it")
+	flag.DurationVar(&dealer.CheckpointMaxAge, "checkpointMaxAge", 10*time.Minute, "age of the oldest checkpoint warm started from, older ones are ignored, 0 takes any")
+	flag.DurationVar(&dealer.TerminatingGrace, "terminatingGrace", 0, "hold the gpu of terminating pods until they are gone and let pods count on the gpu of pods due within it, 0 releases terminating pods at once")
+	flag.BoolVar(&dealer.ReleaseFinalizer, "releaseFinalizer", false, "hold the deletion of bound gpu pods until their gpu is released")
+	flag.BoolVar(&dealer.MemoryBallooning, "memoryBallooning", false, "reserve only the guaranteed memory of pods declaring one and track the rest as burst")
+	flag.DurationVar(&dealer.AckTimeout, "ackTimeout", 0, "time the device plugin has to acknowledge the planned gpu of a pod, 0 disables the handshake")
+	flag.DurationVar(&StuckPodTimeout, "stuckPodTimeout", 0, "time a bound gpu pod may take to reach Running before it is reported stuck, 0 disables it")
+	flag.BoolVar(&ReleaseStuckPods, "releaseStuckPods", false, "delete stuck pods to release their gpu and requeue them")
+	flag.IntVar(&dealer.RequeueThreshold, "requeueThreshold", 0, "gpu percent a node must free within a requeue period to signal the pods waiting for gpu capacity that fit on it, 0 disables it")
+	flag.DurationVar(&RequeuePeriod, "requeuePeriod", 2*time.Second, "period of signaling the pods waiting for gpu capacity")
+	flag.BoolVar(&dealer.InferCUDAVersion, "inferCUDAVersion", false, "read the cuda version of pods without the cuda version annotation from their image tags, it only fails nodes reporting a lower version")
+	flag.DurationVar(&dealer.SchedulingHintTTL, "schedulingHintTTL", 0, "how long a pod skips planning the cards of a node it failed on while the node doesn't change, 0 disables it")
+	flag.IntVar(&dealer.RetryBudget, "retryBudget", 0, "attempts a pod waiting for gpu capacity gets before an event reports it will never fit with the dimension limiting it, 0 disables it")
+	flag.BoolVar(&RequeueAnnotate, "requeueAnnotate", false, "also touch an annotation of the signaled pods so that the scheduler retries them before their backoff expires")
+	flag.DurationVar(&OrphanGCPeriod, "orphanGCPeriod", 10*time.Minute, "period of releasing the gpu of pods deleted while their delete event was missed, 0 disables it")
+	flag.DurationVar(&MIGReconfigurePeriod, "migReconfigurePeriod", 0, "period of repartitioning idle mig cards for pending pods, 0 disables it")
+	flag.DurationVar(&dealer.GangTimeout, "gangTimeout", 0, "how long the gpu of the scored members of a Volcano or coscheduling pod group is held for them before the whole group is released, 0 disables it")
+	flag.BoolVar(&dealer.FaultInjection, "faultInjection", false, "serve /debug/faults to inject bind errors, stale usage and vanished nodes for resilience testing, never enable it in production")
+	flag.StringVar(&dealer.RecordPath, "recordPath", "", "file every extender request and dealer state change is appended to, for replaying with the simulator, empty disables it")
+	flag.StringVar(&ShadowPolicyConfigPath, "shadowPolicyConfigPath", "", "policy config evaluated next to the active one on every scored pod, whose placements are only logged and counted in the shadow metrics, empty disables it")
+	flag.StringVar(&ShadowPriority, "shadowPriority", "binpack", "priority algorithm of the shadow policy")
+	flag.StringVar(&ExperimentPolicyConfigPath, "experimentPolicyConfigPath", "", "policy config scheduling the pods of the experiment arm, empty disables the experiment")
+	flag.StringVar(&ExperimentPriority, "experimentPriority", "", "priority algorithm of the experiment arm, empty keeps the stable one")
+	flag.StringVar(&ExperimentNamespaces, "experimentNamespaces", "", "comma separated namespaces whose pods are all in the experiment arm")
+	flag.IntVar(&ExperimentPercent, "experimentPercent", 0, "percent of the pods of the other namespaces in the experiment arm")
+	flag.StringVar(&dealer.FederationURL, "federationURL", "", "/federation/clusters endpoint of the federation hub the free gpu capacity of the cluster is published to, empty disables it")
+	flag.StringVar(&dealer.FederationCluster, "federationCluster", "", "name the cluster publishes its gpu capacity under, required with federationURL")
+	flag.DurationVar(&dealer.FederationInterval, "federationInterval", 30*time.Second, "period of publishing the gpu capacity to the federation hub")
+	flag.StringVar(&FederationTokenFile, "federationTokenFile", "", "file holding the bearer token the gpu capacity is published to the federation hub with")
+	flag.BoolVar(&FederationHub, "federationHub", false, "serve the federation hub collecting the gpu capacity of the clusters of a fleet and answering which clusters fit a demand")
+	flag.DurationVar(&dealer.FederationStaleAfter, "federationStaleAfter", 5*time.Minute, "how long the federation hub trusts the capacity a cluster published")
+	flag.StringVar(&ShardOptions.Name, "shardName", "", "shard of the gpu nodes this scheduler owns when several schedulers split a cluster, its ownership is recorded in a Lease, empty owns all nodes")
+	flag.StringVar(&ShardSelector, "shardSelector", "", "label selector of the gpu nodes of the shard, required with shardName")
+	flag.StringVar(&ShardOptions.Namespace, "shardNamespace", "kube-system", "namespace of the Leases of the shards")
+	flag.DurationVar(&ShardOptions.LeaseDuration, "shardLeaseDuration", 15*time.Second, "how long the Lease of a shard is held without renewal before another scheduler may take the shard")
+	flag.DurationVar(&ShardOptions.RenewDeadline, "shardRenewDeadline", 10*time.Second, "how long the holder of a shard keeps placing pods without renewing its Lease, shorter than shardLeaseDuration")
+	flag.StringVar(&SchedulerNames, "schedulerNames", "", "comma separated scheduler names whose pods the extender serves, the schedulerName of the extender profiles is added, empty serves all pods")
+	flag.StringVar(&ExtenderProfilesPath, "extenderProfilesPath", "", "yaml list of extender profiles served next to the default extender, each with its name, url prefix or port, schedulerName, priority, policyConfigPath and loadAware, empty disables them")
+	flag.BoolVar(&WorkloadProfiles, "workloadProfiles", false, "resolve the policy of pods labeled with a workload profile from the profiles of the policy config, which is then read without isLoadSchedule too")
+	flag.DurationVar(&ElasticPeriod, "elasticPeriod", 0, "period of publishing the replicas of elastic training jobs that fit on their pending pods, 0 disables it")
+	flag.DurationVar(&KueueUsagePeriod, "kueueUsagePeriod", 0, "period of reporting the gpu share of every Kueue ResourceFlavor, enables deferring the pods of a Kueue queue until their workload is admitted, 0 disables it")
+	flag.DurationVar(&AutoscalerPeriod, "autoscalerPeriod", 0, "period of requesting whole gpus from the cluster autoscaler for the gpu share of unschedulable pods, 0 disables it")
+	flag.StringVar(&AutoscalerOptions.Namespace, "autoscalerNamespace", "kube-system", "namespace of the placeholder pods requesting whole gpus")
+	flag.StringVar(&AutoscalerOptions.PriorityClass, "autoscalerPriorityClass", "", "PriorityClass of the placeholder pods, a negative one lets any pod preempt them")
+	flag.StringVar(&AutoscalerResource, "autoscalerResource", string(types.ResourceNvidiaGPU), "whole gpu resource requested by the placeholder pods")
+	flag.StringVar(&AutoscalerOptions.Image, "autoscalerImage", "k8s.gcr.io/pause:3.2", "image of the placeholder pods")
+	flag.IntVar(&AutoscalerOptions.MaxGPUs, "autoscalerMaxGPUs", 1, "largest number of whole gpus a placeholder pod requests, above 1 for Karpenter to provision a node for many pods")
+	flag.StringVar(&AutoscalerOptions.CountLabel, "autoscalerCountLabel", "", "node label of the gpu count placeholder pods require at least their gpus of, like "+types.LabelKarpenterGPUCount+", empty leaves it out")
+	flag.IntVar(&dealer.RetiredPagesLimit, "retiredPagesLimit", 48, "retired pages after which a card is unhealthy")
 
 
 }
@@ -80,19 +262,111 @@ func main() {
 
 	log.Info("Priority algorithm is ", PriorityAlgorithm)
 
-	switch PriorityAlgorithm {
-	case types.PrioritySpread:
-		controller.Rater = &dealer.Spread{}
-	case types.PriorityBinPack:
-		controller.Rater = &dealer.Binpack{}
-	default:
-		log.Errorf("Priority algorithm %s is not supported", PriorityAlgorithm)
+	shape, err := dealer.ParseShape(RTCRShape)
+	if err != nil {
+		log.Fatalf("invalid rtcrShape: %v", err)
+	}
+	dealer.RTCRShape = shape
+	dealer.RegisterRater(types.PriorityThermalSpread, func() dealer.Rater {
+		return &dealer.ThermalSpread{Threshold: ThermalThreshold}
+	})
+	rater, err := dealer.NewRater(PriorityAlgorithm)
+	if err != nil {
+		log.Error(err)
 		return
 	}
+	controller.Rater = rater
+	if dealer.UsagePredictor, err = dealer.NewPredictor(Predictor); err != nil {
+		log.Fatalf("invalid predictor: %v", err)
+	}
+	if dealer.NamespaceRaters, err = dealer.ParseNamespaceRaters(NamespaceRaters); err != nil {
+		log.Fatalf("invalid namespaceRaters: %v", err)
+	}
+	if ShadowPolicyConfigPath != "" {
+		shadowRater, err := dealer.NewRater(ShadowPriority)
+		if err != nil {
+			log.Fatalf("invalid shadowPriority: %v", err)
+		}
+		dealer.Shadow = &dealer.ShadowPolicy{Spec: dealer.GetAlternatePolicyFromFile(ShadowPolicyConfigPath), Rater: shadowRater}
+	}
+	if ExperimentPolicyConfigPath != "" {
+		experiment := &dealer.ExperimentPolicy{
+			Spec:       dealer.GetAlternatePolicyFromFile(ExperimentPolicyConfigPath),
+			Namespaces: make(map[string]struct{}),
+			Percent:    ExperimentPercent,
+		}
+		if ExperimentPriority != "" {
+			if experiment.Rater, err = dealer.NewRater(ExperimentPriority); err != nil {
+				log.Fatalf("invalid experimentPriority: %v", err)
+			}
+		}
+		for _, ns := range strings.Split(ExperimentNamespaces, ",") {
+			if ns = strings.TrimSpace(ns); ns != "" {
+				experiment.Namespaces[ns] = struct{}{}
+			}
+		}
+		dealer.Experiment = experiment
+	}
+
+	dealer.SchedulerNames = dealer.ParseSchedulerNames(SchedulerNames)
+	dealer.PriorityAware = PriorityAware
+	dealer.StarvationTimeout = StarvationTimeout
+	dealer.InterferenceWeight = InterferenceWeight
+	dealer.UsageSmoothingAlpha = UsageSmoothingAlpha
+	dealer.UsageSpikeThreshold = UsageSpikeThreshold
+	if err := dealer.ValidateStalePolicy(StalePolicy); err != nil {
+		log.Error(err)
+		return
+	}
+	dealer.StalenessWindow = StalenessWindow
+	dealer.StalePolicy = StalePolicy
+	if dealer.QoSReservation && !isLoadSchedule {
+		log.Warning("qosReservation requires isLoadSchedule, every pod reserves its gpu percent")
+		dealer.QoSReservation = false
+	}
+
+	if ShardOptions.Name != "" {
+		selector, err := labels.Parse(ShardSelector)
+		if err != nil || ShardSelector == "" {
+			log.Errorf("invalid shardSelector %q: %v", ShardSelector, err)
+			return
+		}
+		dealer.ShardSelector = selector
+		if ShardOptions.RenewDeadline <= 0 || ShardOptions.RenewDeadline >= ShardOptions.LeaseDuration {
+			log.Errorf("shardRenewDeadline %s must be positive and shorter than shardLeaseDuration %s", ShardOptions.RenewDeadline, ShardOptions.LeaseDuration)
+			return
+		}
+		if ShardOptions.Identity = os.Getenv("POD_NAME"); ShardOptions.Identity == "" {
+			ShardOptions.Identity, _ = os.Hostname()
+		}
+	}
+	if dealer.FederationURL != "" && dealer.FederationCluster == "" {
+		log.Error("federationCluster is required with federationURL")
+		return
+	}
+	if FederationTokenFile != "" {
+		token, err := ioutil.ReadFile(FederationTokenFile)
+		if err != nil {
+			log.Errorf("read federationTokenFile: %v", err)
+			return
+		}
+		dealer.FederationToken = strings.TrimSpace(string(token))
+	}
 
 	threadness := StringToInt(os.Getenv("THREADNESS"))
 
 	initKubeClient()
+	if err := initReservedHeadroom(); err != nil {
+		log.Errorf("Failed to init reserved headroom: %v", err)
+		return
+	}
+	if AllocationObjects {
+		dynamicClient, err := dynamic.NewForConfig(restConfig)
+		if err != nil {
+			log.Fatalf("Failed to init dynamic client due to %v", err)
+		}
+		dealer.AllocationClient = dynamicClient
+	}
 	port := os.Getenv("PORT")
 	if _, err := strconv.Atoi(port); err != nil {
 		port = "39999"
@@ -100,6 +374,16 @@ func main() {
 
 	// Set up signals so we handle the first shutdown signal gracefully.
 	stopCh := signals.SetupSignalHandler()
+	if AllocationObjects {
+		allocationInformers := dynamicinformer.NewDynamicSharedInformerFactory(dealer.AllocationClient, resyncPeriod)
+		dealer.AllocationLister = allocationInformers.ForResource(dealer.AllocationGVR).Lister()
+		allocationInformers.Start(stopCh)
+		for gvr, synced := range allocationInformers.WaitForCacheSync(stopCh) {
+			if !synced {
+				log.Fatalf("Failed to sync the informer of %s", gvr.Resource)
+			}
+		}
+	}
 	informerFactory := informers.NewSharedInformerFactory(clientset, resyncPeriod)
 	schudulerController, err := controller.NewController(clientset, informerFactory, PrometheusUrl, InstancePort, PolicyConfigPath, SyncPeriod, isLoadSchedule, stopCh)
 	if err != nil {
@@ -109,10 +393,102 @@ func main() {
 
 	go schudulerController.Run(threadness, stopCh)
 
+	if enableElasticQuota {
+		dynamicClient, err := dynamic.NewForConfig(restConfig)
+		if err != nil {
+			log.Fatalf("Failed to init dynamic client due to %v", err)
+		}
+		quotaController := controller.NewQuotaController(clientset, dynamicClient, schudulerController.GetPodLister(),
+			schudulerController.GetDealer(), resyncPeriod, QuotaReclaimPeriod)
+		go quotaController.Run(stopCh)
+	}
+
+	if ElasticPeriod > 0 {
+		elasticController := controller.NewElasticController(clientset, schudulerController.GetPodLister(), schudulerController.GetDealer())
+		go elasticController.Run(ElasticPeriod, stopCh)
+	}
+
+	if KueueUsagePeriod > 0 {
+		dynamicClient, err := dynamic.NewForConfig(restConfig)
+		if err != nil {
+			log.Fatalf("Failed to init dynamic client due to %v", err)
+		}
+		kueueController := controller.NewKueueController(dynamicClient, schudulerController.GetDealer(), resyncPeriod)
+		go kueueController.Run(KueueUsagePeriod, stopCh)
+	}
+
+	if isLoadSchedule && OverloadThreshold > 0 {
+		overloadController, err := controller.NewOverloadController(clientset, schudulerController.GetNodeLister(),
+			schudulerController.GetDealer(), PolicyConfigPath, OverloadThreshold, OverloadDuration, OverloadAction)
+		if err != nil {
+			log.Fatalf("Failed to start overload controller due to %v", err)
+		}
+		go overloadController.Run(SyncPeriod, stopCh)
+	}
+
+	if DefragMode != "" {
+		defragController, err := controller.NewDefragController(clientset, schudulerController.GetPodLister(),
+			schudulerController.GetDealer(), DefragMode)
+		if err != nil {
+			log.Fatalf("Failed to start defrag controller due to %v", err)
+		}
+		go defragController.Run(DefragPeriod, stopCh)
+	}
+
+	if MIGReconfigurePeriod > 0 {
+		migController := controller.NewMIGController(clientset, schudulerController.GetPodLister(), schudulerController.GetDealer())
+		go migController.Run(MIGReconfigurePeriod, stopCh)
+	}
+
+	if AutoscalerPeriod > 0 {
+		AutoscalerOptions.Resource = v1.ResourceName(AutoscalerResource)
+		autoscalerController := controller.NewAutoscalerController(clientset, schudulerController.GetPodLister(), AutoscalerOptions)
+		go autoscalerController.Run(AutoscalerPeriod, stopCh)
+	}
+
+	if ShardOptions.Name != "" {
+		shardController := controller.NewShardController(clientset, schudulerController.GetDealer(), ShardOptions)
+		go shardController.Run(stopCh)
+	}
+
+	if OrphanGCPeriod > 0 {
+		orphanController := controller.NewOrphanController(clientset, schudulerController.GetDealer())
+		go orphanController.Run(OrphanGCPeriod, stopCh)
+	}
+
+	if StuckPodTimeout > 0 {
+		stuckController := controller.NewStuckController(clientset, schudulerController.GetPodLister(),
+			schudulerController.GetDealer(), StuckPodTimeout, ReleaseStuckPods)
+		go stuckController.Run(StuckPodTimeout, stopCh)
+	}
+
+	if dealer.RequeueThreshold > 0 {
+		requeueController := controller.NewRequeueController(clientset, schudulerController.GetPodLister(),
+			schudulerController.GetDealer(), RequeueAnnotate)
+		go requeueController.Run(RequeuePeriod, stopCh)
+	}
+
+	if dealer.RetryBudget > 0 {
+		retryBudgetController := controller.NewRetryBudgetController(clientset, schudulerController.GetPodLister(),
+			schudulerController.GetDealer())
+		go retryBudgetController.Run(RequeuePeriod, stopCh)
+	}
+
+	if HealthSyncPeriod > 0 {
+		healthController := controller.NewHealthController(schudulerController.GetNodeLister(),
+			prometheus.NewPromConfig(PrometheusUrl, InstancePort), schudulerController.GetDealer(), HealthMetrics)
+		go healthController.Run(HealthSyncPeriod, stopCh)
+		if RemediationPeriod > 0 {
+			remediationController := controller.NewRemediationController(clientset, schudulerController.GetNodeLister(),
+				schudulerController.GetDealer(), RecreateBarePods)
+			go remediationController.Run(RemediationPeriod, stopCh)
+		}
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	var policy dealer.PolicySpec
-	if isLoadSchedule {
+	if isLoadSchedule || WorkloadProfiles {
 		context := DSCtx.NewDSContext(PolicyConfigPath)
 		context.Start()
 		policy = context.GetPolicySpec()
@@ -121,6 +497,7 @@ func main() {
 	predicate := scheduler.NewNanoGPUPredicate(ctx, clientset, schudulerController.GetDealer(), policy, isLoadSchedule)
 	prioritize := scheduler.NewNanoGPUPrioritize(ctx, clientset, schudulerController.GetDealer(), policy, isLoadSchedule)
 	bind := scheduler.NewNanoGPUBind(ctx, clientset, schudulerController.GetDealer(), policy, isLoadSchedule)
+	dryRun := scheduler.NewNanoGPUDryRun(schudulerController.GetDealer(), policy, isLoadSchedule)
 
 	router := httprouter.New()
 	routes.AddPProf(router)
@@ -129,11 +506,122 @@ func main() {
 	routes.AddPrioritize(router, prioritize)
 	routes.AddBind(router, bind)
 	routes.AddStatus(router, schudulerController.GetDealer())
+	routes.AddQuotaStatus(router, schudulerController.GetDealer())
+	routes.AddCapacity(router, schudulerController.GetDealer())
+	routes.AddDryRun(router, dryRun)
+	routes.AddBurstStatus(router, schudulerController.GetDealer())
+	routes.AddAudit(router, schudulerController.GetDealer())
+	routes.AddHistory(router, schudulerController.GetDealer())
+	routes.AddHottest(router, schudulerController.GetDealer())
+	routes.AddHeatmap(router, schudulerController.GetDealer())
+	routes.AddPlacements(router, schudulerController.GetDealer())
+	routes.AddQueue(router, schudulerController.GetDealer())
+	routes.AddAllocationHistory(router, schudulerController.GetDealer())
+	if UsagePushTokenFile != "" {
+		token, err := ioutil.ReadFile(UsagePushTokenFile)
+		if err != nil {
+			log.Fatalf("read usagePushTokenFile: %v", err)
+		}
+		routes.AddUsagePush(router, schudulerController.GetDealer(), strings.TrimSpace(string(token)))
+	}
+	if ExtenderProfilesPath != "" {
+		addExtenderProfiles(ctx, router, schudulerController.GetDealer())
+	}
+	if FederationHub {
+		routes.AddFederation(router, dealer.NewFederationHub(), routes.NewSARAuthorizer(clientset))
+	}
+	if dealer.FaultInjection {
+		log.Warning("fault injection is enabled, faults armed on /debug/faults fail real scheduling")
+		routes.AddFaults(router, schudulerController.GetDealer())
+	}
+	routes.AddMetrics(router)
+	metrics.Register(schudulerController.GetDealer())
+
+	if StatusPort != "" {
+		readOnlyRouter := routes.NewReadOnlyRouter(schudulerController.GetDealer())
+		statusServer := routes.NewServer(":"+StatusPort,
+			routes.Authorize(readOnlyRouter, routes.NewSARAuthorizer(clientset)), ServerOptions)
+		go func() {
+			log.Infof("read-only status server starting on the port :%s", StatusPort)
+			if err := statusServer.ListenAndServe(); err != nil {
+				log.Fatal(err)
+			}
+		}()
+	}
 
 	log.Infof("server starting on the port :%s", port)
-	if err := http.ListenAndServe(":"+port, router); err != nil {
-		log.Fatal(err)
+	server := routes.NewServer(":"+port, router, ServerOptions)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	<-stopCh
+	// no pod is bound once the server is down, so the checkpoint misses none
+	if err := server.Shutdown(context.Background()); err != nil {
+		log.Errorf("Failed to shut the server down due to %v", err)
+	}
+	if dealer.CheckpointPath != "" {
+		if err := schudulerController.GetDealer().SaveCheckpoint(dealer.CheckpointPath); err != nil {
+			log.Fatalf("Failed to save checkpoint due to %v", err)
+		}
+		log.Infof("saved checkpoint to %s", dealer.CheckpointPath)
+	}
+}
+
+// addExtenderProfiles serves the extender profiles under their prefix on the router,
+// or on a listener of their own.
+func addExtenderProfiles(ctx context.Context, router *httprouter.Router, d dealer.Dealer) {
+	profiles, err := dealer.LoadExtenderProfiles(ExtenderProfilesPath)
+	if err != nil {
+		log.Fatalf("load extenderProfilesPath: %v", err)
+	}
+	for _, p := range profiles {
+		if len(dealer.SchedulerNames) > 0 && p.SchedulerName != "" {
+			dealer.SchedulerNames[p.SchedulerName] = struct{}{}
+		}
+		load := isLoadSchedule && p.LoadAware
+		predicate := scheduler.NewNanoGPUPredicate(ctx, clientset, d, p.Spec, load)
+		prioritize := scheduler.NewNanoGPUPrioritize(ctx, clientset, d, p.Spec, load)
+		bind := scheduler.NewNanoGPUBind(ctx, clientset, d, p.Spec, load)
+		if p.Port == "" {
+			routes.AddExtender(router, p.Prefix, predicate, prioritize, bind)
+			log.Infof("extender profile %s is served under %s", p.Name, p.Prefix)
+			continue
+		}
+		profileRouter := httprouter.New()
+		routes.AddExtender(profileRouter, "", predicate, prioritize, bind)
+		server := routes.NewServer(":"+p.Port, profileRouter, ServerOptions)
+		go func(name, port string) {
+			log.Infof("extender profile %s starting on the port :%s", name, port)
+			if err := server.ListenAndServe(); err != nil {
+				log.Fatal(err)
+			}
+		}(p.Name, p.Port)
+	}
+}
+
+func initReservedHeadroom() error {
+	if ReservedPercent == 0 {
+		return nil
+	}
+	if ReservedPercent < 0 || ReservedPercent >= types.GPUPercentEachCard {
+		return fmt.Errorf("reservedPercent %d out of range [0, %d)", ReservedPercent, types.GPUPercentEachCard)
+	}
+	if ReservedPriorityClass == "" {
+		return fmt.Errorf("reservedPriorityClass is required when reservedPercent is set")
+	}
+	pc, err := clientset.SchedulingV1().PriorityClasses().Get(context.Background(), ReservedPriorityClass, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	dealer.ReservedHeadroom = dealer.Headroom{
+		Percent:  ReservedPercent,
+		Priority: pc.Value,
 	}
+	log.Infof("reserve %d gpu percent of every card for pods with priority >= %d", ReservedPercent, pc.Value)
+	return nil
 }
 
 func StringToInt(sThread string) int {
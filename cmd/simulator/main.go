@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	log "k8s.io/klog/v2"
+)
+
+var (
+	SnapshotPath     string
+	ReplayPath       string
+	WorkloadPath     string
+	PolicyConfigPath string
+	Priority         string
+	LoadAware        bool
+	OutputJSON       bool
+	CoreUsage        string
+	MemoryUsage      string
+)
+
+// workloadItem is a pod of the workload file, placed Replicas times.
+type workloadItem struct {
+	Replicas int    `json:"replicas"`
+	Pod      v1.Pod `json:"pod"`
+}
+
+func InitFlag() {
+	flag.StringVar(&SnapshotPath, "snapshot", "", "json file of the cluster snapshot: the nodes and a dealer checkpoint with the assumed pods and the usage")
+	flag.StringVar(&ReplayPath, "replay", "", "recording of a scheduler run with recordPath to replay instead of placing a workload, the decisions differing from the recorded ones are reported")
+	flag.StringVar(&WorkloadPath, "workload", "", "json file of the pods to place in order, like [{\"replicas\": 2, \"pod\": {...}}]")
+	flag.StringVar(&PolicyConfigPath, "policyConfigPath", "", "policy config of the scheduler, empty schedules by request only")
+	flag.StringVar(&Priority, "priority", "binpack", "priority algorithm, binpack/spread/requested-to-capacity-ratio/consolidation/balanced/card-spread")
+	flag.BoolVar(&LoadAware, "isLoadSchedule", false, "schedule by the usage of the snapshot, requires policyConfigPath")
+	flag.BoolVar(&OutputJSON, "json", false, "print the report as json")
+	flag.StringVar(&CoreUsage, "coreUsage", "", "synthetic core usage of the cards replacing the usage of the snapshot, constant:usage, sawtooth:min:max:period or bursty:base:peak:chance:interval")
+	flag.StringVar(&MemoryUsage, "memoryUsage", "", "synthetic memory usage of the cards replacing the usage of the snapshot, in the format of coreUsage")
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// workloadPods expands the replicas of the workload, every pod gets a uid so that
+// the dealer tells them apart.
+func workloadPods(items []workloadItem) []*v1.Pod {
+	pods := make([]*v1.Pod, 0, len(items))
+	for _, item := range items {
+		replicas := item.Replicas
+		if replicas < 1 {
+			replicas = 1
+		}
+		for i := 0; i < replicas; i++ {
+			pod := item.Pod.DeepCopy()
+			if pod.Namespace == "" {
+				pod.Namespace = metav1.NamespaceDefault
+			}
+			if replicas > 1 {
+				pod.Name = fmt.Sprintf("%s-%d", pod.Name, i)
+			}
+			if pod.UID == "" || replicas > 1 {
+				pod.UID = k8stypes.UID(pod.Namespace + "/" + pod.Name)
+			}
+			pods = append(pods, pod)
+		}
+	}
+	return pods
+}
+
+func main() {
+	InitFlag()
+	log.InitFlags(nil)
+	flag.Parse()
+
+	rater, err := dealer.NewRater(Priority)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var policy dealer.PolicySpec
+	if PolicyConfigPath != "" {
+		policy = dealer.GetPolicyFromFile(PolicyConfigPath).Spec
+	} else if LoadAware {
+		log.Fatal("isLoadSchedule requires policyConfigPath")
+	}
+	if ReplayPath != "" {
+		replay(policy, rater)
+		return
+	}
+
+	if SnapshotPath == "" || WorkloadPath == "" {
+		log.Fatal("snapshot and workload are required")
+	}
+	snapshot := &dealer.ClusterSnapshot{}
+	if err := readJSON(SnapshotPath, snapshot); err != nil {
+		log.Fatalf("read snapshot: %v", err)
+	}
+	items := make([]workloadItem, 0)
+	if err := readJSON(WorkloadPath, &items); err != nil {
+		log.Fatalf("read workload: %v", err)
+	}
+	d := dealer.NewSimulatedDealer(snapshot, rater)
+	fillUsage(d, snapshot)
+	report, err := d.Simulate(workloadPods(items), policy, LoadAware)
+	if err != nil {
+		log.Fatalf("simulate: %v", err)
+	}
+	if OutputJSON {
+		printJSON(report)
+		return
+	}
+	for _, p := range report.Placements {
+		if p.Node == "" {
+			fmt.Printf("%s\tunplaced\t%s\n", p.Pod, p.Reason)
+			continue
+		}
+		fmt.Printf("%s\t%s\t%v\n", p.Pod, p.Node, p.GPUIndexes)
+	}
+	fmt.Printf("placed %d, unplaced %d\n", report.Placed, report.Unplaced)
+	fmt.Printf("utilization %.3f, fragmentation %.3f, free %d\n",
+		report.Utilization, report.Fragmentation.Cluster.Score, report.Fragmentation.Cluster.Free)
+}
+
+func printJSON(v interface{}) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// replay plays the recording with the policy and the priority algorithm the recorded
+// scheduler ran with.
+func replay(policy dealer.PolicySpec, rater dealer.Rater) {
+	f, err := os.Open(ReplayPath)
+	if err != nil {
+		log.Fatalf("open recording: %v", err)
+	}
+	defer f.Close()
+	report, err := dealer.Replay(f, policy, rater)
+	if err != nil {
+		log.Fatalf("replay: %v", err)
+	}
+	if OutputJSON {
+		printJSON(report)
+		return
+	}
+	for _, div := range report.Divergences {
+		fmt.Printf("entry %d %s %s: recorded %s, replayed %s\n", div.Entry, div.Op, div.Pod, div.Recorded, div.Replayed)
+	}
+	fmt.Printf("entries %d, decisions %d, divergences %d\n", report.Entries, report.Decisions, len(report.Divergences))
+}
+
+// fillUsage reports the synthetic usage patterns for the cards of the snapshot nodes.
+func fillUsage(d dealer.Dealer, snapshot *dealer.ClusterSnapshot) {
+	if CoreUsage == "" && MemoryUsage == "" {
+		return
+	}
+	var core, memory dealer.UsagePattern
+	var err error
+	if CoreUsage != "" {
+		if core, err = dealer.ParseUsagePattern(CoreUsage); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if MemoryUsage != "" {
+		if memory, err = dealer.ParseUsagePattern(MemoryUsage); err != nil {
+			log.Fatal(err)
+		}
+	}
+	cards := make(map[string]int)
+	for _, node := range snapshot.Nodes {
+		cards[node.Name] = utils.GetGPUDeviceCountOfNode(node)
+	}
+	dealer.FillUsage(d, cards, core, memory, 0)
+}
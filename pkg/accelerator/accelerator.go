@@ -0,0 +1,107 @@
+package accelerator
+
+import (
+	"sync"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Accelerator describes a device shared in percent of a card. The dealer schedules
+// every accelerator the same way, a backend only tells where its cards, share and
+// usage are found and how a plan is recorded on the pod.
+type Accelerator interface {
+	// Vendor is the value of the vendor label selecting the backend for a node.
+	Vendor() string
+	// DeviceCount discovers the number of cards of a node.
+	DeviceCount(node *v1.Node) int
+	// PercentResource is the extended resource pods request the share with.
+	PercentResource() v1.ResourceName
+	// UsageMetric returns the metric the backend reports a usage key under.
+	UsageMetric(key string) string
+	// ContainerAnnotation is the pod annotation holding the card of a container.
+	ContainerAnnotation(container string) string
+}
+
+var (
+	lock     sync.RWMutex
+	backends = map[string]Accelerator{}
+	// resources keeps the registration order so that lookups are stable.
+	resources []v1.ResourceName
+)
+
+// Register adds a backend, a later backend of the same vendor replaces the former.
+func Register(a Accelerator) {
+	lock.Lock()
+	defer lock.Unlock()
+	if old, ok := backends[a.Vendor()]; ok {
+		for i, r := range resources {
+			if r == old.PercentResource() {
+				resources = append(resources[:i], resources[i+1:]...)
+				break
+			}
+		}
+	}
+	backends[a.Vendor()] = a
+	resources = append(resources, a.PercentResource())
+}
+
+func Lookup(vendor string) (Accelerator, bool) {
+	lock.RLock()
+	defer lock.RUnlock()
+	a, ok := backends[vendor]
+	return a, ok
+}
+
+// VendorOfNode returns the vendor labeled on the node, nvidia by default.
+func VendorOfNode(node *v1.Node) string {
+	if vendor := node.Labels[types.LabelGPUVendor]; vendor != "" {
+		return vendor
+	}
+	return types.GPUVendorNVIDIA
+}
+
+// ForNode returns the backend of the node, nil for unknown vendors.
+func ForNode(node *v1.Node) Accelerator {
+	a, _ := Lookup(VendorOfNode(node))
+	return a
+}
+
+// ForPod returns the backend whose share the pod requests, nvidia when none.
+func ForPod(pod *v1.Pod) Accelerator {
+	lock.RLock()
+	defer lock.RUnlock()
+	for _, a := range backends {
+		for _, c := range pod.Spec.Containers {
+			if _, ok := c.Resources.Limits[a.PercentResource()]; ok {
+				return a
+			}
+		}
+	}
+	return backends[types.GPUVendorNVIDIA]
+}
+
+// ForResource returns the backend whose share is requested with the resource.
+func ForResource(name v1.ResourceName) (Accelerator, bool) {
+	lock.RLock()
+	defer lock.RUnlock()
+	for _, a := range backends {
+		if a.PercentResource() == name {
+			return a, true
+		}
+	}
+	return nil, false
+}
+
+// PercentResources returns the share resources of every backend.
+func PercentResources() []v1.ResourceName {
+	lock.RLock()
+	defer lock.RUnlock()
+	return append([]v1.ResourceName(nil), resources...)
+}
+
+func init() {
+	Register(&NVIDIA{})
+	Register(&AMD{})
+}
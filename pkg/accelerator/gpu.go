@@ -0,0 +1,74 @@
+package accelerator
+
+import (
+	"fmt"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// AMDMetricPrefix is prepended to the usage metrics queried for AMD nodes, so that
+// the ROCm exporter metrics can be recorded under the names of the policy.
+var AMDMetricPrefix = "amd_"
+
+// NVIDIA is the gpu share advertised by the nano-gpu device plugin.
+type NVIDIA struct{}
+
+func (n *NVIDIA) Vendor() string {
+	return types.GPUVendorNVIDIA
+}
+
+func (n *NVIDIA) DeviceCount(node *v1.Node) int {
+	return percentCount(node, types.ResourceGPUPercent)
+}
+
+func (n *NVIDIA) PercentResource() v1.ResourceName {
+	return types.ResourceGPUPercent
+}
+
+func (n *NVIDIA) UsageMetric(key string) string {
+	return key
+}
+
+func (n *NVIDIA) ContainerAnnotation(container string) string {
+	return fmt.Sprintf(types.AnnotationGPUContainerOn, container)
+}
+
+// AMD is the gpu share of nodes running the ROCm device plugin.
+type AMD struct{}
+
+func (a *AMD) Vendor() string {
+	return types.GPUVendorAMD
+}
+
+func (a *AMD) DeviceCount(node *v1.Node) int {
+	if _, ok := node.Status.Capacity[types.ResourceAMDGPUPercent]; ok {
+		return percentCount(node, types.ResourceAMDGPUPercent)
+	}
+	// the ROCm device plugin advertises whole gpus
+	if val, ok := node.Status.Capacity[types.ResourceAMDGPU]; ok {
+		return int(val.Value())
+	}
+	return 0
+}
+
+func (a *AMD) PercentResource() v1.ResourceName {
+	return types.ResourceAMDGPUPercent
+}
+
+func (a *AMD) UsageMetric(key string) string {
+	return AMDMetricPrefix + key
+}
+
+func (a *AMD) ContainerAnnotation(container string) string {
+	return fmt.Sprintf(types.AnnotationGPUContainerOn, container)
+}
+
+func percentCount(node *v1.Node, name v1.ResourceName) int {
+	val, ok := node.Status.Capacity[name]
+	if !ok {
+		return 0
+	}
+	return int(val.Value()) / types.GPUPercentEachCard
+}
@@ -0,0 +1,202 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/record"
+	log "k8s.io/klog/v2"
+)
+
+// autoscalerPendingAge gives the scheduler a chance to place the pod on the current
+// nodes before more are asked for.
+const autoscalerPendingAge = time.Minute
+
+const annotationSafeToEvict = "cluster-autoscaler.kubernetes.io/safe-to-evict"
+
+// AutoscalerOptions are the placeholder pods of the AutoscalerController.
+type AutoscalerOptions struct {
+	// Namespace holds the placeholder pods.
+	Namespace string
+	// PriorityClass is the PriorityClass of the placeholder pods, a negative one lets
+	// any pod preempt them.
+	PriorityClass string
+	// Resource is the whole gpu resource the node groups advertise.
+	Resource v1.ResourceName
+	// Image is the image of the placeholder pods.
+	Image string
+	// MaxGPUs is the largest number of whole gpus a placeholder pod requests, the
+	// pending share is rolled up into pods of up to MaxGPUs cards so that a node is
+	// provisioned for many pods rather than one by pod. Cluster Autoscaler wants 1.
+	MaxGPUs int
+	// CountLabel is the node label of the number of cards placeholder pods require at
+	// least their gpus of, as Karpenter provisions by, empty leaves it out.
+	CountLabel string
+}
+
+// AutoscalerController turns the gpu share of unschedulable pods into a demand for
+// whole gpus Cluster Autoscaler and Karpenter understand. They can't reason about
+// nano-gpu shares, so the whole gpus the pending shares fit on are requested by
+// placeholder pods kept pending, with the gpu model the pending pods require. The
+// nodes scale up for the placeholders, the pending pods take the new cards and the
+// placeholders are removed once no share is pending anymore.
+type AutoscalerController struct {
+	clientset *kubernetes.Clientset
+
+	podLister corelisters.PodLister
+
+	recorder record.EventRecorder
+
+	options AutoscalerOptions
+}
+
+func NewAutoscalerController(clientset *kubernetes.Clientset, podLister corelisters.PodLister, options AutoscalerOptions) *AutoscalerController {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "nano-gpu-scheduler"})
+
+	return &AutoscalerController{
+		clientset: clientset,
+		podLister: podLister,
+		recorder:  recorder,
+		options:   options,
+	}
+}
+
+func (ac *AutoscalerController) Run(period time.Duration, stopCh <-chan struct{}) {
+	log.Info("Started autoscaler controller")
+	wait.Until(ac.reconcile, period, stopCh)
+}
+
+func (ac *AutoscalerController) reconcile() {
+	pods, err := ac.podLister.List(labels.Everything())
+	if err != nil {
+		log.Errorf("list pods failed: %s", err.Error())
+		return
+	}
+	pending := make([]*v1.Pod, 0)
+	for _, pod := range pods {
+		if !utils.IsGPUSharingPod(pod) || !dealer.IsUnschedulable(pod) {
+			continue
+		}
+		if time.Since(pod.CreationTimestamp.Time) < autoscalerPendingAge {
+			continue
+		}
+		pending = append(pending, pod)
+	}
+	hints := dealer.ProvisioningHints(pending, ac.options.MaxGPUs)
+
+	placeholders, err := ac.podLister.Pods(ac.options.Namespace).List(labels.SelectorFromSet(labels.Set{types.LabelPlaceholder: "true"}))
+	if err != nil {
+		log.Errorf("list placeholder pods failed: %s", err.Error())
+		return
+	}
+	existing := make(map[string][]*v1.Pod)
+	for _, p := range placeholders {
+		key := p.Annotations[types.AnnotationPlaceholderHint]
+		existing[key] = append(existing[key], p)
+	}
+	missing := make([]dealer.ProvisioningHint, 0)
+	for _, hint := range hints {
+		key := hintKey(hint)
+		if len(existing[key]) > 0 {
+			existing[key] = existing[key][1:]
+			continue
+		}
+		missing = append(missing, hint)
+	}
+	for _, stale := range existing {
+		for _, p := range stale {
+			err := ac.clientset.CoreV1().Pods(p.Namespace).Delete(context.Background(), p.Name, metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				log.Errorf("delete placeholder pod %s/%s failed: %s", p.Namespace, p.Name, err.Error())
+			}
+		}
+	}
+	for _, hint := range missing {
+		if _, err := ac.clientset.CoreV1().Pods(ac.options.Namespace).Create(context.Background(), ac.placeholder(hint), metav1.CreateOptions{}); err != nil {
+			log.Errorf("create placeholder pod for %s failed: %s", hintKey(hint), err.Error())
+			return
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+	gpus := 0
+	for _, hint := range hints {
+		gpus += hint.GPUs
+	}
+	log.Infof("%d whole gpus on %d nodes requested for the gpu share of %d unschedulable pods", gpus, len(hints), len(pending))
+	for _, pod := range pending {
+		ac.recorder.Eventf(pod, v1.EventTypeNormal, "WholeGPUsRequested",
+			"%d whole gpus on %d nodes requested from the cluster autoscaler for the gpu share of %d unschedulable pods", gpus, len(hints), len(pending))
+	}
+}
+
+func hintKey(hint dealer.ProvisioningHint) string {
+	return fmt.Sprintf("%s=%s/%d", hint.ModelLabel, hint.Model, hint.GPUs)
+}
+
+// placeholder returns a pod asking for a node of the hint: its whole gpus, the gpu
+// model the pending pods require and, when a count label is set, a node with at least
+// as many cards, which Karpenter provisions from.
+func (ac *AutoscalerController) placeholder(hint dealer.ProvisioningHint) *v1.Pod {
+	grace := int64(0)
+	whole := v1.ResourceList{ac.options.Resource: *resource.NewQuantity(int64(hint.GPUs), resource.DecimalSI)}
+	requirements := make([]v1.NodeSelectorRequirement, 0)
+	if hint.ModelLabel != "" {
+		requirements = append(requirements, v1.NodeSelectorRequirement{
+			Key: hint.ModelLabel, Operator: v1.NodeSelectorOpIn, Values: []string{hint.Model},
+		})
+	}
+	if ac.options.CountLabel != "" {
+		requirements = append(requirements, v1.NodeSelectorRequirement{
+			Key: ac.options.CountLabel, Operator: v1.NodeSelectorOpGt, Values: []string{strconv.Itoa(hint.GPUs - 1)},
+		})
+	}
+	var affinity *v1.Affinity
+	if len(requirements) > 0 {
+		affinity = &v1.Affinity{NodeAffinity: &v1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+				NodeSelectorTerms: []v1.NodeSelectorTerm{{MatchExpressions: requirements}},
+			},
+		}}
+	}
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "nano-gpu-placeholder-",
+			Namespace:    ac.options.Namespace,
+			Labels:       map[string]string{types.LabelPlaceholder: "true"},
+			Annotations: map[string]string{
+				annotationSafeToEvict:           "true",
+				types.AnnotationPlaceholderHint: hintKey(hint),
+			},
+		},
+		Spec: v1.PodSpec{
+			PriorityClassName:             ac.options.PriorityClass,
+			TerminationGracePeriodSeconds: &grace,
+			Affinity:                      affinity,
+			Containers: []v1.Container{{
+				Name:      "placeholder",
+				Image:     ac.options.Image,
+				Resources: v1.ResourceRequirements{Requests: whole, Limits: whole},
+			}},
+		},
+	}
+}
@@ -9,11 +9,13 @@ import (
 	"time"
 
 	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
 
 	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/informers"
@@ -34,6 +36,8 @@ var (
 const (
 	defaultBackOff = 10 * time.Second
 	maxBackOff     = 360 * time.Second
+	// flushDeferredPeriod is how often writes deferred in the degraded mode are retried.
+	flushDeferredPeriod = 10 * time.Second
 )
 var Rater dealer.Rater
 
@@ -134,6 +138,12 @@ func NewController(clientset *kubernetes.Clientset, kubeInformerFactory informer
 	}
 	// Start informer goroutines.
 	go kubeInformerFactory.Start(stopCh)
+	if dealer.AllocationClient != nil {
+		// the pods of the allocation objects are read from the pod lister
+		if !clientgocache.WaitForCacheSync(stopCh, c.podInformerSynced) {
+			return nil, fmt.Errorf("wait for the pod informer to sync failed")
+		}
+	}
 
 	// Create scheduler Cache
 	c.dealer, err = dealer.NewDealer(c.clientset, c.nodeLister, c.podLister, Rater)
@@ -141,6 +151,39 @@ func NewController(clientset *kubernetes.Clientset, kubeInformerFactory informer
 		log.Errorf("create dealer failed: %s", err.Error())
 		return nil, err
 	}
+	// keep the node cache of the dealer in step with the nodes once it is ready, so
+	// that scheduling finds the nodes in memory, and follow gpu hot-plug
+	nodeInformer.Informer().AddEventHandler(clientgocache.ResourceEventHandlerFuncs{
+		AddFunc:    c.addNodeToCache,
+		UpdateFunc: c.updateNodeInCache,
+		DeleteFunc: c.deleteNodeFromCache,
+	})
+	// take the usage reports annotated by the node agents
+	nodeInformer.Informer().AddEventHandler(clientgocache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.updateAnnotatedUsage(nil, obj) },
+		UpdateFunc: c.updateAnnotatedUsage,
+	})
+	// keep shared pods off the cards of whole gpu pods
+	podInformer.Informer().AddEventHandler(clientgocache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			switch t := obj.(type) {
+			case *v1.Pod:
+				return utils.GetWholeGPUCountFromPodResource(t) > 0
+			case clientgocache.DeletedFinalStateUnknown:
+				if pod, ok := t.Obj.(*v1.Pod); ok {
+					return utils.GetWholeGPUCountFromPodResource(pod) > 0
+				}
+				return false
+			default:
+				return false
+			}
+		},
+		Handler: clientgocache.ResourceEventHandlerFuncs{
+			AddFunc:    c.updateWholeGPUPod,
+			UpdateFunc: func(_, newObj interface{}) { c.updateWholeGPUPod(newObj) },
+			DeleteFunc: c.deleteWholeGPUPod,
+		},
+	})
 
 	log.Info("begin to wait for cache")
 
@@ -165,6 +208,14 @@ func (c *Controller) GetDealer() dealer.Dealer {
 	return c.dealer
 }
 
+func (c *Controller) GetPodLister() corelisters.PodLister {
+	return c.podLister
+}
+
+func (c *Controller) GetNodeLister() corelisters.NodeLister {
+	return c.nodeLister
+}
+
 // Run will set up the event handlers
 func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 	defer runtime.HandleCrash()
@@ -189,6 +240,19 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 
 	}
 
+	if dealer.AckTimeout > 0 {
+		go wait.Until(c.alarmUnconfirmed, dealer.AckTimeout, stopCh)
+	}
+	if dealer.ThrottleThreshold > 0 {
+		go wait.Until(c.dealer.FlushDeferred, flushDeferredPeriod, stopCh)
+	}
+	if dealer.FederationURL != "" {
+		go wait.Until(func() { dealer.PublishSummary(c.dealer) }, dealer.FederationInterval, stopCh)
+	}
+	if dealer.RemoteWriteURL != "" {
+		go wait.Until(dealer.FlushRemoteWrite, dealer.RemoteWriteInterval, stopCh)
+	}
+
 	log.Info("Started workers")
 	<-stopCh
 	log.Info("Shutting down workers")
@@ -221,21 +285,31 @@ func (c *Controller) syncPod(key string) (forget bool, err error) {
 	case err != nil:
 		log.Warningf("unable to retrieve pod %v from the store: %v", key, err)
 	default:
-		if utils.IsCompletedPod(pod) {
+		if dealer.ShouldRelease(pod) {
 			log.V(2).Infof("pod %s/%s has completed.", ns, name)
-			if err := c.dealer.Release(pod); err != nil {
+			if err := c.dealer.Release(pod); err != nil && !dealer.NothingToRelease(err) {
 				log.Errorf("release pod %s/%s failed: %s", pod.Namespace, pod.Name, err.Error())
+			} else if err := c.removeReleaseFinalizer(pod); err != nil {
+				return false, err
 			}
 			c.dealer.PrintStatus(pod, "release")
 		} else {
 			if pod.Spec.NodeName == "" {
 				return true, nil
 			}
+			if pod.DeletionTimestamp != nil {
+				// terminating, the share is held until the containers are gone
+				c.dealer.MarkTerminating(pod)
+				return true, nil
+			}
 			err := c.dealer.Allocate(pod)
 			c.dealer.PrintStatus(pod, "allocate")
 			if err != nil {
 				return false, err
 			}
+			if err := c.confirm(pod); err != nil {
+				return false, err
+			}
 		}
 	}
 
@@ -303,8 +377,25 @@ func (c *Controller) updatePodInCache(oldObj, newObj interface{}) {
 	if c.dealer.KnownPod(oldPod) && utils.IsCompletedPod(newPod) {
 		needUpdate = true
 	}
+	// terminating pods are released once their containers are gone
+	if c.dealer.KnownPod(oldPod) && newPod.DeletionTimestamp != nil {
+		needUpdate = true
+	}
+	// the deletion waits for the release finalizer even if the pod is unknown
+	if dealer.HasReleaseFinalizer(newPod) && utils.IsCompletedPod(newPod) {
+		needUpdate = true
+	}
 	// 2. Need update when it's unknown and unreleased pod, and GPU annotation has been set
-	if !c.dealer.KnownPod(oldPod) && !c.dealer.PodReleased(oldPod) && utils.IsAssumed(newPod) {
+	// or the pod is bound with an allocation object
+	if !c.dealer.KnownPod(oldPod) && !c.dealer.PodReleased(oldPod) && (utils.IsAssumed(newPod) || dealer.HasAllocation(newPod)) {
+		needUpdate = true
+	}
+	// 3. Need update when the init containers of a known pod are done
+	if c.dealer.KnownPod(oldPod) && !utils.IsInitialized(oldPod) && utils.IsInitialized(newPod) {
+		needUpdate = true
+	}
+	// 4. Need update when the device plugin acknowledges the cards of a known pod
+	if c.dealer.KnownPod(oldPod) && ackChanged(oldPod, newPod) {
 		needUpdate = true
 	}
 	if needUpdate {
@@ -353,9 +444,97 @@ func (c *Controller) deletePodFromCache(obj interface{}) {
 
 	log.Infof("delete pod %s/%s", pod.Namespace, pod.Name)
 
+	if dealer.TerminatingGrace > 0 && c.dealer.KnownPod(pod) {
+		// the share of terminating pods is held until they are gone
+		if err := c.dealer.Release(pod); err != nil && !dealer.NothingToRelease(err) {
+			log.Errorf("release pod %s/%s failed: %s", pod.Namespace, pod.Name, err.Error())
+		}
+	}
 	c.dealer.Forget(pod)
 }
 
+func (c *Controller) updateWholeGPUPod(obj interface{}) {
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		log.Warningf("cannot convert to *v1.Pod: %v", obj)
+		return
+	}
+	c.dealer.UpdateWholeGPUPod(pod)
+}
+
+func (c *Controller) deleteWholeGPUPod(obj interface{}) {
+	var pod *v1.Pod
+	switch t := obj.(type) {
+	case *v1.Pod:
+		pod = t
+	case clientgocache.DeletedFinalStateUnknown:
+		var ok bool
+		pod, ok = t.Obj.(*v1.Pod)
+		if !ok {
+			log.Warningf("cannot convert to *v1.Pod: %v", t.Obj)
+			return
+		}
+	default:
+		log.Warningf("cannot convert to *v1.Pod: %v", t)
+		return
+	}
+	c.dealer.ForgetWholeGPUPod(pod)
+}
+
+func (c *Controller) addNodeToCache(obj interface{}) {
+	node, ok := obj.(*v1.Node)
+	if !ok {
+		log.Warningf("cannot convert to *v1.Node: %v", obj)
+		return
+	}
+	c.dealer.UpdateNode(node)
+}
+
+func (c *Controller) deleteNodeFromCache(obj interface{}) {
+	var node *v1.Node
+	switch t := obj.(type) {
+	case *v1.Node:
+		node = t
+	case clientgocache.DeletedFinalStateUnknown:
+		var ok bool
+		node, ok = t.Obj.(*v1.Node)
+		if !ok {
+			log.Warningf("cannot convert to *v1.Node: %v", t.Obj)
+			return
+		}
+	default:
+		log.Warningf("cannot convert to *v1.Node: %v", t)
+		return
+	}
+	c.dealer.DeleteNode(node.Name)
+}
+
+func (c *Controller) updateNodeInCache(oldObj, newObj interface{}) {
+	oldNode, ok := oldObj.(*v1.Node)
+	if !ok {
+		log.Warningf("cannot convert oldObj to *v1.Node: %v", oldObj)
+		return
+	}
+	newNode, ok := newObj.(*v1.Node)
+	if !ok {
+		log.Warningf("cannot convert newObj to *v1.Node: %v", newObj)
+		return
+	}
+	if GetGPUDeviceCountOfNode(oldNode) == GetGPUDeviceCountOfNode(newNode) &&
+		labels.Equals(oldNode.Labels, newNode.Labels) &&
+		dealer.IsMPSNode(oldNode) == dealer.IsMPSNode(newNode) &&
+		oldNode.Annotations[types.AnnotationVGPUProfiles] == newNode.Annotations[types.AnnotationVGPUProfiles] &&
+		oldNode.Annotations[types.AnnotationMIGLayout] == newNode.Annotations[types.AnnotationMIGLayout] &&
+		oldNode.Annotations[types.AnnotationMIGLayoutDesired] == newNode.Annotations[types.AnnotationMIGLayoutDesired] &&
+		oldNode.Annotations[types.AnnotationMIGProfiles] == newNode.Annotations[types.AnnotationMIGProfiles] &&
+		oldNode.Annotations[types.AnnotationGPUUUIDs] == newNode.Annotations[types.AnnotationGPUUUIDs] &&
+		oldNode.Annotations[types.AnnotationGPUExcluded] == newNode.Annotations[types.AnnotationGPUExcluded] &&
+		oldNode.Annotations[types.AnnotationSystemReserved] == newNode.Annotations[types.AnnotationSystemReserved] {
+		return
+	}
+	c.dealer.UpdateNode(newNode)
+}
+
 func getSyncPeriodFormPolicyConfig(path string) []dealer.Period {
 	syncPolicy := new(dealer.Policy)
 	yamlFile, err := ioutil.ReadFile(path)
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/record"
+	log "k8s.io/klog/v2"
+)
+
+const (
+	DefragModePropose = "propose"
+	DefragModeEvict   = "evict"
+
+	// defragPendingAge gives the scheduler a chance to place the pod before we
+	// consider it blocked by fragmentation.
+	defragPendingAge = time.Minute
+)
+
+// DefragController finds pending gpu pods which can't be placed only because free
+// share is fragmented across cards, and proposes or evicts the movable pods whose
+// departure consolidates enough free share on a single card. Evictions go through
+// the eviction API so PodDisruptionBudgets are respected.
+type DefragController struct {
+	clientset *kubernetes.Clientset
+
+	podLister corelisters.PodLister
+
+	recorder record.EventRecorder
+
+	dealer dealer.Dealer
+
+	mode string
+}
+
+func NewDefragController(clientset *kubernetes.Clientset, podLister corelisters.PodLister, d dealer.Dealer, mode string) (*DefragController, error) {
+	if mode != DefragModePropose && mode != DefragModeEvict {
+		return nil, fmt.Errorf("defrag mode %s is not supported", mode)
+	}
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "nano-gpu-scheduler"})
+
+	return &DefragController{
+		clientset: clientset,
+		podLister: podLister,
+		recorder:  recorder,
+		dealer:    d,
+		mode:      mode,
+	}, nil
+}
+
+func (dc *DefragController) Run(period time.Duration, stopCh <-chan struct{}) {
+	log.Infof("Started defrag controller in %s mode", dc.mode)
+	wait.Until(dc.defrag, period, stopCh)
+}
+
+// defrag handles at most one pending pod per round so that evicted pods are
+// rescheduled before the next consolidation is planned.
+func (dc *DefragController) defrag() {
+	pods, err := dc.podLister.List(labels.Everything())
+	if err != nil {
+		log.Errorf("list pods failed: %s", err.Error())
+		return
+	}
+	for _, pod := range pods {
+		if pod.Spec.NodeName != "" || utils.IsCompletedPod(pod) || !utils.IsGPUSharingPod(pod) {
+			continue
+		}
+		if time.Since(pod.CreationTimestamp.Time) < defragPendingAge {
+			continue
+		}
+		plan := dc.dealer.DefragPlan(pod)
+		if plan == nil {
+			continue
+		}
+		names := make([]string, len(plan.Victims))
+		for i, victim := range plan.Victims {
+			names[i] = victim.Namespace + "/" + victim.Name
+		}
+		message := fmt.Sprintf("moving %s off gpu %d of node %s consolidates %d gpu percent",
+			strings.Join(names, ","), plan.Card, plan.Node, plan.Moved)
+		log.Infof("defrag for pod %s/%s: %s", pod.Namespace, pod.Name, message)
+		dc.recorder.Event(pod, v1.EventTypeNormal, "GPUFragmented", message)
+		if dc.mode == DefragModeEvict {
+			dc.evict(plan)
+		}
+		return
+	}
+}
+
+func (dc *DefragController) evict(plan *dealer.DefragPlan) {
+	for _, victim := range plan.Victims {
+		err := dc.clientset.CoreV1().Pods(victim.Namespace).Evict(context.Background(), &policy.Eviction{
+			ObjectMeta: metav1.ObjectMeta{Namespace: victim.Namespace, Name: victim.Name},
+		})
+		if err != nil {
+			// most likely blocked by a PodDisruptionBudget, retry next round
+			log.Warningf("evict pod %s/%s failed: %s", victim.Namespace, victim.Name, err.Error())
+			return
+		}
+		dc.recorder.Eventf(victim, v1.EventTypeNormal, "GPUDefragEvicted",
+			"evicted to consolidate free share on gpu %d of node %s", plan.Card, plan.Node)
+	}
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/record"
+	log "k8s.io/klog/v2"
+)
+
+// ElasticController publishes how many replicas of an elastic training job fit right
+// now on its pending pods, so that an elastic operator like PyTorch elastic or
+// Horovod elastic starts the training at the granted size rather than waiting for
+// its max replicas.
+type ElasticController struct {
+	clientset *kubernetes.Clientset
+
+	podLister corelisters.PodLister
+
+	recorder record.EventRecorder
+
+	dealer dealer.Dealer
+}
+
+func NewElasticController(clientset *kubernetes.Clientset, podLister corelisters.PodLister, d dealer.Dealer) *ElasticController {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "nano-gpu-scheduler"})
+
+	return &ElasticController{
+		clientset: clientset,
+		podLister: podLister,
+		recorder:  recorder,
+		dealer:    d,
+	}
+}
+
+func (ec *ElasticController) Run(period time.Duration, stopCh <-chan struct{}) {
+	log.Info("Started elastic controller")
+	wait.Until(ec.publish, period, stopCh)
+}
+
+// publish grants every job once, from one of its pending pods.
+func (ec *ElasticController) publish() {
+	pods, err := ec.podLister.List(labels.Everything())
+	if err != nil {
+		log.Errorf("list pods failed: %s", err.Error())
+		return
+	}
+	grants := make(map[k8stypes.UID]int)
+	for _, pod := range pods {
+		if pod.Spec.NodeName != "" || utils.IsCompletedPod(pod) || pod.Annotations[types.AnnotationMaxReplicas] == "" {
+			continue
+		}
+		owner := metav1.GetControllerOf(pod)
+		if owner == nil {
+			continue
+		}
+		granted, ok := grants[owner.UID]
+		if !ok {
+			granted = ec.dealer.ElasticGrant(pod)
+			grants[owner.UID] = granted
+		}
+		value := strconv.Itoa(granted)
+		if pod.Annotations[types.AnnotationGrantedReplicas] == value {
+			continue
+		}
+		if err := ec.patchGrant(pod, value); err != nil {
+			log.Errorf("publish granted replicas of pod %s/%s failed: %s", pod.Namespace, pod.Name, err.Error())
+			continue
+		}
+		ec.recorder.Eventf(pod, v1.EventTypeNormal, "ReplicasGranted", "%d replicas of %s %s fit", granted, owner.Kind, owner.Name)
+	}
+}
+
+func (ec *ElasticController) patchGrant(pod *v1.Pod, value string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{types.AnnotationGrantedReplicas: value},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = ec.clientset.CoreV1().Pods(pod.Namespace).Patch(context.Background(), pod.Name, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	log "k8s.io/klog/v2"
+)
+
+// removeReleaseFinalizer lets the deletion of a released pod go on.
+func (c *Controller) removeReleaseFinalizer(pod *v1.Pod) error {
+	if !dealer.HasReleaseFinalizer(pod) {
+		return nil
+	}
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		latest, err := c.clientset.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if !dealer.HasReleaseFinalizer(latest) {
+			return nil
+		}
+		latest.Finalizers = dealer.WithoutReleaseFinalizer(latest)
+		_, err = c.clientset.CoreV1().Pods(pod.Namespace).Update(context.Background(), latest, metav1.UpdateOptions{})
+		return err
+	})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err == nil {
+		log.V(2).Infof("removed release finalizer of pod %s/%s", pod.Namespace, pod.Name)
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	log "k8s.io/klog/v2"
+)
+
+// confirm reconciles the plan of the pod with the cards the device plugin
+// acknowledged, the pod annotations follow the acknowledged cards.
+func (c *Controller) confirm(pod *v1.Pod) error {
+	indexes, annotations, err := c.dealer.Confirm(pod)
+	if indexes == nil && err == nil {
+		return nil
+	}
+	c.recorder.Eventf(pod, v1.EventTypeWarning, "GPUAssignmentMismatch",
+		"device plugin assigned gpu %v instead of the planned ones", indexes)
+	if err != nil {
+		log.Errorf("confirm pod %s/%s failed: %v", pod.Namespace, pod.Name, err)
+		return nil
+	}
+	if c.dealer.Degraded() {
+		c.dealer.DeferWrite(pod.Namespace, pod.Name, nil, annotations)
+		return nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.clientset.CoreV1().Pods(pod.Namespace).Patch(context.Background(), pod.Name, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// alarmUnconfirmed raises events for the pods the device plugin hasn't acknowledged.
+func (c *Controller) alarmUnconfirmed() {
+	for _, pod := range c.dealer.UnconfirmedPods() {
+		log.Warningf("gpu assignment of pod %s/%s is not acknowledged by the device plugin", pod.Namespace, pod.Name)
+		c.recorder.Event(pod, v1.EventTypeWarning, "GPUAssignmentUnconfirmed", "device plugin hasn't acknowledged the planned gpu")
+	}
+}
+
+func ackChanged(oldPod, newPod *v1.Pod) bool {
+	for _, container := range newPod.Spec.Containers {
+		key := fmt.Sprintf(types.AnnotationGPUContainerAck, container.Name)
+		if oldPod.Annotations[key] != newPod.Annotations[key] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/prometheus"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	log "k8s.io/klog/v2"
+)
+
+// HealthMetrics names the prometheus metrics carrying the health of every card.
+type HealthMetrics struct {
+	XID          string
+	RetiredPages string
+	DoubleBitECC string
+}
+
+// HealthController syncs the health signals of every card into the dealer, which
+// keeps unhealthy cards out of new plans.
+type HealthController struct {
+	nodeLister corelisters.NodeLister
+
+	prom prometheus.PromAPIS
+
+	dealer dealer.Dealer
+
+	metrics HealthMetrics
+}
+
+func NewHealthController(nodeLister corelisters.NodeLister, prom prometheus.PromAPIS, d dealer.Dealer, metrics HealthMetrics) *HealthController {
+	return &HealthController{
+		nodeLister: nodeLister,
+		prom:       prom,
+		dealer:     d,
+		metrics:    metrics,
+	}
+}
+
+func (hc *HealthController) Run(period time.Duration, stopCh <-chan struct{}) {
+	log.Infof("Started health controller, metrics=%+v", hc.metrics)
+	wait.Until(hc.sync, period, stopCh)
+}
+
+func (hc *HealthController) sync() {
+	nodes, err := hc.nodeLister.List(labels.Everything())
+	if err != nil {
+		log.Errorf("list nodes failed: %s", err.Error())
+		return
+	}
+	for _, node := range nodes {
+		if isNotGPUNode(node) {
+			continue
+		}
+		for card := 0; card < GetGPUDeviceCountOfNode(node); card++ {
+			health := dealer.GPUHealth{
+				XID:          hc.query(node.Name, hc.metrics.XID, card),
+				RetiredPages: hc.query(node.Name, hc.metrics.RetiredPages, card),
+				DoubleBitECC: hc.query(node.Name, hc.metrics.DoubleBitECC, card),
+			}
+			if reason := health.Reason(); reason != "" {
+				log.Warningf("gpu %d of node %s is unhealthy: %s", card, node.Name, reason)
+			}
+			hc.dealer.UpdateHealth(node.Name, card, health)
+		}
+	}
+}
+
+// query returns 0 when the metric is not configured or not reported.
+func (hc *HealthController) query(nodeName, metric string, card int) int {
+	if metric == "" {
+		return 0
+	}
+	value, err := hc.prom.QueryRawData(nodeName, metric, strconv.Itoa(card))
+	if err != nil || value == "" {
+		return 0
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Warningf("parse %s of gpu %d on %s failed: %s", metric, card, nodeName, err.Error())
+		return 0
+	}
+	return int(v)
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	clientgocache "k8s.io/client-go/tools/cache"
+	log "k8s.io/klog/v2"
+)
+
+var KueueWorkloadGVR = schema.GroupVersionResource{
+	Group:    types.KueueGroup,
+	Version:  types.KueueVersion,
+	Resource: types.KueueWorkloadResource,
+}
+
+var KueueFlavorGVR = schema.GroupVersionResource{
+	Group:    types.KueueGroup,
+	Version:  types.KueueVersion,
+	Resource: types.KueueFlavorResource,
+}
+
+// KueueController keeps the dealer in step with Kueue: the pods of a queue are only
+// placed once their workload is admitted, and the gpu share really placed on the
+// nodes of every ResourceFlavor is reported on the flavor so that the quotas of Kueue
+// can be checked against it.
+type KueueController struct {
+	dynamicClient dynamic.Interface
+
+	workloadInformer clientgocache.SharedIndexInformer
+
+	dealer dealer.Dealer
+}
+
+func NewKueueController(dynamicClient dynamic.Interface, d dealer.Dealer, resync time.Duration) *KueueController {
+	kc := &KueueController{
+		dynamicClient: dynamicClient,
+		dealer:        d,
+	}
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, resync)
+	kc.workloadInformer = factory.ForResource(KueueWorkloadGVR).Informer()
+	kc.workloadInformer.AddEventHandler(clientgocache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { kc.updateAdmissions() },
+		UpdateFunc: func(interface{}, interface{}) { kc.updateAdmissions() },
+		DeleteFunc: func(interface{}) { kc.updateAdmissions() },
+	})
+	return kc
+}
+
+// Run starts the workload informer and reports the flavor usage every period.
+func (kc *KueueController) Run(period time.Duration, stopCh <-chan struct{}) {
+	go kc.workloadInformer.Run(stopCh)
+	if ok := clientgocache.WaitForCacheSync(stopCh, kc.workloadInformer.HasSynced); !ok {
+		log.Errorf("failed to wait for kueue workload caches to sync")
+		return
+	}
+	kc.updateAdmissions()
+	log.Info("Started kueue controller")
+	wait.Until(kc.reportFlavorUsage, period, stopCh)
+}
+
+// updateAdmissions hands the owners of the admitted workloads to the dealer, the pod
+// of a plain pod workload or the job of the pods otherwise.
+func (kc *KueueController) updateAdmissions() {
+	admitted := make(map[k8stypes.UID]struct{})
+	for _, obj := range kc.workloadInformer.GetStore().List() {
+		workload, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		if _, found, _ := unstructured.NestedMap(workload.Object, "status", "admission"); !found {
+			continue
+		}
+		for _, owner := range workload.GetOwnerReferences() {
+			admitted[owner.UID] = struct{}{}
+		}
+	}
+	kc.dealer.UpdateKueueAdmissions(admitted)
+}
+
+func (kc *KueueController) reportFlavorUsage() {
+	flavors, err := kc.dynamicClient.Resource(KueueFlavorGVR).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		log.Errorf("list resource flavors failed: %s", err.Error())
+		return
+	}
+	for i := range flavors.Items {
+		flavor := &flavors.Items[i]
+		nodeLabels, _, err := unstructured.NestedStringMap(flavor.Object, "spec", "nodeLabels")
+		if err != nil {
+			log.Errorf("parse node labels of resource flavor %s failed: %s", flavor.GetName(), err.Error())
+			continue
+		}
+		usage, err := kc.dealer.FlavorUsage(nodeLabels)
+		if err != nil {
+			log.Errorf("get gpu usage of resource flavor %s failed: %s", flavor.GetName(), err.Error())
+			continue
+		}
+		data, err := json.Marshal(usage)
+		if err != nil || flavor.GetAnnotations()[types.AnnotationFlavorUsage] == string(data) {
+			continue
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]string{types.AnnotationFlavorUsage: string(data)},
+			},
+		})
+		if err != nil {
+			continue
+		}
+		_, err = kc.dynamicClient.Resource(KueueFlavorGVR).Patch(context.Background(), flavor.GetName(), k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+		if err != nil {
+			log.Errorf("report gpu usage of resource flavor %s failed: %s", flavor.GetName(), err.Error())
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/record"
+	log "k8s.io/klog/v2"
+)
+
+// migPendingAge gives the scheduler a chance to place the pod on the current layout.
+const migPendingAge = time.Minute
+
+// MIGController repartitions idle cards for pending pods whose MIG profile doesn't
+// fit the current layout. It writes the desired layout on the node, the node agent
+// applies it and reports the new layout, after which the pods are admitted. Cards
+// being reconfigured take no new pods.
+type MIGController struct {
+	clientset *kubernetes.Clientset
+
+	podLister corelisters.PodLister
+
+	recorder record.EventRecorder
+
+	dealer dealer.Dealer
+}
+
+func NewMIGController(clientset *kubernetes.Clientset, podLister corelisters.PodLister, d dealer.Dealer) *MIGController {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "nano-gpu-scheduler"})
+
+	return &MIGController{
+		clientset: clientset,
+		podLister: podLister,
+		recorder:  recorder,
+		dealer:    d,
+	}
+}
+
+func (mc *MIGController) Run(period time.Duration, stopCh <-chan struct{}) {
+	log.Info("Started mig reconfiguration controller")
+	wait.Until(mc.reconfigure, period, stopCh)
+}
+
+// reconfigure switches at most one card per round, the next round sees the desired
+// layout and doesn't pick the card again.
+func (mc *MIGController) reconfigure() {
+	pods, err := mc.podLister.List(labels.Everything())
+	if err != nil {
+		log.Errorf("list pods failed: %s", err.Error())
+		return
+	}
+	for _, pod := range pods {
+		if pod.Spec.NodeName != "" || utils.IsCompletedPod(pod) || dealer.GetMIGProfileOfPod(pod) == "" {
+			continue
+		}
+		if time.Since(pod.CreationTimestamp.Time) < migPendingAge {
+			continue
+		}
+		plan := mc.dealer.MIGReconfigurePlan(pod)
+		if plan == nil {
+			continue
+		}
+		if err := mc.patchLayout(plan); err != nil {
+			log.Errorf("reconfigure gpu %d of node %s failed: %s", plan.Card, plan.Node, err.Error())
+			return
+		}
+		if plan.Pending {
+			// patched again in case the last patch was lost
+			continue
+		}
+		log.Infof("reconfigure gpu %d of node %s to %s for pod %s/%s", plan.Card, plan.Node, plan.Profile, pod.Namespace, pod.Name)
+		mc.recorder.Eventf(pod, v1.EventTypeNormal, "MIGReconfiguring",
+			"gpu %d of node %s is repartitioned to %s", plan.Card, plan.Node, plan.Profile)
+		return
+	}
+}
+
+func (mc *MIGController) patchLayout(plan *dealer.MIGReconfiguration) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{types.AnnotationMIGLayoutDesired: plan.Layout},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = mc.clientset.CoreV1().Nodes().Patch(context.Background(), plan.Node, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
@@ -1,8 +1,12 @@
 package controller
 
 import (
+	"encoding/json"
 	"fmt"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/accelerator"
 	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
 	"k8s.io/apimachinery/pkg/labels"
 	"strconv"
 	"strings"
@@ -105,11 +109,12 @@ func (c *Controller) syncNode(key string) error {
 	for i := 0; i < gpuCount; i++ {
 		err = c.annotatorNode(newNode, metricName, strconv.Itoa(i))
 	}
+	c.dealer.RecordUsageSync(nodeName, metricName, err)
 	return err
 }
 
 func (c *Controller) annotatorNode(node *v1.Node, key, cardNum string) error {
-	value, err := c.Prom.QueryLasterData(node.Name, key, cardNum)
+	value, err := c.Prom.QueryLasterData(node.Name, metricOfNode(node, key), cardNum)
 	if len(value) == 0 || err != nil {
 		klog.Errorf("QueryLasterData %s for node %s return value: %s , error: %v", key, node.Name, value, err)
 		return errors.Errorf("QueryLasterData %s for node %s return value: %s , error: %v", key, node.Name, value, err)
@@ -124,6 +129,8 @@ func (c *Controller) annotatorNode(node *v1.Node, key, cardNum string) error {
 			c.dealer.AddCoreUsage(node.Name)
 		}
 		c.dealer.UpdateCoreUsage(node.Name, value, c.getLocalTime(), card)
+	} else if dealer.IsPressureMetric(key) {
+		c.dealer.UpdateMetricUsage(node.Name, key, value, c.getLocalTime(), card)
 	} else {
 		_, ok := c.dealer.GetMemoryUsageLock(node.Name)
 		if !ok {
@@ -154,13 +161,46 @@ func isNotGPUNode(node *v1.Node) bool {
 	if node.Labels["nvidia-device-enable"] == "enable" {
 		return false
 	}
+	if vendor := node.Labels[types.LabelGPUVendor]; vendor != "" {
+		_, ok := accelerator.Lookup(vendor)
+		return !ok
+	}
 	return true
 }
 
 func GetGPUDeviceCountOfNode(node *v1.Node) int {
-	val, ok := node.Status.Capacity[ResourceGPUPercent]
+	return utils.GetGPUDeviceCountOfNode(node)
+}
+
+// metricOfNode returns the name under which the vendor of the node reports a metric.
+func metricOfNode(node *v1.Node, key string) string {
+	if a := accelerator.ForNode(node); a != nil {
+		return a.UsageMetric(key)
+	}
+	return key
+}
+
+// updateAnnotatedUsage takes the usage report of a node when its annotation
+// changes, reports are sequenced like pushed ones.
+func (c *Controller) updateAnnotatedUsage(oldObj, newObj interface{}) {
+	node, ok := newObj.(*v1.Node)
 	if !ok {
-		return 0
+		return
+	}
+	value, ok := node.Annotations[types.AnnotationGPUUsage]
+	if !ok {
+		return
+	}
+	if old, ok := oldObj.(*v1.Node); ok && old.Annotations[types.AnnotationGPUUsage] == value {
+		return
+	}
+	var report dealer.UsageReport
+	if err := json.Unmarshal([]byte(value), &report); err != nil {
+		klog.Warningf("invalid usage annotation of node %s: %v", node.Name, err)
+		return
+	}
+	report.Node = node.Name
+	if err := c.dealer.PushUsage(report); err != nil {
+		klog.V(4).Infof("reject usage annotation of node %s: %v", node.Name, err)
 	}
-	return int(val.Value()) / GPUPercentEachCard
 }
\ No newline at end of file
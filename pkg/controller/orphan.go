@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	log "k8s.io/klog/v2"
+)
+
+// OrphanController releases the share of pods deleted while the scheduler missed
+// the delete event, e.g. across a restart or a watch gap.
+type OrphanController struct {
+	clientset *kubernetes.Clientset
+
+	dealer dealer.Dealer
+}
+
+func NewOrphanController(clientset *kubernetes.Clientset, d dealer.Dealer) *OrphanController {
+	return &OrphanController{
+		clientset: clientset,
+		dealer:    d,
+	}
+}
+
+func (oc *OrphanController) Run(period time.Duration, stopCh <-chan struct{}) {
+	log.Infof("Started orphaned allocation collector")
+	wait.Until(oc.collect, period, stopCh)
+}
+
+func (oc *OrphanController) collect() {
+	candidates := oc.dealer.KnownPodUIDs()
+	// list from the apiserver, the informer store may miss the same events. Bound pods
+	// may lack the assume label, it is written after a degraded bind and never with
+	// allocation objects, so every pod is listed from the watch cache.
+	pods, err := oc.clientset.CoreV1().Pods(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{
+		ResourceVersion: "0",
+	})
+	if err != nil {
+		log.Errorf("list pods failed: %s", err.Error())
+		return
+	}
+	live := make(map[k8stypes.UID]struct{})
+	for i := range pods.Items {
+		if !utils.IsCompletedPod(&pods.Items[i]) {
+			live[pods.Items[i].UID] = struct{}{}
+		}
+	}
+	for _, pod := range oc.dealer.ReleaseOrphans(candidates, live) {
+		log.Infof("released orphaned pod %s/%s", pod.Namespace, pod.Name)
+	}
+}
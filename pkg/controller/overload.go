@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+
+	v1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/record"
+	log "k8s.io/klog/v2"
+)
+
+const (
+	OverloadActionFlag  = "flag"
+	OverloadActionEvict = "evict"
+)
+
+// OverloadController watches the measured usage of every gpu card and, when a card
+// stays above the threshold for the sustain duration, flags or evicts the pod with
+// the lowest priority on it so that it can be rescheduled onto a cooler card.
+type OverloadController struct {
+	clientset *kubernetes.Clientset
+
+	nodeLister corelisters.NodeLister
+
+	recorder record.EventRecorder
+
+	dealer dealer.Dealer
+
+	policy []dealer.Period
+
+	threshold float64
+
+	sustain time.Duration
+
+	action string
+
+	// overSince records when a card, keyed by node/card, went above threshold.
+	overSince map[string]time.Time
+}
+
+func NewOverloadController(clientset *kubernetes.Clientset, nodeLister corelisters.NodeLister, d dealer.Dealer, policyConfigPath string, threshold float64, sustain time.Duration, action string) (*OverloadController, error) {
+	if action != OverloadActionFlag && action != OverloadActionEvict {
+		return nil, fmt.Errorf("overload action %s is not supported", action)
+	}
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "nano-gpu-scheduler"})
+
+	return &OverloadController{
+		clientset:  clientset,
+		nodeLister: nodeLister,
+		recorder:   recorder,
+		dealer:     d,
+		policy:     getSyncPeriodFormPolicyConfig(policyConfigPath),
+		threshold:  threshold,
+		sustain:    sustain,
+		action:     action,
+		overSince:  make(map[string]time.Time),
+	}, nil
+}
+
+func (oc *OverloadController) Run(period time.Duration, stopCh <-chan struct{}) {
+	log.Infof("Started overload controller, threshold=%v sustain=%v action=%s", oc.threshold, oc.sustain, oc.action)
+	wait.Until(oc.check, period, stopCh)
+}
+
+func (oc *OverloadController) check() {
+	nodes, err := oc.nodeLister.List(labels.Everything())
+	if err != nil {
+		log.Errorf("list nodes failed: %s", err.Error())
+		return
+	}
+	now := time.Now()
+	for _, node := range nodes {
+		if isNotGPUNode(node) {
+			continue
+		}
+		for card := 0; card < GetGPUDeviceCountOfNode(node); card++ {
+			key := fmt.Sprintf("%s/%d", node.Name, card)
+			if oc.cardUsage(node.Name, card) <= oc.threshold {
+				delete(oc.overSince, key)
+				continue
+			}
+			since, ok := oc.overSince[key]
+			if !ok {
+				oc.overSince[key] = now
+				continue
+			}
+			if now.Sub(since) < oc.sustain {
+				continue
+			}
+			oc.relieve(node.Name, card)
+			// start a new sustain window to give the replacement pod time to settle
+			oc.overSince[key] = now
+		}
+	}
+}
+
+// cardUsage returns the highest usage among the synced metrics of the card.
+func (oc *OverloadController) cardUsage(nodeName string, card int) float64 {
+	max := 0.0
+	for _, period := range oc.policy {
+		if dealer.IsPressureMetric(period.Name) {
+			continue
+		}
+		exist, usage, err := oc.dealer.GetUsageLock(nodeName, period.Name, card, dealer.ActiveDuration(period.Period))
+		if !exist || err != nil {
+			continue
+		}
+		if usage > max {
+			max = usage
+		}
+	}
+	return max
+}
+
+func (oc *OverloadController) relieve(nodeName string, card int) {
+	pods := oc.dealer.PodsOnCard(nodeName, card)
+	if len(pods) < 2 {
+		// a single pod owns the whole load, moving it won't help
+		return
+	}
+	victim := pods[0]
+	for _, pod := range pods[1:] {
+		if lowerPriority(pod, victim) {
+			victim = pod
+		}
+	}
+	message := fmt.Sprintf("gpu %d of node %s stays above %.0f%% utilization for %v", card, nodeName, oc.threshold*100, oc.sustain)
+	if oc.action == OverloadActionFlag {
+		log.Infof("flag pod %s/%s: %s", victim.Namespace, victim.Name, message)
+		oc.recorder.Event(victim, v1.EventTypeWarning, "GPUOverloaded", message)
+		return
+	}
+	log.Infof("evict pod %s/%s: %s", victim.Namespace, victim.Name, message)
+	err := oc.clientset.CoreV1().Pods(victim.Namespace).Evict(context.Background(), &policy.Eviction{
+		ObjectMeta: metav1.ObjectMeta{Namespace: victim.Namespace, Name: victim.Name},
+	})
+	if err != nil {
+		log.Errorf("evict pod %s/%s failed: %s", victim.Namespace, victim.Name, err.Error())
+		return
+	}
+	oc.recorder.Event(victim, v1.EventTypeWarning, "GPUOverloadEvicted", message)
+}
+
+// lowerPriority reports whether a should be descheduled before b, the newest pod
+// wins among pods with the same priority.
+func lowerPriority(a, b *v1.Pod) bool {
+	pa, pb := int32(0), int32(0)
+	if a.Spec.Priority != nil {
+		pa = *a.Spec.Priority
+	}
+	if b.Spec.Priority != nil {
+		pb = *b.Spec.Priority
+	}
+	if pa != pb {
+		return pa < pb
+	}
+	return b.CreationTimestamp.Before(&a.CreationTimestamp)
+}
@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	clientgocache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	log "k8s.io/klog/v2"
+)
+
+var ElasticQuotaGVR = schema.GroupVersionResource{
+	Group:    types.ElasticQuotaGroup,
+	Version:  types.ElasticQuotaVersion,
+	Resource: types.ElasticQuotaResource,
+}
+
+// QuotaController keeps the dealer's elastic quotas in sync with ElasticGPUQuota
+// objects and evicts borrowed share when a lender has pending pods.
+type QuotaController struct {
+	clientset *kubernetes.Clientset
+
+	podLister corelisters.PodLister
+
+	quotaInformer clientgocache.SharedIndexInformer
+
+	recorder record.EventRecorder
+
+	dealer dealer.Dealer
+
+	reclaimPeriod time.Duration
+}
+
+func NewQuotaController(clientset *kubernetes.Clientset, dynamicClient dynamic.Interface, podLister corelisters.PodLister, d dealer.Dealer, resync, reclaimPeriod time.Duration) *QuotaController {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "nano-gpu-scheduler"})
+
+	qc := &QuotaController{
+		clientset:     clientset,
+		podLister:     podLister,
+		recorder:      recorder,
+		dealer:        d,
+		reclaimPeriod: reclaimPeriod,
+	}
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, resync)
+	qc.quotaInformer = factory.ForResource(ElasticQuotaGVR).Informer()
+	qc.quotaInformer.AddEventHandler(clientgocache.ResourceEventHandlerFuncs{
+		AddFunc:    qc.updateQuota,
+		UpdateFunc: func(_, newObj interface{}) { qc.updateQuota(newObj) },
+		DeleteFunc: qc.deleteQuota,
+	})
+	return qc
+}
+
+// Run starts the quota informer and the reclaim loop.
+func (qc *QuotaController) Run(stopCh <-chan struct{}) {
+	go qc.quotaInformer.Run(stopCh)
+	if ok := clientgocache.WaitForCacheSync(stopCh, qc.quotaInformer.HasSynced); !ok {
+		log.Errorf("failed to wait for elastic quota caches to sync")
+		return
+	}
+	log.Info("Started elastic quota controller")
+	wait.Until(qc.reclaim, qc.reclaimPeriod, stopCh)
+}
+
+func (qc *QuotaController) updateQuota(obj interface{}) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		log.Warningf("cannot convert to *unstructured.Unstructured: %v", obj)
+		return
+	}
+	quota, err := quotaFromUnstructured(u)
+	if err != nil {
+		log.Errorf("parse elastic quota %s failed: %s", u.GetName(), err.Error())
+		return
+	}
+	log.Infof("update elastic quota %s: namespaces=%v min=%d max=%d", quota.Name, quota.Namespaces, quota.Min, quota.Max)
+	qc.dealer.UpdateQuota(quota)
+}
+
+func (qc *QuotaController) deleteQuota(obj interface{}) {
+	if d, ok := obj.(clientgocache.DeletedFinalStateUnknown); ok {
+		obj = d.Obj
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		log.Warningf("cannot convert to *unstructured.Unstructured: %v", obj)
+		return
+	}
+	log.Infof("delete elastic quota %s", u.GetName())
+	qc.dealer.DeleteQuota(u.GetName())
+}
+
+// reclaim evicts pods of borrowing quotas for every quota which is below its min
+// while having pending gpu pods.
+func (qc *QuotaController) reclaim() {
+	pods, err := qc.podLister.List(labels.Everything())
+	if err != nil {
+		log.Errorf("list pods failed: %s", err.Error())
+		return
+	}
+	// a pod evicted for a quota isn't evicted again for the next one
+	evicted := make(map[k8stypes.UID]struct{})
+	for _, quota := range qc.dealer.QuotaStatus() {
+		if quota.Used >= quota.Min {
+			continue
+		}
+		pending := pendingDemandOfQuota(&quota, pods)
+		if pending == 0 {
+			continue
+		}
+		amount := quota.Min - quota.Used
+		if pending < amount {
+			amount = pending
+		}
+		reclaimed := 0
+		for _, victim := range qc.dealer.ReclaimCandidates(quota.Name, amount) {
+			if reclaimed >= amount {
+				break
+			}
+			if _, ok := evicted[victim.UID]; !ok && !qc.evict(victim, &quota) {
+				continue
+			}
+			evicted[victim.UID] = struct{}{}
+			reclaimed += int(utils.GetGPUPercentFromPodResource(victim))
+		}
+	}
+}
+
+func (qc *QuotaController) evict(pod *v1.Pod, lender *dealer.ElasticQuota) bool {
+	log.Infof("reclaim gpu share for quota %s: evict pod %s/%s", lender.Name, pod.Namespace, pod.Name)
+	err := qc.clientset.CoreV1().Pods(pod.Namespace).Evict(context.Background(), &policy.Eviction{
+		ObjectMeta: metav1.ObjectMeta{Namespace: pod.Namespace, Name: pod.Name},
+	})
+	if err != nil {
+		log.Errorf("evict pod %s/%s failed: %s", pod.Namespace, pod.Name, err.Error())
+		return false
+	}
+	qc.recorder.Eventf(pod, v1.EventTypeWarning, "GPUQuotaReclaimed",
+		"evicted to return borrowed gpu share to elastic quota %s", lender.Name)
+	return true
+}
+
+func pendingDemandOfQuota(quota *dealer.ElasticQuota, pods []*v1.Pod) int {
+	namespaces := make(map[string]struct{}, len(quota.Namespaces))
+	for _, ns := range quota.Namespaces {
+		namespaces[ns] = struct{}{}
+	}
+	pending := 0
+	for _, pod := range pods {
+		if _, ok := namespaces[pod.Namespace]; !ok {
+			continue
+		}
+		if pod.Spec.NodeName != "" || utils.IsCompletedPod(pod) {
+			continue
+		}
+		pending += int(utils.GetGPUPercentFromPodResource(pod))
+	}
+	return pending
+}
+
+func quotaFromUnstructured(u *unstructured.Unstructured) (*dealer.ElasticQuota, error) {
+	namespaces, _, err := unstructured.NestedStringSlice(u.Object, "spec", "namespaces")
+	if err != nil {
+		return nil, err
+	}
+	min, _, err := unstructured.NestedInt64(u.Object, "spec", "min")
+	if err != nil {
+		return nil, err
+	}
+	max, found, err := unstructured.NestedInt64(u.Object, "spec", "max")
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		max = min
+	}
+	if min > max {
+		return nil, fmt.Errorf("min %d is bigger than max %d", min, max)
+	}
+	return &dealer.ElasticQuota{
+		Name:       u.GetName(),
+		Namespaces: namespaces,
+		Min:        int(min),
+		Max:        int(max),
+	}, nil
+}
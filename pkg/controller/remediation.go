@@ -0,0 +1,180 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/accelerator"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/record"
+	log "k8s.io/klog/v2"
+)
+
+// RemediationController moves pods off unhealthy cards. Pods owned by a controller
+// are evicted and recreated by their owner, bare pods are recreated by the
+// controller when enabled. The share of a moved pod is released by the dealer once
+// the pod is deleted, so the unhealthy card stays accounted until then.
+type RemediationController struct {
+	clientset *kubernetes.Clientset
+
+	nodeLister corelisters.NodeLister
+
+	recorder record.EventRecorder
+
+	dealer dealer.Dealer
+
+	recreateBarePods bool
+
+	// handled remembers the pods already moved so that terminating pods are not
+	// evicted twice.
+	handled map[k8stypes.UID]struct{}
+
+	// recreating holds the deleted bare pods, keyed by namespace/name, until their
+	// copy is created.
+	recreating map[string]*v1.Pod
+}
+
+func NewRemediationController(clientset *kubernetes.Clientset, nodeLister corelisters.NodeLister, d dealer.Dealer, recreateBarePods bool) *RemediationController {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "nano-gpu-scheduler"})
+
+	return &RemediationController{
+		clientset:        clientset,
+		nodeLister:       nodeLister,
+		recorder:         recorder,
+		dealer:           d,
+		recreateBarePods: recreateBarePods,
+		handled:          make(map[k8stypes.UID]struct{}),
+		recreating:       make(map[string]*v1.Pod),
+	}
+}
+
+func (rc *RemediationController) Run(period time.Duration, stopCh <-chan struct{}) {
+	log.Infof("Started remediation controller, recreateBarePods=%v", rc.recreateBarePods)
+	wait.Until(rc.remediate, period, stopCh)
+}
+
+func (rc *RemediationController) remediate() {
+	recreatePods(rc.clientset, rc.recreating)
+	nodes, err := rc.nodeLister.List(labels.Everything())
+	if err != nil {
+		log.Errorf("list nodes failed: %s", err.Error())
+		return
+	}
+	seen := make(map[k8stypes.UID]struct{})
+	for _, node := range nodes {
+		if isNotGPUNode(node) {
+			continue
+		}
+		for card, reason := range rc.dealer.GetUnhealthyCardsLock(node.Name) {
+			for _, pod := range rc.dealer.PodsOnCard(node.Name, card) {
+				seen[pod.UID] = struct{}{}
+				if _, ok := rc.handled[pod.UID]; ok || pod.DeletionTimestamp != nil {
+					continue
+				}
+				message := fmt.Sprintf("gpu %d of node %s is unhealthy: %s", card, node.Name, reason)
+				if err := rc.move(pod, message); err != nil {
+					log.Errorf("move pod %s/%s off unhealthy gpu failed: %s", pod.Namespace, pod.Name, err.Error())
+					continue
+				}
+				rc.handled[pod.UID] = struct{}{}
+			}
+		}
+	}
+	for uid := range rc.handled {
+		if _, ok := seen[uid]; !ok {
+			delete(rc.handled, uid)
+		}
+	}
+}
+
+func (rc *RemediationController) move(pod *v1.Pod, message string) error {
+	if metav1.GetControllerOf(pod) != nil {
+		log.Infof("evict pod %s/%s: %s", pod.Namespace, pod.Name, message)
+		err := rc.clientset.CoreV1().Pods(pod.Namespace).Evict(context.Background(), &policy.Eviction{
+			ObjectMeta: metav1.ObjectMeta{Namespace: pod.Namespace, Name: pod.Name},
+		})
+		if err != nil {
+			return err
+		}
+		rc.recorder.Event(pod, v1.EventTypeWarning, "GPUUnhealthyEvicted", message)
+		return nil
+	}
+	if !rc.recreateBarePods {
+		rc.recorder.Event(pod, v1.EventTypeWarning, "GPUUnhealthy", message)
+		return nil
+	}
+	log.Infof("delete pod %s/%s for recreation: %s", pod.Namespace, pod.Name, message)
+	err := rc.clientset.CoreV1().Pods(pod.Namespace).Delete(context.Background(), pod.Name, metav1.DeleteOptions{})
+	if err != nil {
+		return err
+	}
+	rc.recorder.Event(pod, v1.EventTypeWarning, "GPUUnhealthyRecreated", message)
+	rc.recreating[pod.Namespace+"/"+pod.Name] = podForRecreate(pod)
+	return nil
+}
+
+// recreatePods creates the copies of the deleted bare pods, a copy is rejected as
+// long as the old pod is terminating and is retried next round.
+func recreatePods(clientset *kubernetes.Clientset, recreating map[string]*v1.Pod) {
+	for key, pod := range recreating {
+		_, err := clientset.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+		if errors.IsAlreadyExists(err) {
+			continue
+		}
+		if err != nil {
+			log.Errorf("recreate pod %s failed: %s", key, err.Error())
+			continue
+		}
+		log.Infof("recreated pod %s", key)
+		delete(recreating, key)
+	}
+}
+
+// podForRecreate returns an unscheduled copy of the pod without the gpu assignment.
+func podForRecreate(pod *v1.Pod) *v1.Pod {
+	newPod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        pod.Name,
+			Namespace:   pod.Namespace,
+			Labels:      make(map[string]string),
+			Annotations: make(map[string]string),
+		},
+		Spec: *pod.Spec.DeepCopy(),
+	}
+	for k, v := range pod.Labels {
+		if k != types.LabelGPUAssume {
+			newPod.Labels[k] = v
+		}
+	}
+	for k, v := range pod.Annotations {
+		if k != types.AnnotationGPUAssume {
+			newPod.Annotations[k] = v
+		}
+	}
+	a := accelerator.ForPod(pod)
+	for _, c := range pod.Spec.Containers {
+		delete(newPod.Annotations, a.ContainerAnnotation(c.Name))
+		delete(newPod.Annotations, fmt.Sprintf(types.AnnotationGPUContainerAck, c.Name))
+	}
+	for _, c := range pod.Spec.InitContainers {
+		delete(newPod.Annotations, a.ContainerAnnotation(c.Name))
+	}
+	newPod.Spec.NodeName = ""
+	return newPod
+}
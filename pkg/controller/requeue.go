@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/record"
+	log "k8s.io/klog/v2"
+)
+
+// RequeueController signals the pods waiting for gpu capacity when a node frees
+// enough for them with an event and, when annotate is set, by touching an
+// annotation of the pod, which makes the default scheduler retry it at once instead
+// of after its backoff.
+type RequeueController struct {
+	clientset *kubernetes.Clientset
+
+	podLister corelisters.PodLister
+
+	recorder record.EventRecorder
+
+	dealer dealer.Dealer
+
+	annotate bool
+}
+
+func NewRequeueController(clientset *kubernetes.Clientset, podLister corelisters.PodLister, d dealer.Dealer, annotate bool) *RequeueController {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "nano-gpu-scheduler"})
+
+	return &RequeueController{
+		clientset: clientset,
+		podLister: podLister,
+		recorder:  recorder,
+		dealer:    d,
+		annotate:  annotate,
+	}
+}
+
+func (rc *RequeueController) Run(period time.Duration, stopCh <-chan struct{}) {
+	log.Infof("Started requeue controller, threshold=%d, annotate=%v", dealer.RequeueThreshold, rc.annotate)
+	wait.Until(rc.reconcile, period, stopCh)
+}
+
+func (rc *RequeueController) reconcile() {
+	for _, q := range rc.dealer.RequeueCandidates() {
+		pod, err := rc.podLister.Pods(q.Namespace).Get(q.Name)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			log.Errorf("get pod %s/%s failed: %s", q.Namespace, q.Name, err.Error())
+			continue
+		}
+		if pod.UID != q.UID || pod.Spec.NodeName != "" {
+			continue
+		}
+		rc.recorder.Eventf(pod, v1.EventTypeNormal, "GPUCapacityFreed", "node %s freed enough gpu for the pod", q.ClosestNode)
+		if !rc.annotate {
+			continue
+		}
+		if err := rc.touch(pod); err != nil {
+			log.Errorf("requeue pod %s/%s failed: %s", pod.Namespace, pod.Name, err.Error())
+		}
+	}
+}
+
+// touch updates the requeue annotation of the pod.
+func (rc *RequeueController) touch(pod *v1.Pod) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{types.AnnotationRequeue: time.Now().Format(time.RFC3339)},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = rc.clientset.CoreV1().Pods(pod.Namespace).Patch(context.Background(), pod.Name, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
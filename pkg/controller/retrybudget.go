@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/record"
+	log "k8s.io/klog/v2"
+)
+
+// RetryBudgetController reports the pods which used up their retry budget waiting
+// for gpu capacity with a definitive event naming what limited them, so that their
+// users stop waiting on requests the cluster can't hold.
+type RetryBudgetController struct {
+	podLister corelisters.PodLister
+
+	recorder record.EventRecorder
+
+	dealer dealer.Dealer
+}
+
+func NewRetryBudgetController(clientset *kubernetes.Clientset, podLister corelisters.PodLister, d dealer.Dealer) *RetryBudgetController {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "nano-gpu-scheduler"})
+
+	return &RetryBudgetController{
+		podLister: podLister,
+		recorder:  recorder,
+		dealer:    d,
+	}
+}
+
+func (rc *RetryBudgetController) Run(period time.Duration, stopCh <-chan struct{}) {
+	log.Infof("Started retry budget controller, budget=%d", dealer.RetryBudget)
+	wait.Until(rc.reconcile, period, stopCh)
+}
+
+func (rc *RetryBudgetController) reconcile() {
+	for _, q := range rc.dealer.ExhaustedPods() {
+		pod, err := rc.podLister.Pods(q.Namespace).Get(q.Name)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			log.Errorf("get pod %s/%s failed: %s", q.Namespace, q.Name, err.Error())
+			continue
+		}
+		if pod.UID != q.UID || pod.Spec.NodeName != "" {
+			continue
+		}
+		log.Warningf("pod %s/%s will never fit after %d attempts, limited by %s", pod.Namespace, pod.Name, q.Attempts, q.Limit)
+		rc.recorder.Eventf(pod, v1.EventTypeWarning, "GPUWillNeverFit",
+			"gpu demand %v will never fit after %d attempts, limited by %s: the closest node %s misses %d gpu percent",
+			q.Demand, q.Attempts, q.Limit, q.ClosestNode, q.Shortfall)
+	}
+}
@@ -0,0 +1,212 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	log "k8s.io/klog/v2"
+)
+
+// ShardOptions configure the shard of the gpu nodes a scheduler owns.
+type ShardOptions struct {
+	// Name is the shard, the schedulers of a shard compete for its Lease.
+	Name string
+	// Namespace holds the Leases of the shards.
+	Namespace string
+	// Identity is the scheduler instance holding the Lease.
+	Identity string
+	// LeaseDuration is how long the Lease is held without renewal.
+	LeaseDuration time.Duration
+	// RenewDeadline is how long the holder keeps the shard without renewal, it is
+	// shorter than LeaseDuration so that the holder gives the shard up before
+	// another scheduler may take it.
+	RenewDeadline time.Duration
+}
+
+// ShardController records the ownership of the shard in a Lease and renews it, the
+// dealer places gpu pods only while the Lease is held. A Lease held by another
+// scheduler is taken over once it expires. The holder shares the pods it placed but
+// which aren't bound yet in a ConfigMap, the scheduler taking over reserves their
+// share before placing any pod.
+type ShardController struct {
+	clientset *kubernetes.Clientset
+	dealer    dealer.Dealer
+	options   ShardOptions
+
+	held bool
+	// renewed is when the last successful renewal started, no later than the
+	// RenewTime it wrote.
+	renewed time.Time
+}
+
+func NewShardController(clientset *kubernetes.Clientset, d dealer.Dealer, options ShardOptions) *ShardController {
+	return &ShardController{
+		clientset: clientset,
+		dealer:    d,
+		options:   options,
+	}
+}
+
+func (sc *ShardController) Run(stopCh <-chan struct{}) {
+	log.Infof("Started shard %s as %s", sc.options.Name, sc.options.Identity)
+	wait.Until(sc.sync, sc.options.LeaseDuration/3, stopCh)
+	sc.dealer.SetShardHeld(false)
+}
+
+func (sc *ShardController) sync() {
+	now := time.Now()
+	held, err := sc.renew(now)
+	switch {
+	case err != nil:
+		// keep the shard until the renew deadline, well before the lease runs out
+		log.Warningf("renew lease of shard %s failed: %v", sc.options.Name, err)
+		held = sc.held && time.Since(sc.renewed) < sc.renewDeadline()
+	case held:
+		sc.renewed = now
+	}
+	if held != sc.held {
+		log.Infof("shard %s held by %s: %v", sc.options.Name, sc.options.Identity, held)
+	}
+	if held && !sc.held {
+		sc.importAssumptions()
+	}
+	sc.held = held
+	sc.dealer.SetShardHeld(held)
+	if held {
+		if err := sc.shareAssumptions(); err != nil {
+			log.Warningf("share assumptions of shard %s failed: %v", sc.options.Name, err)
+		}
+	}
+}
+
+// renewDeadline returns the RenewDeadline, two thirds of the LeaseDuration when
+// unset like the defaults of client-go leader election.
+func (sc *ShardController) renewDeadline() time.Duration {
+	if d := sc.options.RenewDeadline; d > 0 && d < sc.options.LeaseDuration {
+		return d
+	}
+	return sc.options.LeaseDuration * 2 / 3
+}
+
+func (sc *ShardController) assumedName() string {
+	return types.ShardLeasePrefix + sc.options.Name + types.ShardAssumedSuffix
+}
+
+// importAssumptions reserves the pods in flight left by the former holder.
+func (sc *ShardController) importAssumptions() {
+	cm, err := sc.clientset.CoreV1().ConfigMaps(sc.options.Namespace).Get(context.Background(), sc.assumedName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return
+	}
+	if err != nil {
+		log.Warningf("get assumptions of shard %s failed: %v", sc.options.Name, err)
+		return
+	}
+	assumptions := make([]dealer.Assumption, 0)
+	if err := json.Unmarshal([]byte(cm.Data[types.ShardAssumedKey]), &assumptions); err != nil {
+		log.Warningf("parse assumptions of shard %s failed: %v", sc.options.Name, err)
+		return
+	}
+	n := sc.dealer.ImportAssumptions(assumptions)
+	log.Infof("shard %s imported %d of %d assumptions", sc.options.Name, n, len(assumptions))
+}
+
+// shareAssumptions records the pods in flight for the next holder.
+func (sc *ShardController) shareAssumptions() error {
+	data, err := json.Marshal(sc.dealer.InFlightAssumptions())
+	if err != nil {
+		return err
+	}
+	cms := sc.clientset.CoreV1().ConfigMaps(sc.options.Namespace)
+	cm, err := cms.Get(context.Background(), sc.assumedName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		cm = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Namespace: sc.options.Namespace, Name: sc.assumedName()},
+			Data:       map[string]string{types.ShardAssumedKey: string(data)},
+		}
+		_, err = cms.Create(context.Background(), cm, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if cm.Data[types.ShardAssumedKey] == string(data) {
+		return nil
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[types.ShardAssumedKey] = string(data)
+	_, err = cms.Update(context.Background(), cm, metav1.UpdateOptions{})
+	return err
+}
+
+// renew takes or renews the Lease of the shard, it returns false when another live
+// scheduler holds it.
+func (sc *ShardController) renew(now time.Time) (bool, error) {
+	leases := sc.clientset.CoordinationV1().Leases(sc.options.Namespace)
+	name := types.ShardLeasePrefix + sc.options.Name
+	lease, err := leases.Get(context.Background(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Namespace: sc.options.Namespace, Name: name}}
+		sc.hold(lease, now)
+		_, err = leases.Create(context.Background(), lease, metav1.CreateOptions{})
+		return err == nil, err
+	}
+	if err != nil {
+		return false, err
+	}
+	if !shardLeaseFree(lease, sc.options.Identity, now) {
+		return false, nil
+	}
+	sc.hold(lease, now)
+	_, err = leases.Update(context.Background(), lease, metav1.UpdateOptions{})
+	return err == nil, err
+}
+
+// shardLeaseFree determines if the identity may hold the Lease, it holds it already
+// or the holder let it expire.
+func shardLeaseFree(lease *coordinationv1.Lease, identity string, now time.Time) bool {
+	spec := lease.Spec
+	if spec.HolderIdentity == nil || *spec.HolderIdentity == identity || *spec.HolderIdentity == "" {
+		return true
+	}
+	if spec.RenewTime == nil || spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	return now.After(spec.RenewTime.Add(time.Duration(*spec.LeaseDurationSeconds) * time.Second))
+}
+
+func (sc *ShardController) hold(lease *coordinationv1.Lease, now time.Time) {
+	renew := metav1.NewMicroTime(now)
+	duration := int32(sc.options.LeaseDuration / time.Second)
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != sc.options.Identity {
+		identity := sc.options.Identity
+		transitions := int32(0)
+		if lease.Spec.LeaseTransitions != nil {
+			transitions = *lease.Spec.LeaseTransitions
+		}
+		if lease.Spec.HolderIdentity != nil {
+			transitions++
+		}
+		lease.Spec.HolderIdentity = &identity
+		lease.Spec.AcquireTime = &renew
+		lease.Spec.LeaseTransitions = &transitions
+	}
+	lease.Spec.RenewTime = &renew
+	lease.Spec.LeaseDurationSeconds = &duration
+	if lease.Annotations == nil {
+		lease.Annotations = map[string]string{}
+	}
+	lease.Annotations[types.AnnotationShardSelector] = dealer.ShardSelector.String()
+}
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/record"
+	log "k8s.io/klog/v2"
+)
+
+// StuckController finds the pods bound by the dealer which don't reach Running in
+// time, e.g. stuck in ContainerCreating after a device plugin failure. When enabled
+// the pods are deleted, which releases their share, and requeued: owned pods by
+// their owner and bare pods by a copy without node.
+type StuckController struct {
+	clientset *kubernetes.Clientset
+
+	podLister corelisters.PodLister
+
+	recorder record.EventRecorder
+
+	dealer dealer.Dealer
+
+	timeout time.Duration
+
+	release bool
+
+	// reported remembers the stuck pods already reported.
+	reported map[k8stypes.UID]struct{}
+
+	// recreating holds the deleted bare pods, keyed by namespace/name, until their
+	// copy is created.
+	recreating map[string]*v1.Pod
+}
+
+func NewStuckController(clientset *kubernetes.Clientset, podLister corelisters.PodLister, d dealer.Dealer, timeout time.Duration, release bool) *StuckController {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "nano-gpu-scheduler"})
+
+	return &StuckController{
+		clientset:  clientset,
+		podLister:  podLister,
+		recorder:   recorder,
+		dealer:     d,
+		timeout:    timeout,
+		release:    release,
+		reported:   make(map[k8stypes.UID]struct{}),
+		recreating: make(map[string]*v1.Pod),
+	}
+}
+
+func (sc *StuckController) Run(period time.Duration, stopCh <-chan struct{}) {
+	log.Infof("Started stuck pod controller, timeout=%v, release=%v", sc.timeout, sc.release)
+	wait.Until(sc.reconcile, period, stopCh)
+}
+
+func (sc *StuckController) reconcile() {
+	recreatePods(sc.clientset, sc.recreating)
+	pods, err := sc.podLister.List(labels.SelectorFromSet(labels.Set{types.LabelGPUAssume: "true"}))
+	if err != nil {
+		log.Errorf("list pods failed: %s", err.Error())
+		return
+	}
+	seen := make(map[k8stypes.UID]struct{})
+	now := time.Now()
+	for _, pod := range pods {
+		if !isStuck(pod, now, sc.timeout) || !sc.dealer.KnownPod(pod) {
+			continue
+		}
+		seen[pod.UID] = struct{}{}
+		if _, ok := sc.reported[pod.UID]; ok {
+			continue
+		}
+		message := fmt.Sprintf("pod is not running %v after it was bound to node %s", sc.timeout, pod.Spec.NodeName)
+		log.Warningf("pod %s/%s is stuck: %s", pod.Namespace, pod.Name, message)
+		if !sc.release {
+			sc.recorder.Event(pod, v1.EventTypeWarning, "GPUPodStuck", message)
+			sc.reported[pod.UID] = struct{}{}
+			continue
+		}
+		if err := sc.requeue(pod, message); err != nil {
+			log.Errorf("requeue stuck pod %s/%s failed: %s", pod.Namespace, pod.Name, err.Error())
+			continue
+		}
+		sc.reported[pod.UID] = struct{}{}
+	}
+	for uid := range sc.reported {
+		if _, ok := seen[uid]; !ok {
+			delete(sc.reported, uid)
+		}
+	}
+}
+
+func (sc *StuckController) requeue(pod *v1.Pod, message string) error {
+	log.Infof("delete stuck pod %s/%s", pod.Namespace, pod.Name)
+	err := sc.clientset.CoreV1().Pods(pod.Namespace).Delete(context.Background(), pod.Name, metav1.DeleteOptions{})
+	if err != nil {
+		return err
+	}
+	sc.recorder.Event(pod, v1.EventTypeWarning, "GPUPodStuckRequeued", message)
+	if metav1.GetControllerOf(pod) == nil {
+		sc.recreating[pod.Namespace+"/"+pod.Name] = podForRecreate(pod)
+	}
+	return nil
+}
+
+// isStuck determines if the pod is still pending the timeout after it was bound.
+func isStuck(pod *v1.Pod, now time.Time, timeout time.Duration) bool {
+	if pod.Spec.NodeName == "" || pod.DeletionTimestamp != nil || pod.Status.Phase != v1.PodPending {
+		return false
+	}
+	bound := pod.CreationTimestamp.Time
+	for _, c := range pod.Status.Conditions {
+		if c.Type == v1.PodScheduled && c.Status == v1.ConditionTrue {
+			bound = c.LastTransitionTime.Time
+		}
+	}
+	return now.Sub(bound) > timeout
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
 	v1 "k8s.io/api/core/v1"
@@ -24,6 +25,7 @@ type Plan struct {
 	Demand     Demand
 	GPUIndexes []int
 	Score      int
+	Init       *InitPlan
 }
 
 func NewPlanFromPod(pod *v1.Pod) (*Plan, error) {
@@ -35,27 +37,43 @@ func NewPlanFromPod(pod *v1.Pod) (*Plan, error) {
 		GPUIndexes: make([]int, len(pod.Spec.Containers)),
 		Score:      0,
 	}
+	structured, _, err := structuredPlan(pod)
+	if err != nil {
+		klog.Warningf("ignore plan annotation of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+	}
 	for i, c := range pod.Spec.Containers {
 		plan.Demand[i] = GPUResource{
-			Percent: utils.GetGPUPercentFromContainer(&c),
+			Percent: guaranteedPercent(pod, &c),
 		}
 		idx, err := utils.GetContainerAssignIndex(pod, c.Name)
+		if err != nil && structured != nil {
+			if cp, ok := structured.container(c.Name); ok {
+				idx, err = cp.Index, nil
+			}
+		}
 		if err != nil {
+			// sidecars without gpu may carry no annotation at all
 			idx = 0
+			if plan.Demand[i].Percent == 0 {
+				idx = NotNeedGPU
+			}
 		}
 		plan.GPUIndexes[i] = idx
 	}
+	plan.Init = initPlanFromPod(pod, plan)
 
 	return plan, nil
 }
 
+// Demand holds the share of every container of a pod, containers without gpu
+// take 0 and are left off the cards.
 type Demand []GPUResource
 
 func NewDemandFromPod(pod *v1.Pod) Demand {
 	ans := make(Demand, len(pod.Spec.Containers))
 	for i, container := range pod.Spec.Containers {
 		ans[i] = GPUResource{
-			Percent: utils.GetGPUPercentFromContainer(&container),
+			Percent: guaranteedPercent(pod, &container),
 		}
 	}
 	return ans
@@ -89,12 +107,28 @@ func (d *Demand) ToSortableGPUs() SortableGPUs {
 
 type GPUs []*GPUResource
 
+// ErrNoCards is a plan failing because no cards hold the shares of the demand.
+var ErrNoCards = errors.New("no cards fit the gpu shares")
+
 func (g GPUs) Choose(demand Demand, rater Rater, d Dealer, policySpec PolicySpec, nodeName string, isLoadSchedule bool) (ans *Plan, err error) {
 	ans = &Plan{
 		Demand: demand,
 	}
+	if isLoadSchedule && d.UsageBreakerOpen(nodeName) {
+		isLoadSchedule = false
+	}
 	ans.Score = rater.Rate(g, ans, d, policySpec, nodeName, isLoadSchedule)
-	ans.GPUIndexes, err = rater.Choose(g, demand)
+	if isLoadSchedule {
+		ans.Score -= g.pressurePenalty(d, policySpec, nodeName)
+	}
+	if nr, ok := rater.(NodeAwareRater); ok {
+		ans.GPUIndexes, err = nr.ChooseOnNode(g, demand, d, policySpec, nodeName)
+	} else {
+		ans.GPUIndexes, err = rater.Choose(g, demand)
+	}
+	if err != nil {
+		err = fmt.Errorf("%w: %v", ErrNoCards, err)
+	}
 
 	return
 }
@@ -108,12 +142,25 @@ func (g GPUs) Allocate(plan *Plan) error {
 		if !g[plan.GPUIndexes[i]].CanAllocate(plan.Demand[i]) {
 			// restore
 			for j := 0; j < i; j++ {
-				g[plan.GPUIndexes[j]].Add(plan.Demand[i])
+				if plan.GPUIndexes[j] >= 0 {
+					g[plan.GPUIndexes[j]].Add(plan.Demand[j])
+				}
 			}
 			return fmt.Errorf("can't apply plan %v on %s", plan, g)
 		}
 		g[plan.GPUIndexes[i]].Sub(plan.Demand[i])
 	}
+	if plan.Init != nil && plan.Init.Extra > 0 {
+		if g[plan.Init.Index].Percent < plan.Init.Extra {
+			for j := range plan.GPUIndexes {
+				if plan.GPUIndexes[j] >= 0 {
+					g[plan.GPUIndexes[j]].Add(plan.Demand[j])
+				}
+			}
+			return fmt.Errorf("can't apply init containers of plan %v on %s", plan, g)
+		}
+		g[plan.Init.Index].Percent -= plan.Init.Extra
+	}
 	return nil
 }
 
@@ -127,6 +174,9 @@ func (g GPUs) Release(plan *Plan) error {
 		}
 		g[plan.GPUIndexes[i]].Add(plan.Demand[i])
 	}
+	if plan.Init != nil && plan.Init.Index < len(g) {
+		g[plan.Init.Index].Percent += plan.Init.Extra
+	}
 	return nil
 }
 
@@ -173,6 +223,9 @@ func (gpus GPUs) Usage() float64 {
 func (g *GPUResource) LoadUsage(d Dealer, gpuIndex int, policySpec PolicySpec, nodeName string) float64 {
 	var usage float64 = 0
 	for _, priorityPolicy := range policySpec.SyncPeriod {
+		if IsPressureMetric(priorityPolicy.Name) {
+			continue
+		}
 		activeDuration, err := getActiveDuration(policySpec.SyncPeriod, priorityPolicy.Name)
 		if err != nil || activeDuration == 0 {
 			klog.Warningf("getScore %s, getactiveDuration error %s", priorityPolicy.Name, err)
@@ -183,12 +236,19 @@ func (g *GPUResource) LoadUsage(d Dealer, gpuIndex int, policySpec PolicySpec, n
 		if !exist {
 			continue
 		}
-		if err != nil {
+		if errors.Is(err, ErrUsageStale) {
+			var ok bool
+			if priorityUsage, ok = g.staleUsage(); !ok {
+				klog.Warningf("%s of gpu %d on %s is stale, set %s score=0", priorityPolicy.Name, gpuIndex, nodeName, priorityPolicy.Name)
+				continue
+			}
+		} else if err != nil {
 			klog.Errorf("error %v when get score, set %s score=0", err, priorityPolicy.Name)
 			continue
 		}
+		priorityUsage = predictUsage(nodeName, priorityPolicy.Name, gpuIndex, priorityUsage)
 		priorityUsage = math.Ceil(10*priorityUsage) / 10
-		usage += priorityUsage
+		usage += policySpec.Weight(priorityPolicy.Name) * priorityUsage
 	}
     g.RemainLoad = LoadTotal - int(usage)
 	return usage
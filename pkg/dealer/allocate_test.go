@@ -11,6 +11,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
 )
 
 func TestGPUResource(t *testing.T) {
@@ -21,17 +22,17 @@ func TestGPUResource(t *testing.T) {
 	}
 	subTests := []Cases{
 		{
-			Origin: GPUResource{100, 100},
-			Target: GPUResource{80, 100},
-			Expect: GPUResource{20, 100},
+			Origin: GPUResource{100, 100, 0},
+			Target: GPUResource{80, 100, 0},
+			Expect: GPUResource{20, 100, 0},
 		}, {
-			Origin: GPUResource{100, 100},
-			Target: GPUResource{20, 100},
-			Expect: GPUResource{80, 100},
+			Origin: GPUResource{100, 100, 0},
+			Target: GPUResource{20, 100, 0},
+			Expect: GPUResource{80, 100, 0},
 		}, {
-			Origin: GPUResource{100, 100},
-			Target: GPUResource{0, 100},
-			Expect: GPUResource{100, 100},
+			Origin: GPUResource{100, 100, 0},
+			Target: GPUResource{0, 100, 0},
+			Expect: GPUResource{100, 100, 0},
 		},
 	}
 	for _, tc := range subTests {
@@ -41,13 +42,13 @@ func TestGPUResource(t *testing.T) {
 
 	addTests := []Cases{
 		{
-			Origin: GPUResource{20, 100},
-			Target: GPUResource{80, 100},
-			Expect: GPUResource{100, 100},
+			Origin: GPUResource{20, 100, 0},
+			Target: GPUResource{80, 100, 0},
+			Expect: GPUResource{100, 100, 0},
 		}, {
-			Origin: GPUResource{10, 100},
-			Target: GPUResource{80, 100},
-			Expect: GPUResource{90, 100},
+			Origin: GPUResource{10, 100, 0},
+			Target: GPUResource{80, 100, 0},
+			Expect: GPUResource{90, 100, 0},
 		},
 	}
 	for _, tc := range addTests {
@@ -56,25 +57,25 @@ func TestGPUResource(t *testing.T) {
 	}
 	subIfAvailedTests := []Cases{
 		{
-			Origin: GPUResource{100, 100},
-			Target: GPUResource{80, 100},
-			Expect: GPUResource{20, 100},
+			Origin: GPUResource{100, 100, 0},
+			Target: GPUResource{80, 100, 0},
+			Expect: GPUResource{20, 100, 0},
 		}, {
-			Origin: GPUResource{100, 100},
-			Target: GPUResource{80, 100},
-			Expect: GPUResource{20, 100},
+			Origin: GPUResource{100, 100, 0},
+			Target: GPUResource{80, 100, 0},
+			Expect: GPUResource{20, 100, 0},
 		}, {
-			Origin: GPUResource{100, 100},
-			Target: GPUResource{100, 100},
-			Expect: GPUResource{0, 100},
+			Origin: GPUResource{100, 100, 0},
+			Target: GPUResource{100, 100, 0},
+			Expect: GPUResource{0, 100, 0},
 		}, {
-			Origin: GPUResource{100, 100},
-			Target: GPUResource{120, 100},
-			Expect: GPUResource{100, 100},
+			Origin: GPUResource{100, 100, 0},
+			Target: GPUResource{120, 100, 0},
+			Expect: GPUResource{100, 100, 0},
 		}, {
-			Origin: GPUResource{30, 100},
-			Target: GPUResource{100, 100},
-			Expect: GPUResource{30, 100},
+			Origin: GPUResource{30, 100, 0},
+			Target: GPUResource{100, 100, 0},
+			Expect: GPUResource{30, 100, 0},
 		},
 	}
 	for _, tc := range subIfAvailedTests {
@@ -123,8 +124,8 @@ func MockPodWithDemand(demand Demand) *v1.Pod {
 
 func TestNewDemandFromPod(t *testing.T) {
 	demandList := []Demand{
-		{{100, 0}, {100, 0}},
-		{{100, 0}, {50, 0}, {50, 0}},
+		{{100, 0, 0}, {100, 0, 0}},
+		{{100, 0, 0}, {50, 0, 0}, {50, 0, 0}},
 		{},
 	}
 	for _, demand := range demandList {
@@ -136,15 +137,15 @@ func TestNewDemandFromPod(t *testing.T) {
 func TestNewPlanFromPod(t *testing.T) {
 	plans := []Plan{
 		{
-			Demand:     Demand{{100, 0}, {100, 0}},
+			Demand:     Demand{{100, 0, 0}, {100, 0, 0}},
 			GPUIndexes: []int{0, 1},
 			Score:      0,
 		}, {
-			Demand:     Demand{{50, 0}, {100, 0}},
+			Demand:     Demand{{50, 0, 0}, {100, 0, 0}},
 			GPUIndexes: []int{0, 0},
 			Score:      0,
 		}, {
-			Demand:     Demand{{100, 0}, {100, 0}},
+			Demand:     Demand{{100, 0, 0}, {100, 0, 0}},
 			GPUIndexes: []int{0, 0},
 			Score:      0,
 		},
@@ -157,6 +158,31 @@ func TestNewPlanFromPod(t *testing.T) {
 	}
 }
 
+func TestMultiContainerPod(t *testing.T) {
+	// an inference container with a gpu-less sidecar
+	pod := MockPodWithDemand(Demand{{Percent: 60}, {Percent: 0}, {Percent: 30}})
+	for i := range pod.Spec.Containers {
+		pod.Spec.Containers[i].Name = strconv.Itoa(i)
+	}
+	demand := NewDemandFromPod(pod)
+	gpus := GPUs{{Percent: 100, PercentTotal: 100}, {Percent: 30, PercentTotal: 100}}
+	plan, err := gpus.Choose(demand, &Binpack{}, nil, PolicySpec{}, "", false)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, NotNeedGPU, 1}, plan.GPUIndexes)
+
+	assumed := utils.GetUpdatedPodAnnotationSpec(pod, plan.GPUIndexes)
+	assert.Equal(t, []int{0, 1}, utils.GetGPUIDFromAnnotation(assumed))
+	delete(assumed.Annotations, fmt.Sprintf(types.AnnotationGPUContainerOn, "1"))
+	restored, err := NewPlanFromPod(assumed)
+	assert.Nil(t, err)
+	assert.Equal(t, plan.GPUIndexes, restored.GPUIndexes)
+
+	// a failed allocation leaves every card as it was
+	gpus[1].Percent = 20
+	assert.NotNil(t, gpus.Allocate(plan))
+	assert.Equal(t, GPUs{{Percent: 100, PercentTotal: 100}, {Percent: 20, PercentTotal: 100}}, gpus)
+}
+
 func TestChoose(t *testing.T) {
 	type ChooseCase struct {
 		GPUs    GPUs
@@ -165,43 +191,43 @@ func TestChoose(t *testing.T) {
 	}
 	chooses := []ChooseCase{
 		{
-			GPUs:    []*GPUResource{{100, 0}, {100, 0}},
-			Demand:  []GPUResource{{50, 0}, {50, 0}},
+			GPUs:    []*GPUResource{{100, 0, 0}, {100, 0, 0}},
+			Demand:  []GPUResource{{50, 0, 0}, {50, 0, 0}},
 			Success: true,
 		}, {
-			GPUs:    []*GPUResource{{100, 0}},
-			Demand:  []GPUResource{{50, 0}, {50, 0}},
+			GPUs:    []*GPUResource{{100, 0, 0}},
+			Demand:  []GPUResource{{50, 0, 0}, {50, 0, 0}},
 			Success: true,
 		}, {
-			GPUs:    []*GPUResource{{100, 0}},
-			Demand:  []GPUResource{{50, 0}, {60, 0}},
+			GPUs:    []*GPUResource{{100, 0, 0}},
+			Demand:  []GPUResource{{50, 0, 0}, {60, 0, 0}},
 			Success: false,
 		}, {
-			GPUs:    []*GPUResource{{100, 0}, {100, 0}},
-			Demand:  []GPUResource{{100, 0}, {10, 0}},
+			GPUs:    []*GPUResource{{100, 0, 0}, {100, 0, 0}},
+			Demand:  []GPUResource{{100, 0, 0}, {10, 0, 0}},
 			Success: true,
 		},
 	}
 	rater := &SampleRater{}
 	for _, choose := range chooses {
-		_, err := choose.GPUs.Choose(choose.Demand, rater)
+		_, err := choose.GPUs.Choose(choose.Demand, rater, nil, PolicySpec{}, "", false)
 		assert.Equal(t, choose.Success, err == nil)
 	}
 }
 
 func TestToSortableGPUs(t *testing.T) {
 	gpus := GPUs{
-		&GPUResource{80, 100},
-		&GPUResource{100, 100},
-		&GPUResource{30, 100},
-		&GPUResource{50, 100},
+		&GPUResource{80, 100, 0},
+		&GPUResource{100, 100, 0},
+		&GPUResource{30, 100, 0},
+		&GPUResource{50, 100, 0},
 	}
 
 	expected := SortableGPUs{
-		&GPUResourceWithIndex{&GPUResource{80, 100}, 0},
-		&GPUResourceWithIndex{&GPUResource{100, 100}, 1},
-		&GPUResourceWithIndex{&GPUResource{30, 100}, 2},
-		&GPUResourceWithIndex{&GPUResource{50, 100}, 3},
+		&GPUResourceWithIndex{&GPUResource{80, 100, 0}, 0},
+		&GPUResourceWithIndex{&GPUResource{100, 100, 0}, 1},
+		&GPUResourceWithIndex{&GPUResource{30, 100, 0}, 2},
+		&GPUResourceWithIndex{&GPUResource{50, 100, 0}, 3},
 	}
 
 	sortableGpus := gpus.ToSortableGPUs()
@@ -212,16 +238,16 @@ func TestToSortableGPUs(t *testing.T) {
 
 func TestSortableGPUs(t *testing.T) {
 	gpus := SortableGPUs{
-		&GPUResourceWithIndex{&GPUResource{80, 100}, 0},
-		&GPUResourceWithIndex{&GPUResource{100, 100}, 1},
-		&GPUResourceWithIndex{&GPUResource{30, 100}, 2},
-		&GPUResourceWithIndex{&GPUResource{50, 100}, 3},
+		&GPUResourceWithIndex{&GPUResource{80, 100, 0}, 0},
+		&GPUResourceWithIndex{&GPUResource{100, 100, 0}, 1},
+		&GPUResourceWithIndex{&GPUResource{30, 100, 0}, 2},
+		&GPUResourceWithIndex{&GPUResource{50, 100, 0}, 3},
 	}
 	expected := SortableGPUs{
-		&GPUResourceWithIndex{&GPUResource{30, 100}, 2},
-		&GPUResourceWithIndex{&GPUResource{50, 100}, 3},
-		&GPUResourceWithIndex{&GPUResource{80, 100}, 0},
-		&GPUResourceWithIndex{&GPUResource{100, 100}, 1},
+		&GPUResourceWithIndex{&GPUResource{30, 100, 0}, 2},
+		&GPUResourceWithIndex{&GPUResource{50, 100, 0}, 3},
+		&GPUResourceWithIndex{&GPUResource{80, 100, 0}, 0},
+		&GPUResourceWithIndex{&GPUResource{100, 100, 0}, 1},
 	}
 
 	sort.Sort(gpus)
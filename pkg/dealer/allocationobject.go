@@ -0,0 +1,300 @@
+package dealer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	log "k8s.io/klog/v2"
+)
+
+// AllocationClient records every allocation as a NanoGPUAllocation object when set,
+// the pods are then bound without being updated.
+var AllocationClient dynamic.Interface
+
+// AllocationLister reads the NanoGPUAllocation objects from an informer, it is set
+// along with AllocationClient.
+var AllocationLister cache.GenericLister
+
+var AllocationGVR = schema.GroupVersionResource{
+	Group:    schetypes.ElasticQuotaGroup,
+	Version:  schetypes.ElasticQuotaVersion,
+	Resource: schetypes.AllocationResource,
+}
+
+// AllocationSpec is the spec of a NanoGPUAllocation, the object has the name and
+// the namespace of its pod.
+type AllocationSpec struct {
+	Pod        AllocationPodRef      `json:"pod"`
+	Node       string                `json:"node"`
+	Containers []AllocationContainer `json:"containers"`
+}
+
+type AllocationPodRef struct {
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	UID       types.UID `json:"uid"`
+}
+
+// AllocationContainer is the card of a container, a share reserves the same
+// percent of the core and the memory of the card.
+type AllocationContainer struct {
+	Name   string `json:"name"`
+	Index  int    `json:"index"`
+	UUID   string `json:"uuid,omitempty"`
+	Core   int    `json:"core"`
+	Memory int    `json:"memory"`
+	QoS    string `json:"qos,omitempty"`
+}
+
+func newAllocationSpec(pod *v1.Pod, node string, pa *PlanAnnotation) *AllocationSpec {
+	spec := &AllocationSpec{
+		Pod:        AllocationPodRef{Namespace: pod.Namespace, Name: pod.Name, UID: pod.UID},
+		Node:       node,
+		Containers: make([]AllocationContainer, 0, len(pa.Containers)),
+	}
+	for _, cp := range pa.Containers {
+		spec.Containers = append(spec.Containers, AllocationContainer{
+			Name: cp.Name, Index: cp.Index, UUID: cp.UUID, Core: cp.Percent, Memory: cp.Percent, QoS: cp.QoS,
+		})
+	}
+	return spec
+}
+
+// newAllocationObject returns the NanoGPUAllocation of the pod, it is owned by the
+// pod so that it goes away with it.
+func newAllocationObject(pod *v1.Pod, node string, pa *PlanAnnotation) (*unstructured.Unstructured, error) {
+	spec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(newAllocationSpec(pod, node, pa))
+	if err != nil {
+		return nil, err
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetAPIVersion(AllocationGVR.GroupVersion().String())
+	obj.SetKind(schetypes.AllocationKind)
+	obj.SetNamespace(pod.Namespace)
+	obj.SetName(pod.Name)
+	obj.SetLabels(map[string]string{schetypes.LabelAllocationNode: node})
+	obj.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Name:       pod.Name,
+		UID:        pod.UID,
+	}})
+	return obj, nil
+}
+
+func allocationSpecOf(obj *unstructured.Unstructured) (*AllocationSpec, error) {
+	raw, ok := obj.Object["spec"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("allocation %s/%s has no spec", obj.GetNamespace(), obj.GetName())
+	}
+	spec := &AllocationSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// podWithAllocation returns the pod annotated with the plan of its allocation as if
+// the plan had been written to it.
+func podWithAllocation(pod *v1.Pod, spec *AllocationSpec) *v1.Pod {
+	pa := &PlanAnnotation{Version: PlanVersionV1}
+	indexes := make([]int, len(pod.Spec.Containers))
+	for i, c := range pod.Spec.Containers {
+		indexes[i] = -1
+		for _, ac := range spec.Containers {
+			if ac.Name == c.Name {
+				indexes[i] = ac.Index
+				pa.Containers = append(pa.Containers, ContainerPlan{Name: ac.Name, Index: ac.Index, UUID: ac.UUID, Percent: ac.Core, QoS: ac.QoS})
+			}
+		}
+	}
+	newPod := utils.GetUpdatedPodAnnotationSpec(pod, indexes)
+	if data, err := json.Marshal(pa); err == nil {
+		newPod.Annotations[schetypes.AnnotationGPUPlan] = string(data)
+	}
+	return newPod
+}
+
+// allocationOfPod returns the allocation object made for the pod, false when it has
+// none or the object is left by an earlier pod of the same name.
+func allocationOfPod(pod *v1.Pod) (*AllocationSpec, bool) {
+	if AllocationLister == nil {
+		return nil, false
+	}
+	obj, err := AllocationLister.ByNamespace(pod.Namespace).Get(pod.Name)
+	if err != nil {
+		return nil, false
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, false
+	}
+	spec, err := allocationSpecOf(u)
+	if err != nil || spec.Pod.UID != pod.UID {
+		return nil, false
+	}
+	return spec, true
+}
+
+// HasAllocation reports whether the pod is bound with an allocation object, the pod
+// itself then carries no plan.
+func HasAllocation(pod *v1.Pod) bool {
+	if utils.IsAssumed(pod) {
+		return false
+	}
+	_, ok := allocationOfPod(pod)
+	return ok
+}
+
+// withAllocation returns the pod annotated with the plan of its allocation object,
+// the pod itself when it carries its plan or has no object.
+func withAllocation(pod *v1.Pod) *v1.Pod {
+	if utils.IsAssumed(pod) {
+		return pod
+	}
+	if spec, ok := allocationOfPod(pod); ok {
+		return podWithAllocation(pod, spec)
+	}
+	return pod
+}
+
+// allocatedPods returns the running pods of the allocation objects annotated with
+// their plan, objects of finished or recreated pods are skipped.
+func (d *DealerImpl) allocatedPods(objs []*unstructured.Unstructured) []v1.Pod {
+	pods := make([]v1.Pod, 0, len(objs))
+	for _, obj := range objs {
+		spec, err := allocationSpecOf(obj)
+		if err != nil {
+			log.Errorf("parse allocation %s/%s failed: %v", obj.GetNamespace(), obj.GetName(), err)
+			continue
+		}
+		pod, err := d.PodLister.Pods(spec.Pod.Namespace).Get(spec.Pod.Name)
+		if err != nil || pod.UID != spec.Pod.UID || pod.Spec.NodeName == "" || utils.IsCompletedPod(pod) {
+			continue
+		}
+		pods = append(pods, *podWithAllocation(pod, spec))
+	}
+	return pods
+}
+
+// allocatedPodsOfNode returns the running pods of the allocation objects of the node.
+func (d *DealerImpl) allocatedPodsOfNode(name string) ([]v1.Pod, error) {
+	list, err := AllocationLister.List(labels.SelectorFromSet(labels.Set{schetypes.LabelAllocationNode: name}))
+	if err != nil {
+		return nil, err
+	}
+	objs := make([]*unstructured.Unstructured, 0, len(list))
+	for _, obj := range list {
+		if u, ok := obj.(*unstructured.Unstructured); ok {
+			objs = append(objs, u)
+		}
+	}
+	return d.allocatedPods(objs), nil
+}
+
+// listAllocatedPods lists the allocation objects cluster-wide and returns their
+// running pods.
+func (d *DealerImpl) listAllocatedPods(ctx context.Context) ([]v1.Pod, int, error) {
+	list, err := AllocationClient.Resource(AllocationGVR).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, 0, err
+	}
+	objs := make([]*unstructured.Unstructured, len(list.Items))
+	for i := range list.Items {
+		objs[i] = &list.Items[i]
+	}
+	return d.allocatedPods(objs), len(objs), nil
+}
+
+// bindWithAllocation records the plan of the pod as an allocation object and binds
+// the pod as it is, the object is removed again when the binding fails.
+func (d *DealerImpl) bindWithAllocation(ctx context.Context, newPod *v1.Pod, node string) error {
+	pa, ok, err := structuredPlan(newPod)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("pod %s/%s has no plan", newPod.Namespace, newPod.Name)
+	}
+	obj, err := newAllocationObject(newPod, node, pa)
+	if err != nil {
+		return err
+	}
+	if err := d.createAllocation(ctx, obj); err != nil {
+		return err
+	}
+	err = d.Client.CoreV1().Pods(newPod.Namespace).Bind(ctx, &v1.Binding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: newPod.Namespace, Name: newPod.Name, UID: newPod.UID},
+		Target: v1.ObjectReference{
+			Kind: "Node",
+			Name: node,
+		},
+	}, metav1.CreateOptions{})
+	if d.observe(err) != nil {
+		d.deleteAllocation(newPod)
+		return err
+	}
+	return nil
+}
+
+// createAllocation creates the object, an object left by an earlier attempt to bind
+// the pod is replaced.
+func (d *DealerImpl) createAllocation(ctx context.Context, obj *unstructured.Unstructured) error {
+	client := AllocationClient.Resource(AllocationGVR).Namespace(obj.GetNamespace())
+	_, err := client.Create(ctx, obj, metav1.CreateOptions{})
+	if !apierrors.IsAlreadyExists(err) {
+		return d.observe(err)
+	}
+	existing, err := client.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if d.observe(err) != nil {
+		return err
+	}
+	obj.SetResourceVersion(existing.GetResourceVersion())
+	_, err = client.Update(ctx, obj, metav1.UpdateOptions{})
+	return d.observe(err)
+}
+
+// deleteAllocation removes the allocation object of the pod, if any.
+func (d *DealerImpl) deleteAllocation(pod *v1.Pod) {
+	if AllocationClient == nil {
+		return
+	}
+	ctx, cancel := apiContext()
+	defer cancel()
+	err := AllocationClient.Resource(AllocationGVR).Namespace(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		log.Errorf("delete allocation of pod %s/%s failed: %v", pod.Namespace, pod.Name, d.observe(err))
+	}
+}
+
+// loadAllocations accounts the running pods of the allocation objects, the pods are
+// read from the pod lister.
+func (d *DealerImpl) loadAllocations() error {
+	allocated, objs, err := d.listAllocatedPods(context.Background())
+	if err != nil {
+		return err
+	}
+	pods := make([]v1.Pod, 0, len(allocated))
+	for _, pod := range allocated {
+		if _, ok := d.PodMaps[pod.UID]; !ok {
+			pods = append(pods, pod)
+		}
+	}
+	d.accountPods(pods)
+	log.Infof("loaded %d allocation objects", objs)
+	return nil
+}
@@ -0,0 +1,80 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestAllocationObject(t *testing.T) {
+	pod := MockQuotaPod("a", "p0", 40)
+	pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{Name: "sidecar"})
+	pod.Spec.Containers[0].Name = "main"
+	plan := &Plan{GPUIndexes: []int{1, -1}, Demand: Demand{{Percent: 40}, {}}}
+	pa := newPlanAnnotation(pod, plan, []string{"GPU-0", "GPU-1"})
+
+	obj, err := newAllocationObject(pod, "n1", pa)
+	assert.NoError(t, err)
+	assert.Equal(t, "nano-gpu.io/v1alpha1", obj.GetAPIVersion())
+	assert.Equal(t, "NanoGPUAllocation", obj.GetKind())
+	assert.Equal(t, "n1", obj.GetLabels()[schetypes.LabelAllocationNode])
+	assert.Equal(t, pod.UID, obj.GetOwnerReferences()[0].UID)
+
+	spec, err := allocationSpecOf(obj)
+	assert.NoError(t, err)
+	assert.Equal(t, "n1", spec.Node)
+	assert.Equal(t, AllocationContainer{Name: "main", Index: 1, UUID: "GPU-1", Core: 40, Memory: 40, QoS: pa.Containers[0].QoS}, spec.Containers[0])
+
+	annotated := podWithAllocation(pod, spec)
+	restored, err := NewPlanFromPod(annotated)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, -1}, restored.GPUIndexes)
+	restoredPA, ok, err := structuredPlan(annotated)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "GPU-1", restoredPA.Containers[0].UUID)
+}
+
+func TestPodsOfAllocationObjects(t *testing.T) {
+	defer func() { AllocationLister = nil }()
+	d := MockDealer(MockNode("n1", 2))
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	d.PodLister = corelisters.NewPodLister(pods)
+	objs := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	AllocationLister = cache.NewGenericLister(objs, AllocationGVR.GroupResource())
+
+	// the live pod is bound as it is, the plan is on the object only
+	pod := MockQuotaPod("a", "p0", 40)
+	pod.Spec.Containers[0].Name = "main"
+	pod.Spec.NodeName = "n1"
+	assert.NoError(t, pods.Add(pod))
+	pa := newPlanAnnotation(pod, &Plan{GPUIndexes: []int{1}, Demand: Demand{{Percent: 40}}}, nil)
+	obj, err := newAllocationObject(pod, "n1", pa)
+	assert.NoError(t, err)
+	assert.NoError(t, objs.Add(obj))
+	assert.True(t, HasAllocation(pod))
+
+	// a node built from the listers accounts the pod
+	ni, err := d.getNodeInfo("n1")
+	assert.NoError(t, err)
+	assert.Equal(t, 60, ni.GPUs[1].Percent)
+	assert.Contains(t, d.PodMaps, pod.UID)
+	assert.True(t, d.audit(d.allocatedPods([]*unstructured.Unstructured{obj})).Consistent)
+
+	// the informer of another replica allocates it from the object as well
+	other := MockDealer(MockNode("n1", 2))
+	other.NodeMaps["n1"] = NewNodeInfo("n1", MockNode("n1", 2), other.Rater)
+	assert.NoError(t, other.Allocate(pod))
+	assert.Equal(t, 60, other.NodeMaps["n1"].GPUs[1].Percent)
+
+	// an object left by an earlier pod of the same name doesn't count
+	recreated := pod.DeepCopy()
+	recreated.UID = "a/p0-new"
+	assert.False(t, HasAllocation(recreated))
+}
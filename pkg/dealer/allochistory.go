@@ -0,0 +1,68 @@
+package dealer
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	AllocationActionAllocate = "allocate"
+	AllocationActionRelease  = "release"
+)
+
+// AllocationHistorySize is the number of allocations and releases kept by card, 0
+// disables the history.
+var AllocationHistorySize = 200
+
+// AllocationEvent is a container of a pod taking or giving back its share of a card.
+type AllocationEvent struct {
+	Time      time.Time `json:"time"`
+	Action    string    `json:"action"`
+	Pod       string    `json:"pod"`
+	UID       string    `json:"uid"`
+	Container string    `json:"container"`
+	Percent   int       `json:"percent"`
+}
+
+// recordAllocation appends an event to the cards of the plan, the oldest events of a
+// card are dropped once it holds AllocationHistorySize.
+func (d *DealerImpl) recordAllocation(nodeName string, pod *v1.Pod, plan *Plan, action string) {
+	if AllocationHistorySize <= 0 {
+		return
+	}
+	if d.Allocations[nodeName] == nil {
+		d.Allocations[nodeName] = make(map[int][]AllocationEvent)
+	}
+	now := time.Now()
+	for i, card := range plan.GPUIndexes {
+		if card == NotNeedGPU || i >= len(pod.Spec.Containers) {
+			continue
+		}
+		events := append(d.Allocations[nodeName][card], AllocationEvent{
+			Time:      now,
+			Action:    action,
+			Pod:       pod.Namespace + "/" + pod.Name,
+			UID:       string(pod.UID),
+			Container: pod.Spec.Containers[i].Name,
+			Percent:   plan.Demand[i].Percent,
+		})
+		if len(events) > AllocationHistorySize {
+			events = events[len(events)-AllocationHistorySize:]
+		}
+		d.Allocations[nodeName][card] = events
+	}
+}
+
+// AllocationHistory returns the events of a card since the time, oldest first.
+func (d *DealerImpl) AllocationHistory(nodeName string, card int, since time.Time) []AllocationEvent {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	ans := make([]AllocationEvent, 0)
+	for _, e := range d.Allocations[nodeName][card] {
+		if !e.Time.Before(since) {
+			ans = append(ans, e)
+		}
+	}
+	return ans
+}
@@ -0,0 +1,36 @@
+package dealer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllocationHistory(t *testing.T) {
+	defer func(size int) { AllocationHistorySize = size }(AllocationHistorySize)
+	AllocationHistorySize = 3
+
+	node := MockNode("n1", 2)
+	d := MockDealer(node)
+	d.NodeMaps["n1"] = NewNodeInfo("n1", node, d.Rater)
+	start := time.Now()
+	for _, name := range []string{"p0", "p1"} {
+		pod := MockQuotaPod("a", name, 30)
+		pod.Spec.NodeName = "n1"
+		pod = utils.GetUpdatedPodAnnotationSpec(pod, []int{1})
+		assert.Nil(t, d.Allocate(pod))
+		assert.Nil(t, d.Release(pod))
+	}
+
+	events := d.AllocationHistory("n1", 1, start)
+	assert.Len(t, events, 3)
+	assert.Equal(t, AllocationActionRelease, events[0].Action)
+	assert.Equal(t, "a/p0", events[0].Pod)
+	assert.Equal(t, AllocationActionAllocate, events[1].Action)
+	assert.Equal(t, "a/p1", events[1].Pod)
+	assert.Equal(t, 30, events[1].Percent)
+	assert.Empty(t, d.AllocationHistory("n1", 0, start))
+	assert.Empty(t, d.AllocationHistory("n1", 1, time.Now().Add(time.Minute)))
+}
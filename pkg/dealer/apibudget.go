@@ -0,0 +1,17 @@
+package dealer
+
+import (
+	"context"
+	"time"
+)
+
+// APICallTimeout bounds every apiserver call the dealer makes, 0 leaves them unbounded.
+// Only binding and auditing call the apiserver, scoring and assuming read listers.
+var APICallTimeout = 10 * time.Second
+
+func apiContext() (context.Context, context.CancelFunc) {
+	if APICallTimeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), APICallTimeout)
+}
@@ -0,0 +1,131 @@
+package dealer
+
+import (
+	"fmt"
+	"sort"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CardDiscrepancy is a card whose accounted share differs from the share of the
+// pods annotated on it.
+type CardDiscrepancy struct {
+	Node      string   `json:"node"`
+	Card      int      `json:"card"`
+	Expected  int      `json:"expected"`
+	Accounted int      `json:"accounted"`
+	Pods      []string `json:"pods"`
+}
+
+// AuditReport diffs the cache against the allocations derived from the pod
+// annotations. A pod bound while auditing may show up as stale, audit again to
+// confirm.
+type AuditReport struct {
+	Consistent bool              `json:"consistent"`
+	Cards      []CardDiscrepancy `json:"cards"`
+	// MissingPods are assumed pods of the cluster the cache doesn't account.
+	MissingPods []string `json:"missingPods"`
+	// StalePods are accounted pods which are gone or completed in the cluster.
+	StalePods []string `json:"stalePods"`
+}
+
+// Audit lists the assumed pods cluster-wide and diffs them against the cache, with
+// allocation objects the pods of the objects count as assumed.
+func (d *DealerImpl) Audit() (*AuditReport, error) {
+	ctx, cancel := apiContext()
+	defer cancel()
+	pods, err := d.Client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", schetypes.GPUAssume, "true"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	items := pods.Items
+	if AllocationClient != nil {
+		allocated, _, err := d.listAllocatedPods(ctx)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, allocated...)
+	}
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	return d.audit(items), nil
+}
+
+func (d *DealerImpl) audit(pods []v1.Pod) *AuditReport {
+	report := &AuditReport{
+		Cards:       make([]CardDiscrepancy, 0),
+		MissingPods: make([]string, 0),
+		StalePods:   make([]string, 0),
+	}
+	expected := make(map[string]map[int]int)
+	podsOnCard := make(map[string]map[int][]string)
+	live := make(map[string]struct{})
+	for i := range pods {
+		pod := &pods[i]
+		key := pod.Namespace + "/" + pod.Name
+		if pod.Spec.NodeName == "" || utils.IsCompletedPod(pod) {
+			continue
+		}
+		if _, ok := d.NodeMaps[pod.Spec.NodeName]; !ok {
+			// nodes are cached lazily
+			continue
+		}
+		live[string(pod.UID)] = struct{}{}
+		if _, ok := d.PodMaps[pod.UID]; !ok {
+			report.MissingPods = append(report.MissingPods, key)
+		}
+		plan, err := NewPlanFromPod(pod)
+		if err != nil {
+			continue
+		}
+		if expected[pod.Spec.NodeName] == nil {
+			expected[pod.Spec.NodeName] = make(map[int]int)
+			podsOnCard[pod.Spec.NodeName] = make(map[int][]string)
+		}
+		for j, idx := range plan.GPUIndexes {
+			if idx >= 0 && plan.Demand[j].Percent > 0 {
+				expected[pod.Spec.NodeName][idx] += plan.Demand[j].Percent
+				podsOnCard[pod.Spec.NodeName][idx] = append(podsOnCard[pod.Spec.NodeName][idx], key)
+			}
+		}
+		if plan.Init != nil && plan.Init.Extra > 0 {
+			expected[pod.Spec.NodeName][plan.Init.Index] += plan.Init.Extra
+		}
+	}
+	for uid, pod := range d.PodMaps {
+		if _, ok := live[string(uid)]; !ok {
+			report.StalePods = append(report.StalePods, pod.Namespace+"/"+pod.Name)
+		}
+	}
+	for name, ni := range d.NodeMaps {
+		for card, g := range ni.GPUs {
+			accounted := g.PercentTotal - g.Percent
+			if accounted == expected[name][card] {
+				continue
+			}
+			report.Cards = append(report.Cards, CardDiscrepancy{
+				Node:      name,
+				Card:      card,
+				Expected:  expected[name][card],
+				Accounted: accounted,
+				Pods:      podsOnCard[name][card],
+			})
+		}
+	}
+	sort.Slice(report.Cards, func(i, j int) bool {
+		if report.Cards[i].Node != report.Cards[j].Node {
+			return report.Cards[i].Node < report.Cards[j].Node
+		}
+		return report.Cards[i].Card < report.Cards[j].Card
+	})
+	sort.Strings(report.MissingPods)
+	sort.Strings(report.StalePods)
+	report.Consistent = len(report.Cards) == 0 && len(report.MissingPods) == 0 && len(report.StalePods) == 0
+	return report
+}
@@ -0,0 +1,41 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+)
+
+func TestAudit(t *testing.T) {
+	node := MockNode("n1", 2)
+	d := MockDealer(node)
+	ni := NewNodeInfo("n1", node, d.Rater)
+	d.NodeMaps["n1"] = ni
+
+	pods := make([]v1.Pod, 0)
+	for _, name := range []string{"p0", "p1"} {
+		pod := MockQuotaPod("a", name, 30)
+		pod.Spec.NodeName = "n1"
+		pod = utils.GetUpdatedPodAnnotationSpec(pod, []int{0})
+		assert.Nil(t, d.Allocate(pod))
+		pods = append(pods, *pod)
+	}
+	assert.True(t, d.audit(pods).Consistent)
+
+	// p1 is gone, p2 was missed and card 1 is counted twice
+	missed := MockQuotaPod("a", "p2", 40)
+	missed.Spec.NodeName = "n1"
+	pods = []v1.Pod{pods[0], *utils.GetUpdatedPodAnnotationSpec(missed, []int{1})}
+	ni.GPUs[1].Percent = 20
+	report := d.audit(pods)
+	assert.False(t, report.Consistent)
+	assert.Equal(t, []string{"a/p2"}, report.MissingPods)
+	assert.Equal(t, []string{"a/p1"}, report.StalePods)
+	assert.Equal(t, []CardDiscrepancy{
+		{Node: "n1", Card: 0, Expected: 30, Accounted: 60, Pods: []string{"a/p0"}},
+		{Node: "n1", Card: 1, Expected: 40, Accounted: 80, Pods: []string{"a/p2"}},
+	}, report.Cards)
+}
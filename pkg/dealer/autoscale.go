@@ -0,0 +1,130 @@
+package dealer
+
+import (
+	"sort"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// IsUnschedulable determines if the scheduler gave up on placing the pod for now, it is
+// the signal cluster autoscalers act on.
+func IsUnschedulable(pod *v1.Pod) bool {
+	if pod.Spec.NodeName != "" || utils.IsCompletedPod(pod) || pod.DeletionTimestamp != nil {
+		return false
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == v1.PodScheduled && c.Status == v1.ConditionFalse && c.Reason == v1.PodReasonUnschedulable {
+			return true
+		}
+	}
+	return false
+}
+
+// WholeGPUEquivalent returns the number of whole cards the gpu shares of the pods fit
+// on, packing the largest shares first.
+func WholeGPUEquivalent(pods []*v1.Pod) int {
+	shares := make([]int, 0)
+	for _, pod := range pods {
+		for _, r := range NewDemandFromPod(pod) {
+			if r.Percent > 0 {
+				shares = append(shares, r.Percent)
+			}
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(shares)))
+	free := make([]int, 0)
+	for _, share := range shares {
+		placed := false
+		for i := range free {
+			if free[i] >= share {
+				free[i] -= share
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			free = append(free, schetypes.GPUPercentEachCard-share)
+		}
+	}
+	return len(free)
+}
+
+// GPUModelLabels are the node labels a pod may require its gpu model by, the
+// provisioned nodes must carry the same label.
+var GPUModelLabels = []string{schetypes.LabelGPUProduct, schetypes.LabelKarpenterGPUName}
+
+// ProvisioningHint is a node to provision for unschedulable pods: GPUs whole cards,
+// of the model the pods require when ModelLabel is set.
+type ProvisioningHint struct {
+	ModelLabel string
+	Model      string
+	GPUs       int
+}
+
+// requiredGPUModel returns the gpu model label and value the pod requires, by node
+// selector or by a single valued In requirement of its node affinity.
+func requiredGPUModel(pod *v1.Pod) (string, string) {
+	for _, label := range GPUModelLabels {
+		if model, ok := pod.Spec.NodeSelector[label]; ok {
+			return label, model
+		}
+	}
+	affinity := pod.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return "", ""
+	}
+	terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) != 1 {
+		return "", ""
+	}
+	for _, req := range terms[0].MatchExpressions {
+		if req.Operator != v1.NodeSelectorOpIn || len(req.Values) != 1 {
+			continue
+		}
+		for _, label := range GPUModelLabels {
+			if req.Key == label {
+				return label, req.Values[0]
+			}
+		}
+	}
+	return "", ""
+}
+
+// ProvisioningHints rolls the gpu share of the pods up into whole cards by required
+// gpu model and splits them into nodes of at most maxGPUs cards, so that one node is
+// provisioned for many fractional pods.
+func ProvisioningHints(pods []*v1.Pod, maxGPUs int) []ProvisioningHint {
+	if maxGPUs < 1 {
+		maxGPUs = 1
+	}
+	groups := make(map[ProvisioningHint][]*v1.Pod)
+	for _, pod := range pods {
+		label, model := requiredGPUModel(pod)
+		key := ProvisioningHint{ModelLabel: label, Model: model}
+		groups[key] = append(groups[key], pod)
+	}
+	hints := make([]ProvisioningHint, 0)
+	for key, group := range groups {
+		for gpus := WholeGPUEquivalent(group); gpus > 0; gpus -= maxGPUs {
+			hint := key
+			hint.GPUs = gpus
+			if hint.GPUs > maxGPUs {
+				hint.GPUs = maxGPUs
+			}
+			hints = append(hints, hint)
+		}
+	}
+	sort.Slice(hints, func(i, j int) bool {
+		if hints[i].ModelLabel != hints[j].ModelLabel {
+			return hints[i].ModelLabel < hints[j].ModelLabel
+		}
+		if hints[i].Model != hints[j].Model {
+			return hints[i].Model < hints[j].Model
+		}
+		return hints[i].GPUs > hints[j].GPUs
+	})
+	return hints
+}
@@ -0,0 +1,62 @@
+package dealer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestWholeGPUEquivalent(t *testing.T) {
+	assert.Equal(t, 0, WholeGPUEquivalent(nil))
+	// 70 and 30 share a card, 60 and 40 the next, 50 and 20 a third
+	pods := []*v1.Pod{
+		MockQuotaPod("a", "p0", 30),
+		MockQuotaPod("a", "p1", 70),
+		MockQuotaPod("a", "p2", 60),
+		MockQuotaPod("a", "p3", 50),
+		MockQuotaPod("a", "p4", 40),
+		MockQuotaPod("a", "p5", 20),
+	}
+	assert.Equal(t, 3, WholeGPUEquivalent(pods))
+	assert.Equal(t, 2, WholeGPUEquivalent([]*v1.Pod{pods[0], MockQuotaPod("a", "p6", 100)}))
+}
+
+func TestIsUnschedulable(t *testing.T) {
+	pod := MockQuotaPod("a", "p0", 30)
+	assert.False(t, IsUnschedulable(pod))
+	pod.Status.Conditions = []v1.PodCondition{{Type: v1.PodScheduled, Status: v1.ConditionFalse, Reason: v1.PodReasonUnschedulable}}
+	assert.True(t, IsUnschedulable(pod))
+	pod.Spec.NodeName = "n1"
+	assert.False(t, IsUnschedulable(pod))
+}
+
+func TestProvisioningHints(t *testing.T) {
+	pods := make([]*v1.Pod, 0)
+	for i := 0; i < 10; i++ {
+		pods = append(pods, MockQuotaPod("a", fmt.Sprintf("p%d", i), 50))
+	}
+	a100 := MockQuotaPod("a", "a100", 30)
+	a100.Spec.NodeSelector = map[string]string{schetypes.LabelGPUProduct: "NVIDIA-A100-SXM4-40GB"}
+	t4 := MockQuotaPod("a", "t4", 30)
+	t4.Spec.Affinity = &v1.Affinity{NodeAffinity: &v1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+			NodeSelectorTerms: []v1.NodeSelectorTerm{{MatchExpressions: []v1.NodeSelectorRequirement{
+				{Key: schetypes.LabelKarpenterGPUName, Operator: v1.NodeSelectorOpIn, Values: []string{"t4"}},
+			}}},
+		},
+	}}
+	pods = append(pods, a100, t4)
+
+	// ten halves take five cards, split into nodes of up to four
+	assert.Equal(t, []ProvisioningHint{
+		{GPUs: 4},
+		{GPUs: 1},
+		{ModelLabel: schetypes.LabelKarpenterGPUName, Model: "t4", GPUs: 1},
+		{ModelLabel: schetypes.LabelGPUProduct, Model: "NVIDIA-A100-SXM4-40GB", GPUs: 1},
+	}, ProvisioningHints(pods, 4))
+	assert.Len(t, ProvisioningHints(pods, 1), 7)
+}
@@ -0,0 +1,118 @@
+package dealer
+
+import (
+	"math"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// shapeHistory is the number of recently bound pods whose memory to core ratio is
+// the typical demand shape.
+const shapeHistory = 100
+
+// CardShape is the free core and memory share of a card, they differ when core
+// requests or guaranteed memory are below the gpu percent of containers.
+type CardShape struct {
+	CoreFree   int
+	MemoryFree int
+}
+
+// stranded is the free share no demand of the typical shape can use.
+func (s CardShape) stranded(ratio float64) float64 {
+	core, memory := math.Max(float64(s.CoreFree), 0), math.Max(float64(s.MemoryFree), 0)
+	usable := math.Min(core, memory/ratio)
+	return core - usable + memory - usable*ratio
+}
+
+// recordShape remembers the memory to core ratio of a bound pod.
+func (d *DealerImpl) recordShape(pod *v1.Pod) {
+	core, memory := 0, 0
+	for i := range pod.Spec.Containers {
+		core += coreReserved(pod, &pod.Spec.Containers[i])
+		memory += memoryReserved(pod, &pod.Spec.Containers[i])
+	}
+	if core == 0 || memory == 0 {
+		return
+	}
+	d.Shapes = append(d.Shapes, float64(memory)/float64(core))
+	if len(d.Shapes) > shapeHistory {
+		d.Shapes = d.Shapes[len(d.Shapes)-shapeHistory:]
+	}
+}
+
+// TypicalShape returns the mean memory to core ratio of the recently bound pods, 1
+// before any was bound.
+func (d *DealerImpl) TypicalShape() float64 {
+	if len(d.Shapes) == 0 {
+		return 1
+	}
+	sum := 0.0
+	for _, r := range d.Shapes {
+		sum += r
+	}
+	return sum / float64(len(d.Shapes))
+}
+
+// CardShapes returns the free core and memory of the cards of the node.
+func (d *DealerImpl) CardShapes(nodeName string) []CardShape {
+	ni, ok := d.NodeMaps[nodeName]
+	if !ok {
+		return nil
+	}
+	ans := make([]CardShape, len(ni.GPUs))
+	for i, g := range ni.GPUs {
+		ans[i] = CardShape{CoreFree: g.PercentTotal, MemoryFree: g.PercentTotal}
+	}
+	for _, pod := range d.PodMaps {
+		if pod.Spec.NodeName != nodeName {
+			continue
+		}
+		plan, err := NewPlanFromPod(pod)
+		if err != nil {
+			continue
+		}
+		for i, idx := range plan.GPUIndexes {
+			if idx < 0 || idx >= len(ans) || i >= len(pod.Spec.Containers) {
+				continue
+			}
+			ans[idx].CoreFree -= coreReserved(pod, &pod.Spec.Containers[i])
+			ans[idx].MemoryFree -= memoryReserved(pod, &pod.Spec.Containers[i])
+		}
+	}
+	return ans
+}
+
+// Balanced packs like Binpack and takes off the score the share the plan would
+// strand on the node: free core without the memory to use it, or the other way
+// round, for demands shaped like the recently bound pods.
+type Balanced struct {
+	Binpack
+}
+
+func (b *Balanced) Rate(gpus GPUs, p *Plan, d Dealer, policySpec PolicySpec, nodeName string, isLoadSchedule bool) int {
+	score := b.Binpack.Rate(gpus, p, d, policySpec, nodeName, isLoadSchedule)
+	shapes := d.CardShapes(nodeName)
+	if len(shapes) != len(gpus) {
+		return score
+	}
+	indexes, err := b.Choose(gpus, p.Demand)
+	if err != nil {
+		return score
+	}
+	for i, idx := range indexes {
+		if idx >= 0 {
+			shapes[idx].CoreFree -= p.Demand[i].Percent
+			shapes[idx].MemoryFree -= p.Demand[i].Percent
+		}
+	}
+	ratio := d.TypicalShape()
+	stranded, total := 0.0, 0
+	for i, s := range shapes {
+		stranded += s.stranded(ratio)
+		total += gpus[i].PercentTotal
+	}
+	if total == 0 {
+		return score
+	}
+	return score - int(ScoreMax*stranded/float64(total))
+}
@@ -0,0 +1,43 @@
+package dealer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+)
+
+func TestCardShapeStranded(t *testing.T) {
+	assert.Equal(t, 0.0, CardShape{CoreFree: 40, MemoryFree: 40}.stranded(1))
+	assert.Equal(t, 40.0, CardShape{CoreFree: 0, MemoryFree: 40}.stranded(1))
+	assert.Equal(t, 0.0, CardShape{CoreFree: 20, MemoryFree: 40}.stranded(2))
+}
+
+func TestBalancedRate(t *testing.T) {
+	defer func(b bool) { MemoryBallooning = b }(MemoryBallooning)
+	MemoryBallooning = true
+
+	d := MockDealer(MockNode("n1", 2))
+	ni := NewNodeInfo("n1", MockNode("n1", 2), d.Rater)
+	d.NodeMaps["n1"] = ni
+	pod := MockQuotaPod("a", "p0", 60)
+	pod.Spec.Containers[0].Name = "c0"
+	pod.Spec.NodeName = "n1"
+	pod.Annotations[fmt.Sprintf(types.AnnotationGPUMemoryGuaranteed, "c0")] = "20"
+	pod = utils.GetUpdatedPodAnnotationSpec(pod, []int{0})
+	d.accountPod(ni, pod)
+
+	assert.Equal(t, 1.0, d.TypicalShape())
+	d.recordShape(pod)
+	assert.InDelta(t, 1.0/3, d.TypicalShape(), 1e-9)
+	d.Shapes = nil
+
+	assert.Equal(t, []CardShape{{CoreFree: 40, MemoryFree: 80}, {CoreFree: 100, MemoryFree: 100}}, d.CardShapes("n1"))
+	b := &Balanced{}
+	plan := &Plan{Demand: Demand{{Percent: 40}}}
+	// the plan fills the core of card 0 and strands its free memory
+	assert.Equal(t, b.Binpack.Rate(ni.GPUs, plan, d, PolicySpec{}, "n1", false)-20, b.Rate(ni.GPUs, plan, d, PolicySpec{}, "n1", false))
+}
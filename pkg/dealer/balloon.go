@@ -0,0 +1,97 @@
+package dealer
+
+import (
+	"fmt"
+	"strconv"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// MemoryBallooning reserves only the guaranteed memory of containers declaring one,
+// the rest of their gpu percent is burst the runtime may reclaim.
+var MemoryBallooning = false
+
+// CardBurst is the burst memory of the pods on a card.
+type CardBurst struct {
+	Guaranteed int `json:"guaranteed"`
+	Burst      int `json:"burst"`
+	Free       int `json:"free"`
+	// Pressure is the part of the burst the free share can't serve, 0 means every
+	// pod may burst at once and 1 means none can.
+	Pressure float64 `json:"pressure"`
+}
+
+// guaranteedPercent returns the share reserved for the container, which is its gpu
+// percent unless ballooning is on and the pod declares a smaller guaranteed amount,
+// or QoS reservation is on and the core request is smaller.
+func guaranteedPercent(pod *v1.Pod, c *v1.Container) int {
+	percent := coreReserved(pod, c)
+	if memory := memoryReserved(pod, c); memory < percent {
+		return memory
+	}
+	return percent
+}
+
+// memoryReserved returns the guaranteed memory share of the container when
+// ballooning is on, its gpu percent otherwise.
+func memoryReserved(pod *v1.Pod, c *v1.Container) int {
+	limit := utils.GetGPUPercentFromContainer(c)
+	if !MemoryBallooning || pod.Annotations == nil {
+		return limit
+	}
+	val, ok := pod.Annotations[fmt.Sprintf(schetypes.AnnotationGPUMemoryGuaranteed, c.Name)]
+	if !ok {
+		return limit
+	}
+	guaranteed, err := strconv.Atoi(val)
+	if err != nil || guaranteed < 0 || guaranteed > limit {
+		return limit
+	}
+	return guaranteed
+}
+
+// BurstPressure returns the cards hosting burstable containers by node.
+func (d *DealerImpl) BurstPressure() map[string]map[int]CardBurst {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	ans := make(map[string]map[int]CardBurst)
+	for _, pod := range d.PodMaps {
+		ni, ok := d.NodeMaps[pod.Spec.NodeName]
+		if !ok {
+			continue
+		}
+		plan, err := NewPlanFromPod(pod)
+		if err != nil {
+			continue
+		}
+		for i, idx := range plan.GPUIndexes {
+			if idx < 0 || idx >= len(ni.GPUs) || i >= len(pod.Spec.Containers) {
+				continue
+			}
+			burst := utils.GetGPUPercentFromContainer(&pod.Spec.Containers[i]) - plan.Demand[i].Percent
+			if burst <= 0 {
+				continue
+			}
+			if ans[ni.Name] == nil {
+				ans[ni.Name] = make(map[int]CardBurst)
+			}
+			card := ans[ni.Name][idx]
+			card.Guaranteed += plan.Demand[i].Percent
+			card.Burst += burst
+			card.Free = ni.GPUs[idx].Percent
+			ans[ni.Name][idx] = card
+		}
+	}
+	for _, cards := range ans {
+		for idx, card := range cards {
+			if card.Burst > card.Free {
+				card.Pressure = float64(card.Burst-card.Free) / float64(card.Burst)
+			}
+			cards[idx] = card
+		}
+	}
+	return ans
+}
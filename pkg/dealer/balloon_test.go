@@ -0,0 +1,37 @@
+package dealer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+)
+
+func TestMemoryBallooning(t *testing.T) {
+	node := MockNode("n1", 1)
+	d := MockDealer(node)
+	ni := NewNodeInfo("n1", node, d.Rater)
+	d.NodeMaps["n1"] = ni
+
+	pod := MockQuotaPod("a", "p", 60)
+	pod.Spec.NodeName = "n1"
+	pod.Spec.Containers[0].Name = "main"
+	pod.Annotations[fmt.Sprintf(types.AnnotationGPUMemoryGuaranteed, "main")] = "20"
+	assert.Equal(t, Demand{{Percent: 60}}, NewDemandFromPod(pod))
+
+	MemoryBallooning = true
+	defer func() { MemoryBallooning = false }()
+	assert.Equal(t, Demand{{Percent: 20}}, NewDemandFromPod(pod))
+
+	assert.Nil(t, d.Allocate(utils.GetUpdatedPodAnnotationSpec(pod, []int{0})))
+	assert.Equal(t, 80, ni.GPUs[0].Percent)
+	assert.Equal(t, map[string]map[int]CardBurst{"n1": {0: {Guaranteed: 20, Burst: 40, Free: 80}}}, d.BurstPressure())
+
+	other := MockQuotaPod("a", "o", 70)
+	other.Spec.NodeName = "n1"
+	assert.Nil(t, d.Allocate(utils.GetUpdatedPodAnnotationSpec(other, []int{0})))
+	assert.Equal(t, CardBurst{Guaranteed: 20, Burst: 40, Free: 10, Pressure: 0.75}, d.BurstPressure()["n1"][0])
+}
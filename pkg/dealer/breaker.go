@@ -0,0 +1,80 @@
+package dealer
+
+import (
+	"time"
+
+	log "k8s.io/klog/v2"
+)
+
+var (
+	// UsageBreakerThreshold is the number of consecutive failed usage syncs of a
+	// metric which trips the breaker of the node, 0 disables the breaker.
+	UsageBreakerThreshold = 0
+	// UsageBreakerCooldown is how long a tripped node is scheduled by request only.
+	UsageBreakerCooldown = 5 * time.Minute
+)
+
+type usageBreaker struct {
+	// Failures counts the consecutive failed syncs by metric.
+	Failures  map[string]int
+	OpenUntil time.Time
+	Trips     int
+}
+
+// BreakerState is the usage breaker of a node.
+type BreakerState struct {
+	Open      bool      `json:"open"`
+	OpenUntil time.Time `json:"openUntil,omitempty"`
+	Trips     int       `json:"trips"`
+}
+
+// RecordUsageSync records the result of syncing a metric of the node and trips the
+// breaker of the node once the metric failed UsageBreakerThreshold times in a row.
+// A failure while the breaker is half open, after the cooldown, trips it again.
+func (d *DealerImpl) RecordUsageSync(nodeName, metric string, err error) {
+	if UsageBreakerThreshold <= 0 {
+		return
+	}
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	b, ok := d.Breakers[nodeName]
+	if !ok {
+		b = &usageBreaker{Failures: make(map[string]int)}
+		d.Breakers[nodeName] = b
+	}
+	if err == nil {
+		delete(b.Failures, metric)
+		return
+	}
+	b.Failures[metric]++
+	now := time.Now()
+	if b.Failures[metric] < UsageBreakerThreshold || now.Before(b.OpenUntil) {
+		return
+	}
+	b.OpenUntil = now.Add(UsageBreakerCooldown)
+	b.Trips++
+	d.cleanPlanOfNode(nodeName)
+	log.Warningf("usage breaker of node %s tripped by %s until %s: %v", nodeName, metric, b.OpenUntil.Format(time.RFC3339), err)
+}
+
+// UsageBreakerOpen determines if the node has to be scheduled by request as its
+// usage can't be trusted.
+func (d *DealerImpl) UsageBreakerOpen(nodeName string) bool {
+	b, ok := d.Breakers[nodeName]
+	return ok && time.Now().Before(b.OpenUntil)
+}
+
+// UsageBreakers returns the breaker of every node which tripped at least once.
+func (d *DealerImpl) UsageBreakers() map[string]BreakerState {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	ans := make(map[string]BreakerState)
+	now := time.Now()
+	for name, b := range d.Breakers {
+		if b.Trips == 0 {
+			continue
+		}
+		ans[name] = BreakerState{Open: now.Before(b.OpenUntil), OpenUntil: b.OpenUntil, Trips: b.Trips}
+	}
+	return ans
+}
@@ -0,0 +1,38 @@
+package dealer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsageBreaker(t *testing.T) {
+	threshold, cooldown := UsageBreakerThreshold, UsageBreakerCooldown
+	defer func() { UsageBreakerThreshold, UsageBreakerCooldown = threshold, cooldown }()
+	UsageBreakerThreshold, UsageBreakerCooldown = 2, time.Minute
+
+	d := MockDealer(MockNode("n1", 1))
+	failed := errors.New("prometheus unavailable")
+	d.RecordUsageSync("n1", GPUCoreUsagePriority, failed)
+	d.RecordUsageSync("n1", GPUMemoryUsagePriority, nil)
+	assert.False(t, d.UsageBreakerOpen("n1"))
+	assert.Empty(t, d.UsageBreakers())
+
+	d.RecordUsageSync("n1", GPUCoreUsagePriority, failed)
+	assert.True(t, d.UsageBreakerOpen("n1"))
+	assert.Equal(t, 1, d.UsageBreakers()["n1"].Trips)
+
+	// half open after the cooldown, the next failure trips it again
+	d.Breakers["n1"].OpenUntil = time.Now().Add(-time.Second)
+	assert.False(t, d.UsageBreakerOpen("n1"))
+	d.RecordUsageSync("n1", GPUCoreUsagePriority, failed)
+	assert.True(t, d.UsageBreakerOpen("n1"))
+	assert.Equal(t, 2, d.UsageBreakers()["n1"].Trips)
+
+	d.Breakers["n1"].OpenUntil = time.Now().Add(-time.Second)
+	d.RecordUsageSync("n1", GPUCoreUsagePriority, nil)
+	d.RecordUsageSync("n1", GPUCoreUsagePriority, failed)
+	assert.False(t, d.UsageBreakerOpen("n1"))
+}
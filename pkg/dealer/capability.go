@@ -0,0 +1,91 @@
+package dealer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	log "k8s.io/klog/v2"
+)
+
+// CapabilityScoreBonus is added to the score of the nodes meeting the preferred compute
+// capability of a pod, 0 disables it.
+var CapabilityScoreBonus = 0
+
+// ParseComputeCapability parses a compute capability written as 8.0, 80 or sm_80 into
+// major*10+minor.
+func ParseComputeCapability(s string) (int, error) {
+	v := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), "sm_"), "sm")
+	if parts := strings.SplitN(v, ".", 2); len(parts) == 2 {
+		ma, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return 0, fmt.Errorf("invalid compute capability %q", s)
+		}
+		mi, err := strconv.Atoi(parts[1])
+		if err != nil || mi > 9 {
+			return 0, fmt.Errorf("invalid compute capability %q", s)
+		}
+		return ma*10 + mi, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid compute capability %q", s)
+	}
+	if n < 10 {
+		return n * 10, nil
+	}
+	return n, nil
+}
+
+// computeCapabilityOf returns the compute capability of the cards of the node, false
+// when it is not labeled.
+func computeCapabilityOf(ni *NodeInfo) (int, bool) {
+	return ni.Attributes.ComputeCapability, ni.Attributes.ComputeCapability > 0
+}
+
+// podCapability returns the compute capability of the annotation of the pod, false
+// when it has none or it is invalid.
+func podCapability(pod *v1.Pod, annotation string) (int, bool) {
+	value, ok := pod.Annotations[annotation]
+	if !ok {
+		return 0, false
+	}
+	c, err := ParseComputeCapability(value)
+	if err != nil {
+		log.Warningf("ignore %s of pod %s/%s: %v", annotation, pod.Namespace, pod.Name, err)
+		return 0, false
+	}
+	return c, true
+}
+
+// checkNodeCapability fails the nodes below the lowest compute capability the pod
+// accepts, and the nodes whose capability is unknown.
+func (d *DealerImpl) checkNodeCapability(ni *NodeInfo, pod *v1.Pod) error {
+	required, ok := podCapability(pod, schetypes.AnnotationComputeCapabilityMin)
+	if !ok {
+		return nil
+	}
+	c, ok := computeCapabilityOf(ni)
+	if !ok {
+		return fmt.Errorf("compute capability of node %s is unknown", ni.Name)
+	}
+	if c < required {
+		return fmt.Errorf("compute capability %d.%d of node %s is below %d.%d", c/10, c%10, ni.Name, required/10, required%10)
+	}
+	return nil
+}
+
+// capabilityBonus prefers the nodes meeting the preferred compute capability of the pod.
+func capabilityBonus(ni *NodeInfo, pod *v1.Pod) int {
+	preferred, ok := podCapability(pod, schetypes.AnnotationComputeCapabilityPreferred)
+	if !ok {
+		return 0
+	}
+	if c, ok := computeCapabilityOf(ni); ok && c >= preferred {
+		return CapabilityScoreBonus
+	}
+	return 0
+}
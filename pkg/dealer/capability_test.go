@@ -0,0 +1,49 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestParseComputeCapability(t *testing.T) {
+	for s, want := range map[string]int{"8.0": 80, "8.6": 86, "80": 80, "sm_80": 80, "SM70": 70, "7": 70} {
+		c, err := ParseComputeCapability(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, want, c, s)
+	}
+	for _, s := range []string{"", "ampere", "8.x", "8.10"} {
+		_, err := ParseComputeCapability(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestComputeCapability(t *testing.T) {
+	defer func(bonus int) { CapabilityScoreBonus = bonus }(CapabilityScoreBonus)
+	CapabilityScoreBonus = 10
+	d := MockDealer()
+	nodes := make(map[string]*NodeInfo)
+	for name, major := range map[string]string{"v100": "7", "a100": "8", "unknown": ""} {
+		node := MockNode(name, 1)
+		node.Labels = map[string]string{types.LabelGPUComputeMajor: major, types.LabelGPUComputeMinor: "0"}
+		nodes[name] = NewNodeInfo(name, node, &Binpack{})
+	}
+	pod := MockQuotaPod("a", "p0", 40)
+	assert.NoError(t, d.checkNodeCapability(nodes["unknown"], pod))
+	assert.Equal(t, 0, capabilityBonus(nodes["a100"], pod))
+
+	pod.Annotations = map[string]string{
+		types.AnnotationComputeCapabilityMin:       "7.0",
+		types.AnnotationComputeCapabilityPreferred: "sm_80",
+	}
+	assert.NoError(t, d.checkNodeCapability(nodes["v100"], pod))
+	assert.NoError(t, d.checkNodeCapability(nodes["a100"], pod))
+	assert.Error(t, d.checkNodeCapability(nodes["unknown"], pod))
+	assert.Equal(t, 0, capabilityBonus(nodes["v100"], pod))
+	assert.Equal(t, 10, capabilityBonus(nodes["a100"], pod))
+
+	pod.Annotations[types.AnnotationComputeCapabilityMin] = "8.0"
+	assert.Error(t, d.checkNodeCapability(nodes["v100"], pod))
+}
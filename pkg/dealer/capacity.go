@@ -0,0 +1,102 @@
+package dealer
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	log "k8s.io/klog/v2"
+)
+
+// Placement is a hypothetical replica placement returned by Capacity.
+type Placement struct {
+	Node       string `json:"node"`
+	GPUIndexes []int  `json:"gpuIndexes"`
+}
+
+type CapacityReport struct {
+	Demand     Demand      `json:"demand"`
+	Replicas   int         `json:"replicas"`
+	Placements []Placement `json:"placements"`
+}
+
+// maxCapacityReplicas bounds the replicas placed by a capacity report.
+const maxCapacityReplicas = 1000
+
+// nodeCards are the cards of a node as a pod sees them, taken off the dealer so that
+// replicas are placed on them without the lock.
+type nodeCards struct {
+	Name  string
+	GPUs  GPUs
+	Rater Rater
+}
+
+// Capacity reports how many replicas of the demand could be placed right now, up to
+// maxReplicas. Plans are applied on copies of the node gpus, the dealer state is
+// left untouched.
+func (d *DealerImpl) Capacity(demand Demand, maxReplicas int) (*CapacityReport, error) {
+	d.Lock.Lock()
+	cards, err := d.capacityCards(&v1.Pod{}, demand)
+	d.Lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return placeReplicas(cards, demand, maxReplicas), nil
+}
+
+// capacityCards returns copies of the schedulable cards of the gpu nodes which pass
+// the node constraints of the pod, as checked before its cards are planned.
+func (d *DealerImpl) capacityCards(pod *v1.Pod, demand Demand) ([]nodeCards, error) {
+	nodes, err := d.NodeLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+
+	cards := make([]nodeCards, 0, len(nodes))
+	for _, node := range nodes {
+		if utils.GetGPUDeviceCountOfNode(node) == 0 {
+			continue
+		}
+		ni, err := d.checkNode(node.Name, pod, demand, PolicySpec{}, false)
+		switch {
+		case errors.Is(err, ErrNodeNotOwned):
+			continue
+		case err != nil:
+			log.V(4).Infof("capacity: skip node %s: %s", node.Name, err.Error())
+			continue
+		}
+		cards = append(cards, nodeCards{Name: ni.Name, GPUs: ni.schedulableGPUs(pod, d).Clone(), Rater: ni.Rater})
+	}
+	return cards, nil
+}
+
+// placeReplicas places up to maxReplicas replicas of the demand on the cards, the
+// nodes are filled in turn.
+func placeReplicas(cards []nodeCards, demand Demand, maxReplicas int) *CapacityReport {
+	if maxReplicas > maxCapacityReplicas {
+		maxReplicas = maxCapacityReplicas
+	}
+	report := &CapacityReport{Demand: demand, Placements: make([]Placement, 0)}
+	for _, node := range cards {
+		gpus := node.GPUs
+		for report.Replicas < maxReplicas {
+			indexes, err := node.Rater.Choose(gpus.Clone(), demand)
+			if err != nil {
+				break
+			}
+			if err := gpus.Allocate(&Plan{Demand: demand, GPUIndexes: indexes}); err != nil {
+				break
+			}
+			report.Replicas++
+			report.Placements = append(report.Placements, Placement{Node: node.Name, GPUIndexes: indexes})
+		}
+		if report.Replicas >= maxReplicas {
+			break
+		}
+	}
+	return report
+}
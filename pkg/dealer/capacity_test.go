@@ -0,0 +1,48 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapacity(t *testing.T) {
+	d := MockDealer(MockNode("n1", 2), MockNode("n2", 1), MockNode("cpu", 0))
+	d.NodeMaps["n1"] = NewNodeInfo("n1", MockNode("n1", 2), d.Rater)
+	d.NodeMaps["n1"].GPUs[0].Percent = 30
+	d.NodeMaps["n2"] = NewNodeInfo("n2", MockNode("n2", 1), d.Rater)
+
+	report, err := d.Capacity(Demand{{Percent: 40}}, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, report.Replicas)
+	assert.Equal(t, Placement{Node: "n1", GPUIndexes: []int{1}}, report.Placements[0])
+	assert.Equal(t, "n2", report.Placements[3].Node)
+	// dealer state is untouched
+	assert.Equal(t, 30, d.NodeMaps["n1"].GPUs[0].Percent)
+	assert.Equal(t, 100, d.NodeMaps["n1"].GPUs[1].Percent)
+
+	report, err = d.Capacity(Demand{{Percent: 40}}, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, report.Replicas)
+
+	// the replicas are placed on the cards as a pod sees them
+	d.NodeMaps["n2"].SystemReserved = 70
+	report, err = d.Capacity(Demand{{Percent: 40}}, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, report.Replicas)
+}
+
+func TestCapacitySkipsUnschedulableNodes(t *testing.T) {
+	cordoned := MockNode("n2", 1)
+	cordoned.Spec.Unschedulable = true
+	d := MockDealer(MockNode("n1", 1), cordoned)
+	d.NodeMaps["n1"] = NewNodeInfo("n1", MockNode("n1", 1), d.Rater)
+	d.NodeMaps["n2"] = NewNodeInfo("n2", cordoned, d.Rater)
+
+	report, err := d.Capacity(Demand{{Percent: 50}}, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, report.Replicas)
+	for _, p := range report.Placements {
+		assert.Equal(t, "n1", p.Node)
+	}
+}
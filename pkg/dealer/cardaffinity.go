@@ -0,0 +1,124 @@
+package dealer
+
+import (
+	"fmt"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	log "k8s.io/klog/v2"
+)
+
+// cardAntiAffinity returns the selector of the pods the pod must not share a card
+// with, nil when it has none.
+func cardAntiAffinity(pod *v1.Pod) labels.Selector {
+	value, ok := pod.Annotations[schetypes.AnnotationCardAntiAffinity]
+	if !ok {
+		return nil
+	}
+	selector, err := labels.Parse(value)
+	if err != nil {
+		log.Warningf("ignore card anti-affinity of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		return nil
+	}
+	return selector
+}
+
+// trackAntiAffinity parses the card anti-affinity of a pod once when it becomes
+// known, the cards are planned against it for every other pod.
+func (d *DealerImpl) trackAntiAffinity(pod *v1.Pod) {
+	if selector := cardAntiAffinity(pod); selector != nil {
+		d.AntiAffinity[pod.UID] = selector
+	} else {
+		delete(d.AntiAffinity, pod.UID)
+	}
+}
+
+// repels reports whether the pods must not share a card, the anti-affinity of either
+// one counts.
+func repels(pod *v1.Pod, selector labels.Selector, other *v1.Pod, otherSelector labels.Selector) bool {
+	if pod.Namespace != other.Namespace || pod.UID == other.UID {
+		return false
+	}
+	if selector != nil && selector.Matches(labels.Set(other.Labels)) {
+		return true
+	}
+	return otherSelector != nil && otherSelector.Matches(labels.Set(pod.Labels))
+}
+
+// antiAffinityExcludedCards are the cards of the node used by the pods the pod must
+// not share a card with.
+func (d *DealerImpl) antiAffinityExcludedCards(nodeName string, pod *v1.Pod) map[int]string {
+	ans := make(map[int]string)
+	selector := cardAntiAffinity(pod)
+	for uid, other := range d.PodMaps {
+		if other.Spec.NodeName != nodeName || !repels(pod, selector, other, d.AntiAffinity[uid]) {
+			continue
+		}
+		plan, err := NewPlanFromPod(other)
+		if err != nil {
+			continue
+		}
+		for i, idx := range plan.GPUIndexes {
+			if idx >= 0 && i < len(plan.Demand) && plan.Demand[i].Percent > 0 {
+				ans[idx] = fmt.Sprintf("anti-affinity with pod %s/%s", other.Namespace, other.Name)
+			}
+		}
+	}
+	return ans
+}
+
+// cardAffinityCards returns the node and the cards of the pod the pod must share a card
+// with, an empty node when it has no card affinity.
+func (d *DealerImpl) cardAffinityCards(pod *v1.Pod) (string, map[int]bool, error) {
+	name, ok := pod.Annotations[schetypes.AnnotationCardAffinity]
+	if !ok {
+		return "", nil, nil
+	}
+	target := d.knownPod(pod.Namespace, name)
+	if target == nil {
+		return "", nil, fmt.Errorf("pod %s/%s to share a card with is not placed yet", pod.Namespace, name)
+	}
+	plan, err := NewPlanFromPod(target)
+	if err != nil {
+		return "", nil, err
+	}
+	cards := make(map[int]bool)
+	for _, idx := range plan.GPUIndexes {
+		if idx >= 0 {
+			cards[idx] = true
+		}
+	}
+	return target.Spec.NodeName, cards, nil
+}
+
+// checkCardAffinity fails every node but the one of the pod the pod must share a card
+// with, and every node while that pod is not placed.
+func (d *DealerImpl) checkCardAffinity(ni *NodeInfo, pod *v1.Pod) error {
+	node, _, err := d.cardAffinityCards(pod)
+	if err != nil || node == "" || node == ni.Name {
+		return err
+	}
+	return fmt.Errorf("pod %s/%s to share a card with is on node %s", pod.Namespace, pod.Annotations[schetypes.AnnotationCardAffinity], node)
+}
+
+// affinityExcludedCards are the cards of the node not used by the pod the pod must
+// share a card with.
+func (d *DealerImpl) affinityExcludedCards(nodeName string, pod *v1.Pod) map[int]string {
+	ans := make(map[int]string)
+	node, cards, err := d.cardAffinityCards(pod)
+	if err != nil || node == "" {
+		return ans
+	}
+	ni, ok := d.NodeMaps[nodeName]
+	if !ok {
+		return ans
+	}
+	for i := range ni.GPUs {
+		if node != nodeName || !cards[i] {
+			ans[i] = "not the card of pod " + pod.Annotations[schetypes.AnnotationCardAffinity]
+		}
+	}
+	return ans
+}
@@ -0,0 +1,75 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+)
+
+func mockPodOnCards(ns, name string, node string, cards ...int) *v1.Pod {
+	pod := utils.GetUpdatedPodAnnotationSpec(MockQuotaPod(ns, name, 30), cards)
+	pod.Spec.NodeName = node
+	return pod
+}
+
+func TestCardAntiAffinity(t *testing.T) {
+	d := MockDealer(MockNode("n1", 2))
+	d.NodeMaps["n1"] = NewNodeInfo("n1", MockNode("n1", 2), d.Rater)
+	server := mockPodOnCards("a", "server", "n1", 0)
+	server.Labels["app"] = "server"
+	server.Annotations[types.AnnotationCardAntiAffinity] = "app=batch"
+	d.PodMaps[server.UID] = server
+	d.trackAntiAffinity(server)
+
+	batch := MockQuotaPod("a", "batch", 30)
+	batch.Labels = map[string]string{"app": "batch"}
+	assert.Equal(t, map[int]string{0: "anti-affinity with pod a/server"}, d.ExcludedCards("n1", batch))
+
+	// the selector of the new pod counts as well
+	web := MockQuotaPod("a", "web", 30)
+	web.Annotations = map[string]string{types.AnnotationCardAntiAffinity: "app in (server, db)"}
+	assert.Len(t, d.ExcludedCards("n1", web), 1)
+
+	// other namespaces and pods which don't match are unaffected
+	other := MockQuotaPod("b", "batch", 30)
+	other.Labels = map[string]string{"app": "batch"}
+	assert.Empty(t, d.ExcludedCards("n1", other))
+	assert.Empty(t, d.ExcludedCards("n1", MockQuotaPod("a", "plain", 30)))
+
+	ok, err := d.NodeMaps["n1"].Assume(NewDemandFromPod(batch), batch, d, PolicySpec{}, false)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []int{1}, d.NodeMaps["n1"].PlanCache[planKey(NewDemandFromPod(batch), batch)].GPUIndexes)
+
+	// the selector of a known pod goes with it
+	assert.NoError(t, d.Release(server))
+	assert.Empty(t, d.AntiAffinity)
+	assert.Empty(t, d.ExcludedCards("n1", batch))
+}
+
+func TestCardAffinity(t *testing.T) {
+	d := MockDealer(MockNode("n1", 2), MockNode("n2", 2))
+	for _, name := range []string{"n1", "n2"} {
+		d.NodeMaps[name] = NewNodeInfo(name, MockNode(name, 2), d.Rater)
+	}
+	tokenizer := MockQuotaPod("a", "tokenizer", 30)
+	tokenizer.Annotations = map[string]string{types.AnnotationCardAffinity: "server"}
+	assert.Error(t, d.checkCardAffinity(d.NodeMaps["n1"], tokenizer))
+
+	server := mockPodOnCards("a", "server", "n2", 1)
+	d.PodMaps[server.UID] = server
+	assert.Error(t, d.checkCardAffinity(d.NodeMaps["n1"], tokenizer))
+	assert.NoError(t, d.checkCardAffinity(d.NodeMaps["n2"], tokenizer))
+	assert.Equal(t, map[int]string{0: "not the card of pod server"}, d.ExcludedCards("n2", tokenizer))
+
+	ok, err := d.NodeMaps["n2"].Assume(NewDemandFromPod(tokenizer), tokenizer, d, PolicySpec{}, false)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []int{1}, d.NodeMaps["n2"].PlanCache[planKey(NewDemandFromPod(tokenizer), tokenizer)].GPUIndexes)
+
+	assert.NoError(t, d.checkCardAffinity(d.NodeMaps["n1"], MockQuotaPod("a", "plain", 30)))
+}
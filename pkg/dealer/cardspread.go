@@ -0,0 +1,12 @@
+package dealer
+
+// CardSpread packs pods on the fullest nodes like Binpack but spreads them over the
+// cards of a node like Spread, for workloads sensitive to contention on a card even
+// under correct limits.
+type CardSpread struct {
+	Binpack
+}
+
+func (cs *CardSpread) Choose(gpus GPUs, demand Demand) ([]int, error) {
+	return (&Spread{}).Choose(gpus, demand)
+}
@@ -0,0 +1,29 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCardSpread(t *testing.T) {
+	defer func(r map[string]Rater) { NamespaceRaters = r }(NamespaceRaters)
+	var err error
+	NamespaceRaters, err = ParseNamespaceRaters("ml=card-spread, batch=binpack")
+	assert.NoError(t, err)
+	_, err = ParseNamespaceRaters("ml")
+	assert.Error(t, err)
+
+	ni := NewNodeInfo("n1", MockNode("n1", 2), &Binpack{})
+	ni.GPUs[0].Percent = 70
+
+	ml := MockQuotaPod("ml", "p0", 20)
+	assumed, err := ni.Assume(Demand{{Percent: 20}}, ml, nil, PolicySpec{}, false)
+	assert.True(t, assumed)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1}, ni.PlanCache[planKey(Demand{{Percent: 20}}, ml)].GPUIndexes)
+
+	batch := MockQuotaPod("batch", "p0", 20)
+	ni.Assume(Demand{{Percent: 20}}, batch, nil, PolicySpec{}, false)
+	assert.Equal(t, []int{0}, ni.PlanCache[planKey(Demand{{Percent: 20}}, batch)].GPUIndexes)
+}
@@ -0,0 +1,124 @@
+package dealer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	log "k8s.io/klog/v2"
+)
+
+// CheckpointPath is the file, e.g. on a PVC, the dealer state is flushed to on
+// shutdown and warm started from, empty disables it.
+var CheckpointPath string
+
+// CheckpointMaxAge is the age a checkpoint is warm started from at most, an older one
+// is ignored and the pods are listed, 0 takes a checkpoint of any age.
+var CheckpointMaxAge time.Duration
+
+// Checkpoint is the state of the dealer which is expensive to rebuild. The cards
+// are not part of it, they are rebuilt from the plans of the pods.
+type Checkpoint struct {
+	Time        time.Time                              `json:"time"`
+	Pods        []*v1.Pod                              `json:"pods"`
+	CoreUsage   map[string]map[int]GPUCoreUsage        `json:"coreUsage"`
+	MemoryUsage map[string]map[int]GPUMemoryUsage      `json:"memoryUsage"`
+	MetricUsage map[string]map[string]map[int]GPUUsage `json:"metricUsage"`
+	Health      map[string]map[int]GPUHealth           `json:"health"`
+	Allocations map[string]map[int][]AllocationEvent   `json:"allocations"`
+}
+
+// SaveCheckpoint writes the state to the path, the file is replaced at once so a
+// crash never leaves half a checkpoint.
+func (d *DealerImpl) SaveCheckpoint(path string) error {
+	d.Lock.Lock()
+	cp := &Checkpoint{
+		Time:        time.Now(),
+		Pods:        make([]*v1.Pod, 0, len(d.PodMaps)),
+		CoreUsage:   d.CoreUsage,
+		MemoryUsage: d.MemoryUsage,
+		MetricUsage: d.MetricUsage,
+		Health:      d.Health,
+		Allocations: d.Allocations,
+	}
+	for _, pod := range d.PodMaps {
+		cp.Pods = append(cp.Pods, pod)
+	}
+	// pods of nodes not rebuilt since the last warm start
+	for _, pods := range d.Restored {
+		cp.Pods = append(cp.Pods, pods...)
+	}
+	data, err := json.Marshal(cp)
+	d.Lock.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// loadCheckpoint restores the state from the path. The pods are accounted when
+// their node is first used, so no pod has to be listed; pods deleted meanwhile are
+// released by the orphan collector and pods changed meanwhile by the informer. The
+// file is moved aside once read so that a later restart never takes it again.
+func (d *DealerImpl) loadCheckpoint(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(path, path+".loaded"); err != nil {
+		log.Warningf("move loaded checkpoint %s aside failed: %v", path, err)
+	}
+	cp := &Checkpoint{}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return err
+	}
+	if age := time.Since(cp.Time); CheckpointMaxAge > 0 && age > CheckpointMaxAge {
+		return fmt.Errorf("checkpoint of %v is %v old, older than %v", cp.Time, age, CheckpointMaxAge)
+	}
+	d.restoreCheckpoint(cp)
+	log.Infof("warm start from checkpoint of %v with %d pods", cp.Time, len(cp.Pods))
+	return nil
+}
+
+// restoreCheckpoint takes over the state of the checkpoint, the pods are accounted
+// when their node is first used.
+func (d *DealerImpl) restoreCheckpoint(cp *Checkpoint) {
+	for _, pod := range cp.Pods {
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+		d.Restored[pod.Spec.NodeName] = append(d.Restored[pod.Spec.NodeName], pod)
+	}
+	if cp.CoreUsage != nil {
+		d.CoreUsage = cp.CoreUsage
+	}
+	if cp.MemoryUsage != nil {
+		d.MemoryUsage = cp.MemoryUsage
+	}
+	if cp.MetricUsage != nil {
+		d.MetricUsage = cp.MetricUsage
+	}
+	if cp.Health != nil {
+		d.Health = cp.Health
+	}
+	if cp.Allocations != nil {
+		d.Allocations = cp.Allocations
+	}
+	d.warm = len(d.Restored) > 0
+}
@@ -0,0 +1,88 @@
+package dealer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+)
+
+func TestCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+
+	node := MockNode("n1", 2)
+	d := MockDealer(node)
+	d.NodeMaps["n1"] = NewNodeInfo("n1", node, d.Rater)
+	for i, name := range []string{"p0", "p1"} {
+		pod := MockQuotaPod("a", name, 30)
+		pod.Spec.NodeName = "n1"
+		assert.Nil(t, d.Allocate(utils.GetUpdatedPodAnnotationSpec(pod, []int{i})))
+	}
+	d.UpdateHealth("n1", 1, GPUHealth{XID: 79})
+	assert.Nil(t, d.SaveCheckpoint(path))
+
+	restarted := MockDealer(node)
+	assert.Nil(t, restarted.loadCheckpoint(path))
+	assert.Empty(t, restarted.PodMaps)
+	assert.Equal(t, d.Health, restarted.Health)
+
+	// the node is rebuilt from the checkpoint without listing pods
+	ni, err := restarted.getNodeInfo("n1")
+	assert.Nil(t, err)
+	assert.Equal(t, 70, ni.GPUs[0].Percent)
+	assert.Equal(t, 70, ni.GPUs[1].Percent)
+	assert.Len(t, restarted.PodMaps, 2)
+	assert.Empty(t, restarted.Restored)
+	assert.False(t, restarted.warm)
+
+	// the checkpoint is taken once
+	assert.Error(t, MockDealer(node).loadCheckpoint(path))
+
+	// a checkpoint too old is ignored
+	assert.Nil(t, d.SaveCheckpoint(path))
+	CheckpointMaxAge = time.Nanosecond
+	defer func() { CheckpointMaxAge = 0 }()
+	stale := MockDealer(node)
+	assert.Error(t, stale.loadCheckpoint(path))
+	assert.Empty(t, stale.Restored)
+	assert.False(t, stale.warm)
+}
+
+func TestCheckpointListsNodesItMisses(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+
+	n1, n2 := MockNode("n1", 1), MockNode("n2", 1)
+	d := MockDealer(n1)
+	d.NodeMaps["n1"] = NewNodeInfo("n1", n1, d.Rater)
+	pod := MockQuotaPod("a", "p0", 30)
+	pod.Spec.NodeName = "n1"
+	assert.Nil(t, d.Allocate(utils.GetUpdatedPodAnnotationSpec(pod, []int{0})))
+	assert.Nil(t, d.SaveCheckpoint(path))
+
+	restarted := MockDealer(n1, n2)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	restarted.PodLister = corelisters.NewPodLister(indexer)
+	bound := utils.GetUpdatedPodAnnotationSpec(MockQuotaPod("a", "p1", 40), []int{0})
+	bound.Spec.NodeName = "n2"
+	assert.Nil(t, indexer.Add(bound))
+	assert.Nil(t, restarted.loadCheckpoint(path))
+
+	// a node the checkpoint doesn't have is listed while others are still restored
+	ni, err := restarted.getNodeInfo("n2")
+	assert.Nil(t, err)
+	assert.Equal(t, 60, ni.GPUs[0].Percent)
+	assert.True(t, restarted.warm)
+}
@@ -0,0 +1,41 @@
+package dealer
+
+// Consolidation keeps the number of cards in use minimal cluster-wide so that idle
+// nodes can be scaled down. Its plans fill the partially used cards first like
+// Binpack, and nodes score by how few idle cards the plan opens, busy nodes above
+// idle ones.
+type Consolidation struct {
+	Binpack
+}
+
+func (c *Consolidation) Rate(gpus GPUs, p *Plan, d Dealer, policySpec PolicySpec, nodeName string, isLoadSchedule bool) int {
+	if len(gpus) == 0 {
+		return ScoreMin
+	}
+	indexes, err := c.Choose(gpus, p.Demand)
+	if err != nil {
+		return ScoreMin
+	}
+	opened := make(map[int]struct{})
+	for _, idx := range indexes {
+		if idx >= 0 && gpus[idx].Percent == gpus[idx].PercentTotal {
+			opened[idx] = struct{}{}
+		}
+	}
+	inUse := 0
+	for _, g := range gpus {
+		if g.Percent < g.PercentTotal {
+			inUse = 1
+			break
+		}
+	}
+	total, reserved := 0, 0
+	for _, g := range gpus {
+		total += g.PercentTotal
+		reserved += g.PercentTotal - g.Percent
+	}
+	for _, r := range p.Demand {
+		reserved += r.Percent
+	}
+	return 40*inUse + 40*(len(gpus)-len(opened))/len(gpus) + 20*reserved/total
+}
@@ -0,0 +1,26 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsolidationRate(t *testing.T) {
+	c := &Consolidation{}
+	demand := Demand{{Percent: 30}}
+	idle := GPUs{{Percent: 100, PercentTotal: 100}, {Percent: 100, PercentTotal: 100}}
+	partial := GPUs{{Percent: 100, PercentTotal: 100}, {Percent: 50, PercentTotal: 100}}
+	full := GPUs{{Percent: 100, PercentTotal: 100}, {Percent: 20, PercentTotal: 100}}
+
+	idleScore := c.Rate(idle, &Plan{Demand: demand}, nil, PolicySpec{}, "n1", false)
+	partialScore := c.Rate(partial, &Plan{Demand: demand}, nil, PolicySpec{}, "n2", false)
+	fullScore := c.Rate(full, &Plan{Demand: demand}, nil, PolicySpec{}, "n3", false)
+	// filling a partial card beats opening a card on a busy node, which beats opening an idle node
+	assert.Greater(t, partialScore, fullScore)
+	assert.Greater(t, fullScore, idleScore)
+
+	indexes, err := c.Choose(partial, demand)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1}, indexes)
+}
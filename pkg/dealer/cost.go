@@ -0,0 +1,58 @@
+package dealer
+
+import (
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+// CostPolicy prefers the nodes of cheaper gpu models, so that fractional pods land
+// on small cards before the large ones. The cards of a node are assumed to be of a
+// single model.
+type CostPolicy struct {
+	// Label is the node label holding the gpu model, the gpu product label by default.
+	Label string `yaml:"label"`
+	// Prices maps the gpu models to their hourly cost by card.
+	Prices map[string]float64 `yaml:"prices"`
+	// Weight is the score of the cheapest model, the most expensive one scores 0.
+	Weight int `yaml:"weight"`
+}
+
+func (cp CostPolicy) label() string {
+	if cp.Label == "" {
+		return schetypes.LabelGPUProduct
+	}
+	return cp.Label
+}
+
+// Price returns the hourly cost of a card of the node, false when its model has no
+// price.
+func (cp CostPolicy) Price(ni *NodeInfo) (float64, bool) {
+	price, ok := cp.Prices[ni.Labels[cp.label()]]
+	return price, ok
+}
+
+// costScore scales the price of the node between the cheapest and the most expensive
+// model, nodes of unpriced models score 0.
+func costScore(ni *NodeInfo, cp CostPolicy) int {
+	if cp.Weight == 0 || len(cp.Prices) == 0 {
+		return 0
+	}
+	price, ok := cp.Price(ni)
+	if !ok {
+		return 0
+	}
+	first := true
+	min, max := 0.0, 0.0
+	for _, p := range cp.Prices {
+		if first || p < min {
+			min = p
+		}
+		if first || p > max {
+			max = p
+		}
+		first = false
+	}
+	if max == min {
+		return 0
+	}
+	return int(float64(cp.Weight) * (max - price) / (max - min))
+}
@@ -0,0 +1,38 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestCostScore(t *testing.T) {
+	cp := CostPolicy{Weight: 20, Prices: map[string]float64{"T4": 0.5, "A10": 1, "A100": 3}}
+	nodes := map[string]string{"t4": "T4", "a10": "A10", "a100": "A100", "v100": "V100"}
+	scores := make(map[string]int)
+	for name, model := range nodes {
+		node := MockNode(name, 2)
+		node.Labels = map[string]string{types.LabelGPUProduct: model}
+		scores[name] = costScore(NewNodeInfo(name, node, &Binpack{}), cp)
+	}
+	assert.Equal(t, 20, scores["t4"])
+	assert.Equal(t, 16, scores["a10"])
+	assert.Equal(t, 0, scores["a100"])
+	assert.Equal(t, 0, scores["v100"])
+
+	cp.Weight = 0
+	assert.Equal(t, 0, costScore(NewNodeInfo("t4", MockNode("t4", 2), &Binpack{}), cp))
+}
+
+func TestCostPolicyLabel(t *testing.T) {
+	cp := CostPolicy{Label: "cloud/instance-type", Weight: 10, Prices: map[string]float64{"g4dn": 0.5, "p4d": 4}}
+	node := MockNode("n1", 1)
+	node.Labels = map[string]string{"cloud/instance-type": "g4dn", types.LabelGPUProduct: "T4"}
+	ni := NewNodeInfo("n1", node, &Binpack{})
+	price, ok := cp.Price(ni)
+	assert.True(t, ok)
+	assert.Equal(t, 0.5, price)
+	assert.Equal(t, 10, costScore(ni, cp))
+}
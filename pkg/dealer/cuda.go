@@ -0,0 +1,158 @@
+package dealer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	log "k8s.io/klog/v2"
+)
+
+// Version is a dotted version, 12.1 or 535.104.05.
+type Version []int
+
+func ParseVersion(s string) (Version, error) {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(s), "v"), ".")
+	v := make(Version, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version %q", s)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+// Less compares the versions part by part, missing parts are 0.
+func (v Version) Less(o Version) bool {
+	for i := 0; i < len(v) || i < len(o); i++ {
+		a, b := 0, 0
+		if i < len(v) {
+			a = v[i]
+		}
+		if i < len(o) {
+			b = o[i]
+		}
+		if a != b {
+			return a < b
+		}
+	}
+	return false
+}
+
+func (v Version) String() string {
+	parts := make([]string, len(v))
+	for i, n := range v {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".")
+}
+
+// InferCUDAVersion reads the cuda version of pods without annotation from the tags
+// of their images. An inferred version only fails the nodes which report a lower
+// one, nodes of unknown version are kept.
+var InferCUDAVersion bool
+
+// imageCUDAVersion matches the cuda version in image tags like nvidia/cuda:12.1.0-runtime
+// or pytorch:2.1.0-cuda12.1-cudnn8.
+var imageCUDAVersion = regexp.MustCompile(`(?i)cuda[:_-]?v?(\d+\.\d+)`)
+
+// podCUDAVersion returns the lowest cuda version the pod runs with, from its annotation
+// or else, with InferCUDAVersion, the highest version in the tags of its images.
+// inferred tells the version came from the images.
+func podCUDAVersion(pod *v1.Pod) (v Version, inferred bool, ok bool) {
+	if value, ok := pod.Annotations[schetypes.AnnotationCUDAVersion]; ok {
+		v, err := ParseVersion(value)
+		if err != nil {
+			log.Warningf("ignore cuda version of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+			return nil, false, false
+		}
+		return v, false, true
+	}
+	if !InferCUDAVersion {
+		return nil, false, false
+	}
+	var ans Version
+	for _, c := range pod.Spec.Containers {
+		m := imageCUDAVersion.FindStringSubmatch(c.Image)
+		if m == nil {
+			continue
+		}
+		if v, err := ParseVersion(m[1]); err == nil && ans.Less(v) {
+			ans = v
+		}
+	}
+	return ans, true, ans != nil
+}
+
+// nodeVersion returns the version of the node annotation, or else the one of the
+// labels, false when it has neither.
+func nodeVersion(node *v1.Node, annotation string, labels ...string) (Version, bool) {
+	if value, ok := node.Annotations[annotation]; ok {
+		if v, err := ParseVersion(value); err == nil {
+			return v, true
+		}
+	}
+	parts := make([]string, 0, len(labels))
+	for _, l := range labels {
+		value, ok := node.Labels[l]
+		if !ok {
+			break
+		}
+		parts = append(parts, value)
+	}
+	if len(parts) == 0 {
+		return nil, false
+	}
+	v, err := ParseVersion(strings.Join(parts, "."))
+	return v, err == nil
+}
+
+// checkVersion fails the node when the version it has is unknown or below the one
+// required.
+func checkVersion(node *v1.Node, kind string, required Version, annotation string, labels ...string) error {
+	v, ok := nodeVersion(node, annotation, labels...)
+	if !ok {
+		return fmt.Errorf("%s version of node %s is unknown", kind, node.Name)
+	}
+	if v.Less(required) {
+		return fmt.Errorf("%s version %s of node %s is below %s", kind, v, node.Name, required)
+	}
+	return nil
+}
+
+// checkNodeCUDA fails the nodes whose cuda or driver version is below the one of the pod.
+func (d *DealerImpl) checkNodeCUDA(ni *NodeInfo, pod *v1.Pod) error {
+	cuda, inferred, needCUDA := podCUDAVersion(pod)
+	var driver Version
+	if value, ok := pod.Annotations[schetypes.AnnotationDriverVersion]; ok {
+		v, err := ParseVersion(value)
+		if err != nil {
+			log.Warningf("ignore driver version of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+		driver = v
+	}
+	if !needCUDA && driver == nil {
+		return nil
+	}
+	node, err := d.NodeLister.Get(ni.Name)
+	if err != nil {
+		return err
+	}
+	if _, known := nodeVersion(node, schetypes.AnnotationCUDAVersion, schetypes.LabelCUDARuntimeMajor, schetypes.LabelCUDARuntimeMinor); needCUDA && (known || !inferred) {
+		if err := checkVersion(node, "cuda", cuda, schetypes.AnnotationCUDAVersion,
+			schetypes.LabelCUDARuntimeMajor, schetypes.LabelCUDARuntimeMinor); err != nil {
+			return err
+		}
+	}
+	if driver != nil {
+		return checkVersion(node, "driver", driver, schetypes.AnnotationDriverVersion,
+			schetypes.LabelCUDADriverMajor, schetypes.LabelCUDADriverMinor, schetypes.LabelCUDADriverRev)
+	}
+	return nil
+}
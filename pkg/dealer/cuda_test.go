@@ -0,0 +1,79 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestVersion(t *testing.T) {
+	v, err := ParseVersion("535.104.05")
+	assert.NoError(t, err)
+	assert.Equal(t, Version{535, 104, 5}, v)
+	assert.True(t, Version{11, 8}.Less(Version{12}))
+	assert.False(t, Version{12}.Less(Version{12, 0}))
+	assert.True(t, Version{12}.Less(Version{12, 0, 1}))
+	_, err = ParseVersion("12.x")
+	assert.Error(t, err)
+}
+
+func TestPodCUDAVersion(t *testing.T) {
+	pod := MockQuotaPod("a", "p0", 40)
+	_, _, ok := podCUDAVersion(pod)
+	assert.False(t, ok)
+
+	pod.Spec.Containers = []v1.Container{
+		{Image: "nvcr.io/nvidia/cuda:11.8.0-runtime-ubuntu22.04"},
+		{Image: "pytorch/pytorch:2.1.0-cuda12.1-cudnn8-runtime"},
+	}
+	// the images are only read when enabled
+	_, _, ok = podCUDAVersion(pod)
+	assert.False(t, ok)
+	InferCUDAVersion = true
+	defer func() { InferCUDAVersion = false }()
+	v, inferred, ok := podCUDAVersion(pod)
+	assert.True(t, ok)
+	assert.True(t, inferred)
+	assert.Equal(t, Version{12, 1}, v)
+
+	pod.Annotations = map[string]string{types.AnnotationCUDAVersion: "11.2"}
+	v, inferred, ok = podCUDAVersion(pod)
+	assert.True(t, ok)
+	assert.False(t, inferred)
+	assert.Equal(t, Version{11, 2}, v)
+}
+
+func TestCheckNodeCUDA(t *testing.T) {
+	cuda11, cuda12, unknown := MockNode("cuda11", 1), MockNode("cuda12", 1), MockNode("unknown", 1)
+	cuda11.Labels = map[string]string{types.LabelCUDARuntimeMajor: "11", types.LabelCUDARuntimeMinor: "4",
+		types.LabelCUDADriverMajor: "470", types.LabelCUDADriverMinor: "82", types.LabelCUDADriverRev: "01"}
+	cuda12.Annotations = map[string]string{types.AnnotationCUDAVersion: "12.2", types.AnnotationDriverVersion: "535.104.05"}
+	d := MockDealer(cuda11, cuda12, unknown)
+	ni := func(node *v1.Node) *NodeInfo { return NewNodeInfo(node.Name, node, d.Rater) }
+
+	pod := MockQuotaPod("a", "p0", 40)
+	assert.NoError(t, d.checkNodeCUDA(ni(unknown), pod))
+
+	pod.Annotations = map[string]string{types.AnnotationCUDAVersion: "12.0"}
+	assert.Error(t, d.checkNodeCUDA(ni(cuda11), pod))
+	assert.NoError(t, d.checkNodeCUDA(ni(cuda12), pod))
+	assert.Error(t, d.checkNodeCUDA(ni(unknown), pod))
+
+	pod.Annotations = map[string]string{types.AnnotationCUDAVersion: "11.0", types.AnnotationDriverVersion: "470.82"}
+	assert.NoError(t, d.checkNodeCUDA(ni(cuda11), pod))
+	pod.Annotations[types.AnnotationDriverVersion] = "525"
+	assert.Error(t, d.checkNodeCUDA(ni(cuda11), pod))
+	assert.NoError(t, d.checkNodeCUDA(ni(cuda12), pod))
+
+	// a version inferred from the images keeps the nodes of unknown version
+	InferCUDAVersion = true
+	defer func() { InferCUDAVersion = false }()
+	pod = MockQuotaPod("a", "p1", 40)
+	pod.Spec.Containers[0].Image = "nvidia/cuda:12.1.0-runtime"
+	assert.NoError(t, d.checkNodeCUDA(ni(unknown), pod))
+	assert.Error(t, d.checkNodeCUDA(ni(cuda11), pod))
+	assert.NoError(t, d.checkNodeCUDA(ni(cuda12), pod))
+}
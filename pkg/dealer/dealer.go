@@ -1,9 +1,8 @@
 package dealer
 
 import (
-	"context"
 	"fmt"
-	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"sync"
 	"time"
@@ -12,6 +11,7 @@ import (
 	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
 
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	corelisters "k8s.io/client-go/listers/core/v1"
@@ -30,7 +30,7 @@ type Dealer interface {
 	KnownPod(pod *v1.Pod) bool
 	PodReleased(pod *v1.Pod) bool
 	PrintStatus(pod *v1.Pod, action string)
-	Status() (map[string]*NodeInfo, error)
+	Status() (*Status, error)
 	GetCoreUsage(nodeName string) (map[int]GPUCoreUsage, bool)
 	GetMemoryUsage(nodeName string) (map[int]GPUMemoryUsage, bool)
 	GetMemoryUsageLock(nodeName string) (map[int]GPUMemoryUsage, bool)
@@ -39,11 +39,106 @@ type Dealer interface {
 	AddMemoryUsage(nodeName string)
 	UpdateCoreUsage(nodeName, coreUsage, updateTime string, cardNum int)
 	UpdateMemoryUsage(nodeName, memoryUsage, updateTime string, cardNum int)
+	UpdateMetricUsage(nodeName, key, usage, updateTime string, cardNum int)
+	UpdateHealth(nodeName string, card int, health GPUHealth)
+	UpdateNode(node *v1.Node)
+	DeleteNode(name string)
+	GetUnhealthyCards(nodeName string) map[int]string
+	GetUnhealthyCardsLock(nodeName string) map[int]string
+	ExcludedCards(nodeName string, pod *v1.Pod) map[int]string
+	MIGReconfigurePlan(pod *v1.Pod) *MIGReconfiguration
+	UpdateWholeGPUPod(pod *v1.Pod)
+	ForgetWholeGPUPod(pod *v1.Pod)
+	Confirm(pod *v1.Pod) ([]int, map[string]string, error)
+	UnconfirmedPods() []*v1.Pod
+	MarkTerminating(pod *v1.Pod)
+	SoonFreeShare(nodeName string) map[int]int
+	SaveCheckpoint(path string) error
 	GetUsage(nodeName, key string, card int, activeDuration time.Duration) (bool, float64, error)
+	GetUsageLock(nodeName, key string, card int, activeDuration time.Duration) (bool, float64, error)
+	PodsOnCard(nodeName string, card int) []*v1.Pod
+	DefragPlan(pod *v1.Pod) *DefragPlan
+	Fragmentation() *FragmentationReport
+	Capacity(demand Demand, maxReplicas int) (*CapacityReport, error)
+	BurstPressure() map[string]map[int]CardBurst
+	UpdateQuota(quota *ElasticQuota)
+	DeleteQuota(name string)
+	QuotaStatus() []ElasticQuota
+	ReclaimCandidates(lender string, amount int) []*v1.Pod
+	KnownPodUIDs() []types.UID
+	Audit() (*AuditReport, error)
+	ReleaseOrphans(candidates []types.UID, live map[types.UID]struct{}) []*v1.Pod
+	RecordUsageSync(nodeName, metric string, err error)
+	UsageBreakerOpen(nodeName string) bool
+	UsageBreakers() map[string]BreakerState
+	Degraded() bool
+	DeferWrite(namespace, name string, labels, annotations map[string]string)
+	FlushDeferred()
+	FilterNonGPU(nodes []string, pod *v1.Pod) ([]bool, []error)
+	CardShapes(nodeName string) []CardShape
+	TypicalShape() float64
+	UsageHistory(nodeName, metric string, window time.Duration, withSamples bool) ([]UsageHistory, error)
+	UsageQuantile(nodeName, metric string, card int, window time.Duration, q float64) (float64, bool)
+	PushUsage(report UsageReport) error
+	GetUsageSample(nodeName, key string, card int) (UsageSample, bool)
+	HottestCards(metric string, k int) []HotCard
+	Heatmap() []HeatmapRow
+	PodPlacement(namespace, name string) (*PodPlacement, error)
+	CardPlacements(nodeName string, card int) []PodPlacement
+	AllocationHistory(nodeName string, card int, since time.Time) []AllocationEvent
+	QueryStatus(q StatusQuery) (*Status, error)
+	UpdateKueueAdmissions(admitted map[types.UID]struct{})
+	FlavorUsage(nodeLabels map[string]string) (*FlavorUsage, error)
+	ElasticGrant(pod *v1.Pod) int
+	ShadowStatus() ShadowStats
+	PlanWriteStatus() PlanWriteStats
+	Summary(cluster string) *ClusterSummary
+	SetShardHeld(held bool)
+	InFlightAssumptions() []Assumption
+	ImportAssumptions(assumptions []Assumption) int
+	QueuedPods() []QueuedPod
+	RequeueCandidates() []QueuedPod
+	RequeueStatus() RequeueStats
+	ExhaustedPods() []QueuedPod
+	HintStatus() HintStats
+	InjectFault(fault Fault) error
+	ClearFaults(point string)
+	ArmedFaults() []Fault
+	ArmStatus() map[string]*ArmStats
+	DryRun(nodes []string, pod *v1.Pod, policySpec PolicySpec, isLoadSchedule bool) (*DryRunReport, error)
 }
 
 func NewDealer(clientset *kubernetes.Clientset, nodeLister corelisters.NodeLister, podLister corelisters.PodLister, rater Rater) (Dealer, error) {
-	di := &DealerImpl{
+	di := newDealerImpl(clientset, nodeLister, podLister, rater)
+	if err := di.load(); err != nil {
+		return nil, err
+	}
+	if RecordPath != "" {
+		return NewRecordingDealer(di, RecordPath)
+	}
+	return di, nil
+}
+
+// load accounts the assumed pods, from the checkpoint when there is one.
+func (d *DealerImpl) load() error {
+	if CheckpointPath != "" {
+		err := d.loadCheckpoint(CheckpointPath)
+		if err == nil {
+			return nil
+		}
+		log.Warningf("load checkpoint %s failed, list all pods: %v", CheckpointPath, err)
+	}
+	if err := d.loadAssumedPods(); err != nil {
+		return err
+	}
+	if AllocationClient != nil {
+		return d.loadAllocations()
+	}
+	return nil
+}
+
+func newDealerImpl(clientset *kubernetes.Clientset, nodeLister corelisters.NodeLister, podLister corelisters.PodLister, rater Rater) *DealerImpl {
+	return &DealerImpl{
 		Client:         clientset,
 		NodeLister:     nodeLister,
 		PodLister:      podLister,
@@ -54,23 +149,34 @@ func NewDealer(clientset *kubernetes.Clientset, nodeLister corelisters.NodeListe
 		CoreUsage:      make(map[string]map[int]GPUCoreUsage),
 		MemoryUsage:    make(map[string]map[int]GPUMemoryUsage),
 		ReleasedPodMap: make(map[types.UID]struct{}),
+		Quotas:         make(map[string]*ElasticQuota),
+		PendingPods:    make(map[types.UID]*pendingPod),
+		CoreHistory:    make(map[string]map[int][]float64),
+		UsageFilters:   make(map[string]*usageFilter),
+		MetricUsage:    make(map[string]map[string]map[int]GPUUsage),
+		Health:         make(map[string]map[int]GPUHealth),
+		WholeGPUPods:   make(map[types.UID]*wholeGPUPod),
+		Unconfirmed:    make(map[types.UID]time.Time),
+		Terminating:    make(map[types.UID]time.Time),
+		Restored:       make(map[string][]*v1.Pod),
+		Breakers:       make(map[string]*usageBreaker),
+		History:        make(map[string]map[int]*usageRing),
+		Pushes:         make(map[string]pushState),
+		UsageCache:     make(map[string]map[int]cachedUsage),
+		Allocations:    make(map[string]map[int][]AllocationEvent),
+		Gangs:          make(map[string]*gang),
+		JobPlans:       make(map[types.UID]*jobPlan),
+		ShadowChoices:  make(map[types.UID]*shadowChoice),
+		ArmSeen:        make(map[types.UID]time.Time),
+		ArmLatency:     make(map[string]*armLatency),
+		InFlight:       make(map[types.UID]*Assumption),
+		Queued:         make(map[types.UID]*QueuedPod),
+		Freed:          make(map[string]int),
+		Hints:          make(map[types.UID]*schedulingHint),
+		Imported:       make(map[types.UID]*Assumption),
+		MIGPending:     make(map[types.UID]*MIGReconfiguration),
+		AntiAffinity:   make(map[types.UID]labels.Selector),
 	}
-	pods, err := clientset.CoreV1().Pods(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{
-		LabelSelector: fmt.Sprintf("%s=%s", schetypes.GPUAssume, "true"),
-	})
-	if err != nil {
-		return nil, err
-	}
-	for _, pod := range pods.Items {
-		if pod.Spec.NodeName == "" {
-			continue
-		}
-		if _, err := di.getNodeInfo(pod.Spec.NodeName); err != nil {
-			log.Errorf("get node %s failed: %s", pod.Spec.NodeName, err.Error())
-			continue
-		}
-	}
-	return di, nil
 }
 
 type DealerImpl struct {
@@ -84,25 +190,104 @@ type DealerImpl struct {
 	CoreUsage      map[string]map[int]GPUCoreUsage
 	MemoryUsage    map[string]map[int]GPUMemoryUsage
 	ReleasedPodMap map[types.UID]struct{}
+	Quotas         map[string]*ElasticQuota
+	PendingPods    map[types.UID]*pendingPod
+	CoreHistory    map[string]map[int][]float64
+	UsageFilters   map[string]*usageFilter
+	MetricUsage    map[string]map[string]map[int]GPUUsage
+	Health         map[string]map[int]GPUHealth
+	WholeGPUPods   map[types.UID]*wholeGPUPod
+	// Unconfirmed holds the pods whose plan waits for the device plugin since when.
+	Unconfirmed map[types.UID]time.Time
+	// Terminating holds the known terminating pods with the time they are due.
+	Terminating map[types.UID]time.Time
+	// Restored holds the pods of a checkpoint by node until the node is used.
+	Restored map[string][]*v1.Pod
+	// Breakers holds the usage breaker by node.
+	Breakers map[string]*usageBreaker
+	// History holds the recent usage samples by node and metric.
+	History map[string]map[int]*usageRing
+	// Pushes holds the last usage report pushed by node.
+	Pushes map[string]pushState
+	// UsageCache holds the parsed usage by node and metric, it is filled while the
+	// cards are planned in parallel and so has its own lock.
+	UsageCache     map[string]map[int]cachedUsage
+	usageCacheLock sync.Mutex
+	// Allocations holds the recent allocation events by node and card.
+	Allocations map[string]map[int][]AllocationEvent
+	Throttle throttle
+	Faults   faults
+	// Shapes are the memory to core ratios of the recently bound pods.
+	Shapes []float64
+	// KueueAdmitted holds the owners of the admitted Kueue workloads, nil when Kueue
+	// isn't integrated.
+	KueueAdmitted map[types.UID]struct{}
+	// Gangs holds the shares reserved for the waiting members of pod groups by group.
+	Gangs map[string]*gang
+	// JobPlans holds the placement of the pending replicas of a controller by controller.
+	JobPlans map[types.UID]*jobPlan
+	// ShadowChoices holds the placement the shadow policy picks for the scored pods.
+	ShadowChoices map[types.UID]*shadowChoice
+	ShadowStats   ShadowStats
+	PlanWrites    PlanWriteStats
+	// ShardHeld is whether the scheduler holds the Lease of its shard.
+	ShardHeld bool
+	// InFlight holds the scored pods waiting for their binding, Imported the share
+	// reserved for those of the scheduler which held the shard before.
+	InFlight map[types.UID]*Assumption
+	Imported map[types.UID]*Assumption
+	// Queued holds the pods waiting for gpu capacity.
+	Queued map[types.UID]*QueuedPod
+	// Freed holds the gpu percent released by node in the current requeue period.
+	Freed    map[string]int
+	Requeues RequeueStats
+	// Hints holds the nodes the pods failed on in their last filterings.
+	Hints     map[types.UID]*schedulingHint
+	HintStats HintStats
+	// ArmSeen holds when the pods of the experiment arms were first filtered.
+	ArmSeen    map[types.UID]time.Time
+	ArmLatency map[string]*armLatency
+	// MIGPending holds the card planned to be repartitioned for a pod until its
+	// node reports the new layout.
+	MIGPending map[types.UID]*MIGReconfiguration
+	// AntiAffinity holds the parsed card anti-affinity of the known pods which
+	// have one.
+	AntiAffinity map[types.UID]labels.Selector
+	warm         bool
 }
 
 func (d *DealerImpl) Assume(nodes []string, pod *v1.Pod, policySpec PolicySpec, isLoadSchedule bool) ([]bool, []error) {
 	d.Lock.Lock()
 	defer d.Lock.Unlock()
 
+	d.expireGangs(time.Now())
+	d.unreserveGang(pod.UID)
+	d.expireImported(time.Now())
+	d.dropImported(pod.UID)
+	d.pruneTerminating()
+	d.trackArm(pod.UID, time.Now())
 	demand := NewDemandFromPod(pod)
 	res := make([]error, len(nodes))
 	ans := make([]bool, len(nodes))
+	if err := d.checkPod(pod); err != nil {
+		for i := range nodes {
+			res[i] = err
+		}
+		delete(d.Queued, pod.UID)
+		return ans, res
+	}
 	nodeInfos := make([]*NodeInfo, len(nodes))
 	for i, name := range nodes {
-		ni, err := d.getNodeInfo(name)
+		ni, err := d.checkNode(name, pod, demand, policySpec, isLoadSchedule)
 		if err != nil {
 			ni = nil
 			ans[i] = false
-			res[i] = fmt.Errorf("nano gpu scheduler get node failed: %v", err)
+			res[i] = err
 		}
 		nodeInfos[i] = ni
 	}
+	d.applyJobPlan(pod, nodeInfos, res, policySpec, isLoadSchedule)
+	states, skipped := d.applyHints(pod, demand, nodeInfos, ans, res, isLoadSchedule)
 
 	ch := make(chan int, len(nodeInfos))
 	wg := sync.WaitGroup{}
@@ -117,11 +302,11 @@ func (d *DealerImpl) Assume(nodes []string, pod *v1.Pod, policySpec PolicySpec,
 			for {
 				select {
 				case number := <-ch:
-					if nodeInfos[number] == nil {
+					if nodeInfos[number] == nil || skipped != nil && skipped[number] {
 						continue
 					}
 					nodeInfos[number].cleanPlan()
-					assumed, err := nodeInfos[number].Assume(demand, d, policySpec, isLoadSchedule)
+					assumed, err := nodeInfos[number].Assume(demand, pod, d, policySpec, isLoadSchedule)
 					ans[number] = assumed
 					res[number] = err
 				default:
@@ -132,14 +317,102 @@ func (d *DealerImpl) Assume(nodes []string, pod *v1.Pod, policySpec PolicySpec,
 		}()
 	}
 	wg.Wait()
+	for i, ni := range nodeInfos {
+		if ni != nil && !ans[i] {
+			d.forgetJobReplica(pod, true)
+		}
+	}
+	d.recordHints(pod, demand, nodeInfos, ans, res, states, skipped)
+	d.trackPending(pod, nodeInfos, ans)
+	d.trackQueued(pod, demand, nodeInfos, ans, res, policySpec, isLoadSchedule)
 	return ans, res
 }
 
+// checkPod checks the constraints of the pod which hold on every node.
+func (d *DealerImpl) checkPod(pod *v1.Pod) error {
+	if err := d.checkShard(); err != nil {
+		return err
+	}
+	if err := d.checkKueueAdmission(pod); err != nil {
+		return err
+	}
+	if err := d.checkQuota(pod); err != nil {
+		return err
+	}
+	return d.checkElastic(pod)
+}
+
+// checkNode returns the node when the pod passes its constraints, before the cards
+// of the node are planned.
+func (d *DealerImpl) checkNode(name string, pod *v1.Pod, demand Demand, policySpec PolicySpec, isLoadSchedule bool) (*NodeInfo, error) {
+	ni, err := d.getNodeInfo(name)
+	if err != nil {
+		return nil, fmt.Errorf("nano gpu scheduler get node failed: %w", err)
+	}
+	if err := d.checkNodeSchedulable(ni, pod); err != nil {
+		return nil, err
+	} else if err := d.checkTerminationNotice(ni); err != nil {
+		return nil, err
+	} else if err := d.checkNodePool(ni, pod); err != nil {
+		return nil, err
+	} else if err := checkNodeVendor(ni, pod); err != nil {
+		return nil, err
+	} else if err := d.checkNodeVGPU(ni, pod); err != nil {
+		return nil, err
+	} else if err := d.checkNodeMIG(ni, pod); err != nil {
+		return nil, err
+	} else if err := d.checkNodeCapability(ni, pod); err != nil {
+		return nil, err
+	} else if err := d.checkMaintenance(ni, pod, time.Now()); err != nil {
+		return nil, err
+	} else if err := d.checkCardAffinity(ni, pod); err != nil {
+		return nil, err
+	} else if err := d.checkNodeCUDA(ni, pod); err != nil {
+		return nil, err
+	} else if err := d.checkRules(ni, pod, demand, policySpec, isLoadSchedule); err != nil {
+		return nil, err
+	}
+	return ni, nil
+}
+
 func (d *DealerImpl) Score(nodes []string, pod *v1.Pod, policySpec PolicySpec, isLoadSchedule bool) []int {
+	scores, plans := d.score(nodes, pod, policySpec, isLoadSchedule)
+	if ScoreWebhookURL != "" {
+		scores = adjustScores(pod, nodes, plans, scores)
+	}
+	if NormalizeScores {
+		return normalizeScores(scores)
+	}
+	return scores
+}
+
+// scoreNode scores the plan of the pod on the node, plan is nil when the pod
+// doesn't fit.
+func (d *DealerImpl) scoreNode(ni *NodeInfo, pod *v1.Pod, demand Demand, plan *Plan, spread int, policySpec PolicySpec, isLoadSchedule bool) int {
+	score := ScoreMin
+	if plan != nil {
+		score = plan.Score
+		if policySpec.Scoring.Enabled() {
+			score = policySpec.Scoring.Score(d.subScores(ni, demand, policySpec, isLoadSchedule), spreads(raterOf(pod, policySpec, d.Rater)))
+		}
+		score += d.ruleScore(ni, pod, demand, policySpec, isLoadSchedule) + costScore(ni, policySpec.Cost) + preferredCardBonus(ni, pod, plan)
+	}
+	score = score - d.contentionPenalty(pod, ni) - d.interferencePenalty(pod, ni, demand) + mpsBonus(ni, demand) + capabilityBonus(ni, pod) + spotScore(ni, pod) + spread
+	if score < ScoreMin {
+		score = ScoreMin
+	}
+	return score
+}
+
+// score returns the scores of the nodes with the cards of the plan on every
+// feasible one.
+func (d *DealerImpl) score(nodes []string, pod *v1.Pod, policySpec PolicySpec, isLoadSchedule bool) ([]int, [][]int) {
 	d.Lock.Lock()
 	defer d.Lock.Unlock()
 	demand := NewDemandFromPod(pod)
 	scores := make([]int, len(nodes))
+	plans := make([][]int, len(nodes))
+	spread := d.spreadScores(nodes, pod)
 	for i := 0; i < len(nodes); i++ {
 		ni, err := d.getNodeInfo(nodes[i])
 		if err != nil {
@@ -147,15 +420,30 @@ func (d *DealerImpl) Score(nodes []string, pod *v1.Pod, policySpec PolicySpec, i
 			scores[i] = ScoreMin
 			continue
 		}
-		scores[i] = ni.Score(demand, d, policySpec, isLoadSchedule)
+		ni.Score(demand, pod, d, policySpec, isLoadSchedule)
+		plan, feasible := ni.PlanCache[planKey(demand, pod)]
+		if feasible {
+			plans[i] = append([]int(nil), plan.GPUIndexes...)
+		}
+		scores[i] = d.scoreNode(ni, pod, demand, plan, spread[i], policySpec, isLoadSchedule)
+		if log.V(4).Enabled() {
+			log.Infof("score pod %s/%s on %s: raw %d %+v", pod.Namespace, pod.Name, nodes[i], scores[i],
+				d.subScores(ni, demand, policySpec, isLoadSchedule))
+		}
 	}
-	return scores
+	d.reserveBest(pod, nodes, scores, demand)
+	d.trackAssumption(pod, nodes, scores, demand)
+	d.shadowScore(pod, nodes, demand, isLoadSchedule)
+	return scores, plans
 }
 
 func (d *DealerImpl) Bind(node string, pod *v1.Pod, policySpec PolicySpec, isLoadSchedule bool) (err error) {
 	d.Lock.Lock()
 	defer d.Lock.Unlock()
 
+	if err := d.checkShard(); err != nil {
+		return err
+	}
 	ni, err := d.getNodeInfo(node)
 	if err != nil {
 		return err
@@ -168,36 +456,63 @@ func (d *DealerImpl) Bind(node string, pod *v1.Pod, policySpec PolicySpec, isLoa
 	if anno == nil {
 		anno = map[string]string{}
 	}
-	plan, err := ni.Bind(NewDemandFromPod(pod), d, policySpec, isLoadSchedule)
+	demand := NewDemandFromPod(pod)
+	d.unreserveGang(pod.UID)
+	d.dropImported(pod.UID)
+	delete(d.InFlight, pod.UID)
+	d.waitForRelease(ni, demand, pod, policySpec, isLoadSchedule)
+	plan, err := ni.Bind(demand, pod, d, policySpec, isLoadSchedule)
 	if err != nil {
 		return err
 	}
-
-	newPod := utils.GetUpdatedPodAnnotationSpec(pod, plan.GPUIndexes)
-	if _, err := d.Client.CoreV1().Pods(newPod.Namespace).Update(context.Background(), newPod, metav1.UpdateOptions{}); err != nil {
-		if err.Error() == OptimisticLockErrorMsg {
-			pod, err = d.Client.CoreV1().Pods(pod.Namespace).Get(context.Background(), pod.Name, metav1.GetOptions{})
-			if err != nil {
-				return err
-			}
-			newPod = utils.GetUpdatedPodAnnotationSpec(pod, plan.GPUIndexes)
-			if _, err = d.Client.CoreV1().Pods(pod.Namespace).Update(context.Background(), newPod, metav1.UpdateOptions{}); err != nil {
-				return err
+	defer func() {
+		// the cards of a pod which isn't bound are free again
+		if err != nil {
+			if rerr := ni.Release(plan); rerr != nil {
+				log.Errorf("roll back plan of pod %s/%s on %s failed: %v", pod.Namespace, pod.Name, node, rerr)
 			}
-		} else {
-			return nil
 		}
+	}()
+
+	newPod := podWithPlan(pod, nodeInfo, plan)
+	if fault, ok := d.Faults.fire(FaultBindError, node); ok {
+		return d.observe(fault.apiError(pod))
 	}
-	if err := d.Client.CoreV1().Pods(newPod.Namespace).Bind(context.Background(), &v1.Binding{
-		ObjectMeta: metav1.ObjectMeta{Namespace: newPod.Namespace, Name: newPod.Name, UID: newPod.UID},
-		Target: v1.ObjectReference{
-			Kind: "Node",
-			Name: node,
-		},
-	}, metav1.CreateOptions{}); err != nil {
-		return err
+	ctx, cancel := apiContext()
+	defer cancel()
+	if AllocationClient != nil {
+		if err := d.bindWithAllocation(ctx, newPod, node); err != nil {
+			return err
+		}
+	} else if d.Degraded() && !HasReleaseFinalizer(newPod) {
+		if err := d.bindDegraded(ctx, newPod, node); err != nil {
+			return err
+		}
+	} else {
+		if newPod, err = d.writePlan(ctx, pod, nodeInfo, plan); err != nil {
+			return err
+		}
+		if err := d.Client.CoreV1().Pods(newPod.Namespace).Bind(ctx, &v1.Binding{
+			ObjectMeta: metav1.ObjectMeta{Namespace: newPod.Namespace, Name: newPod.Name, UID: newPod.UID},
+			Target: v1.ObjectReference{
+				Kind: "Node",
+				Name: node,
+			},
+		}, metav1.CreateOptions{}); d.observe(err) != nil {
+			return err
+		}
 	}
+	newPod.Spec.NodeName = node
+	ni.addQoS(newPod, plan, 1)
 	d.PodMaps[pod.UID] = newPod
+	d.trackAntiAffinity(newPod)
+	d.recordAllocation(ni.Name, newPod, plan, AllocationActionAllocate)
+	d.trackUnconfirmed(newPod)
+	d.forgetPending(pod.UID)
+	d.forgetJobReplica(pod, false)
+	d.compareShadow(pod, node, plan)
+	d.observeArm(pod, time.Now())
+	d.recordShape(newPod)
 
 	return nil
 }
@@ -213,8 +528,11 @@ func (d *DealerImpl) Allocate(pod *v1.Pod) error {
 		return err
 	}
 	if _, ok := d.PodMaps[pod.UID]; ok {
-		return nil
+		return d.releaseInit(ni, pod)
 	}
+	d.dropImported(pod.UID)
+	delete(d.InFlight, pod.UID)
+	pod = resolveUUIDs(ni, withAllocation(pod))
 	plan, err := NewPlanFromPod(pod)
 	if err != nil {
 		return err
@@ -223,24 +541,35 @@ func (d *DealerImpl) Allocate(pod *v1.Pod) error {
 	if err != nil {
 		return err
 	}
+	ni.addQoS(pod, plan, 1)
 	d.PodMaps[pod.UID] = pod
+	d.trackAntiAffinity(pod)
+	d.recordAllocation(ni.Name, pod, plan, AllocationActionAllocate)
+	d.trackUnconfirmed(pod)
+	d.forgetPending(pod.UID)
 	return nil
 }
 
 func (d *DealerImpl) Release(pod *v1.Pod) error {
 	d.Lock.Lock()
 	defer d.Lock.Unlock()
+	return d.release(pod)
+}
 
+func (d *DealerImpl) release(pod *v1.Pod) error {
 	ni, err := d.getNodeInfo(pod.Spec.NodeName)
 	if err != nil {
 		log.Errorf("release pod %s failed: %s", pod.Name, err.Error())
 		return err
 	}
-	if _, ok := d.PodMaps[pod.UID]; !ok {
+	known, ok := d.PodMaps[pod.UID]
+	if !ok {
 		log.Errorf("no such pod %s/%s", pod.Namespace, pod.Name)
 		return nil
 	}
-	plan, err := NewPlanFromPod(pod)
+	// release what is accounted, the cards may have been reconciled with the device
+	// plugin and the init share is held until the known pod gets initialized
+	plan, err := NewPlanFromPod(known)
 	if err != nil {
 		log.Errorf("create plan from pod failed: %s", err.Error())
 		return err
@@ -249,7 +578,14 @@ func (d *DealerImpl) Release(pod *v1.Pod) error {
 		log.Errorf("release pod %s failed: node info release failed: %s", pod.Name, err.Error())
 		return err
 	}
+	ni.addQoS(known, plan, -1)
+	d.recordAllocation(ni.Name, known, plan, AllocationActionRelease)
+	d.trackFreed(ni.Name, plan)
 	delete(d.PodMaps, pod.UID)
+	delete(d.AntiAffinity, pod.UID)
+	delete(d.Terminating, pod.UID)
+	d.forgetUnconfirmed(pod.UID)
+	d.deleteAllocation(known)
 	d.ReleasedPodMap[pod.UID] = struct{}{}
 	return nil
 }
@@ -269,35 +605,120 @@ func (d *DealerImpl) PodReleased(pod *v1.Pod) bool {
 }
 
 func (d *DealerImpl) getNodeInfo(name string) (*NodeInfo, error) {
+	if _, ok := d.Faults.fire(FaultNodeGone, name); ok {
+		return nil, apierrors.NewNotFound(v1.Resource("nodes"), name)
+	}
 	if ni, ok := d.NodeMaps[name]; ok {
 		return ni, nil
 	}
+	// the node informer adds the nodes ahead of scheduling, this covers a node event
+	// not delivered yet
 	node, err := d.NodeLister.Get(name)
 	if err != nil {
 		return nil, err
 	}
-	pods, err := d.Client.CoreV1().Pods(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{
-		LabelSelector: fmt.Sprintf("%s=%s", schetypes.GPUAssume, "true"),
-		FieldSelector: fields.OneTermEqualSelector(schetypes.NodeNameField, name).String(),
-	})
+	if !OwnsNode(node) {
+		return nil, errNodeNotOwned(name)
+	}
+	return d.addNode(node)
+}
+
+// addNode builds the node info of the node from the assumed pods on it.
+func (d *DealerImpl) addNode(node *v1.Node) (*NodeInfo, error) {
+	pods, err := d.podsOfNode(node.Name)
 	if err != nil {
 		return nil, err
 	}
-	d.NodeMaps[name] = NewNodeInfo(name, node, d.Rater)
-	for _, pod := range pods.Items {
+	ni := NewNodeInfo(node.Name, node, d.Rater)
+	d.NodeMaps[node.Name] = ni
+	for _, pod := range pods {
 		// todo: check pod status
-		plan, err := NewPlanFromPod(&pod)
-		if err != nil {
-			log.Errorf("stat pod %s/%s failed: %s", pod.Namespace, pod.Name, err.Error())
+		d.accountPod(ni, pod)
+	}
+	return ni, nil
+}
+
+// accountPod allocates the plan of an assumed pod on its node.
+func (d *DealerImpl) accountPod(ni *NodeInfo, pod *v1.Pod) {
+	pod = resolveUUIDs(ni, pod)
+	plan, err := NewPlanFromPod(pod)
+	if err != nil {
+		log.Errorf("stat pod %s/%s failed: %s", pod.Namespace, pod.Name, err.Error())
+		return
+	}
+	if err := ni.Allocate(plan); err != nil {
+		log.Errorf("allocate pod %s/%s failed: %s", pod.Namespace, pod.Name, err.Error())
+		return
+	}
+	ni.addQoS(pod, plan, 1)
+	d.PodMaps[pod.UID] = pod
+	d.trackAntiAffinity(pod)
+}
+
+// podsOfNode returns the assumed pods of the node, from the checkpoint on a warm
+// start when it has the node. It reads the pod lister so that scoring and assuming
+// never wait on the apiserver, pods the lister misses yet are accounted by the
+// informer later.
+func (d *DealerImpl) podsOfNode(name string) ([]*v1.Pod, error) {
+	if pods, ok := d.Restored[name]; d.warm && ok {
+		delete(d.Restored, name)
+		// all the nodes of the checkpoint are rebuilt, later nodes are listed
+		d.warm = len(d.Restored) > 0
+		return pods, nil
+	}
+	list, err := d.PodLister.List(labels.SelectorFromSet(labels.Set{schetypes.GPUAssume: "true"}))
+	if err != nil {
+		return nil, err
+	}
+	pods := make([]*v1.Pod, 0)
+	seen := make(map[types.UID]bool)
+	for _, pod := range list {
+		if pod.Spec.NodeName == name && !utils.IsCompletedPod(pod) {
+			pods = append(pods, pod.DeepCopy())
+			seen[pod.UID] = true
+		}
+	}
+	if AllocationLister == nil {
+		return pods, nil
+	}
+	// pods bound with an allocation object carry no plan nor label
+	allocated, err := d.allocatedPodsOfNode(name)
+	if err != nil {
+		return nil, err
+	}
+	for i := range allocated {
+		if pod := &allocated[i]; pod.Spec.NodeName == name && !seen[pod.UID] {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// PodsOnCard returns the known pods which have at least one container on the card.
+func (d *DealerImpl) PodsOnCard(nodeName string, card int) []*v1.Pod {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	return d.podsOnCard(nodeName, card)
+}
+
+func (d *DealerImpl) podsOnCard(nodeName string, card int) []*v1.Pod {
+	pods := make([]*v1.Pod, 0)
+	for _, pod := range d.PodMaps {
+		if pod.Spec.NodeName != nodeName {
 			continue
 		}
-		if err := d.NodeMaps[name].Allocate(plan); err != nil {
-			log.Errorf("allocate pod %s/%s failed: %s", pod.Namespace, pod.Name, err.Error())
+		plan, err := NewPlanFromPod(pod)
+		if err != nil {
 			continue
 		}
-		d.PodMaps[pod.UID] = &pod
+		for _, idx := range plan.GPUIndexes {
+			if idx == card {
+				pods = append(pods, pod)
+				break
+			}
+		}
 	}
-	return d.NodeMaps[name], nil
+	return pods
 }
 
 func (d *DealerImpl) PrintStatus(pod *v1.Pod, action string) {
@@ -314,10 +735,34 @@ func (d *DealerImpl) Forget(pod *v1.Pod) error {
 
 	delete(d.ReleasedPodMap, pod.UID)
 	delete(d.PodMaps, pod.UID)
+	delete(d.AntiAffinity, pod.UID)
+	delete(d.Terminating, pod.UID)
+	d.forgetPending(pod.UID)
+	d.forgetUnconfirmed(pod.UID)
+	d.unreserveGang(pod.UID)
+	d.dropImported(pod.UID)
+	delete(d.InFlight, pod.UID)
+	delete(d.ShadowChoices, pod.UID)
+	delete(d.ArmSeen, pod.UID)
 
 	return nil
 }
 
-func (d *DealerImpl) Status() (map[string]*NodeInfo, error) {
-	return d.NodeMaps, nil
+func (d *DealerImpl) Status() (*Status, error) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	pools, err := d.poolStatus()
+	if err != nil {
+		return nil, err
+	}
+	nodes := make(map[string]*NodeInfo, len(d.NodeMaps))
+	for name, ni := range d.NodeMaps {
+		nodes[name] = ni.snapshot()
+	}
+	return &Status{
+		Nodes:         nodes,
+		Pools:         pools,
+		Fragmentation: d.fragmentation(),
+		Unhealthy:     d.unhealthy(),
+	}, nil
 }
@@ -0,0 +1,21 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+)
+
+func TestPodsOnCard(t *testing.T) {
+	d := MockDealer(MockNode("n1", 2))
+	p1 := MockPodWithPlan(&Plan{Demand: Demand{{Percent: 20}, {Percent: 30}}, GPUIndexes: []int{0, 1}})
+	p1.Name, p1.UID, p1.Spec.NodeName = "p1", k8stypes.UID("p1"), "n1"
+	p2 := MockPodWithPlan(&Plan{Demand: Demand{{Percent: 20}}, GPUIndexes: []int{1}})
+	p2.Name, p2.UID, p2.Spec.NodeName = "p2", k8stypes.UID("p2"), "n1"
+	d.PodMaps[p1.UID], d.PodMaps[p2.UID] = p1, p2
+
+	assert.Equal(t, 1, len(d.PodsOnCard("n1", 0)))
+	assert.Equal(t, 2, len(d.PodsOnCard("n1", 1)))
+	assert.Equal(t, 0, len(d.PodsOnCard("n2", 1)))
+}
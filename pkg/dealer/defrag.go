@@ -0,0 +1,167 @@
+package dealer
+
+import (
+	"sort"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefragPlan describes which pods should move off a card so that a pending demand,
+// which fails only because free share is fragmented across cards, fits on the node.
+type DefragPlan struct {
+	Node    string
+	Card    int
+	Victims []*v1.Pod
+	Moved   int
+}
+
+// IsMovablePod reports whether a pod may be descheduled for defragmentation: it
+// must be recreated by a controller and must not opt out by annotation.
+func IsMovablePod(pod *v1.Pod) bool {
+	if pod.Annotations[schetypes.AnnotationGPUMovable] == "false" {
+		return false
+	}
+	return metav1.GetControllerOf(pod) != nil
+}
+
+func (g GPUs) Clone() GPUs {
+	ans := make(GPUs, len(g))
+	for i, r := range g {
+		c := *r
+		ans[i] = &c
+	}
+	return ans
+}
+
+// DefragPlan returns nil when the pod already fits on some known node or when no
+// node can be consolidated for it. Otherwise the plan moving the least share wins.
+func (d *DealerImpl) DefragPlan(pod *v1.Pod) *DefragPlan {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+
+	demand := NewDemandFromPod(pod)
+	total := 0
+	for _, r := range demand {
+		total += r.Percent
+	}
+	for _, ni := range d.NodeMaps {
+		if _, err := ni.Rater.Choose(ni.schedulableGPUs(pod, d).Clone(), demand); err == nil {
+			return nil
+		}
+	}
+
+	var best *DefragPlan
+	for name, ni := range d.NodeMaps {
+		gpus := ni.schedulableGPUs(pod, d)
+		available, _ := gpus.PercentAvailableAndFreeGpuCount()
+		if available < total {
+			continue
+		}
+		unavailable := d.unavailableCards(ni, pod)
+		for card := range gpus {
+			if _, ok := unavailable[card]; ok {
+				continue
+			}
+			plan := d.defragCard(ni, gpus, unavailable, card, demand)
+			if plan != nil && (best == nil || plan.Moved < best.Moved) {
+				plan.Node = name
+				best = plan
+			}
+		}
+	}
+	return best
+}
+
+// unavailableCards returns the cards of the node the pod can't be placed on.
+func (d *DealerImpl) unavailableCards(ni *NodeInfo, pod *v1.Pod) map[int]string {
+	ans := ni.removedCards()
+	for card, reason := range d.ExcludedCards(ni.Name, pod) {
+		ans[card] = reason
+	}
+	return ans
+}
+
+// defragCard moves the smallest movable pods off the card until the demand fits on
+// the schedulable gpus of the node, provided the moved pods still fit on the other
+// cards. The share freed on the unavailable cards is never counted.
+func (d *DealerImpl) defragCard(ni *NodeInfo, schedulable GPUs, unavailable map[int]string, card int, demand Demand) *DefragPlan {
+	type resident struct {
+		pod  *v1.Pod
+		plan *Plan
+		size int
+	}
+	residents := make([]resident, 0)
+	for _, pod := range d.PodMaps {
+		if pod.Spec.NodeName != ni.Name || !IsMovablePod(pod) {
+			continue
+		}
+		plan, err := NewPlanFromPod(pod)
+		if err != nil {
+			continue
+		}
+		size := 0
+		for i, idx := range plan.GPUIndexes {
+			if idx == card {
+				size += plan.Demand[i].Percent
+			}
+		}
+		if size > 0 {
+			residents = append(residents, resident{pod: pod, plan: plan, size: size})
+		}
+	}
+	sort.Slice(residents, func(i, j int) bool { return residents[i].size < residents[j].size })
+
+	gpus := schedulable.Clone()
+	ans := &DefragPlan{Card: card}
+	for _, r := range residents {
+		if err := gpus.Release(r.plan); err != nil {
+			return nil
+		}
+		for c := range unavailable {
+			if c >= 0 && c < len(gpus) {
+				gpus[c].Percent = 0
+			}
+		}
+		ans.Victims = append(ans.Victims, r.pod)
+		ans.Moved += r.size
+
+		// the moved pods must find room on the other cards of the node again
+		replaced := gpus.Clone()
+		freed := replaced[card].Percent
+		replaced[card].Percent = 0
+		if !replaceVictims(replaced, ans.Victims) {
+			return nil
+		}
+		replaced[card].Percent = freed
+		if _, err := ni.Rater.Choose(replaced, demand); err == nil {
+			return ans
+		}
+	}
+	return nil
+}
+
+// replaceVictims places the containers of the victims first fit on the given gpus.
+func replaceVictims(gpus GPUs, victims []*v1.Pod) bool {
+	for _, pod := range victims {
+		for _, r := range NewDemandFromPod(pod) {
+			if r.Percent == 0 {
+				continue
+			}
+			placed := false
+			for _, g := range gpus {
+				if g.CanAllocate(r) {
+					g.Sub(r)
+					placed = true
+					break
+				}
+			}
+			if !placed {
+				return false
+			}
+		}
+	}
+	return true
+}
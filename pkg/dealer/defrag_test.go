@@ -0,0 +1,59 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+)
+
+func mockResident(d *DealerImpl, name string, percent, card int) {
+	pod := MockPodWithPlan(&Plan{Demand: Demand{{Percent: percent}}, GPUIndexes: []int{card}})
+	pod.Name, pod.UID, pod.Spec.NodeName = name, k8stypes.UID(name), "n1"
+	controller := true
+	pod.OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "rs", Controller: &controller}}
+	d.PodMaps[pod.UID] = pod
+	d.NodeMaps["n1"].GPUs[card].Sub(GPUResource{Percent: percent})
+}
+
+func TestDefragPlan(t *testing.T) {
+	d := MockDealer(MockNode("n1", 2))
+	d.NodeMaps["n1"] = NewNodeInfo("n1", MockNode("n1", 2), d.Rater)
+	mockResident(d, "a", 50, 0)
+	mockResident(d, "b", 20, 1)
+	mockResident(d, "c", 30, 1)
+
+	// 50 + 50 free, but no card has 60
+	pending := MockQuotaPod("ns", "pending", 60)
+	plan := d.DefragPlan(pending)
+	assert.NotNil(t, plan)
+	assert.Equal(t, "n1", plan.Node)
+	assert.Equal(t, 1, plan.Card)
+	assert.Equal(t, 20, plan.Moved)
+	assert.Equal(t, "b", plan.Victims[0].Name)
+
+	// fits without moving anything
+	assert.Nil(t, d.DefragPlan(MockQuotaPod("ns", "small", 40)))
+	// can't fit even after consolidation
+	assert.Nil(t, d.DefragPlan(MockQuotaPod("ns", "huge", 120)))
+}
+
+func TestDefragPlanKeepsSystemReserved(t *testing.T) {
+	d := MockDealer(MockNode("n1", 2))
+	d.NodeMaps["n1"] = NewNodeInfo("n1", MockNode("n1", 2), d.Rater)
+	mockResident(d, "a", 50, 0)
+	mockResident(d, "b", 20, 1)
+	mockResident(d, "c", 30, 1)
+	d.NodeMaps["n1"].SystemReserved = 15
+
+	// 40 fits on the raw free share but not on the reserved one
+	plan := d.DefragPlan(MockQuotaPod("ns", "small", 40))
+	assert.NotNil(t, plan)
+	assert.Equal(t, 1, plan.Card)
+	assert.Equal(t, "b", plan.Victims[0].Name)
+
+	// 60 fits on a consolidated raw card but never beside the reserved share
+	d.NodeMaps["n1"].SystemReserved = 30
+	assert.Nil(t, d.DefragPlan(MockQuotaPod("ns", "pending", 60)))
+}
@@ -0,0 +1,72 @@
+package dealer
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// DryRunNode is the outcome of filtering and scoring the pod on a node, Reason tells
+// why the pod doesn't fit.
+type DryRunNode struct {
+	Node       string `json:"node"`
+	Fits       bool   `json:"fits"`
+	Reason     string `json:"reason,omitempty"`
+	GPUIndexes []int  `json:"gpuIndexes,omitempty"`
+	Score      int    `json:"score,omitempty"`
+}
+
+// DryRunReport lists the nodes the pod fits on by descending score, then the others.
+// Error is set when a constraint of the pod itself, like its quota, keeps it off
+// every node.
+type DryRunReport struct {
+	Error string       `json:"error,omitempty"`
+	Nodes []DryRunNode `json:"nodes"`
+}
+
+// DryRun filters and scores the pod on the nodes, all nodes when none are given,
+// like a scheduling cycle would. Nothing is cached or reserved for the pod.
+func (d *DealerImpl) DryRun(nodes []string, pod *v1.Pod, policySpec PolicySpec, isLoadSchedule bool) (*DryRunReport, error) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	if len(nodes) == 0 {
+		list, err := d.NodeLister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range list {
+			nodes = append(nodes, node.Name)
+		}
+	}
+	report := &DryRunReport{Nodes: make([]DryRunNode, 0, len(nodes))}
+	if err := d.checkPod(pod); err != nil {
+		report.Error = err.Error()
+		return report, nil
+	}
+	demand := NewDemandFromPod(pod)
+	spread := d.spreadScores(nodes, pod)
+	for i, name := range nodes {
+		result := DryRunNode{Node: name}
+		ni, err := d.checkNode(name, pod, demand, policySpec, isLoadSchedule)
+		var plan *Plan
+		if err == nil {
+			plan, err = ni.plan(demand, pod, d, policySpec, isLoadSchedule)
+		}
+		if err != nil {
+			result.Reason = err.Error()
+		} else {
+			result.Fits = true
+			result.GPUIndexes = plan.GPUIndexes
+			result.Score = d.scoreNode(ni, pod, demand, plan, spread[i], policySpec, isLoadSchedule)
+		}
+		report.Nodes = append(report.Nodes, result)
+	}
+	sort.SliceStable(report.Nodes, func(i, j int) bool {
+		if report.Nodes[i].Fits != report.Nodes[j].Fits {
+			return report.Nodes[i].Fits
+		}
+		return report.Nodes[i].Score > report.Nodes[j].Score
+	})
+	return report, nil
+}
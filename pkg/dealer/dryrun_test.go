@@ -0,0 +1,37 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRun(t *testing.T) {
+	d := MockDealer(MockNode("n1", 1), MockNode("n2", 2))
+	n1 := NewNodeInfo("n1", MockNode("n1", 1), d.Rater)
+	n1.GPUs[0].Percent = 60
+	n2 := NewNodeInfo("n2", MockNode("n2", 2), d.Rater)
+	n2.GPUs[1].Percent = 90
+	d.NodeMaps["n1"], d.NodeMaps["n2"] = n1, n2
+
+	pod := MockQuotaPod("a", "p0", 80)
+	report, err := d.DryRun(nil, pod, PolicySpec{}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "", report.Error)
+	assert.Len(t, report.Nodes, 2)
+	assert.Equal(t, "n2", report.Nodes[0].Node)
+	assert.True(t, report.Nodes[0].Fits)
+	assert.Equal(t, []int{1}, report.Nodes[0].GPUIndexes)
+	assert.Equal(t, "n1", report.Nodes[1].Node)
+	assert.False(t, report.Nodes[1].Fits)
+	assert.NotEmpty(t, report.Nodes[1].Reason)
+
+	// nothing is cached for the pod
+	assert.Empty(t, n2.PlanCache)
+	assert.Empty(t, d.PendingPods)
+	assert.Equal(t, 90, n2.GPUs[1].Percent)
+
+	report, err = d.DryRun([]string{"n1"}, pod, PolicySpec{}, false)
+	assert.Nil(t, err)
+	assert.Len(t, report.Nodes, 1)
+}
@@ -0,0 +1,80 @@
+package dealer
+
+import (
+	"fmt"
+	"strconv"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	log "k8s.io/klog/v2"
+)
+
+// elasticRange returns the min and max replicas of a pod of an elastic training job,
+// false when the pod isn't elastic.
+func elasticRange(pod *v1.Pod) (int, int, bool) {
+	max, err := strconv.Atoi(pod.Annotations[schetypes.AnnotationMaxReplicas])
+	if err != nil || max < 1 || metav1.GetControllerOf(pod) == nil {
+		return 0, 0, false
+	}
+	min := 1
+	if v, ok := pod.Annotations[schetypes.AnnotationMinReplicas]; ok {
+		if min, err = strconv.Atoi(v); err != nil || min < 1 || min > max {
+			return 0, 0, false
+		}
+	}
+	return min, max, true
+}
+
+// ElasticGrant returns how many replicas of the elastic job of the pod can run right
+// now, the bound ones included, up to its max. It is 0 when fewer than its min fit.
+func (d *DealerImpl) ElasticGrant(pod *v1.Pod) int {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	return d.elasticGrant(pod)
+}
+
+func (d *DealerImpl) elasticGrant(pod *v1.Pod) int {
+	min, max, ok := elasticRange(pod)
+	if !ok {
+		return 0
+	}
+	owner := metav1.GetControllerOf(pod).UID
+	bound := 0
+	for _, p := range d.PodMaps {
+		if o := metav1.GetControllerOf(p); o != nil && o.UID == owner {
+			bound++
+		}
+	}
+	granted := bound
+	if bound < max {
+		cards, err := d.capacityCards(pod, NewDemandFromPod(pod))
+		if err != nil {
+			log.Warningf("capacity of elastic pod %s/%s failed: %s", pod.Namespace, pod.Name, err.Error())
+			return 0
+		}
+		granted += placeReplicas(cards, NewDemandFromPod(pod), max-bound).Replicas
+	}
+	if granted < min {
+		return 0
+	}
+	// replicas beyond the max are only bound while the job scales in
+	if granted > max {
+		granted = max
+	}
+	return granted
+}
+
+// checkElastic keeps the replicas of an elastic job pending while fewer than its min
+// replicas fit, so that no replica is placed for a job which can't start.
+func (d *DealerImpl) checkElastic(pod *v1.Pod) error {
+	min, _, ok := elasticRange(pod)
+	if !ok {
+		return nil
+	}
+	if d.elasticGrant(pod) == 0 {
+		return fmt.Errorf("fewer than the %d min replicas of pod %s/%s fit", min, pod.Namespace, pod.Name)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestElasticGrant(t *testing.T) {
+	d := MockDealer(MockNode("n1", 2))
+	d.NodeMaps["n1"] = NewNodeInfo("n1", MockNode("n1", 2), d.Rater)
+	pod := MockQuotaPod("a", "w0", 50)
+	controller := true
+	pod.OwnerReferences = []metav1.OwnerReference{{Kind: "PyTorchJob", Name: "j", UID: "job", Controller: &controller}}
+	pod.Annotations = map[string]string{schetypes.AnnotationMinReplicas: "2", schetypes.AnnotationMaxReplicas: "8"}
+
+	// four halves fit on two cards
+	assert.Equal(t, 4, d.ElasticGrant(pod))
+	assert.Nil(t, d.checkElastic(pod))
+
+	// a bound replica counts
+	bound := MockQuotaPod("a", "w1", 50)
+	bound.OwnerReferences = pod.OwnerReferences
+	d.PodMaps[bound.UID] = bound
+	d.NodeMaps["n1"].GPUs[0].Percent = 50
+	assert.Equal(t, 4, d.ElasticGrant(pod))
+
+	// fewer than min fit
+	d.NodeMaps["n1"].GPUs[0].Percent = 0
+	d.NodeMaps["n1"].GPUs[1].Percent = 0
+	assert.Equal(t, 0, d.ElasticGrant(pod))
+	assert.NotNil(t, d.checkElastic(pod))
+
+	// more replicas bound than the max after it was lowered
+	pod.Annotations[schetypes.AnnotationMaxReplicas] = "1"
+	pod.Annotations[schetypes.AnnotationMinReplicas] = "1"
+	extra := MockQuotaPod("a", "w2", 50)
+	extra.OwnerReferences = pod.OwnerReferences
+	d.PodMaps[extra.UID] = extra
+	assert.Equal(t, 1, d.ElasticGrant(pod))
+
+	// not elastic
+	delete(pod.Annotations, schetypes.AnnotationMaxReplicas)
+	assert.Nil(t, d.checkElastic(pod))
+}
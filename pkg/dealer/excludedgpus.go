@@ -0,0 +1,79 @@
+package dealer
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	log "k8s.io/klog/v2"
+)
+
+const reasonExcluded = "excluded by node"
+
+// excludedOfNode returns the sorted card indexes the node excludes from allocation,
+// invalid entries are skipped.
+func excludedOfNode(node *v1.Node) []int {
+	value := node.Annotations[schetypes.AnnotationGPUExcluded]
+	if value == "" {
+		return nil
+	}
+	seen := make(map[int]bool)
+	ans := make([]int, 0)
+	for _, s := range strings.Split(value, ",") {
+		card, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || card < 0 {
+			log.Errorf("node %s: invalid excluded gpu %q", node.Name, s)
+			continue
+		}
+		if !seen[card] {
+			seen[card] = true
+			ans = append(ans, card)
+		}
+	}
+	sort.Ints(ans)
+	return ans
+}
+
+// excludedCards are the cards of the node it excludes from allocation.
+func (ni *NodeInfo) excludedCards() map[int]string {
+	ans := make(map[int]string, len(ni.Excluded))
+	for _, card := range ni.Excluded {
+		ans[card] = reasonExcluded
+	}
+	return ans
+}
+
+// excludedPercent returns the share the excluded cards and the system reserved share
+// of the node take off its total and free share.
+func (ni *NodeInfo) excludedPercent() (total, free int) {
+	excluded := make(map[int]bool, len(ni.Excluded))
+	for _, card := range ni.Excluded {
+		excluded[card] = true
+		if card < ni.Capacity {
+			total += schetypes.GPUPercentEachCard
+		}
+		if card < len(ni.GPUs) {
+			free += ni.GPUs[card].Percent
+		}
+	}
+	if ni.SystemReserved <= 0 {
+		return total, free
+	}
+	for card, g := range ni.GPUs {
+		if excluded[card] {
+			continue
+		}
+		if card < ni.Capacity {
+			total += ni.SystemReserved
+		}
+		if g.Percent < ni.SystemReserved {
+			free += g.Percent
+		} else {
+			free += ni.SystemReserved
+		}
+	}
+	return total, free
+}
@@ -0,0 +1,39 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestExcludedGPUs(t *testing.T) {
+	node := MockNode("n1", 4)
+	node.Labels = map[string]string{types.LabelGPUPool: "p"}
+	node.Annotations = map[string]string{types.AnnotationGPUExcluded: "3, 0,x,3"}
+	assert.Equal(t, []int{0, 3}, excludedOfNode(node))
+
+	d := MockDealer(node)
+	ni := NewNodeInfo("n1", node, d.Rater)
+	d.NodeMaps["n1"] = ni
+	assert.Equal(t, map[int]string{0: reasonExcluded, 3: reasonExcluded}, d.ExcludedCards("n1", MockQuotaPod("a", "p0", 40)))
+
+	status, err := d.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 3}, status.Nodes["n1"].Excluded)
+	assert.Equal(t, &PoolStatus{Nodes: 1, Total: 200, Free: 200}, status.Pools["p"])
+	assert.Equal(t, 200, status.Fragmentation.Nodes["n1"].Free)
+
+	pod := MockQuotaPod("a", "p0", 40)
+	demand := NewDemandFromPod(pod)
+	ok, err := ni.Assume(demand, pod, d, PolicySpec{}, false)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.NotContains(t, []int{0, 3}, ni.PlanCache[planKey(demand, pod)].GPUIndexes[0])
+
+	node = node.DeepCopy()
+	delete(node.Annotations, types.AnnotationGPUExcluded)
+	d.UpdateNode(node)
+	assert.Empty(t, ni.Excluded)
+}
@@ -0,0 +1,151 @@
+package dealer
+
+import (
+	"hash/fnv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	ArmStable     = "stable"
+	ArmExperiment = "experiment"
+)
+
+// Experiment routes the pods of its namespaces and a share of the others through an
+// experimental policy, nil schedules every pod by the stable policy.
+var Experiment *ExperimentPolicy
+
+type ExperimentPolicy struct {
+	// Spec replaces the scoring, the rules and the cost of the stable policy, the
+	// workload profiles of the stable policy still apply.
+	Spec PolicySpec
+	// Rater replaces the global and the namespace priority algorithms, not those of
+	// workload profiles.
+	Rater      Rater
+	Namespaces map[string]struct{}
+	// Percent of the pods of the other namespaces in the experiment, picked by pod uid
+	// so that every attempt to schedule a pod lands in the same arm.
+	Percent int
+}
+
+// ArmOf returns the arm of the experiment the pod is scheduled by.
+func ArmOf(pod *v1.Pod) string {
+	if Experiment == nil {
+		return ArmStable
+	}
+	if _, ok := Experiment.Namespaces[pod.Namespace]; ok {
+		return ArmExperiment
+	}
+	h := fnv.New32a()
+	h.Write([]byte(pod.UID))
+	if int(h.Sum32()%100) < Experiment.Percent {
+		return ArmExperiment
+	}
+	return ArmStable
+}
+
+// experimentSpec returns the policy of the arm of the pod.
+func experimentSpec(pod *v1.Pod, policySpec PolicySpec) PolicySpec {
+	if ArmOf(pod) != ArmExperiment {
+		return policySpec
+	}
+	resolved := policySpec
+	resolved.Scoring = Experiment.Spec.Scoring
+	resolved.Rules = Experiment.Spec.Rules
+	resolved.Cost = Experiment.Spec.Cost
+	return resolved
+}
+
+// ArmStats compares the outcome of the arms. Utilization and Fragmentation cover the
+// cards the bound pods of the arm run on, latency is from the first filter of a pod
+// to its binding.
+type ArmStats struct {
+	Pods           int     `json:"pods"`
+	Bindings       int     `json:"bindings"`
+	LatencySeconds float64 `json:"latencySeconds"`
+	Utilization    float64 `json:"utilization"`
+	Fragmentation  float64 `json:"fragmentation"`
+}
+
+type armLatency struct {
+	Bindings int
+	Seconds  float64
+}
+
+func (d *DealerImpl) trackArm(uid types.UID, now time.Time) {
+	if Experiment == nil {
+		return
+	}
+	if _, ok := d.ArmSeen[uid]; !ok {
+		d.ArmSeen[uid] = now
+	}
+}
+
+// observeArm records the scheduling latency of the bound pod in its arm.
+func (d *DealerImpl) observeArm(pod *v1.Pod, now time.Time) {
+	seen, ok := d.ArmSeen[pod.UID]
+	if !ok {
+		return
+	}
+	delete(d.ArmSeen, pod.UID)
+	arm := ArmOf(pod)
+	l, ok := d.ArmLatency[arm]
+	if !ok {
+		l = &armLatency{}
+		d.ArmLatency[arm] = l
+	}
+	l.Bindings++
+	l.Seconds += now.Sub(seen).Seconds()
+}
+
+// ArmStatus returns the stats of both arms.
+func (d *DealerImpl) ArmStatus() map[string]*ArmStats {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	ans := map[string]*ArmStats{ArmStable: {}, ArmExperiment: {}}
+	cards := map[string]map[string]map[int]struct{}{ArmStable: {}, ArmExperiment: {}}
+	for _, pod := range d.PodMaps {
+		plan, err := NewPlanFromPod(pod)
+		if err != nil {
+			continue
+		}
+		arm := ArmOf(pod)
+		ans[arm].Pods++
+		if cards[arm][pod.Spec.NodeName] == nil {
+			cards[arm][pod.Spec.NodeName] = make(map[int]struct{})
+		}
+		for _, idx := range plan.GPUIndexes {
+			if idx >= 0 {
+				cards[arm][pod.Spec.NodeName][idx] = struct{}{}
+			}
+		}
+	}
+	for arm, stats := range ans {
+		gpus := make(GPUs, 0)
+		total := 0
+		for node, indexes := range cards[arm] {
+			ni, ok := d.NodeMaps[node]
+			if !ok {
+				continue
+			}
+			for idx := range indexes {
+				if idx < len(ni.GPUs) {
+					gpus = append(gpus, ni.GPUs[idx])
+					total += ni.GPUs[idx].PercentTotal
+				}
+			}
+		}
+		f := gpus.Fragmentation()
+		stats.Fragmentation = f.Score
+		if total > 0 {
+			stats.Utilization = 1 - float64(f.Free)/float64(total)
+		}
+		if l, ok := d.ArmLatency[arm]; ok {
+			stats.Bindings = l.Bindings
+			stats.LatencySeconds = l.Seconds
+		}
+	}
+	return ans
+}
@@ -0,0 +1,63 @@
+package dealer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+)
+
+func TestExperiment(t *testing.T) {
+	pod := MockQuotaPod("a", "p0", 30)
+	other := MockQuotaPod("b", "p1", 30)
+	assert.Equal(t, ArmStable, ArmOf(pod))
+
+	stable := PolicySpec{Cost: CostPolicy{Weight: 1}}
+	Experiment = &ExperimentPolicy{
+		Spec:       PolicySpec{Cost: CostPolicy{Weight: 2}},
+		Rater:      &Spread{},
+		Namespaces: map[string]struct{}{"a": {}},
+	}
+	defer func() { Experiment = nil }()
+	assert.Equal(t, ArmExperiment, ArmOf(pod))
+	assert.Equal(t, ArmStable, ArmOf(other))
+	spec, _ := ResolveProfile(pod, stable, false)
+	assert.Equal(t, 2, spec.Cost.Weight)
+	spec, _ = ResolveProfile(other, stable, false)
+	assert.Equal(t, 1, spec.Cost.Weight)
+	assert.IsType(t, &Spread{}, raterOf(pod, PolicySpec{}, &Binpack{}))
+	assert.IsType(t, &Binpack{}, raterOf(other, PolicySpec{}, &Binpack{}))
+
+	// the arm of a pod is stable by uid
+	Experiment.Percent = 100
+	assert.Equal(t, ArmExperiment, ArmOf(other))
+	Experiment.Percent = 0
+
+	d := MockDealer(MockNode("n1", 2))
+	n1 := NewNodeInfo("n1", MockNode("n1", 2), d.Rater)
+	n1.GPUs[0].Percent = 70
+	n1.GPUs[1].Percent = 70
+	d.NodeMaps["n1"] = n1
+	now := time.Now()
+	d.trackArm(pod.UID, now.Add(-2*time.Second))
+	d.trackArm(pod.UID, now)
+	for card, p := range []*v1.Pod{pod, other} {
+		bound := utils.GetUpdatedPodAnnotationSpec(p, []int{card})
+		bound.Spec.NodeName = "n1"
+		d.PodMaps[bound.UID] = bound
+		d.observeArm(bound, now)
+	}
+
+	stats := d.ArmStatus()
+	assert.Equal(t, 1, stats[ArmExperiment].Pods)
+	assert.Equal(t, 1, stats[ArmExperiment].Bindings)
+	assert.InDelta(t, 2, stats[ArmExperiment].LatencySeconds, 0.01)
+	assert.InDelta(t, 0.3, stats[ArmExperiment].Utilization, 0.001)
+	assert.Equal(t, 1, stats[ArmStable].Pods)
+	// the stable pod was never filtered while the experiment ran
+	assert.Equal(t, 0, stats[ArmStable].Bindings)
+	assert.Empty(t, d.ArmSeen)
+}
@@ -0,0 +1,404 @@
+package dealer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expr is a compiled rule expression. The language is a small CEL-like subset:
+// numbers, 'strings', true and false, dotted variables, the operators
+// ! - * / + - < <= > >= == != && || with the usual precedence, parentheses and the
+// functions min, max and abs.
+type Expr interface {
+	Eval(vars map[string]interface{}) (interface{}, error)
+}
+
+// CompileExpr parses the expression.
+func CompileExpr(src string) (Expr, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q at the end of %q", p.tokens[p.pos].text, src)
+	}
+	return e, nil
+}
+
+type tokenKind int
+
+const (
+	tokenNumber tokenKind = iota
+	tokenString
+	tokenIdent
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+var twoCharOps = []string{"&&", "||", "==", "!=", "<=", ">="}
+
+func tokenize(src string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{tokenNumber, string(runes[i:j])})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_' || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{tokenIdent, string(runes[i:j])})
+			i = j
+		case r == '\'' || r == '"':
+			j := i + 1
+			for j < len(runes) && runes[j] != r {
+				j++
+			}
+			if j == len(runes) {
+				return nil, fmt.Errorf("unterminated string in %q", src)
+			}
+			tokens = append(tokens, token{tokenString, string(runes[i+1 : j])})
+			i = j + 1
+		default:
+			op := string(r)
+			if i+1 < len(runes) {
+				for _, two := range twoCharOps {
+					if string(runes[i:i+2]) == two {
+						op = two
+					}
+				}
+			}
+			if !strings.Contains("!*/+-<>(),", op) && len(op) == 1 {
+				return nil, fmt.Errorf("unexpected %q in %q", op, src)
+			}
+			tokens = append(tokens, token{tokenOp, op})
+			i += len(op)
+		}
+	}
+	return tokens, nil
+}
+
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peekOp(ops ...string) (string, bool) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if p.tokens[p.pos].text == op {
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) binary(next func() (Expr, error), ops ...string) (Expr, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.peekOp(ops...)
+		if !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) or() (Expr, error)  { return p.binary(p.and, "||") }
+func (p *exprParser) and() (Expr, error) { return p.binary(p.cmp, "&&") }
+func (p *exprParser) cmp() (Expr, error) {
+	return p.binary(p.add, "==", "!=", "<", "<=", ">", ">=")
+}
+func (p *exprParser) add() (Expr, error) { return p.binary(p.mul, "+", "-") }
+func (p *exprParser) mul() (Expr, error) { return p.binary(p.unary, "*", "/") }
+
+func (p *exprParser) unary() (Expr, error) {
+	if op, ok := p.peekOp("!", "-"); ok {
+		p.pos++
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{op: op, operand: operand}, nil
+	}
+	return p.primary()
+}
+
+func (p *exprParser) primary() (Expr, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+	switch t.kind {
+	case tokenNumber:
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, err
+		}
+		return literalExpr{v}, nil
+	case tokenString:
+		return literalExpr{t.text}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return literalExpr{true}, nil
+		case "false":
+			return literalExpr{false}, nil
+		}
+		if _, ok := p.peekOp("("); ok {
+			return p.call(t.text)
+		}
+		return variableExpr(t.text), nil
+	}
+	if t.text == "(" {
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.peekOp(")"); !ok {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return e, nil
+	}
+	return nil, fmt.Errorf("unexpected %q", t.text)
+}
+
+func (p *exprParser) call(name string) (Expr, error) {
+	if _, ok := exprFuncs[name]; !ok {
+		return nil, fmt.Errorf("unknown function %s", name)
+	}
+	p.pos++
+	c := &callExpr{name: name}
+	if _, ok := p.peekOp(")"); ok {
+		p.pos++
+		return c, nil
+	}
+	for {
+		arg, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		c.args = append(c.args, arg)
+		op, ok := p.peekOp(",", ")")
+		if !ok {
+			return nil, fmt.Errorf("missing ) after the arguments of %s", name)
+		}
+		p.pos++
+		if op == ")" {
+			return c, nil
+		}
+	}
+}
+
+type literalExpr struct {
+	value interface{}
+}
+
+func (e literalExpr) Eval(map[string]interface{}) (interface{}, error) {
+	return e.value, nil
+}
+
+type variableExpr string
+
+func (e variableExpr) Eval(vars map[string]interface{}) (interface{}, error) {
+	v, ok := vars[string(e)]
+	if !ok {
+		return nil, fmt.Errorf("unknown variable %s", string(e))
+	}
+	return v, nil
+}
+
+type unaryExpr struct {
+	op      string
+	operand Expr
+}
+
+func (e *unaryExpr) Eval(vars map[string]interface{}) (interface{}, error) {
+	v, err := e.operand.Eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	if e.op == "!" {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("! of %v", v)
+		}
+		return !b, nil
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return nil, fmt.Errorf("- of %v", v)
+	}
+	return -f, nil
+}
+
+type binaryExpr struct {
+	op          string
+	left, right Expr
+}
+
+func (e *binaryExpr) Eval(vars map[string]interface{}) (interface{}, error) {
+	l, err := e.left.Eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	// && and || short-circuit
+	if e.op == "&&" || e.op == "||" {
+		lb, ok := l.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s of %v", e.op, l)
+		}
+		if lb == (e.op == "||") {
+			return lb, nil
+		}
+		r, err := e.right.Eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		rb, ok := r.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s of %v", e.op, r)
+		}
+		return rb, nil
+	}
+	r, err := e.right.Eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "==":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	}
+	if ls, ok := l.(string); ok {
+		rs, ok := r.(string)
+		if !ok {
+			return nil, fmt.Errorf("%v %s %v", l, e.op, r)
+		}
+		switch e.op {
+		case "+":
+			return ls + rs, nil
+		case "<":
+			return ls < rs, nil
+		case "<=":
+			return ls <= rs, nil
+		case ">":
+			return ls > rs, nil
+		case ">=":
+			return ls >= rs, nil
+		}
+		return nil, fmt.Errorf("%v %s %v", l, e.op, r)
+	}
+	lf, lok := l.(float64)
+	rf, rok := r.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("%v %s %v", l, e.op, r)
+	}
+	switch e.op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		if rf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return lf / rf, nil
+	case "<":
+		return lf < rf, nil
+	case "<=":
+		return lf <= rf, nil
+	case ">":
+		return lf > rf, nil
+	case ">=":
+		return lf >= rf, nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", e.op)
+}
+
+var exprFuncs = map[string]func(args []float64) (float64, error){
+	"min": func(args []float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, fmt.Errorf("min needs arguments")
+		}
+		ans := args[0]
+		for _, a := range args[1:] {
+			ans = math.Min(ans, a)
+		}
+		return ans, nil
+	},
+	"max": func(args []float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, fmt.Errorf("max needs arguments")
+		}
+		ans := args[0]
+		for _, a := range args[1:] {
+			ans = math.Max(ans, a)
+		}
+		return ans, nil
+	},
+	"abs": func(args []float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("abs takes one argument")
+		}
+		return math.Abs(args[0]), nil
+	},
+}
+
+type callExpr struct {
+	name string
+	args []Expr
+}
+
+func (e *callExpr) Eval(vars map[string]interface{}) (interface{}, error) {
+	args := make([]float64, len(e.args))
+	for i, a := range e.args {
+		v, err := a.Eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		f, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("%s of %v", e.name, v)
+		}
+		args[i] = f
+	}
+	return exprFuncs[e.name](args)
+}
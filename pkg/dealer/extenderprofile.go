@@ -0,0 +1,87 @@
+package dealer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ExtenderProfile is an extender served next to the default one from the same dealer,
+// for a scheduler profile of its own like one packing training pods and one spreading
+// inference pods. The profiles share the gpu accounting but not the policy.
+type ExtenderProfile struct {
+	Name string `yaml:"name"`
+	// Prefix is the url prefix of the filter, prioritize and bind endpoints of the
+	// profile, /profiles/<name> by default. It is ignored when Port is set.
+	Prefix string `yaml:"prefix"`
+	// Port serves the profile on a listener of its own under the default paths.
+	Port string `yaml:"port"`
+	// SchedulerName is the scheduler profile calling the extender, its pods are scored
+	// with Priority wherever they are scored.
+	SchedulerName string `yaml:"schedulerName"`
+	// Priority is the priority algorithm of the profile, the global one when empty.
+	Priority string `yaml:"priority"`
+	// PolicyConfigPath is the policy of the profile, scheduling by request when empty.
+	PolicyConfigPath string `yaml:"policyConfigPath"`
+	// LoadAware schedules by the measured load, it only takes effect when the usage is
+	// synced with isLoadSchedule.
+	LoadAware bool `yaml:"loadAware"`
+
+	Spec PolicySpec `yaml:"-"`
+}
+
+// LoadExtenderProfiles reads the extender profiles, reads their policies and registers
+// their priority algorithms by scheduler name.
+func LoadExtenderProfiles(path string) ([]ExtenderProfile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	profiles := make([]ExtenderProfile, 0)
+	if err := yaml.Unmarshal(data, &profiles); err != nil {
+		return nil, err
+	}
+	names := make(map[string]struct{})
+	endpoints := make(map[string]string)
+	raters := make(map[string]Rater)
+	for i := range profiles {
+		p := &profiles[i]
+		if p.Name == "" {
+			return nil, fmt.Errorf("extender profile %d has no name", i)
+		}
+		if _, ok := names[p.Name]; ok {
+			return nil, fmt.Errorf("extender profile %s is defined twice", p.Name)
+		}
+		names[p.Name] = struct{}{}
+		if p.Prefix == "" {
+			p.Prefix = "/profiles/" + p.Name
+		}
+		p.Prefix = "/" + strings.Trim(p.Prefix, "/")
+		endpoint := p.Prefix
+		if p.Port != "" {
+			endpoint = ":" + p.Port
+		}
+		if other, ok := endpoints[endpoint]; ok {
+			return nil, fmt.Errorf("extender profiles %s and %s are both served on %s", other, p.Name, endpoint)
+		}
+		endpoints[endpoint] = p.Name
+		if p.PolicyConfigPath != "" {
+			p.Spec = GetAlternatePolicyFromFile(p.PolicyConfigPath)
+		}
+		if p.Priority == "" {
+			continue
+		}
+		if p.SchedulerName == "" {
+			return nil, fmt.Errorf("extender profile %s sets a priority without a schedulerName", p.Name)
+		}
+		rater, err := NewRater(p.Priority)
+		if err != nil {
+			return nil, fmt.Errorf("extender profile %s: %v", p.Name, err)
+		}
+		raters[p.SchedulerName] = rater
+	}
+	SchedulerRaters = raters
+	return profiles, nil
+}
@@ -0,0 +1,53 @@
+package dealer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeProfiles(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "profiles")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "profiles.yaml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadExtenderProfiles(t *testing.T) {
+	defer func() { SchedulerRaters = map[string]Rater{} }()
+	profiles, err := LoadExtenderProfiles(writeProfiles(t, `
+- name: spread
+  schedulerName: gpu-spread
+  priority: spread
+- name: binpack
+  prefix: /pack/
+  port: "40000"
+`))
+	assert.NoError(t, err)
+	assert.Len(t, profiles, 2)
+	assert.Equal(t, "/profiles/spread", profiles[0].Prefix)
+	assert.Equal(t, "/pack", profiles[1].Prefix)
+	assert.IsType(t, &Spread{}, SchedulerRaters["gpu-spread"])
+
+	// the pods of the scheduler profile are scored by its rater
+	pod := MockQuotaPod("a", "p0", 40)
+	assert.IsType(t, &Binpack{}, raterOf(pod, PolicySpec{}, &Binpack{}))
+	pod.Spec.SchedulerName = "gpu-spread"
+	assert.IsType(t, &Spread{}, raterOf(pod, PolicySpec{}, &Binpack{}))
+
+	for _, bad := range []string{
+		"- name: a\n- name: a\n",
+		"- name: a\n  prefix: /x\n- name: b\n  prefix: /x\n",
+		"- name: a\n  priority: spread\n",
+		"- name: a\n  schedulerName: s\n  priority: unknown\n",
+		"- prefix: /x\n",
+	} {
+		_, err := LoadExtenderProfiles(writeProfiles(t, bad))
+		assert.Error(t, err, bad)
+	}
+}
@@ -0,0 +1,37 @@
+package dealer
+
+import (
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// IsGPUPod determines if the pod requests any gpu resource the dealer plans.
+func IsGPUPod(pod *v1.Pod) bool {
+	return utils.IsGPUSharingPod(pod) || utils.GetWholeGPUCountFromPodResource(pod) > 0 ||
+		GetVGPUProfileOfPod(pod) != "" || GetMIGProfileOfPod(pod) != ""
+}
+
+// FilterNonGPU filters the nodes for a pod requesting no gpu. It only keeps the pod
+// off gpu pools and vGPU nodes and reads the node lister without taking the lock.
+func (d *DealerImpl) FilterNonGPU(nodes []string, pod *v1.Pod) ([]bool, []error) {
+	ans := make([]bool, len(nodes))
+	res := make([]error, len(nodes))
+	for i, name := range nodes {
+		node, err := d.NodeLister.Get(name)
+		if err != nil {
+			res[i] = err
+			continue
+		}
+		if err := checkPool(node, pod); err != nil {
+			res[i] = err
+			continue
+		}
+		if err := checkVGPU(vgpuProfilesOfNode(node), pod); err != nil {
+			res[i] = err
+			continue
+		}
+		ans[i] = true
+	}
+	return ans, res
+}
@@ -0,0 +1,28 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestFilterNonGPU(t *testing.T) {
+	pooled := MockNode("n2", 1)
+	pooled.Labels = map[string]string{types.LabelGPUPool: "training"}
+	d := MockDealer(MockNode("n1", 1), pooled)
+
+	pod := MockQuotaPod("a", "p0", 0)
+	pod.Spec.Containers[0].Resources = v1.ResourceRequirements{}
+	assert.False(t, IsGPUPod(pod))
+	assert.True(t, IsGPUPod(MockQuotaPod("a", "p1", 10)))
+
+	ans, res := d.FilterNonGPU([]string{"n1", "n2", "n3"}, pod)
+	assert.Equal(t, []bool{true, false, false}, ans)
+	assert.Nil(t, res[0])
+	assert.NotNil(t, res[1])
+	assert.NotNil(t, res[2])
+	assert.Empty(t, d.NodeMaps)
+}
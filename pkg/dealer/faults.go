@@ -0,0 +1,130 @@
+package dealer
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	log "k8s.io/klog/v2"
+)
+
+// FaultInjection lets faults be armed, it must never be enabled in production.
+var FaultInjection = false
+
+const (
+	// FaultBindError fails the apiserver writes of a binding after the cards were
+	// planned, the binding must be rolled back and the pod requeued.
+	FaultBindError = "bind-error"
+	// FaultStaleUsage makes the usage of the cards of a node stale.
+	FaultStaleUsage = "stale-usage"
+	// FaultNodeGone makes a node disappear from the node lister, like a node deleted
+	// between the filter and the binding of a pod.
+	FaultNodeGone = "node-gone"
+)
+
+// Fault is an armed failure point. It fires on Node, on every node when empty, Count
+// times or until cleared when 0, and for Seconds after it is armed when set.
+type Fault struct {
+	Point   string `json:"point"`
+	Node    string `json:"node,omitempty"`
+	Count   int    `json:"count,omitempty"`
+	Seconds int    `json:"seconds,omitempty"`
+	// Status is the http status of the apiserver error of bind-error, 500 by default,
+	// 429 throttles and 409 conflicts.
+	Status  int       `json:"status,omitempty"`
+	Fired   int       `json:"fired"`
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// faults has its own lock as the usage is read with and without the dealer lock.
+type faults struct {
+	sync.Mutex
+	armed map[string]*Fault
+}
+
+// fire returns the fault armed on the point for the node, if it fires now.
+func (f *faults) fire(point, node string) (*Fault, bool) {
+	if !FaultInjection {
+		return nil, false
+	}
+	f.Lock()
+	defer f.Unlock()
+	fault, ok := f.armed[point]
+	if !ok || (fault.Node != "" && fault.Node != node) {
+		return nil, false
+	}
+	if !fault.Expires.IsZero() && time.Now().After(fault.Expires) {
+		delete(f.armed, point)
+		return nil, false
+	}
+	fault.Fired++
+	if fault.Count > 0 && fault.Fired >= fault.Count {
+		delete(f.armed, point)
+	}
+	log.Warningf("fault %s injected on node %s", point, node)
+	return fault, true
+}
+
+func (f *Fault) apiError(pod *v1.Pod) error {
+	msg := fmt.Sprintf("injected fault %s", f.Point)
+	switch f.Status {
+	case http.StatusTooManyRequests:
+		return apierrors.NewTooManyRequests(msg, 1)
+	case http.StatusConflict:
+		return apierrors.NewConflict(schema.GroupResource{Resource: "pods"}, pod.Name, fmt.Errorf(msg))
+	}
+	return apierrors.NewInternalError(fmt.Errorf(msg))
+}
+
+// InjectFault arms the fault, replacing the one armed on its point.
+func (d *DealerImpl) InjectFault(fault Fault) error {
+	if !FaultInjection {
+		return fmt.Errorf("fault injection is disabled")
+	}
+	switch fault.Point {
+	case FaultBindError, FaultStaleUsage, FaultNodeGone:
+	default:
+		return fmt.Errorf("unknown fault point %s", fault.Point)
+	}
+	fault.Fired = 0
+	fault.Expires = time.Time{}
+	if fault.Seconds > 0 {
+		fault.Expires = time.Now().Add(time.Duration(fault.Seconds) * time.Second)
+	}
+	d.Faults.Lock()
+	defer d.Faults.Unlock()
+	if d.Faults.armed == nil {
+		d.Faults.armed = make(map[string]*Fault)
+	}
+	d.Faults.armed[fault.Point] = &fault
+	log.Warningf("fault %s armed: %+v", fault.Point, fault)
+	return nil
+}
+
+// ClearFaults disarms the fault of the point, all faults when the point is empty.
+func (d *DealerImpl) ClearFaults(point string) {
+	d.Faults.Lock()
+	defer d.Faults.Unlock()
+	if point == "" {
+		d.Faults.armed = nil
+		return
+	}
+	delete(d.Faults.armed, point)
+}
+
+// ArmedFaults returns the faults which may still fire.
+func (d *DealerImpl) ArmedFaults() []Fault {
+	d.Faults.Lock()
+	defer d.Faults.Unlock()
+	ans := make([]Fault, 0, len(d.Faults.armed))
+	for _, fault := range d.Faults.armed {
+		ans = append(ans, *fault)
+	}
+	sort.Slice(ans, func(i, j int) bool { return ans[i].Point < ans[j].Point })
+	return ans
+}
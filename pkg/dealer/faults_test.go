@@ -0,0 +1,52 @@
+package dealer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestFaults(t *testing.T) {
+	d := MockDealer(MockNode("n1", 1), MockNode("n2", 1))
+	d.NodeMaps["n1"] = NewNodeInfo("n1", MockNode("n1", 1), d.Rater)
+	d.NodeMaps["n2"] = NewNodeInfo("n2", MockNode("n2", 1), d.Rater)
+	assert.NotNil(t, d.InjectFault(Fault{Point: FaultBindError}))
+
+	FaultInjection = true
+	defer func() { FaultInjection = false }()
+	assert.NotNil(t, d.InjectFault(Fault{Point: "unknown"}))
+
+	// the binding fails after planning and the cards are free again
+	assert.Nil(t, d.InjectFault(Fault{Point: FaultBindError, Count: 1, Status: 429}))
+	pod := MockQuotaPod("a", "p0", 60)
+	err := d.Bind("n1", pod, PolicySpec{}, false)
+	assert.True(t, apierrors.IsTooManyRequests(err))
+	assert.Equal(t, 100, d.NodeMaps["n1"].GPUs[0].Percent)
+	assert.Empty(t, d.PodMaps)
+	assert.Empty(t, d.ArmedFaults())
+
+	assert.Nil(t, d.InjectFault(Fault{Point: FaultNodeGone, Node: "n2"}))
+	fits, errs := d.Assume([]string{"n1", "n2"}, pod, PolicySpec{}, false)
+	assert.Equal(t, []bool{true, false}, fits)
+	assert.Contains(t, errs[1].Error(), "not found")
+	assert.Equal(t, 1, d.ArmedFaults()[0].Fired)
+	d.ClearFaults(FaultNodeGone)
+	fits, _ = d.Assume([]string{"n1", "n2"}, pod, PolicySpec{}, false)
+	assert.Equal(t, []bool{true, true}, fits)
+
+	now := time.Now().In(loc).Format(timeFormat)
+	d.UpdateCoreUsage("n1", "0.5", now, 0)
+	assert.Nil(t, d.InjectFault(Fault{Point: FaultStaleUsage, Count: 1}))
+	_, _, err = d.GetUsage("n1", GPUCoreUsagePriority, 0, time.Minute)
+	assert.True(t, errors.Is(err, ErrUsageStale))
+	_, usage, err := d.GetUsage("n1", GPUCoreUsagePriority, 0, time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.5, usage)
+
+	assert.Nil(t, d.InjectFault(Fault{Point: FaultStaleUsage}))
+	d.ClearFaults("")
+	assert.Empty(t, d.ArmedFaults())
+}
@@ -0,0 +1,181 @@
+package dealer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	log "k8s.io/klog/v2"
+)
+
+var (
+	// FederationURL is the endpoint of the federation hub the free capacity of the
+	// cluster is published to, empty disables publishing.
+	FederationURL string
+	// FederationCluster is the name the cluster publishes its capacity under.
+	FederationCluster string
+	// FederationToken is the bearer token the capacity is published with, the hub
+	// authorizes it to post to the clusters endpoint.
+	FederationToken string
+	// FederationInterval is the period of publishing the capacity.
+	FederationInterval = 30 * time.Second
+	// FederationStaleAfter is how long the hub trusts a published capacity, clusters
+	// which stopped publishing take no placements.
+	FederationStaleAfter = 5 * time.Minute
+
+	federationClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+// ClusterSummary is the free capacity a cluster publishes, the free share of every
+// schedulable card by node without the pods.
+type ClusterSummary struct {
+	Cluster string        `json:"cluster"`
+	Time    time.Time     `json:"time"`
+	Nodes   []NodeSummary `json:"nodes"`
+}
+
+type NodeSummary struct {
+	Name string `json:"name"`
+	Pool string `json:"pool,omitempty"`
+	// Free is the free percent of the cards, 0 for excluded and unhealthy ones.
+	Free []int `json:"free"`
+}
+
+// Summary returns the free capacity of the cluster to publish.
+func (d *DealerImpl) Summary(cluster string) *ClusterSummary {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	summary := &ClusterSummary{Cluster: cluster, Time: time.Now(), Nodes: make([]NodeSummary, 0, len(d.NodeMaps))}
+	for name, ni := range d.NodeMaps {
+		unavailable := ni.excludedCards()
+		for card, reason := range d.GetUnhealthyCards(name) {
+			unavailable[card] = reason
+		}
+		for card, reason := range ni.removedCards() {
+			unavailable[card] = reason
+		}
+		gpus := ni.GPUs.WithoutCards(unavailable)
+		free := make([]int, 0, ni.Capacity)
+		for i := 0; i < ni.Capacity && i < len(gpus); i++ {
+			free = append(free, gpus[i].Percent)
+		}
+		summary.Nodes = append(summary.Nodes, NodeSummary{Name: name, Pool: ni.Pool, Free: free})
+	}
+	sort.Slice(summary.Nodes, func(i, j int) bool { return summary.Nodes[i].Name < summary.Nodes[j].Name })
+	return summary
+}
+
+// PublishSummary posts the free capacity of the cluster to the federation hub.
+func PublishSummary(d Dealer) {
+	data, err := json.Marshal(d.Summary(FederationCluster))
+	if err != nil {
+		log.Errorf("marshal federation summary failed: %v", err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, FederationURL, bytes.NewReader(data))
+	if err != nil {
+		log.Errorf("create federation request failed: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if FederationToken != "" {
+		req.Header.Set("Authorization", "Bearer "+FederationToken)
+	}
+	resp, err := federationClient.Do(req)
+	if err != nil {
+		log.Warningf("publish federation summary failed: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		log.Warningf("publish federation summary failed: status %s", resp.Status)
+	}
+}
+
+// ClusterFit is how many replicas of a demand fit on a cluster.
+type ClusterFit struct {
+	Cluster  string    `json:"cluster"`
+	Replicas int       `json:"replicas"`
+	Time     time.Time `json:"time"`
+}
+
+// FederationHub keeps the capacity the clusters of a fleet publish and answers which
+// clusters fit a demand.
+type FederationHub struct {
+	lock     sync.Mutex
+	clusters map[string]*ClusterSummary
+}
+
+func NewFederationHub() *FederationHub {
+	return &FederationHub{clusters: make(map[string]*ClusterSummary)}
+}
+
+// Update replaces the capacity of the cluster of the summary.
+func (h *FederationHub) Update(summary *ClusterSummary) error {
+	if summary.Cluster == "" {
+		return fmt.Errorf("summary has no cluster")
+	}
+	if summary.Time.IsZero() {
+		summary.Time = time.Now()
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.clusters[summary.Cluster] = summary
+	return nil
+}
+
+// Clusters returns the summaries of the clusters by name.
+func (h *FederationHub) Clusters() []*ClusterSummary {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	ans := make([]*ClusterSummary, 0, len(h.clusters))
+	for _, s := range h.clusters {
+		ans = append(ans, s)
+	}
+	sort.Slice(ans, func(i, j int) bool { return ans[i].Cluster < ans[j].Cluster })
+	return ans
+}
+
+// Place returns the clusters fitting at least one replica of the demand with the
+// replicas they fit up to maxReplicas, the most first. Replicas are packed on the
+// published free shares, clusters with a stale summary are left out.
+func (h *FederationHub) Place(demand Demand, maxReplicas int, now time.Time) []ClusterFit {
+	fits := make([]ClusterFit, 0)
+	rater := &Binpack{}
+	for _, s := range h.Clusters() {
+		if now.Sub(s.Time) > FederationStaleAfter {
+			continue
+		}
+		fit := ClusterFit{Cluster: s.Cluster, Time: s.Time}
+		for _, node := range s.Nodes {
+			gpus := make(GPUs, len(node.Free))
+			for i, free := range node.Free {
+				gpus[i] = &GPUResource{Percent: free, PercentTotal: schetypes.GPUPercentEachCard}
+			}
+			for fit.Replicas < maxReplicas {
+				indexes, err := rater.Choose(gpus.Clone(), demand)
+				if err != nil {
+					break
+				}
+				if err := gpus.Allocate(&Plan{Demand: demand, GPUIndexes: indexes}); err != nil {
+					break
+				}
+				fit.Replicas++
+			}
+			if fit.Replicas >= maxReplicas {
+				break
+			}
+		}
+		if fit.Replicas > 0 {
+			fits = append(fits, fit)
+		}
+	}
+	sort.SliceStable(fits, func(i, j int) bool { return fits[i].Replicas > fits[j].Replicas })
+	return fits
+}
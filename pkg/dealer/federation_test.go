@@ -0,0 +1,35 @@
+package dealer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummaryAndPlace(t *testing.T) {
+	d := MockDealer()
+	d.NodeMaps["n1"] = NewNodeInfo("n1", MockNode("n1", 2), d.Rater)
+	d.NodeMaps["n2"] = NewNodeInfo("n2", MockNode("n2", 1), d.Rater)
+	assert.NoError(t, d.NodeMaps["n1"].Allocate(&Plan{Demand: Demand{{Percent: 70}}, GPUIndexes: []int{0}}))
+	d.NodeMaps["n1"].Excluded = []int{1}
+
+	summary := d.Summary("east")
+	assert.Equal(t, "east", summary.Cluster)
+	assert.Equal(t, []NodeSummary{{Name: "n1", Free: []int{30, 0}}, {Name: "n2", Free: []int{100}}}, summary.Nodes)
+
+	now := time.Now()
+	hub := NewFederationHub()
+	assert.NoError(t, hub.Update(summary))
+	assert.NoError(t, hub.Update(&ClusterSummary{Cluster: "west", Time: now, Nodes: []NodeSummary{{Name: "w1", Free: []int{100, 100}}}}))
+	assert.NoError(t, hub.Update(&ClusterSummary{Cluster: "gone", Time: now.Add(-time.Hour), Nodes: []NodeSummary{{Name: "g1", Free: []int{100}}}}))
+	assert.Error(t, hub.Update(&ClusterSummary{}))
+
+	fits := hub.Place(Demand{{Percent: 30}}, 10, now)
+	assert.Equal(t, []ClusterFit{{Cluster: "west", Replicas: 6, Time: now}, {Cluster: "east", Replicas: 4, Time: summary.Time}}, fits)
+	// only west has a whole free card left beside another
+	fits = hub.Place(Demand{{Percent: 100}, {Percent: 100}}, 10, now)
+	assert.Len(t, fits, 1)
+	assert.Equal(t, "west", fits[0].Cluster)
+	assert.Empty(t, hub.Place(Demand{{Percent: 40}}, 10, now.Add(time.Hour)))
+}
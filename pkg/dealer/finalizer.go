@@ -0,0 +1,47 @@
+package dealer
+
+import (
+	"errors"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ReleaseFinalizer adds a finalizer to bound pods so that their deletion waits for
+// the dealer to release their share.
+var ReleaseFinalizer = false
+
+func HasReleaseFinalizer(pod *v1.Pod) bool {
+	for _, f := range pod.Finalizers {
+		if f == schetypes.FinalizerGPURelease {
+			return true
+		}
+	}
+	return false
+}
+
+func withReleaseFinalizer(pod *v1.Pod) {
+	if ReleaseFinalizer && !HasReleaseFinalizer(pod) {
+		pod.Finalizers = append(pod.Finalizers, schetypes.FinalizerGPURelease)
+	}
+}
+
+// WithoutReleaseFinalizer returns the finalizers of the pod but the release one.
+func WithoutReleaseFinalizer(pod *v1.Pod) []string {
+	ans := make([]string, 0, len(pod.Finalizers))
+	for _, f := range pod.Finalizers {
+		if f != schetypes.FinalizerGPURelease {
+			ans = append(ans, f)
+		}
+	}
+	return ans
+}
+
+// NothingToRelease determines if a release failed because the scheduler holds no
+// share of the pod, its node being gone or out of the shard. The pod counts as
+// released then.
+func NothingToRelease(err error) bool {
+	return apierrors.IsNotFound(err) || errors.Is(err, ErrNodeNotOwned)
+}
@@ -0,0 +1,39 @@
+package dealer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestReleaseFinalizer(t *testing.T) {
+	node := MockNode("n1", 1)
+	pod := MockQuotaPod("a", "p", 30)
+	pod.Finalizers = []string{"other"}
+	plan := &Plan{Demand: Demand{{Percent: 30}}, GPUIndexes: []int{0}}
+	assert.False(t, HasReleaseFinalizer(podWithPlan(pod, node, plan)))
+
+	ReleaseFinalizer = true
+	defer func() { ReleaseFinalizer = false }()
+	bound := podWithPlan(pod, node, plan)
+	assert.Equal(t, []string{"other", types.FinalizerGPURelease}, bound.Finalizers)
+	assert.Equal(t, []string{"other"}, pod.Finalizers)
+	assert.Equal(t, []string{"other"}, WithoutReleaseFinalizer(bound))
+}
+
+func TestNothingToRelease(t *testing.T) {
+	d := MockDealer(MockNode("n1", 1))
+	pod := MockQuotaPod("a", "p", 30)
+	pod.Spec.NodeName = "gone"
+	assert.True(t, NothingToRelease(d.Release(pod)))
+
+	ShardSelector = labels.SelectorFromSet(labels.Set{"shard": "a"})
+	defer func() { ShardSelector = nil }()
+	pod.Spec.NodeName = "n1"
+	assert.True(t, NothingToRelease(d.Release(pod)))
+	assert.False(t, NothingToRelease(errors.New("node info release failed")))
+}
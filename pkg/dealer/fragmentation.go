@@ -0,0 +1,62 @@
+package dealer
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// Fragmentation compares the largest demand a single card can still take with the
+// total free share. Score is 0 when all free share sits on one card and approaches
+// 1 when free share is scattered in small pieces across many cards.
+type Fragmentation struct {
+	Largest int     `json:"largest"`
+	Free    int     `json:"free"`
+	Score   float64 `json:"score"`
+}
+
+type FragmentationReport struct {
+	Cluster Fragmentation            `json:"cluster"`
+	Nodes   map[string]Fragmentation `json:"nodes"`
+}
+
+func (gpus GPUs) Fragmentation() Fragmentation {
+	ans := Fragmentation{}
+	for _, g := range gpus {
+		ans.Free += g.Percent
+		if g.Percent > ans.Largest {
+			ans.Largest = g.Percent
+		}
+	}
+	if ans.Free > 0 {
+		ans.Score = 1 - float64(ans.Largest)/float64(ans.Free)
+	}
+	return ans
+}
+
+// fragmentation reports every known node and the cluster, the cluster score weights
+// the largest placeable demand of each node by the total free share. Only the
+// schedulable share counts, like for the capacity.
+func (d *DealerImpl) fragmentation() *FragmentationReport {
+	report := &FragmentationReport{
+		Nodes: make(map[string]Fragmentation, len(d.NodeMaps)),
+	}
+	sumLargest := 0
+	for name, ni := range d.NodeMaps {
+		f := ni.schedulableGPUs(&v1.Pod{}, d).Fragmentation()
+		report.Nodes[name] = f
+		sumLargest += f.Largest
+		report.Cluster.Free += f.Free
+		if f.Largest > report.Cluster.Largest {
+			report.Cluster.Largest = f.Largest
+		}
+	}
+	if report.Cluster.Free > 0 {
+		report.Cluster.Score = 1 - float64(sumLargest)/float64(report.Cluster.Free)
+	}
+	return report
+}
+
+func (d *DealerImpl) Fragmentation() *FragmentationReport {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	return d.fragmentation()
+}
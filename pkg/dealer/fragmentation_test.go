@@ -0,0 +1,30 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFragmentation(t *testing.T) {
+	d := MockDealer()
+	d.NodeMaps["packed"] = &NodeInfo{Name: "packed", Capacity: 2, GPUs: GPUs{{Percent: 0, PercentTotal: 100}, {Percent: 100, PercentTotal: 100}}}
+	d.NodeMaps["scattered"] = &NodeInfo{Name: "scattered", Capacity: 3, GPUs: GPUs{{Percent: 25, PercentTotal: 100}, {Percent: 25, PercentTotal: 100}, {Percent: 50, PercentTotal: 100}}}
+
+	report := d.Fragmentation()
+	assert.Equal(t, Fragmentation{Largest: 100, Free: 100, Score: 0}, report.Nodes["packed"])
+	assert.Equal(t, Fragmentation{Largest: 50, Free: 100, Score: 0.5}, report.Nodes["scattered"])
+	assert.Equal(t, Fragmentation{Largest: 100, Free: 200, Score: 0.25}, report.Cluster)
+}
+
+func TestFragmentationOfSchedulableShare(t *testing.T) {
+	node := MockNode("n1", 3)
+	d := MockDealer(node)
+	d.NodeMaps["n1"] = NewNodeInfo("n1", node, d.Rater)
+	d.NodeMaps["n1"].SystemReserved = 10
+	d.UpdateHealth("n1", 2, GPUHealth{XID: 79})
+
+	report := d.Fragmentation()
+	assert.Equal(t, Fragmentation{Largest: 90, Free: 180, Score: 0.5}, report.Nodes["n1"])
+	assert.Equal(t, 180, report.Cluster.Free)
+}
@@ -0,0 +1,145 @@
+package dealer
+
+import (
+	"time"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	log "k8s.io/klog/v2"
+)
+
+// GangTimeout is how long the shares reserved for the members of a gang are held
+// before they are all released together, 0 disables the reservations.
+var GangTimeout time.Duration
+
+// gang holds the shares reserved for the members of a pod group of Volcano or of the
+// coscheduling plugin. The members wait for each other after they are scored, so the
+// share of the node each member was scored best on is reserved until it is bound,
+// otherwise the next members would be placed on the same share.
+type gang struct {
+	Members map[types.UID]*gangReservation
+	Expires time.Time
+}
+
+type gangReservation struct {
+	Node string
+	Plan *Plan
+}
+
+// gangOf returns the namespaced pod group of the pod, empty for a pod of no gang.
+func gangOf(pod *v1.Pod) string {
+	name := pod.Annotations[schetypes.AnnotationVolcanoGroupName]
+	if name == "" {
+		name = pod.Labels[schetypes.LabelPodGroup]
+	}
+	if name == "" {
+		name = pod.Labels[schetypes.LabelPodGroupLegacy]
+	}
+	if name == "" {
+		return ""
+	}
+	return pod.Namespace + "/" + name
+}
+
+// reserveGang holds the plan of the pod on the node until the pod is bound or its
+// gang times out. A pod scored again gives up its former reservation first.
+func (d *DealerImpl) reserveGang(pod *v1.Pod, ni *NodeInfo, plan *Plan) {
+	name := gangOf(pod)
+	if GangTimeout <= 0 || name == "" {
+		return
+	}
+	d.unreserveGang(pod.UID)
+	g, ok := d.Gangs[name]
+	if !ok {
+		g = &gang{Members: make(map[types.UID]*gangReservation), Expires: time.Now().Add(GangTimeout)}
+		d.Gangs[name] = g
+	}
+	reserved := &Plan{Demand: plan.Demand, GPUIndexes: append([]int(nil), plan.GPUIndexes...)}
+	// the plans of the other pods are kept, a plan overlapping the reservation fails
+	// to allocate when its pod is bound
+	if err := ni.GPUs.Allocate(reserved); err != nil {
+		log.Warningf("reserve gpu of gang %s for pod %s/%s on %s failed: %s", name, pod.Namespace, pod.Name, ni.Name, err.Error())
+		return
+	}
+	g.Members[pod.UID] = &gangReservation{Node: ni.Name, Plan: reserved}
+}
+
+// unreserveGang releases the share reserved for the pod, if any.
+func (d *DealerImpl) unreserveGang(uid types.UID) {
+	for name, g := range d.Gangs {
+		r, ok := g.Members[uid]
+		if !ok {
+			continue
+		}
+		d.releaseReservation(r)
+		delete(g.Members, uid)
+		if len(g.Members) == 0 {
+			delete(d.Gangs, name)
+		}
+		return
+	}
+}
+
+// expireGangs releases the reservations of all the members of the gangs which timed
+// out at once, a gang that didn't come together keeps no share of any member.
+func (d *DealerImpl) expireGangs(now time.Time) {
+	for name, g := range d.Gangs {
+		if now.Before(g.Expires) {
+			continue
+		}
+		for _, r := range g.Members {
+			d.releaseReservation(r)
+		}
+		delete(d.Gangs, name)
+		log.Infof("gang %s timed out, released the gpu of its %d waiting members", name, len(g.Members))
+	}
+}
+
+// dropGangReservations forgets the reservations on a node which is gone, their
+// members are scored again.
+func (d *DealerImpl) dropGangReservations(nodeName string) {
+	for name, g := range d.Gangs {
+		for uid, r := range g.Members {
+			if r.Node == nodeName {
+				delete(g.Members, uid)
+			}
+		}
+		if len(g.Members) == 0 {
+			delete(d.Gangs, name)
+		}
+	}
+}
+
+func (d *DealerImpl) releaseReservation(r *gangReservation) {
+	ni, ok := d.NodeMaps[r.Node]
+	if !ok {
+		return
+	}
+	if err := ni.Release(r.Plan); err != nil {
+		log.Warningf("release gang reservation on %s failed: %s", r.Node, err.Error())
+	}
+}
+
+// reserveBest reserves the plan of the best scored feasible node for a gang member.
+func (d *DealerImpl) reserveBest(pod *v1.Pod, nodes []string, scores []int, demand Demand) {
+	if GangTimeout <= 0 || gangOf(pod) == "" {
+		return
+	}
+	best := -1
+	var plan *Plan
+	for i, name := range nodes {
+		ni, ok := d.NodeMaps[name]
+		if !ok {
+			continue
+		}
+		p, feasible := ni.PlanCache[planKey(demand, pod)]
+		if feasible && (best < 0 || scores[i] > scores[best]) {
+			best, plan = i, p
+		}
+	}
+	if best >= 0 {
+		d.reserveGang(pod, d.NodeMaps[nodes[best]], plan)
+	}
+}
@@ -0,0 +1,45 @@
+package dealer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestGangReservation(t *testing.T) {
+	GangTimeout = time.Minute
+	defer func() { GangTimeout = 0 }()
+	d := MockDealer(MockNode("n1", 1))
+	d.NodeMaps["n1"] = NewNodeInfo("n1", MockNode("n1", 1), d.Rater)
+	p0 := MockQuotaPod("a", "p0", 60)
+	p0.Annotations = map[string]string{schetypes.AnnotationVolcanoGroupName: "g"}
+	p1 := MockQuotaPod("a", "p1", 60)
+	p1.Labels = map[string]string{schetypes.LabelPodGroup: "g"}
+	assert.Equal(t, "a/g", gangOf(p0))
+	assert.Equal(t, "a/g", gangOf(p1))
+	assert.Equal(t, "", gangOf(MockQuotaPod("a", "p2", 60)))
+
+	ans, _ := d.Assume([]string{"n1"}, p0, PolicySpec{}, false)
+	assert.Equal(t, []bool{true}, ans)
+	// the reservation keeps the plans of the other pods
+	other := MockQuotaPod("a", "p3", 20)
+	d.NodeMaps["n1"].PlanCache[planKey(NewDemandFromPod(other), other)] = &Plan{Demand: NewDemandFromPod(other), GPUIndexes: []int{0}}
+	d.Score([]string{"n1"}, p0, PolicySpec{}, false)
+	assert.Equal(t, 40, d.NodeMaps["n1"].GPUs[0].Percent)
+	assert.Contains(t, d.NodeMaps["n1"].PlanCache, planKey(NewDemandFromPod(other), other))
+	// scored again, the member keeps a single reservation
+	d.Score([]string{"n1"}, p0, PolicySpec{}, false)
+	assert.Equal(t, 40, d.NodeMaps["n1"].GPUs[0].Percent)
+
+	// the next member doesn't get the reserved share
+	ans, _ = d.Assume([]string{"n1"}, p1, PolicySpec{}, false)
+	assert.Equal(t, []bool{false}, ans)
+
+	// the whole gang is released once it times out
+	d.expireGangs(time.Now().Add(time.Minute))
+	assert.Equal(t, 100, d.NodeMaps["n1"].GPUs[0].Percent)
+	assert.Empty(t, d.Gangs)
+}
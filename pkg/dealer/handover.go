@@ -0,0 +1,135 @@
+package dealer
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	log "k8s.io/klog/v2"
+)
+
+// AssumptionTTL is how long a scored pod counts as in flight to the node it scored
+// best on, waiting for its binding.
+var AssumptionTTL = 30 * time.Second
+
+// Assumption is a pod scored but not bound yet with the plan of the node it is
+// expected on. The scheduler taking over a shard reserves the assumptions of the
+// former one, so that the pods bound by the former one right before the failover
+// don't get their share placed twice.
+type Assumption struct {
+	UID       types.UID `json:"uid"`
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name,omitempty"`
+	Node      string    `json:"node"`
+	Plan      *Plan     `json:"plan"`
+	Expires   time.Time `json:"expires"`
+}
+
+// trackAssumption records the plan of the best scored feasible node of the pod.
+func (d *DealerImpl) trackAssumption(pod *v1.Pod, nodes []string, scores []int, demand Demand) {
+	best := -1
+	var plan *Plan
+	for i, name := range nodes {
+		ni, ok := d.NodeMaps[name]
+		if !ok {
+			continue
+		}
+		p, feasible := ni.PlanCache[planKey(demand, pod)]
+		if feasible && (best < 0 || scores[i] > scores[best]) {
+			best, plan = i, p
+		}
+	}
+	if best < 0 {
+		delete(d.InFlight, pod.UID)
+		return
+	}
+	d.InFlight[pod.UID] = &Assumption{
+		UID:       pod.UID,
+		Namespace: pod.Namespace,
+		Name:      pod.Name,
+		Node:      nodes[best],
+		Plan:      &Plan{Demand: plan.Demand, GPUIndexes: append([]int(nil), plan.GPUIndexes...)},
+		Expires:   time.Now().Add(AssumptionTTL),
+	}
+}
+
+// InFlightAssumptions returns the pods in flight, the scored ones and the waiting
+// members of gangs.
+func (d *DealerImpl) InFlightAssumptions() []Assumption {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	now := time.Now()
+	ans := make([]Assumption, 0, len(d.InFlight))
+	for uid, a := range d.InFlight {
+		if now.After(a.Expires) {
+			delete(d.InFlight, uid)
+			continue
+		}
+		ans = append(ans, *a)
+	}
+	for _, g := range d.Gangs {
+		for uid, r := range g.Members {
+			if _, ok := d.InFlight[uid]; !ok {
+				ans = append(ans, Assumption{UID: uid, Node: r.Node, Plan: r.Plan, Expires: g.Expires})
+			}
+		}
+	}
+	return ans
+}
+
+// ImportAssumptions reserves the plans of the assumptions of another scheduler until
+// the pods are bound, scheduled again or the assumptions expire. Known pods and
+// expired assumptions are skipped, it returns the number of reserved ones.
+func (d *DealerImpl) ImportAssumptions(assumptions []Assumption) int {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	now := time.Now()
+	imported := 0
+	for i := range assumptions {
+		a := assumptions[i]
+		if _, known := d.PodMaps[a.UID]; known || now.After(a.Expires) || a.Plan == nil {
+			continue
+		}
+		d.dropImported(a.UID)
+		ni, err := d.getNodeInfo(a.Node)
+		if err != nil {
+			log.Warningf("import assumption of pod %s on %s failed: %v", a.UID, a.Node, err)
+			continue
+		}
+		// only the pods already running there may hold cards the node no longer has
+		if cards := planCards(a.Plan); cards > ni.Capacity {
+			log.Warningf("import assumption of pod %s on %s failed: plan needs %d cards, node has %d", a.UID, a.Node, cards, ni.Capacity)
+			continue
+		}
+		if err := ni.Allocate(a.Plan); err != nil {
+			log.Warningf("import assumption of pod %s on %s failed: %v", a.UID, a.Node, err)
+			continue
+		}
+		d.Imported[a.UID] = &a
+		imported++
+	}
+	return imported
+}
+
+// dropImported releases the share reserved for the imported assumption of the pod.
+func (d *DealerImpl) dropImported(uid types.UID) {
+	a, ok := d.Imported[uid]
+	if !ok {
+		return
+	}
+	delete(d.Imported, uid)
+	if ni, ok := d.NodeMaps[a.Node]; ok {
+		if err := ni.Release(a.Plan); err != nil {
+			log.Warningf("release imported assumption of pod %s on %s failed: %v", uid, a.Node, err)
+		}
+	}
+}
+
+// expireImported releases the imported assumptions whose pods weren't seen in time.
+func (d *DealerImpl) expireImported(now time.Time) {
+	for uid, a := range d.Imported {
+		if now.After(a.Expires) {
+			d.dropImported(uid)
+		}
+	}
+}
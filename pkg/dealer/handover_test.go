@@ -0,0 +1,45 @@
+package dealer
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandover(t *testing.T) {
+	node := MockNode("n1", 1)
+	former := MockDealer(node)
+	former.NodeMaps["n1"] = NewNodeInfo("n1", node, former.Rater)
+	pod := MockQuotaPod("a", "p0", 100)
+	ans, _ := former.Assume([]string{"n1"}, pod, PolicySpec{}, false)
+	assert.Equal(t, []bool{true}, ans)
+	former.Score([]string{"n1"}, pod, PolicySpec{}, false)
+
+	assumptions := former.InFlightAssumptions()
+	assert.Len(t, assumptions, 1)
+	assert.Equal(t, "n1", assumptions[0].Node)
+	data, err := json.Marshal(assumptions)
+	assert.NoError(t, err)
+
+	// the new holder keeps the share of the pod in flight
+	d := MockDealer(node)
+	d.NodeMaps["n1"] = NewNodeInfo("n1", node, d.Rater)
+	shared := make([]Assumption, 0)
+	assert.NoError(t, json.Unmarshal(data, &shared))
+	expired := shared[0]
+	expired.UID, expired.Expires = "a/old", time.Now().Add(-time.Second)
+	bogus := shared[0]
+	bogus.UID, bogus.Plan = "a/bogus", &Plan{Demand: Demand{{Percent: 10}}, GPUIndexes: []int{7}}
+	assert.Equal(t, 1, d.ImportAssumptions(append(shared, expired, bogus)))
+	assert.Len(t, d.NodeMaps["n1"].GPUs, 1)
+	other := MockQuotaPod("a", "p1", 100)
+	ans, _ = d.Assume([]string{"n1"}, other, PolicySpec{}, false)
+	assert.Equal(t, []bool{false}, ans)
+
+	// the pod scheduled again gives the share up
+	ans, _ = d.Assume([]string{"n1"}, pod, PolicySpec{}, false)
+	assert.Equal(t, []bool{true}, ans)
+	assert.Empty(t, d.Imported)
+}
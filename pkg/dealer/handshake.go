@@ -0,0 +1,191 @@
+package dealer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/accelerator"
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	log "k8s.io/klog/v2"
+)
+
+// AckTimeout is how long a plan may wait for the device plugin to acknowledge the
+// cards it assigned, 0 disables the handshake.
+var AckTimeout time.Duration
+
+// trackUnconfirmed holds the plan of the pod as pending confirmation.
+func (d *DealerImpl) trackUnconfirmed(pod *v1.Pod) {
+	if AckTimeout <= 0 {
+		return
+	}
+	if _, ok := d.Unconfirmed[pod.UID]; !ok {
+		d.Unconfirmed[pod.UID] = time.Now()
+	}
+}
+
+// ackedPlan returns the plan with the cards acknowledged by the device plugin, false
+// until every gpu container is acknowledged.
+func ackedPlan(pod *v1.Pod, plan *Plan) (*Plan, bool) {
+	acked := &Plan{Demand: plan.Demand, GPUIndexes: make([]int, len(plan.GPUIndexes)), Init: plan.Init}
+	copy(acked.GPUIndexes, plan.GPUIndexes)
+	for i, c := range pod.Spec.Containers {
+		if i >= len(plan.Demand) || plan.GPUIndexes[i] < 0 {
+			continue
+		}
+		idx, err := utils.GetContainerAckIndex(pod, c.Name)
+		if err != nil {
+			return nil, false
+		}
+		acked.GPUIndexes[i] = idx
+	}
+	return acked, true
+}
+
+// ackedAnnotations returns the annotations of the pod naming the acknowledged cards:
+// the index, the uuid and the structured plan when the pod has one. The uuid of a
+// card the node doesn't publish is emptied so it doesn't move the pod back.
+func ackedAnnotations(ni *NodeInfo, pod *v1.Pod, acked *Plan) map[string]string {
+	a := accelerator.ForPod(pod)
+	ans := make(map[string]string)
+	for i, c := range pod.Spec.Containers {
+		if i >= len(acked.GPUIndexes) || acked.GPUIndexes[i] < 0 {
+			continue
+		}
+		idx := acked.GPUIndexes[i]
+		ans[a.ContainerAnnotation(c.Name)] = strconv.Itoa(idx)
+		key := fmt.Sprintf(schetypes.AnnotationGPUContainerUUID, c.Name)
+		if _, ok := pod.Annotations[key]; ok || idx < len(ni.UUIDs) {
+			ans[key] = ""
+			if idx < len(ni.UUIDs) {
+				ans[key] = ni.UUIDs[idx]
+			}
+		}
+	}
+	if _, ok := pod.Annotations[schetypes.AnnotationGPUPlan]; ok {
+		data, err := json.Marshal(newPlanAnnotation(pod, acked, ni.UUIDs))
+		if err != nil {
+			log.Errorf("marshal plan of pod %s/%s failed: %v", pod.Namespace, pod.Name, err)
+		} else {
+			ans[schetypes.AnnotationGPUPlan] = string(data)
+		}
+	}
+	return ans
+}
+
+// Confirm checks the acknowledgement of the device plugin against the plan of a
+// pod pending confirmation. When they differ the cards the device plugin really
+// assigned are accounted and returned with the annotations the pod has to be
+// patched with.
+func (d *DealerImpl) Confirm(pod *v1.Pod) ([]int, map[string]string, error) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	if _, ok := d.Unconfirmed[pod.UID]; !ok {
+		return nil, nil, nil
+	}
+	known, ok := d.PodMaps[pod.UID]
+	if !ok {
+		delete(d.Unconfirmed, pod.UID)
+		return nil, nil, nil
+	}
+	plan, err := NewPlanFromPod(known)
+	if err != nil {
+		return nil, nil, err
+	}
+	acked, ok := ackedPlan(pod, plan)
+	if !ok {
+		return nil, nil, nil
+	}
+	delete(d.Unconfirmed, pod.UID)
+	if equalIndexes(plan.GPUIndexes, acked.GPUIndexes) {
+		return nil, nil, nil
+	}
+	log.Warningf("device plugin assigned %v to pod %s/%s planned on %v", acked.GPUIndexes, pod.Namespace, pod.Name, plan.GPUIndexes)
+	ni, err := d.getNodeInfo(known.Spec.NodeName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if card, ok := unknownCard(acked, plan, ni.Capacity); ok {
+		return nil, nil, fmt.Errorf("device plugin acknowledged gpu %d of pod %s/%s which node %s doesn't have", card, pod.Namespace, pod.Name, ni.Name)
+	}
+	if err := ni.Release(plan); err != nil {
+		return nil, nil, err
+	}
+	ni.addQoS(known, plan, -1)
+	if err := ni.Allocate(acked); err != nil {
+		// the cards are overcommitted, keep accounting the plan
+		if err := ni.Allocate(plan); err != nil {
+			return nil, nil, fmt.Errorf("account planned cards %v of pod %s/%s again failed: %v", plan.GPUIndexes, pod.Namespace, pod.Name, err)
+		}
+		ni.addQoS(known, plan, 1)
+		return acked.GPUIndexes, nil, fmt.Errorf("account acknowledged cards %v of pod %s/%s failed: %v", acked.GPUIndexes, pod.Namespace, pod.Name, err)
+	}
+	ni.addQoS(known, acked, 1)
+	d.recordAllocation(ni.Name, known, plan, AllocationActionRelease)
+	d.recordAllocation(ni.Name, known, acked, AllocationActionAllocate)
+	annotations := ackedAnnotations(ni, known, acked)
+	confirmed := known.DeepCopy()
+	for k, v := range annotations {
+		confirmed.Annotations[k] = v
+	}
+	d.PodMaps[pod.UID] = confirmed
+	return acked.GPUIndexes, annotations, nil
+}
+
+// UnconfirmedPods returns the known pods waiting for the acknowledgement longer than
+// AckTimeout, their wait starts over.
+func (d *DealerImpl) UnconfirmedPods() []*v1.Pod {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	ans := make([]*v1.Pod, 0)
+	now := time.Now()
+	for uid, since := range d.Unconfirmed {
+		pod, ok := d.PodMaps[uid]
+		if !ok {
+			delete(d.Unconfirmed, uid)
+			continue
+		}
+		if now.Sub(since) < AckTimeout {
+			continue
+		}
+		d.Unconfirmed[uid] = now
+		ans = append(ans, pod)
+	}
+	return ans
+}
+
+func (d *DealerImpl) forgetUnconfirmed(uid types.UID) {
+	delete(d.Unconfirmed, uid)
+}
+
+// unknownCard returns an acknowledged card neither planned nor advertised by the
+// node.
+func unknownCard(acked, plan *Plan, capacity int) (int, bool) {
+	planned := make(map[int]bool, len(plan.GPUIndexes))
+	for _, card := range plan.GPUIndexes {
+		planned[card] = true
+	}
+	for _, card := range acked.GPUIndexes {
+		if !planned[card] && (card < 0 || card >= capacity) {
+			return card, true
+		}
+	}
+	return 0, false
+}
+
+func equalIndexes(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package dealer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+)
+
+func TestConfirm(t *testing.T) {
+	AckTimeout = time.Minute
+	defer func() { AckTimeout = 0 }()
+	node := MockNode("n1", 2)
+	d := MockDealer(node)
+	ni := NewNodeInfo("n1", node, d.Rater)
+	d.NodeMaps["n1"] = ni
+
+	pod := MockQuotaPod("a", "p", 60)
+	pod.Spec.NodeName = "n1"
+	pod.Spec.Containers[0].Name = "main"
+	pod = utils.GetUpdatedPodAnnotationSpec(pod, []int{0})
+	assert.Nil(t, d.Allocate(pod))
+	assert.Contains(t, d.Unconfirmed, pod.UID)
+	assert.Empty(t, d.UnconfirmedPods())
+
+	// no ack yet
+	indexes, _, err := d.Confirm(pod)
+	assert.Nil(t, indexes)
+	assert.Nil(t, err)
+	d.Unconfirmed[pod.UID] = time.Now().Add(-2 * time.Minute)
+	assert.Len(t, d.UnconfirmedPods(), 1)
+
+	// a card the node doesn't have is rejected
+	bogus := pod.DeepCopy()
+	bogus.Annotations[fmt.Sprintf(types.AnnotationGPUContainerAck, "main")] = "7"
+	indexes, _, err = d.Confirm(bogus)
+	assert.Nil(t, indexes)
+	assert.NotNil(t, err)
+	assert.Equal(t, 40, ni.GPUs[0].Percent)
+	d.Unconfirmed[pod.UID] = time.Now()
+
+	// the device plugin picked another card
+	acked := pod.DeepCopy()
+	acked.Annotations[fmt.Sprintf(types.AnnotationGPUContainerAck, "main")] = "1"
+	indexes, _, err = d.Confirm(acked)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1}, indexes)
+	assert.Equal(t, 100, ni.GPUs[0].Percent)
+	assert.Equal(t, 40, ni.GPUs[1].Percent)
+	assert.NotContains(t, d.Unconfirmed, pod.UID)
+
+	// the release follows the acknowledged cards
+	assert.Nil(t, d.Release(acked))
+	assert.Equal(t, 100, ni.GPUs[1].Percent)
+}
+
+func TestConfirmSurvivesRebuild(t *testing.T) {
+	AckTimeout = time.Minute
+	defer func() { AckTimeout = 0 }()
+	node := MockNode("n1", 2)
+	node.Annotations = map[string]string{types.AnnotationGPUUUIDs: "GPU-a,GPU-b"}
+	d := MockDealer(node)
+	d.NodeMaps["n1"] = NewNodeInfo("n1", node, d.Rater)
+
+	pod := MockQuotaPod("a", "p", 60)
+	pod.Spec.Containers[0].Name = "main"
+	pod = podWithPlan(pod, node, &Plan{Demand: Demand{{Percent: 60}}, GPUIndexes: []int{0}})
+	pod.Spec.NodeName = "n1"
+	assert.Nil(t, d.Allocate(pod))
+
+	acked := pod.DeepCopy()
+	acked.Annotations[fmt.Sprintf(types.AnnotationGPUContainerAck, "main")] = "1"
+	indexes, annotations, err := d.Confirm(acked)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1}, indexes)
+	assert.Equal(t, "GPU-b", annotations[fmt.Sprintf(types.AnnotationGPUContainerUUID, "main")])
+	assert.Contains(t, annotations, types.AnnotationGPUPlan)
+
+	// a dealer rebuilt from the patched pod keeps it on the acknowledged card
+	for k, v := range annotations {
+		acked.Annotations[k] = v
+	}
+	rebuilt := MockDealer(node)
+	ni := NewNodeInfo("n1", node, rebuilt.Rater)
+	rebuilt.accountPod(ni, acked)
+	assert.Equal(t, 100, ni.GPUs[0].Percent)
+	assert.Equal(t, 40, ni.GPUs[1].Percent)
+}
@@ -0,0 +1,40 @@
+package dealer
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// Headroom reserves Percent of every gpu card for pods whose priority is at least
+// Priority, other pods see the reserved share as unavailable.
+type Headroom struct {
+	Percent  int
+	Priority int32
+}
+
+var ReservedHeadroom Headroom
+
+// PercentFor returns the share of every card the pod is not allowed to consume.
+func (h Headroom) PercentFor(pod *v1.Pod) int {
+	if h.Percent <= 0 {
+		return 0
+	}
+	if pod.Spec.Priority != nil && *pod.Spec.Priority >= h.Priority {
+		return 0
+	}
+	return h.Percent
+}
+
+// WithHeadroom returns a copy of the gpus whose capacity is shrunk by the reserved share.
+func (gpus GPUs) WithHeadroom(reserved int) GPUs {
+	ans := make(GPUs, len(gpus))
+	for i, g := range gpus {
+		r := *g
+		r.PercentTotal -= reserved
+		r.Percent -= reserved
+		if r.Percent < 0 {
+			r.Percent = 0
+		}
+		ans[i] = &r
+	}
+	return ans
+}
@@ -0,0 +1,29 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReservedHeadroom(t *testing.T) {
+	defer func() { ReservedHeadroom = Headroom{} }()
+	ReservedHeadroom = Headroom{Percent: 20, Priority: 1000}
+
+	ni := NewNodeInfo("n1", MockNode("n1", 1), &Binpack{})
+	ni.GPUs[0].Percent = 50
+
+	small := MockQuotaPod("ns", "small", 30)
+	assumed, _ := ni.Assume(NewDemandFromPod(small), small, nil, PolicySpec{}, false)
+	assert.True(t, assumed)
+
+	big := MockQuotaPod("ns", "big", 40)
+	assumed, _ = ni.Assume(NewDemandFromPod(big), big, nil, PolicySpec{}, false)
+	assert.False(t, assumed)
+
+	priority := int32(1000)
+	critical := MockQuotaPod("ns", "critical", 40)
+	critical.Spec.Priority = &priority
+	assumed, _ = ni.Assume(NewDemandFromPod(critical), critical, nil, PolicySpec{}, false)
+	assert.True(t, assumed)
+}
@@ -0,0 +1,100 @@
+package dealer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RetiredPagesLimit is the number of retired pages after which a card is considered
+// failing, the driver stops retiring pages at 64.
+var RetiredPagesLimit = 48
+
+// fatalXIDs are the xid errors which indicate a hardware fault rather than an
+// application error.
+var fatalXIDs = map[int]string{
+	48: "double bit ecc error",
+	62: "internal micro-controller halt",
+	63: "row remapping pending",
+	64: "row remapping failure",
+	74: "nvlink error",
+	79: "fallen off the bus",
+	92: "high single bit ecc error rate",
+	94: "contained ecc error",
+	95: "uncontained ecc error",
+}
+
+// GPUHealth is the latest health signal reported for a card.
+type GPUHealth struct {
+	XID          int `json:"xid"`
+	RetiredPages int `json:"retiredPages"`
+	DoubleBitECC int `json:"doubleBitEcc"`
+}
+
+// Reason returns why the card is unhealthy, empty when it is healthy.
+func (h GPUHealth) Reason() string {
+	reasons := make([]string, 0)
+	if reason, ok := fatalXIDs[h.XID]; ok {
+		reasons = append(reasons, fmt.Sprintf("xid %d: %s", h.XID, reason))
+	}
+	if h.DoubleBitECC > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d double bit ecc errors", h.DoubleBitECC))
+	}
+	if h.RetiredPages >= RetiredPagesLimit {
+		reasons = append(reasons, fmt.Sprintf("%d retired pages", h.RetiredPages))
+	}
+	return strings.Join(reasons, ", ")
+}
+
+func (d *DealerImpl) UpdateHealth(nodeName string, card int, health GPUHealth) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	if d.Health[nodeName] == nil {
+		d.Health[nodeName] = make(map[int]GPUHealth)
+	}
+	before := d.Health[nodeName][card].Reason()
+	d.Health[nodeName][card] = health
+	if ni, ok := d.NodeMaps[nodeName]; ok && before != health.Reason() {
+		// cached plans may use a card which became unhealthy
+		ni.cleanPlan()
+	}
+}
+
+// GetUnhealthyCards maps the unhealthy cards of a node to the reason.
+func (d *DealerImpl) GetUnhealthyCards(nodeName string) map[int]string {
+	ans := make(map[int]string)
+	for card, health := range d.Health[nodeName] {
+		if reason := health.Reason(); reason != "" {
+			ans[card] = reason
+		}
+	}
+	return ans
+}
+
+func (d *DealerImpl) GetUnhealthyCardsLock(nodeName string) map[int]string {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	return d.GetUnhealthyCards(nodeName)
+}
+
+// unhealthy reports the unhealthy cards of every node for Status.
+func (d *DealerImpl) unhealthy() map[string]map[int]string {
+	ans := make(map[string]map[int]string)
+	for name := range d.Health {
+		if cards := d.GetUnhealthyCards(name); len(cards) > 0 {
+			ans[name] = cards
+		}
+	}
+	return ans
+}
+
+// WithoutCards returns a copy of the gpus where the given cards have no free share,
+// so that no new plan uses them while their existing allocations stay accounted.
+func (g GPUs) WithoutCards(cards map[int]string) GPUs {
+	ans := g.Clone()
+	for card := range cards {
+		if card >= 0 && card < len(ans) {
+			ans[card].Percent = 0
+		}
+	}
+	return ans
+}
@@ -0,0 +1,41 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGPUHealthReason(t *testing.T) {
+	assert.Equal(t, "", GPUHealth{}.Reason())
+	// application errors don't fail the card
+	assert.Equal(t, "", GPUHealth{XID: 13}.Reason())
+	assert.Equal(t, "xid 79: fallen off the bus", GPUHealth{XID: 79}.Reason())
+	assert.Equal(t, "2 double bit ecc errors, 50 retired pages", GPUHealth{DoubleBitECC: 2, RetiredPages: 50}.Reason())
+}
+
+func TestUnhealthyCardsExcluded(t *testing.T) {
+	d := MockDealer(MockNode("n1", 2))
+	ni := NewNodeInfo("n1", MockNode("n1", 2), d.Rater)
+	ni.GPUs[0].Percent = 50
+	d.NodeMaps["n1"] = ni
+
+	pod := MockQuotaPod("a", "p0", 40)
+	demand := NewDemandFromPod(pod)
+	assumed, _ := ni.Assume(demand, pod, d, PolicySpec{}, false)
+	assert.True(t, assumed)
+	assert.Equal(t, []int{0}, ni.PlanCache[planKey(demand, pod)].GPUIndexes)
+
+	d.UpdateHealth("n1", 0, GPUHealth{DoubleBitECC: 1})
+	assert.Equal(t, 0, len(ni.PlanCache))
+	assumed, _ = ni.Assume(demand, pod, d, PolicySpec{}, false)
+	assert.True(t, assumed)
+	assert.Equal(t, []int{1}, ni.PlanCache[planKey(demand, pod)].GPUIndexes)
+	// allocations on the unhealthy card stay accounted
+	assert.Equal(t, 50, ni.GPUs[0].Percent)
+
+	d.UpdateHealth("n1", 1, GPUHealth{XID: 79})
+	assumed, _ = ni.Assume(demand, pod, d, PolicySpec{}, false)
+	assert.False(t, assumed)
+	assert.Equal(t, map[string]map[int]string{"n1": {0: "1 double bit ecc errors", 1: "xid 79: fallen off the bus"}}, d.unhealthy())
+}
@@ -0,0 +1,41 @@
+package dealer
+
+import "sort"
+
+// HeatmapCell is the allocated percent of a card with its measured usage in
+// [0, 1], usage is absent when the card never reported it.
+type HeatmapCell struct {
+	Allocated int      `json:"allocated"`
+	Core      *float64 `json:"core,omitempty"`
+	Memory    *float64 `json:"memory,omitempty"`
+}
+
+// HeatmapRow holds the cards of a node by index.
+type HeatmapRow struct {
+	Node  string        `json:"node"`
+	Cards []HeatmapCell `json:"cards"`
+}
+
+// Heatmap returns a row by node of the known nodes sorted by name.
+func (d *DealerImpl) Heatmap() []HeatmapRow {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	rows := make([]HeatmapRow, 0, len(d.NodeMaps))
+	for name, ni := range d.NodeMaps {
+		row := HeatmapRow{Node: name, Cards: make([]HeatmapCell, len(ni.GPUs))}
+		for i, g := range ni.GPUs {
+			row.Cards[i].Allocated = g.PercentTotal - g.Percent
+			if sample, ok := d.GetUsageSample(name, GPUCoreUsagePriority, i); ok {
+				usage := sample.Usage
+				row.Cards[i].Core = &usage
+			}
+			if sample, ok := d.GetUsageSample(name, GPUMemoryUsagePriority, i); ok {
+				usage := sample.Usage
+				row.Cards[i].Memory = &usage
+			}
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Node < rows[j].Node })
+	return rows
+}
@@ -0,0 +1,27 @@
+package dealer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeatmap(t *testing.T) {
+	d := MockDealer()
+	d.NodeMaps["n2"] = NewNodeInfo("n2", MockNode("n2", 1), d.Rater)
+	d.NodeMaps["n1"] = NewNodeInfo("n1", MockNode("n1", 2), d.Rater)
+	d.NodeMaps["n1"].GPUs[1].Percent = 40
+	now := time.Now().In(loc).Format(timeFormat)
+	d.UpdateCoreUsage("n1", "0.5", now, 1)
+
+	rows := d.Heatmap()
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "n1", rows[0].Node)
+	assert.Equal(t, 0, rows[0].Cards[0].Allocated)
+	assert.Nil(t, rows[0].Cards[0].Core)
+	assert.Equal(t, 60, rows[0].Cards[1].Allocated)
+	assert.Equal(t, 0.5, *rows[0].Cards[1].Core)
+	assert.Nil(t, rows[0].Cards[1].Memory)
+	assert.Len(t, rows[1].Cards, 1)
+}
@@ -0,0 +1,107 @@
+package dealer
+
+import (
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// SchedulingHintTTL is how long the cards of a node a pod failed on are trusted to
+// fail it again while the node doesn't change, 0 disables the hints. The usage
+// moves without the node changing, so load aware scheduling never uses them.
+var SchedulingHintTTL time.Duration
+
+// HintStats counts the card plans skipped by the hints.
+type HintStats struct {
+	Skipped int
+}
+
+// schedulingHint carries what a pod failed on from one filtering to the next, the
+// cards of unchanged nodes aren't planned again.
+type schedulingHint struct {
+	Demand   string
+	Failed   map[string]failedNode
+	LastSeen time.Time
+}
+
+// failedNode is a node the pod found no cards on, in the state it had then.
+type failedNode struct {
+	State   string
+	Err     error
+	Expires time.Time
+}
+
+// nodeState renders what the cards of the node offer the pod, a node in the same
+// state plans the same.
+func (d *DealerImpl) nodeState(ni *NodeInfo, pod *v1.Pod) string {
+	return fmt.Sprintf("%s|%v|%v|%d|%d", ni.GPUs, d.ExcludedCards(ni.Name, pod), d.SoonFreeShare(ni.Name), ni.SystemReserved, ni.Capacity)
+}
+
+// applyHints fails the nodes the pod failed on before in the same state, it returns
+// the states of the nodes and which ones were skipped.
+func (d *DealerImpl) applyHints(pod *v1.Pod, demand Demand, nodeInfos []*NodeInfo, ans []bool, res []error, isLoadSchedule bool) ([]string, []bool) {
+	if SchedulingHintTTL <= 0 || isLoadSchedule {
+		return nil, nil
+	}
+	now := time.Now()
+	states := make([]string, len(nodeInfos))
+	skipped := make([]bool, len(nodeInfos))
+	hint, ok := d.Hints[pod.UID]
+	if ok && hint.Demand != demand.Hash() {
+		ok = false
+	}
+	for i, ni := range nodeInfos {
+		if ni == nil {
+			continue
+		}
+		states[i] = d.nodeState(ni, pod)
+		if !ok {
+			continue
+		}
+		if f, failed := hint.Failed[ni.Name]; failed && f.State == states[i] && now.Before(f.Expires) {
+			skipped[i] = true
+			ans[i], res[i] = false, f.Err
+			d.HintStats.Skipped++
+		}
+	}
+	return states, skipped
+}
+
+// recordHints remembers the nodes the pod failed on, the hint is dropped once the
+// pod fits on a node.
+func (d *DealerImpl) recordHints(pod *v1.Pod, demand Demand, nodeInfos []*NodeInfo, ans []bool, res []error, states []string, skipped []bool) {
+	if states == nil {
+		return
+	}
+	now := time.Now()
+	for uid, h := range d.Hints {
+		if now.Sub(h.LastSeen) > pendingPodExpiration {
+			delete(d.Hints, uid)
+		}
+	}
+	for _, assumed := range ans {
+		if assumed {
+			delete(d.Hints, pod.UID)
+			return
+		}
+	}
+	hint := &schedulingHint{Demand: demand.Hash(), Failed: make(map[string]failedNode), LastSeen: now}
+	old := d.Hints[pod.UID]
+	for i, ni := range nodeInfos {
+		switch {
+		case ni == nil || res[i] == nil:
+		case skipped[i]:
+			hint.Failed[ni.Name] = old.Failed[ni.Name]
+		default:
+			hint.Failed[ni.Name] = failedNode{State: states[i], Err: res[i], Expires: now.Add(SchedulingHintTTL)}
+		}
+	}
+	d.Hints[pod.UID] = hint
+}
+
+func (d *DealerImpl) HintStatus() HintStats {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	return d.HintStats
+}
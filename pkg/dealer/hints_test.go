@@ -0,0 +1,53 @@
+package dealer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedulingHints(t *testing.T) {
+	SchedulingHintTTL = time.Minute
+	defer func() { SchedulingHintTTL = 0 }()
+	node := MockNode("n1", 1)
+	d := MockDealer(node)
+	d.NodeMaps["n1"] = NewNodeInfo("n1", node, d.Rater)
+	running := &Plan{Demand: Demand{{Percent: 70}}, GPUIndexes: []int{0}}
+	assert.NoError(t, d.NodeMaps["n1"].Allocate(running))
+
+	pod := MockQuotaPod("a", "p0", 90)
+	ans, errs := d.Assume([]string{"n1"}, pod, PolicySpec{}, false)
+	assert.Equal(t, []bool{false}, ans)
+	assert.Equal(t, 0, d.HintStatus().Skipped)
+
+	// the unchanged node fails the pod without planning its cards
+	ans, hinted := d.Assume([]string{"n1"}, pod, PolicySpec{}, false)
+	assert.Equal(t, []bool{false}, ans)
+	assert.Equal(t, errs[0].Error(), hinted[0].Error())
+	assert.Equal(t, 1, d.HintStatus().Skipped)
+
+	// a changed node is planned again
+	assert.NoError(t, d.NodeMaps["n1"].Release(running))
+	ans, _ = d.Assume([]string{"n1"}, pod, PolicySpec{}, false)
+	assert.Equal(t, []bool{true}, ans)
+	assert.Equal(t, 1, d.HintStatus().Skipped)
+	assert.Empty(t, d.Hints)
+}
+
+func TestSchedulingHintsSkipLoadSchedule(t *testing.T) {
+	SchedulingHintTTL = time.Minute
+	defer func() { SchedulingHintTTL = 0 }()
+	node := MockNode("n1", 1)
+	d := MockDealer(node)
+	d.NodeMaps["n1"] = NewNodeInfo("n1", node, d.Rater)
+	assert.NoError(t, d.NodeMaps["n1"].Allocate(&Plan{Demand: Demand{{Percent: 70}}, GPUIndexes: []int{0}}))
+
+	pod := MockQuotaPod("a", "p0", 90)
+	for i := 0; i < 2; i++ {
+		ans, _ := d.Assume([]string{"n1"}, pod, PolicySpec{}, true)
+		assert.Equal(t, []bool{false}, ans)
+	}
+	assert.Equal(t, 0, d.HintStatus().Skipped)
+	assert.Empty(t, d.Hints)
+}
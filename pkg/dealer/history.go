@@ -0,0 +1,143 @@
+package dealer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// UsageHistorySize is the number of usage samples kept by card and metric, 0
+// disables the history.
+var UsageHistorySize int
+
+// UsageSample is a usage reported for a card.
+type UsageSample struct {
+	Time  time.Time `json:"time"`
+	Usage float64   `json:"usage"`
+}
+
+// usageRing keeps the latest samples of a card, oldest first once full.
+type usageRing struct {
+	samples []UsageSample
+	next    int
+}
+
+func (r *usageRing) add(s UsageSample) {
+	if len(r.samples) < UsageHistorySize {
+		r.samples = append(r.samples, s)
+		return
+	}
+	r.samples[r.next%len(r.samples)] = s
+	r.next = (r.next + 1) % len(r.samples)
+}
+
+// since returns the samples taken after the time in order.
+func (r *usageRing) since(from time.Time) []UsageSample {
+	ans := make([]UsageSample, 0)
+	for i := range r.samples {
+		s := r.samples[(r.next+i)%len(r.samples)]
+		if s.Time.After(from) {
+			ans = append(ans, s)
+		}
+	}
+	return ans
+}
+
+// UsageHistory summarizes the samples of a card within a window.
+type UsageHistory struct {
+	Card    int           `json:"card"`
+	Count   int           `json:"count"`
+	Avg     float64       `json:"avg"`
+	Max     float64       `json:"max"`
+	P95     float64       `json:"p95"`
+	Samples []UsageSample `json:"samples,omitempty"`
+}
+
+func historyKey(nodeName, metric string) string {
+	return nodeName + "/" + metric
+}
+
+// recordHistory takes a reported usage into the history, malformed values are
+// dropped.
+func (d *DealerImpl) recordHistory(nodeName, metric string, card int, usage string) {
+	if UsageHistorySize <= 0 {
+		return
+	}
+	value, err := strconv.ParseFloat(usage, 64)
+	if err != nil || value < 0 || value > 1 {
+		return
+	}
+	key := historyKey(nodeName, metric)
+	if d.History[key] == nil {
+		d.History[key] = make(map[int]*usageRing)
+	}
+	ring, ok := d.History[key][card]
+	if !ok {
+		ring = &usageRing{}
+		d.History[key][card] = ring
+	}
+	ring.add(UsageSample{Time: time.Now(), Usage: value})
+}
+
+// UsageQuantile returns the quantile in [0, 1] of the usage of a card within the
+// window, false without samples.
+func (d *DealerImpl) UsageQuantile(nodeName, metric string, card int, window time.Duration, q float64) (float64, bool) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	ring, ok := d.History[historyKey(nodeName, metric)][card]
+	if !ok {
+		return 0, false
+	}
+	samples := ring.since(time.Now().Add(-window))
+	if len(samples) == 0 {
+		return 0, false
+	}
+	return quantile(samples, q), true
+}
+
+// UsageHistory returns the history of every card of the node within the window.
+func (d *DealerImpl) UsageHistory(nodeName, metric string, window time.Duration, withSamples bool) ([]UsageHistory, error) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	cards, ok := d.History[historyKey(nodeName, metric)]
+	if !ok {
+		return nil, fmt.Errorf("no %s history of node %s", metric, nodeName)
+	}
+	from := time.Now().Add(-window)
+	ans := make([]UsageHistory, 0, len(cards))
+	for card, ring := range cards {
+		samples := ring.since(from)
+		h := UsageHistory{Card: card, Count: len(samples)}
+		if len(samples) > 0 {
+			sum := 0.0
+			for _, s := range samples {
+				sum += s.Usage
+				h.Max = math.Max(h.Max, s.Usage)
+			}
+			h.Avg = sum / float64(len(samples))
+			h.P95 = quantile(samples, 0.95)
+		}
+		if withSamples {
+			h.Samples = samples
+		}
+		ans = append(ans, h)
+	}
+	sort.Slice(ans, func(i, j int) bool { return ans[i].Card < ans[j].Card })
+	return ans, nil
+}
+
+// quantile returns the nearest-rank quantile of the samples.
+func quantile(samples []UsageSample, q float64) float64 {
+	values := make([]float64, len(samples))
+	for i, s := range samples {
+		values[i] = s.Usage
+	}
+	sort.Float64s(values)
+	rank := int(math.Ceil(q*float64(len(values)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return values[rank]
+}
@@ -0,0 +1,43 @@
+package dealer
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsageHistory(t *testing.T) {
+	defer func(size int) { UsageHistorySize = size }(UsageHistorySize)
+	UsageHistorySize = 20
+
+	d := MockDealer(MockNode("n1", 2))
+	d.AddCoreUsage("n1")
+	now := time.Now().In(loc).Format(timeFormat)
+	for i := 1; i <= 30; i++ {
+		d.UpdateCoreUsage("n1", strconv.FormatFloat(float64(i)/100, 'f', 2, 64), now, 0)
+	}
+	d.UpdateCoreUsage("n1", "bad", now, 1)
+	d.UpdateCoreUsage("n1", "0.5", now, 1)
+
+	history, err := d.UsageHistory("n1", GPUCoreUsagePriority, time.Hour, true)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, 20, history[0].Count)
+	assert.Equal(t, 0.11, history[0].Samples[0].Usage)
+	assert.Equal(t, 0.30, history[0].Samples[19].Usage)
+	assert.InDelta(t, 0.205, history[0].Avg, 1e-9)
+	assert.Equal(t, 0.30, history[0].Max)
+	assert.Equal(t, 0.29, history[0].P95)
+	assert.Equal(t, 1, history[1].Count)
+
+	p50, ok := d.UsageQuantile("n1", GPUCoreUsagePriority, 0, time.Hour, 0.5)
+	assert.True(t, ok)
+	assert.Equal(t, 0.20, p50)
+	_, ok = d.UsageQuantile("n1", GPUCoreUsagePriority, 0, 0, 0.5)
+	assert.False(t, ok)
+
+	_, err = d.UsageHistory("n1", GPUMemoryUsagePriority, time.Hour, false)
+	assert.Error(t, err)
+}
@@ -0,0 +1,158 @@
+package dealer
+
+import (
+	"strings"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/accelerator"
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+	log "k8s.io/klog/v2"
+)
+
+const reasonRemoved = "removed from node"
+
+// UpdateNode adds a new gpu node to the cache, so that filtering and scoring never
+// build it, and follows label changes and gpu hot-plug on a known node. Added cards
+// are available right away, removed cards take no new plans and are dropped once the
+// pods on them are released. Nodes leaving the shard of the scheduler are dropped.
+func (d *DealerImpl) UpdateNode(node *v1.Node) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	ni, ok := d.NodeMaps[node.Name]
+	if ok && !OwnsNode(node) {
+		log.Infof("node %s leaves the shard", node.Name)
+		d.deleteNode(node.Name)
+		return
+	}
+	if !ok {
+		if utils.GetGPUDeviceCountOfNode(node) == 0 || !OwnsNode(node) {
+			return
+		}
+		if _, err := d.addNode(node); err != nil {
+			log.Errorf("add node %s failed: %v", node.Name, err)
+		}
+		return
+	}
+	ni.Pool = node.Labels[schetypes.LabelGPUPool]
+	ni.Labels = node.Labels
+	ni.Vendor = accelerator.VendorOfNode(node)
+	ni.Attributes = cardAttributesOf(node)
+	ni.SystemReserved = systemReservedOf(node, ni.Attributes)
+	ni.Excluded = excludedOfNode(node)
+	ni.MPS = IsMPSNode(node)
+	ni.Profiles = vgpuProfilesOfNode(node)
+	ni.MIG = migOfNode(node)
+	d.remapUUIDs(ni, uuidsOfNode(node))
+	if count := utils.GetGPUDeviceCountOfNode(node); count != ni.Capacity {
+		log.Infof("gpu count of node %s changes from %d to %d", node.Name, ni.Capacity, count)
+		ni.Resize(count)
+	}
+	ni.cleanPlan()
+}
+
+// DeleteNode drops the node and the pods accounted on it, the pods are gone with the
+// node.
+func (d *DealerImpl) DeleteNode(name string) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	d.deleteNode(name)
+}
+
+func (d *DealerImpl) deleteNode(name string) {
+	if _, ok := d.Restored[name]; ok {
+		delete(d.Restored, name)
+		d.warm = len(d.Restored) > 0
+	}
+	if _, ok := d.NodeMaps[name]; !ok {
+		return
+	}
+	for uid, pod := range d.PodMaps {
+		if pod.Spec.NodeName == name {
+			delete(d.PodMaps, uid)
+			delete(d.AntiAffinity, uid)
+			delete(d.Unconfirmed, uid)
+			delete(d.Terminating, uid)
+		}
+	}
+	delete(d.NodeMaps, name)
+	delete(d.CoreUsage, name)
+	delete(d.MemoryUsage, name)
+	for _, nodes := range d.MetricUsage {
+		delete(nodes, name)
+	}
+	delete(d.Pushes, name)
+	delete(d.CoreHistory, name)
+	delete(d.Health, name)
+	delete(d.Allocations, name)
+	d.dropUsageFilters(name)
+	prefix := historyKey(name, "")
+	for k := range d.History {
+		if strings.HasPrefix(k, prefix) {
+			delete(d.History, k)
+		}
+	}
+	d.usageCacheLock.Lock()
+	for k := range d.UsageCache {
+		if strings.HasPrefix(k, prefix) {
+			delete(d.UsageCache, k)
+		}
+	}
+	d.usageCacheLock.Unlock()
+	for _, h := range d.Hints {
+		delete(h.Failed, name)
+	}
+	d.dropGangReservations(name)
+	log.Infof("node %s is dropped", name)
+}
+
+func (ni *NodeInfo) Resize(count int) {
+	ni.Capacity = count
+	ni.ensureCards(count)
+	ni.trim()
+	ni.cleanPlan()
+}
+
+// ensureCards grows the card list to n idle cards, cards beyond the capacity are
+// only kept to account the plans still using them.
+func (ni *NodeInfo) ensureCards(n int) {
+	for len(ni.GPUs) < n {
+		ni.GPUs = append(ni.GPUs, &GPUResource{
+			Percent:      schetypes.GPUPercentEachCard,
+			PercentTotal: schetypes.GPUPercentEachCard,
+		})
+	}
+}
+
+// trim drops the idle trailing cards beyond the capacity.
+func (ni *NodeInfo) trim() {
+	n := len(ni.GPUs)
+	for n > ni.Capacity && ni.GPUs[n-1].Percent == ni.GPUs[n-1].PercentTotal {
+		n--
+	}
+	ni.GPUs = ni.GPUs[:n]
+}
+
+// removedCards are the cards still accounted but no longer advertised by the node.
+func (ni *NodeInfo) removedCards() map[int]string {
+	ans := make(map[int]string)
+	for i := ni.Capacity; i < len(ni.GPUs); i++ {
+		ans[i] = reasonRemoved
+	}
+	return ans
+}
+
+// planCards returns the number of cards the plan needs to be applied.
+func planCards(plan *Plan) int {
+	n := 0
+	for _, idx := range plan.GPUIndexes {
+		if idx+1 > n {
+			n = idx + 1
+		}
+	}
+	if plan.Init != nil && plan.Init.Index+1 > n {
+		n = plan.Init.Index + 1
+	}
+	return n
+}
@@ -0,0 +1,101 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+)
+
+func TestUpdateNodeResize(t *testing.T) {
+	d := MockDealer(MockNode("n1", 2))
+	ni := NewNodeInfo("n1", MockNode("n1", 2), d.Rater)
+	d.NodeMaps["n1"] = ni
+	plan := &Plan{Demand: Demand{{Percent: 60}}, GPUIndexes: []int{1}}
+	assert.Nil(t, ni.Allocate(plan))
+
+	// hot-plug
+	d.UpdateNode(MockNode("n1", 4))
+	assert.Equal(t, 4, ni.Capacity)
+	assert.Equal(t, 4, len(ni.GPUs))
+
+	// hot-remove keeps the busy card accounted but out of new plans
+	d.UpdateNode(MockNode("n1", 1))
+	assert.Equal(t, 2, len(ni.GPUs))
+	pod := MockQuotaPod("a", "p0", 30)
+	demand := NewDemandFromPod(pod)
+	assumed, _ := ni.Assume(demand, pod, d, PolicySpec{}, false)
+	assert.True(t, assumed)
+	assert.Equal(t, []int{0}, ni.PlanCache[planKey(demand, pod)].GPUIndexes)
+
+	// the removed card is dropped once released
+	assert.Nil(t, ni.Release(plan))
+	assert.Equal(t, 1, len(ni.GPUs))
+
+	// pods found on a card the node no longer advertises are still accounted
+	assert.Nil(t, ni.Allocate(&Plan{Demand: Demand{{Percent: 20}}, GPUIndexes: []int{2}}))
+	assert.Equal(t, 3, len(ni.GPUs))
+	assert.Equal(t, 80, ni.GPUs[2].Percent)
+}
+
+func TestUpdateNodeAddsAndDeleteNodeDrops(t *testing.T) {
+	d := MockDealer()
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	d.PodLister = corelisters.NewPodLister(indexer)
+	pod := utils.GetUpdatedPodAnnotationSpec(MockQuotaPod("a", "p0", 40), []int{1})
+	pod.Spec.NodeName = "n1"
+	assert.NoError(t, indexer.Add(pod))
+
+	// nodes without gpus are not cached
+	d.UpdateNode(MockNode("cpu", 0))
+	assert.NotContains(t, d.NodeMaps, "cpu")
+
+	// a new gpu node is built with its pods ahead of scheduling
+	d.UpdateNode(MockNode("n1", 2))
+	ni := d.NodeMaps["n1"]
+	assert.NotNil(t, ni)
+	assert.Equal(t, 60, ni.GPUs[1].Percent)
+	assert.Contains(t, d.PodMaps, pod.UID)
+	got, err := d.getNodeInfo("n1")
+	assert.NoError(t, err)
+	assert.Same(t, ni, got)
+
+	// what is kept by node goes with it
+	d.cacheUsage("n1", "gpu_core_usage", 0, "10", "0")
+	d.History[historyKey("n1", "gpu_core_usage")] = map[int]*usageRing{}
+	d.Pushes["n1"] = pushState{seq: 1}
+	d.Hints["a/p1"] = &schedulingHint{Failed: map[string]failedNode{"n1": {}}}
+	d.Gangs["a/g"] = &gang{Members: map[k8stypes.UID]*gangReservation{"a/p2": {Node: "n1", Plan: &Plan{}}}}
+	d.recordCoreUsage("n1", 0, "0.5")
+	d.smoothUsage("gpu_core_usage", "n1", 0, "0.5")
+	d.smoothUsage("gpu_core_usage", "n10", 0, "0.5")
+	d.Health["n1"] = map[int]GPUHealth{0: {}}
+	d.Allocations["n1"] = map[int][]AllocationEvent{}
+	d.MetricUsage["gpu_core_usage"] = map[string]map[int]GPUUsage{"n1": {}}
+
+	d.DeleteNode("n1")
+	assert.NotContains(t, d.NodeMaps, "n1")
+	assert.NotContains(t, d.PodMaps, pod.UID)
+	assert.Empty(t, d.UsageCache)
+	assert.Empty(t, d.History)
+	assert.Empty(t, d.Pushes)
+	assert.Empty(t, d.Hints["a/p1"].Failed)
+	assert.Empty(t, d.Gangs)
+	assert.Empty(t, d.CoreHistory)
+	assert.Equal(t, []string{"gpu_core_usage/n10/0"}, keysOfFilters(d))
+	assert.Empty(t, d.Health)
+	assert.Empty(t, d.Allocations)
+	assert.Empty(t, d.MetricUsage["gpu_core_usage"])
+}
+
+func keysOfFilters(d *DealerImpl) []string {
+	keys := make([]string, 0, len(d.UsageFilters))
+	for k := range d.UsageFilters {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,84 @@
+package dealer
+
+import (
+	"sort"
+	"time"
+)
+
+// HotCard is a card with its last measured usage and the pods placed on it.
+type HotCard struct {
+	Node       string    `json:"node"`
+	Card       int       `json:"card"`
+	Usage      float64   `json:"usage"`
+	UpdateTime time.Time `json:"updateTime"`
+	Pods       []string  `json:"pods"`
+}
+
+// HottestCards returns the k cards of the cluster with the highest measured usage
+// of the metric, the usage may be stale so the report time is part of the result.
+func (d *DealerImpl) HottestCards(metric string, k int) []HotCard {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	cards := make([]HotCard, 0)
+	for nodeName, n := range d.measuredCards(metric) {
+		for card := 0; card < n; card++ {
+			sample, ok := d.GetUsageSample(nodeName, metric, card)
+			if !ok {
+				continue
+			}
+			cards = append(cards, HotCard{Node: nodeName, Card: card, Usage: sample.Usage, UpdateTime: sample.Time})
+		}
+	}
+	sort.Slice(cards, func(i, j int) bool {
+		if cards[i].Usage != cards[j].Usage {
+			return cards[i].Usage > cards[j].Usage
+		}
+		if cards[i].Node != cards[j].Node {
+			return cards[i].Node < cards[j].Node
+		}
+		return cards[i].Card < cards[j].Card
+	})
+	if k >= 0 && len(cards) > k {
+		cards = cards[:k]
+	}
+	for i := range cards {
+		cards[i].Pods = make([]string, 0)
+		for _, pod := range d.podsOnCard(cards[i].Node, cards[i].Card) {
+			cards[i].Pods = append(cards[i].Pods, pod.Namespace+"/"+pod.Name)
+		}
+		sort.Strings(cards[i].Pods)
+	}
+	return cards
+}
+
+// measuredCards returns the nodes which reported the metric with one past their
+// highest reported card.
+func (d *DealerImpl) measuredCards(metric string) map[string]int {
+	ans := make(map[string]int)
+	add := func(nodeName string, card int) {
+		if card+1 > ans[nodeName] {
+			ans[nodeName] = card + 1
+		}
+	}
+	switch {
+	case metric == GPUCoreUsagePriority:
+		for nodeName, cards := range d.CoreUsage {
+			for card := range cards {
+				add(nodeName, card)
+			}
+		}
+	case IsPressureMetric(metric):
+		for nodeName, cards := range d.MetricUsage[metric] {
+			for card := range cards {
+				add(nodeName, card)
+			}
+		}
+	default:
+		for nodeName, cards := range d.MemoryUsage {
+			for card := range cards {
+				add(nodeName, card)
+			}
+		}
+	}
+	return ans
+}
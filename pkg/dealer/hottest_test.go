@@ -0,0 +1,36 @@
+package dealer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHottestCards(t *testing.T) {
+	node := MockNode("n1", 2)
+	d := MockDealer(node, MockNode("n2", 1))
+	d.NodeMaps["n1"] = NewNodeInfo("n1", node, d.Rater)
+	pod := MockQuotaPod("a", "p0", 30)
+	pod.Spec.NodeName = "n1"
+	pod = utils.GetUpdatedPodAnnotationSpec(pod, []int{1})
+	assert.Nil(t, d.Allocate(pod))
+
+	now := time.Now().In(loc).Format(timeFormat)
+	d.UpdateCoreUsage("n1", "0.2", now, 0)
+	d.UpdateCoreUsage("n1", "0.9", now, 1)
+	d.UpdateCoreUsage("n2", "0.5", now, 0)
+	d.UpdateMemoryUsage("n2", "0.1", now, 0)
+
+	hot := d.HottestCards(GPUCoreUsagePriority, 2)
+	assert.Len(t, hot, 2)
+	assert.Equal(t, "n1", hot[0].Node)
+	assert.Equal(t, 1, hot[0].Card)
+	assert.Equal(t, 0.9, hot[0].Usage)
+	assert.Equal(t, []string{"a/p0"}, hot[0].Pods)
+	assert.Equal(t, "n2", hot[1].Node)
+	assert.Empty(t, hot[1].Pods)
+
+	assert.Len(t, d.HottestCards(GPUMemoryUsagePriority, 10), 1)
+}
@@ -0,0 +1,114 @@
+package dealer
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/accelerator"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// InitPlan places the init containers of a pod. They run one at a time before the
+// containers start, so like kube-scheduler does for other resources the pod needs
+// max(init, containers) on the card rather than the sum.
+type InitPlan struct {
+	// Percent is the share of the largest init container.
+	Percent int
+	// Index is the card every init container runs on.
+	Index int
+	// Extra is the share taken on top of the containers on that card, it is given
+	// back once the init containers are done.
+	Extra int
+}
+
+// placeInit chooses the card which needs the least share on top of the plan.
+func (g GPUs) placeInit(plan *Plan, percent int) error {
+	onCard := make(map[int]int)
+	for i, idx := range plan.GPUIndexes {
+		if idx >= 0 {
+			onCard[idx] += plan.Demand[i].Percent
+		}
+	}
+	best := -1
+	for i := range g {
+		extra := percent - onCard[i]
+		if extra < 0 {
+			extra = 0
+		}
+		if g[i].Percent-onCard[i] < extra {
+			continue
+		}
+		if best < 0 || extra < plan.Init.Extra {
+			best = i
+			plan.Init = &InitPlan{Percent: percent, Index: i, Extra: extra}
+		}
+	}
+	if best < 0 {
+		plan.Init = nil
+		return fmt.Errorf("can't place init containers of %d on %s", percent, g)
+	}
+	return nil
+}
+
+// initPlanFromPod restores the placement of the init containers, nil when they are
+// done or don't use gpu.
+func initPlanFromPod(pod *v1.Pod, plan *Plan) *InitPlan {
+	percent := utils.GetGPUPercentFromInitContainers(pod)
+	if percent == 0 || utils.IsInitialized(pod) {
+		return nil
+	}
+	a := accelerator.ForPod(pod)
+	for _, c := range pod.Spec.InitContainers {
+		if utils.GetGPUPercentFromContainer(&c) == 0 {
+			continue
+		}
+		idx, err := strconv.Atoi(pod.Annotations[a.ContainerAnnotation(c.Name)])
+		if err != nil || idx < 0 {
+			return nil
+		}
+		extra := percent
+		for i, card := range plan.GPUIndexes {
+			if card == idx {
+				extra -= plan.Demand[i].Percent
+			}
+		}
+		if extra < 0 {
+			extra = 0
+		}
+		return &InitPlan{Percent: percent, Index: idx, Extra: extra}
+	}
+	return nil
+}
+
+// annotateInit tells the device plugin the card of the init containers.
+func annotateInit(pod *v1.Pod, plan *Plan) {
+	if plan.Init == nil {
+		return
+	}
+	a := accelerator.ForPod(pod)
+	for _, c := range pod.Spec.InitContainers {
+		if utils.GetGPUPercentFromContainer(&c) > 0 {
+			pod.Annotations[a.ContainerAnnotation(c.Name)] = strconv.Itoa(plan.Init.Index)
+		}
+	}
+}
+
+// releaseInit gives back the init-only share of a known pod whose init containers
+// are done, the known pod takes the status of the pod.
+func (d *DealerImpl) releaseInit(ni *NodeInfo, pod *v1.Pod) error {
+	known := d.PodMaps[pod.UID]
+	plan, err := NewPlanFromPod(known)
+	if err != nil || plan.Init == nil || !utils.IsInitialized(pod) {
+		return err
+	}
+	if plan.Init.Extra > 0 && plan.Init.Index < len(ni.GPUs) {
+		ni.cleanPlan()
+		ni.GPUs[plan.Init.Index].Percent += plan.Init.Extra
+	}
+	known = known.DeepCopy()
+	known.Status = pod.Status
+	d.PodMaps[pod.UID] = known
+	return nil
+}
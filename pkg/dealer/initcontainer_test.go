@@ -0,0 +1,65 @@
+package dealer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func MockInitPod(name string, percent, initPercent int) *v1.Pod {
+	pod := MockQuotaPod("a", name, percent)
+	pod.Spec.NodeName = "n1"
+	pod.Spec.Containers[0].Name = "main"
+	pod.Spec.InitContainers = []v1.Container{{
+		Name: "convert",
+		Resources: v1.ResourceRequirements{
+			Limits: v1.ResourceList{types.ResourceGPUPercent: *resource.NewQuantity(int64(initPercent), resource.DecimalSI)},
+		},
+	}}
+	return pod
+}
+
+func TestInitContainers(t *testing.T) {
+	node := MockNode("n1", 2)
+	d := MockDealer(node)
+	ni := NewNodeInfo("n1", node, d.Rater)
+	d.NodeMaps["n1"] = ni
+	assert.Nil(t, ni.Allocate(&Plan{Demand: Demand{{Percent: 60}}, GPUIndexes: []int{0}}))
+
+	// the init container doesn't fit next to the container on card 0
+	pod := MockInitPod("p", 30, 80)
+	demand := NewDemandFromPod(pod)
+	assumed, err := ni.Assume(demand, pod, d, PolicySpec{}, false)
+	assert.True(t, assumed)
+	assert.Nil(t, err)
+	plan := ni.PlanCache[planKey(demand, pod)]
+	assert.Equal(t, []int{0}, plan.GPUIndexes)
+	assert.Equal(t, &InitPlan{Percent: 80, Index: 1, Extra: 80}, plan.Init)
+
+	bound := podWithPlan(pod, node, plan)
+	assert.Equal(t, "1", bound.Annotations[fmt.Sprintf(types.AnnotationGPUContainerOn, "convert")])
+	assert.Nil(t, d.Allocate(bound))
+	assert.Equal(t, 10, ni.GPUs[0].Percent)
+	assert.Equal(t, 20, ni.GPUs[1].Percent)
+
+	// the init-only share is given back once the pod is running
+	running := bound.DeepCopy()
+	running.Status.Phase = v1.PodRunning
+	assert.Nil(t, d.Allocate(running))
+	assert.Equal(t, 100, ni.GPUs[1].Percent)
+	assert.Nil(t, d.Release(running))
+	assert.Equal(t, 40, ni.GPUs[0].Percent)
+	assert.Equal(t, 100, ni.GPUs[1].Percent)
+
+	// an init container smaller than the container shares its card
+	small := MockInitPod("s", 30, 20)
+	demand = NewDemandFromPod(small)
+	assumed, _ = ni.Assume(demand, small, d, PolicySpec{}, false)
+	assert.True(t, assumed)
+	assert.Equal(t, &InitPlan{Percent: 20, Index: 0, Extra: 0}, ni.PlanCache[planKey(demand, small)].Init)
+}
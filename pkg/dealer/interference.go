@@ -0,0 +1,68 @@
+package dealer
+
+import (
+	"math"
+	"strconv"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// burstinessWindow is the number of core usage samples kept per card.
+const burstinessWindow = 20
+
+// InterferenceWeight scales the burstiness of the chosen cards into a score penalty
+// for latency sensitive pods, 0 disables interference aware scoring.
+var InterferenceWeight = 100
+
+func IsLatencySensitivePod(pod *v1.Pod) bool {
+	return pod.Annotations[schetypes.AnnotationLatencySensitive] == "true"
+}
+
+// recordCoreUsage keeps a sliding window of measured core usage of a card.
+func (d *DealerImpl) recordCoreUsage(nodeName string, card int, coreUsage string) {
+	value, err := strconv.ParseFloat(coreUsage, 64)
+	if err != nil || value < 0 || value > 1 {
+		return
+	}
+	if d.CoreHistory[nodeName] == nil {
+		d.CoreHistory[nodeName] = make(map[int][]float64)
+	}
+	samples := append(d.CoreHistory[nodeName][card], value)
+	if len(samples) > burstinessWindow {
+		samples = samples[len(samples)-burstinessWindow:]
+	}
+	d.CoreHistory[nodeName][card] = samples
+}
+
+// Burstiness is the standard deviation of the recent core usage of a card.
+func (d *DealerImpl) Burstiness(nodeName string, card int) float64 {
+	samples := d.CoreHistory[nodeName][card]
+	if len(samples) == 0 {
+		return 0
+	}
+	return math.Sqrt(Variance(samples))
+}
+
+// interferencePenalty keeps latency sensitive pods away from shared cards whose
+// residents show bursty utilization. Idle cards are never penalized.
+func (d *DealerImpl) interferencePenalty(pod *v1.Pod, ni *NodeInfo, demand Demand) int {
+	if InterferenceWeight == 0 || !IsLatencySensitivePod(pod) {
+		return 0
+	}
+	plan, ok := ni.PlanCache[planKey(demand, pod)]
+	if !ok {
+		return 0
+	}
+	worst := 0.0
+	for _, idx := range plan.GPUIndexes {
+		if idx < 0 || idx >= len(ni.GPUs) || ni.GPUs[idx].Percent == ni.GPUs[idx].PercentTotal {
+			continue
+		}
+		if b := d.Burstiness(ni.Name, idx); b > worst {
+			worst = b
+		}
+	}
+	return int(math.Round(worst * float64(InterferenceWeight)))
+}
@@ -0,0 +1,36 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestInterferencePenalty(t *testing.T) {
+	d := MockDealer(MockNode("n1", 2))
+	ni := NewNodeInfo("n1", MockNode("n1", 2), d.Rater)
+	ni.GPUs[0].Percent = 50
+	for i := 0; i < burstinessWindow+5; i++ {
+		d.recordCoreUsage("n1", 0, []string{"0.1", "0.9"}[i%2])
+		d.recordCoreUsage("n1", 1, "0.5")
+	}
+	assert.Equal(t, burstinessWindow, len(d.CoreHistory["n1"][0]))
+	assert.InDelta(t, 0.4, d.Burstiness("n1", 0), 1e-9)
+	assert.InDelta(t, 0.0, d.Burstiness("n1", 1), 1e-9)
+
+	demand := Demand{{Percent: 30}}
+	pod := MockQuotaPod("a", "p0", 30)
+	ni.PlanCache[planKey(demand, pod)] = &Plan{Demand: demand, GPUIndexes: []int{0}}
+	// not latency sensitive
+	assert.Equal(t, 0, d.interferencePenalty(pod, ni, demand))
+
+	pod.Annotations[types.AnnotationLatencySensitive] = "true"
+	assert.Equal(t, 40, d.interferencePenalty(pod, ni, demand))
+
+	// idle card has no neighbor to interfere with
+	d.recordCoreUsage("n1", 1, "0.9")
+	ni.PlanCache[planKey(demand, pod)] = &Plan{Demand: demand, GPUIndexes: []int{1}}
+	assert.Equal(t, 0, d.interferencePenalty(pod, ni, demand))
+}
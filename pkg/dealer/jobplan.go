@@ -0,0 +1,193 @@
+package dealer
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	log "k8s.io/klog/v2"
+)
+
+// jobPlanTTL bounds how long a job plan is followed, the replicas left are planned
+// again afterwards.
+const jobPlanTTL = time.Minute
+
+// jobPlan places all the pending replicas of a controller at once, every replica is
+// then only admitted on the cards planned for it.
+type jobPlan struct {
+	Assignments map[types.UID]*jobAssignment
+	Expires     time.Time
+}
+
+type jobAssignment struct {
+	Node       string
+	GPUIndexes []int
+}
+
+// jobOf returns the controller of a pod asking for whole job planning.
+func jobOf(pod *v1.Pod) (types.UID, bool) {
+	if pod.Annotations[schetypes.AnnotationJobPlanning] != "true" {
+		return "", false
+	}
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return "", false
+	}
+	return owner.UID, true
+}
+
+// pendingReplicas returns the unbound replicas of the controller of the pod, the pod
+// included, the largest first.
+func (d *DealerImpl) pendingReplicas(pod *v1.Pod, job types.UID) ([]*v1.Pod, error) {
+	pods, err := d.PodLister.Pods(pod.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	replicas := []*v1.Pod{pod}
+	for _, p := range pods {
+		if p.UID == pod.UID || p.Spec.NodeName != "" || utils.IsCompletedPod(p) || p.DeletionTimestamp != nil {
+			continue
+		}
+		if owner, ok := jobOf(p); ok && owner == job {
+			replicas = append(replicas, p)
+		}
+	}
+	sort.Slice(replicas, func(i, j int) bool {
+		pi, pj := utils.GetGPUPercentFromPodResource(replicas[i]), utils.GetGPUPercentFromPodResource(replicas[j])
+		if pi != pj {
+			return pi > pj
+		}
+		return replicas[i].Name < replicas[j].Name
+	})
+	return replicas, nil
+}
+
+// planJob places the replicas one after the other on the cards left by the ones
+// before, each on its best scored node. It fails unless every replica is placed, so
+// that no replica is bound when the last ones would be stranded.
+func (d *DealerImpl) planJob(replicas []*v1.Pod, nodeInfos []*NodeInfo, policySpec PolicySpec, isLoadSchedule bool) (*jobPlan, error) {
+	placed := make(map[string][]*Plan)
+	jp := &jobPlan{Assignments: make(map[types.UID]*jobAssignment), Expires: time.Now().Add(jobPlanTTL)}
+	for _, replica := range replicas {
+		demand := NewDemandFromPod(replica)
+		var best *Plan
+		var bestNode string
+		for _, ni := range nodeInfos {
+			if ni == nil {
+				continue
+			}
+			gpus := ni.schedulableGPUs(replica, d).Clone()
+			for _, p := range placed[ni.Name] {
+				_ = gpus.Allocate(p)
+			}
+			plan, err := gpus.Choose(demand, raterOf(replica, policySpec, ni.Rater), d, policySpec, ni.Name, isLoadSchedule)
+			if err != nil {
+				continue
+			}
+			if best == nil || plan.Score > best.Score {
+				best, bestNode = plan, ni.Name
+			}
+		}
+		if best == nil {
+			return nil, fmt.Errorf("the %d pending replicas of pod %s/%s don't fit at once, replica %s doesn't",
+				len(replicas), replicas[0].Namespace, replicas[0].Name, replica.Name)
+		}
+		placed[bestNode] = append(placed[bestNode], best)
+		jp.Assignments[replica.UID] = &jobAssignment{Node: bestNode, GPUIndexes: best.GPUIndexes}
+	}
+	return jp, nil
+}
+
+// applyJobPlan keeps a replica of a planned job to the node of its assignment, the
+// job is planned when the replica has no assignment yet.
+func (d *DealerImpl) applyJobPlan(pod *v1.Pod, nodeInfos []*NodeInfo, res []error, policySpec PolicySpec, isLoadSchedule bool) {
+	job, ok := jobOf(pod)
+	if !ok {
+		return
+	}
+	jp, ok := d.JobPlans[job]
+	if ok && (time.Now().After(jp.Expires) || jp.Assignments[pod.UID] == nil) {
+		delete(d.JobPlans, job)
+		ok = false
+	}
+	if !ok {
+		replicas, err := d.pendingReplicas(pod, job)
+		if err == nil {
+			jp, err = d.planJob(replicas, nodeInfos, policySpec, isLoadSchedule)
+		}
+		if err != nil {
+			for i := range nodeInfos {
+				if nodeInfos[i] != nil {
+					nodeInfos[i] = nil
+					res[i] = err
+				}
+			}
+			return
+		}
+		d.JobPlans[job] = jp
+		log.Infof("planned %d replicas of pod %s/%s at once", len(jp.Assignments), pod.Namespace, pod.Name)
+	}
+	node := jp.Assignments[pod.UID].Node
+	for i, ni := range nodeInfos {
+		if ni != nil && ni.Name != node {
+			nodeInfos[i] = nil
+			res[i] = fmt.Errorf("the job plan places pod %s/%s on node %s", pod.Namespace, pod.Name, node)
+		}
+	}
+}
+
+// jobPlanExcludedCards returns the cards other than the planned ones of a replica on
+// the node of its assignment.
+func (d *DealerImpl) jobPlanExcludedCards(nodeName string, pod *v1.Pod) map[int]string {
+	ans := make(map[int]string)
+	job, ok := jobOf(pod)
+	if !ok {
+		return ans
+	}
+	jp, ok := d.JobPlans[job]
+	if !ok {
+		return ans
+	}
+	a, ok := jp.Assignments[pod.UID]
+	if !ok || a.Node != nodeName {
+		return ans
+	}
+	ni, ok := d.NodeMaps[nodeName]
+	if !ok {
+		return ans
+	}
+	planned := make(map[int]bool)
+	for _, idx := range a.GPUIndexes {
+		planned[idx] = true
+	}
+	for card := range ni.GPUs {
+		if !planned[card] {
+			ans[card] = "not planned for the replica"
+		}
+	}
+	return ans
+}
+
+// forgetJobReplica drops the assignment of a bound replica, a plan a replica no longer
+// fits is dropped so that the job is planned again.
+func (d *DealerImpl) forgetJobReplica(pod *v1.Pod, stale bool) {
+	job, ok := jobOf(pod)
+	if !ok {
+		return
+	}
+	jp, ok := d.JobPlans[job]
+	if !ok {
+		return
+	}
+	delete(jp.Assignments, pod.UID)
+	if stale || len(jp.Assignments) == 0 {
+		delete(d.JobPlans, job)
+	}
+}
@@ -0,0 +1,50 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func mockReplica(name string, percent int) *v1.Pod {
+	pod := MockQuotaPod("a", name, percent)
+	pod.Annotations = map[string]string{schetypes.AnnotationJobPlanning: "true"}
+	controller := true
+	pod.OwnerReferences = []metav1.OwnerReference{{Kind: "Job", Name: "j", UID: "job", Controller: &controller}}
+	return pod
+}
+
+func TestPlanJob(t *testing.T) {
+	d := MockDealer(MockNode("n1", 1), MockNode("n2", 1))
+	n1 := NewNodeInfo("n1", MockNode("n1", 1), d.Rater)
+	n1.GPUs[0].Percent = 60
+	n2 := NewNodeInfo("n2", MockNode("n2", 1), d.Rater)
+	d.NodeMaps["n1"], d.NodeMaps["n2"] = n1, n2
+	nodeInfos := []*NodeInfo{n1, n2}
+
+	// the largest replica is placed first, on the 60 left on n1
+	replicas := []*v1.Pod{mockReplica("r0", 60), mockReplica("r1", 40)}
+	jp, err := d.planJob(replicas, nodeInfos, PolicySpec{}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "n1", jp.Assignments[replicas[0].UID].Node)
+	assert.Equal(t, "n2", jp.Assignments[replicas[1].UID].Node)
+
+	_, err = d.planJob([]*v1.Pod{mockReplica("r0", 80), mockReplica("r1", 80)}, nodeInfos, PolicySpec{}, false)
+	assert.NotNil(t, err)
+
+	d.JobPlans["job"] = jp
+	res := make([]error, 2)
+	infos := []*NodeInfo{n1, n2}
+	d.applyJobPlan(replicas[1], infos, res, PolicySpec{}, false)
+	assert.Equal(t, []*NodeInfo{nil, n2}, infos)
+	assert.NotNil(t, res[0])
+	assert.Empty(t, d.jobPlanExcludedCards("n2", replicas[1]))
+
+	d.forgetJobReplica(replicas[1], false)
+	d.forgetJobReplica(replicas[0], false)
+	assert.Empty(t, d.JobPlans)
+}
@@ -0,0 +1,77 @@
+package dealer
+
+import (
+	"fmt"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// FlavorUsage is the gpu share of the nodes of a Kueue ResourceFlavor, in percent of
+// a card.
+type FlavorUsage struct {
+	Nodes int `json:"nodes"`
+	Total int `json:"total"`
+	Used  int `json:"used"`
+}
+
+// UpdateKueueAdmissions replaces the owners of the admitted Kueue workloads, the pods
+// of a queue are only placed once the workload of their owner is admitted. Kueue is
+// left alone until it is first called.
+func (d *DealerImpl) UpdateKueueAdmissions(admitted map[types.UID]struct{}) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	d.KueueAdmitted = admitted
+}
+
+// checkKueueAdmission verifies the workload of a pod submitted to a Kueue queue is
+// admitted, the pod itself owns the workload of a plain pod.
+func (d *DealerImpl) checkKueueAdmission(pod *v1.Pod) error {
+	if d.KueueAdmitted == nil || pod.Labels[schetypes.LabelKueueQueueName] == "" {
+		return nil
+	}
+	if _, ok := d.KueueAdmitted[pod.UID]; ok {
+		return nil
+	}
+	if owner := metav1.GetControllerOf(pod); owner != nil {
+		if _, ok := d.KueueAdmitted[owner.UID]; ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("pod %s/%s waits for the admission of its kueue workload", pod.Namespace, pod.Name)
+}
+
+// FlavorUsage returns the gpu share of the nodes carrying the node labels of a flavor,
+// so that the quota of the flavor can be held against what is really placed.
+func (d *DealerImpl) FlavorUsage(nodeLabels map[string]string) (*FlavorUsage, error) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+
+	nodes, err := d.NodeLister.List(labels.SelectorFromSet(nodeLabels))
+	if err != nil {
+		return nil, err
+	}
+	usage := &FlavorUsage{}
+	for _, node := range nodes {
+		total := utils.GetGPUDeviceCountOfNode(node) * schetypes.GPUPercentEachCard
+		if total == 0 {
+			continue
+		}
+		free := total
+		if ni, ok := d.NodeMaps[node.Name]; ok {
+			free, _ = ni.GPUs.PercentAvailableAndFreeGpuCount()
+			excludedTotal, excludedFree := ni.excludedPercent()
+			total -= excludedTotal
+			free -= excludedFree
+		}
+		usage.Nodes++
+		usage.Total += total
+		usage.Used += total - free
+	}
+	return usage, nil
+}
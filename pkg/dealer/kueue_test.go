@@ -0,0 +1,48 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestCheckKueueAdmission(t *testing.T) {
+	d := MockDealer(MockNode("n1", 2))
+	pod := MockQuotaPod("a", "p0", 50)
+	pod.Labels = map[string]string{schetypes.LabelKueueQueueName: "q"}
+	controller := true
+	pod.OwnerReferences = []metav1.OwnerReference{{Kind: "Job", Name: "j", UID: "job", Controller: &controller}}
+
+	// kueue isn't integrated
+	assert.Nil(t, d.checkKueueAdmission(pod))
+	d.UpdateKueueAdmissions(map[k8stypes.UID]struct{}{})
+	assert.NotNil(t, d.checkKueueAdmission(pod))
+	ans, _ := d.Assume([]string{"n1"}, pod, PolicySpec{}, false)
+	assert.Equal(t, []bool{false}, ans)
+	// pods outside of a queue aren't held
+	assert.Nil(t, d.checkKueueAdmission(MockQuotaPod("a", "p1", 50)))
+
+	d.UpdateKueueAdmissions(map[k8stypes.UID]struct{}{"job": {}})
+	assert.Nil(t, d.checkKueueAdmission(pod))
+}
+
+func TestFlavorUsage(t *testing.T) {
+	a100 := MockNode("n1", 2)
+	a100.Labels = map[string]string{schetypes.LabelGPUProduct: "a100"}
+	t4 := MockNode("n2", 4)
+	t4.Labels = map[string]string{schetypes.LabelGPUProduct: "t4"}
+	d := MockDealer(a100, t4)
+	d.NodeMaps["n1"] = NewNodeInfo("n1", a100, d.Rater)
+	d.NodeMaps["n1"].GPUs[0].Percent = 30
+
+	usage, err := d.FlavorUsage(map[string]string{schetypes.LabelGPUProduct: "a100"})
+	assert.Nil(t, err)
+	assert.Equal(t, &FlavorUsage{Nodes: 1, Total: 200, Used: 70}, usage)
+	usage, err = d.FlavorUsage(map[string]string{})
+	assert.Nil(t, err)
+	assert.Equal(t, &FlavorUsage{Nodes: 2, Total: 600, Used: 70}, usage)
+}
@@ -0,0 +1,98 @@
+package dealer
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// LatencySensitiveUsage is the measured core usage of a card at and above which
+// latency sensitive pods are kept off it when scheduling by load, 0 disables it.
+var LatencySensitiveUsage = 0.5
+
+// Coolest places latency sensitive pods on the cards with the lowest measured core
+// usage whatever the priority algorithm, it rates nodes like Spread.
+type Coolest struct {
+	Spread
+}
+
+func (c *Coolest) Choose(gpus GPUs, demand Demand) ([]int, error) {
+	return chooseCoolest(gpus, demand, make([]float64, len(gpus)))
+}
+
+func (c *Coolest) ChooseOnNode(gpus GPUs, demand Demand, d Dealer, policySpec PolicySpec, nodeName string) ([]int, error) {
+	return chooseCoolest(gpus, demand, coreUsages(gpus, d, policySpec, nodeName))
+}
+
+// coreUsages returns the fresh measured core usage of the cards, 0 when unknown.
+func coreUsages(gpus GPUs, d Dealer, policySpec PolicySpec, nodeName string) []float64 {
+	usages := make([]float64, len(gpus))
+	if d == nil {
+		return usages
+	}
+	duration, err := getActiveDuration(policySpec.SyncPeriod, GPUCoreUsagePriority)
+	if err != nil {
+		return usages
+	}
+	for i := range gpus {
+		if exist, usage, err := d.GetUsage(nodeName, GPUCoreUsagePriority, i, duration); exist && err == nil {
+			usages[i] = usage
+		}
+	}
+	return usages
+}
+
+// chooseCoolest places the larger containers first, on the card with the lowest usage
+// and then the most free share.
+func chooseCoolest(gpus GPUs, demand Demand, usages []float64) ([]int, error) {
+	free := make([]int, len(gpus))
+	for i, g := range gpus {
+		free[i] = g.Percent
+	}
+	order := make([]int, len(demand))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return demand[order[a]].Percent > demand[order[b]].Percent })
+	ans := make([]int, len(demand))
+	for _, c := range order {
+		if demand[c].Percent == 0 {
+			ans[c] = NotNeedGPU
+			continue
+		}
+		best := -1
+		for i := range gpus {
+			if free[i] < demand[c].Percent {
+				continue
+			}
+			if best < 0 || usages[i] < usages[best] || (usages[i] == usages[best] && free[i] > free[best]) {
+				best = i
+			}
+		}
+		if best < 0 {
+			return nil, fmt.Errorf("can't allocate %s on %s", demand.String(), gpus)
+		}
+		ans[c] = best
+		free[best] -= demand[c].Percent
+	}
+	return ans, nil
+}
+
+// latencyAware keeps a latency sensitive pod scheduled by load off the cards measured
+// at LatencySensitiveUsage or above and hands it to the Coolest rater.
+func latencyAware(gpus GPUs, rater Rater, pod *v1.Pod, d Dealer, policySpec PolicySpec, nodeName string, isLoadSchedule bool) (GPUs, Rater) {
+	if !isLoadSchedule || LatencySensitiveUsage <= 0 || !IsLatencySensitivePod(pod) {
+		return gpus, rater
+	}
+	busy := make(map[int]string)
+	for i, usage := range coreUsages(gpus, d, policySpec, nodeName) {
+		if usage >= LatencySensitiveUsage {
+			busy[i] = fmt.Sprintf("core usage %.2f is too high for latency sensitive pods", usage)
+		}
+	}
+	if len(busy) > 0 {
+		gpus = gpus.WithoutCards(busy)
+	}
+	return gpus, &Coolest{}
+}
@@ -0,0 +1,44 @@
+package dealer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestLatencyAware(t *testing.T) {
+	d := MockDealer(MockNode("n1", 3))
+	now := time.Now().In(loc).Format(timeFormat)
+	d.UpdateCoreUsage("n1", "0.9", now, 0)
+	d.UpdateCoreUsage("n1", "0.3", now, 1)
+	d.UpdateCoreUsage("n1", "0.1", now, 2)
+	spec := PolicySpec{SyncPeriod: []Period{{Name: GPUCoreUsagePriority, Period: time.Minute}}}
+	gpus := idleGPUs(3)
+	gpus[1].Percent = 60
+	gpus[2].Percent = 40
+
+	pod := MockQuotaPod("a", "p0", 30)
+	// not latency sensitive, the global rater is kept
+	kept, rater := latencyAware(gpus, &Binpack{}, pod, d, spec, "n1", true)
+	assert.IsType(t, &Binpack{}, rater)
+	assert.Equal(t, 100, kept[0].Percent)
+
+	pod.Annotations[schetypes.AnnotationLatencySensitive] = "true"
+	// scheduled by request
+	_, rater = latencyAware(gpus, &Binpack{}, pod, d, spec, "n1", false)
+	assert.IsType(t, &Binpack{}, rater)
+
+	kept, rater = latencyAware(gpus, &Binpack{}, pod, d, spec, "n1", true)
+	assert.IsType(t, &Coolest{}, rater)
+	// the busy card is left out, the coolest one wins although binpack would pick it
+	assert.Equal(t, 0, kept[0].Percent)
+	plan, err := kept.Choose(Demand{{Percent: 30}}, rater, d, spec, "n1", true)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2}, plan.GPUIndexes)
+
+	_, err = kept.Choose(Demand{{Percent: 70}}, rater, d, spec, "n1", true)
+	assert.NotNil(t, err)
+}
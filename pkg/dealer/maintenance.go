@@ -0,0 +1,104 @@
+package dealer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	log "k8s.io/klog/v2"
+)
+
+// MaintenanceLeadTime is how long before a maintenance window of a node long-running
+// pods stop being placed on it, 0 only keeps pods off nodes under maintenance.
+var MaintenanceLeadTime = 24 * time.Hour
+
+// MaintenanceWindow is a planned maintenance of a node.
+type MaintenanceWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// ParseMaintenanceWindows parses start/end or start/duration windows separated by commas.
+func ParseMaintenanceWindows(value string) ([]MaintenanceWindow, error) {
+	ans := make([]MaintenanceWindow, 0)
+	for _, w := range strings.Split(value, ",") {
+		w = strings.TrimSpace(w)
+		if w == "" {
+			continue
+		}
+		parts := strings.SplitN(w, "/", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid maintenance window %q", w)
+		}
+		start, err := time.Parse(time.RFC3339, parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance window %q: %v", w, err)
+		}
+		end, err := time.Parse(time.RFC3339, parts[1])
+		if err != nil {
+			d, derr := time.ParseDuration(parts[1])
+			if derr != nil {
+				return nil, fmt.Errorf("invalid maintenance window %q: %v", w, err)
+			}
+			end = start.Add(d)
+		}
+		if !end.After(start) {
+			return nil, fmt.Errorf("maintenance window %q ends before it starts", w)
+		}
+		ans = append(ans, MaintenanceWindow{Start: start, End: end})
+	}
+	return ans, nil
+}
+
+// expectedDuration returns how long the pod is expected to run, false when it is
+// long-running: it has no expected duration and no Job owns it. Jobs without an
+// expected duration count as short, taking no time.
+func expectedDuration(pod *v1.Pod) (time.Duration, bool) {
+	if value, ok := pod.Annotations[schetypes.AnnotationExpectedDuration]; ok {
+		d, err := time.ParseDuration(value)
+		if err == nil {
+			return d, true
+		}
+		log.Warningf("ignore expected duration of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+	}
+	if owner := metav1.GetControllerOf(pod); owner != nil && owner.Kind == "Job" {
+		return 0, true
+	}
+	return 0, false
+}
+
+// checkMaintenance fails the nodes under maintenance, the nodes whose next window
+// starts before a short pod is expected to finish and, for long-running pods, the
+// nodes whose next window starts within the lead time.
+func (d *DealerImpl) checkMaintenance(ni *NodeInfo, pod *v1.Pod, now time.Time) error {
+	node, err := d.NodeLister.Get(ni.Name)
+	if err != nil {
+		return err
+	}
+	value, ok := node.Annotations[schetypes.AnnotationMaintenance]
+	if !ok {
+		return nil
+	}
+	windows, err := ParseMaintenanceWindows(value)
+	if err != nil {
+		log.Errorf("node %s: %v", ni.Name, err)
+		return nil
+	}
+	duration, short := expectedDuration(pod)
+	for _, w := range windows {
+		switch {
+		case !w.End.After(now):
+		case !w.Start.After(now):
+			return fmt.Errorf("node %s is under maintenance until %s", ni.Name, w.End.Format(time.RFC3339))
+		case short && now.Add(duration).After(w.Start):
+			return fmt.Errorf("node %s enters maintenance at %s before the pod is expected to finish", ni.Name, w.Start.Format(time.RFC3339))
+		case !short && w.Start.Sub(now) < MaintenanceLeadTime:
+			return fmt.Errorf("node %s enters maintenance at %s, in %s, and the pod is long-running", ni.Name, w.Start.Format(time.RFC3339), w.Start.Sub(now).Round(time.Minute))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package dealer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestParseMaintenanceWindows(t *testing.T) {
+	windows, err := ParseMaintenanceWindows("2026-10-20T02:00:00Z/4h, 2026-11-01T00:00:00Z/2026-11-01T06:00:00Z")
+	assert.NoError(t, err)
+	assert.Len(t, windows, 2)
+	assert.Equal(t, 4*time.Hour, windows[0].End.Sub(windows[0].Start))
+	assert.Equal(t, 6*time.Hour, windows[1].End.Sub(windows[1].Start))
+	for _, value := range []string{"2026-10-20T02:00:00Z", "tomorrow/4h", "2026-10-20T02:00:00Z/-1h"} {
+		_, err := ParseMaintenanceWindows(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestCheckMaintenance(t *testing.T) {
+	now := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+	node := MockNode("n1", 1)
+	node.Annotations = map[string]string{types.AnnotationMaintenance: "2026-10-20T06:00:00Z/4h"}
+	d := MockDealer(node)
+	ni := NewNodeInfo("n1", node, d.Rater)
+	controller := true
+
+	service := MockQuotaPod("a", "service", 30)
+	service.OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "rs", Controller: &controller}}
+	assert.Error(t, d.checkMaintenance(ni, service, now))
+	assert.NoError(t, d.checkMaintenance(ni, service, now.Add(10*time.Hour)))
+
+	job := MockQuotaPod("a", "job", 30)
+	job.OwnerReferences = []metav1.OwnerReference{{Kind: "Job", Name: "j", Controller: &controller}}
+	assert.NoError(t, d.checkMaintenance(ni, job, now))
+	err := d.checkMaintenance(ni, job, now.Add(7*time.Hour))
+	assert.EqualError(t, err, "node n1 is under maintenance until 2026-10-20T10:00:00Z")
+
+	job.Annotations = map[string]string{types.AnnotationExpectedDuration: "8h"}
+	assert.Error(t, d.checkMaintenance(ni, job, now))
+	job.Annotations[types.AnnotationExpectedDuration] = "2h"
+	assert.NoError(t, d.checkMaintenance(ni, job, now))
+
+	defer func(lead time.Duration) { MaintenanceLeadTime = lead }(MaintenanceLeadTime)
+	MaintenanceLeadTime = time.Hour
+	assert.NoError(t, d.checkMaintenance(ni, service, now))
+}
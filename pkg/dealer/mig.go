@@ -0,0 +1,249 @@
+package dealer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	log "k8s.io/klog/v2"
+)
+
+// MIGState is the MIG geometry of a node. Every MIG card is split into equal
+// instances of a single profile, the node agent applies the desired layout and
+// reports it back as the current layout.
+type MIGState struct {
+	// Profiles maps the profiles the cards support to the instances per card.
+	Profiles map[string]int `json:"profiles"`
+	// Layout is the profile of every card, empty when MIG is disabled on it.
+	Layout  []string `json:"layout"`
+	Desired []string `json:"desired"`
+}
+
+func parseMIGLayout(value string) []string {
+	if value == "" {
+		return nil
+	}
+	layout := strings.Split(value, ",")
+	for i := range layout {
+		layout[i] = strings.TrimSpace(layout[i])
+	}
+	return layout
+}
+
+// ParseMIGState reads the MIG geometry of a node, nil means the node has no MIG.
+func ParseMIGState(node *v1.Node) (*MIGState, error) {
+	value := node.Annotations[schetypes.AnnotationMIGProfiles]
+	if value == "" {
+		return nil, nil
+	}
+	state := &MIGState{
+		Profiles: make(map[string]int),
+		Layout:   parseMIGLayout(node.Annotations[schetypes.AnnotationMIGLayout]),
+		Desired:  parseMIGLayout(node.Annotations[schetypes.AnnotationMIGLayoutDesired]),
+	}
+	for _, item := range strings.Split(value, ",") {
+		kv := strings.SplitN(strings.TrimSpace(item), ":", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid mig profile %q", item)
+		}
+		count, err := strconv.Atoi(kv[1])
+		if err != nil || count <= 0 || count > schetypes.GPUPercentEachCard {
+			return nil, fmt.Errorf("invalid instance count of mig profile %q", item)
+		}
+		state.Profiles[kv[0]] = count
+	}
+	return state, nil
+}
+
+func migOfNode(node *v1.Node) *MIGState {
+	state, err := ParseMIGState(node)
+	if err != nil {
+		log.Errorf("node %s: %s", node.Name, err.Error())
+	}
+	return state
+}
+
+func (m *MIGState) profileOf(card int) string {
+	if card < len(m.Layout) {
+		return m.Layout[card]
+	}
+	return ""
+}
+
+// Reconfiguring reports whether the agent has not applied the desired profile yet.
+func (m *MIGState) Reconfiguring(card int) bool {
+	return card < len(m.Desired) && m.Desired[card] != "" && m.Desired[card] != m.profileOf(card)
+}
+
+// WithProfiles returns the desired layout of a node of n cards with the cards
+// switched to their profiles.
+func (m *MIGState) WithProfiles(n int, profiles map[int]string) string {
+	layout := make([]string, n)
+	for i := range layout {
+		layout[i] = m.profileOf(i)
+		if i < len(m.Desired) && m.Desired[i] != "" {
+			layout[i] = m.Desired[i]
+		}
+		if profile, ok := profiles[i]; ok {
+			layout[i] = profile
+		}
+	}
+	return strings.Join(layout, ",")
+}
+
+func GetMIGProfileOfPod(pod *v1.Pod) string {
+	return pod.Annotations[schetypes.AnnotationMIGProfile]
+}
+
+// checkMIG lets a pod requesting a MIG profile onto nodes supporting the profile,
+// every gpu container takes exactly one instance.
+func checkMIG(state *MIGState, pod *v1.Pod) error {
+	profile := GetMIGProfileOfPod(pod)
+	if profile == "" {
+		return nil
+	}
+	if state == nil {
+		return fmt.Errorf("node doesn't support mig profile %s", profile)
+	}
+	count, ok := state.Profiles[profile]
+	if !ok {
+		return fmt.Errorf("node doesn't support mig profile %s", profile)
+	}
+	percent := schetypes.GPUPercentEachCard / count
+	for _, r := range NewDemandFromPod(pod) {
+		if r.Percent != 0 && r.Percent != percent {
+			return fmt.Errorf("mig profile %s takes %d gpu percent, container requests %d", profile, percent, r.Percent)
+		}
+	}
+	return nil
+}
+
+func (d *DealerImpl) checkNodeMIG(ni *NodeInfo, pod *v1.Pod) error {
+	node, err := d.NodeLister.Get(ni.Name)
+	if err != nil {
+		return err
+	}
+	ni.MIG = migOfNode(node)
+	return checkMIG(ni.MIG, pod)
+}
+
+// migExcludedCards are the cards whose geometry doesn't match the pod and the cards
+// being reconfigured.
+func migExcludedCards(ni *NodeInfo, pod *v1.Pod) map[int]string {
+	ans := make(map[int]string)
+	if ni == nil || ni.MIG == nil {
+		return ans
+	}
+	profile := GetMIGProfileOfPod(pod)
+	for card := range ni.GPUs {
+		if ni.MIG.Reconfiguring(card) {
+			ans[card] = "mig reconfiguring"
+		} else if current := ni.MIG.profileOf(card); current != profile {
+			ans[card] = fmt.Sprintf("mig profile %q", current)
+		}
+	}
+	return ans
+}
+
+// MIGReconfiguration asks the agent of a node to switch an idle card to a profile.
+type MIGReconfiguration struct {
+	Node    string
+	Card    int
+	Profile string
+	// Layout is the desired layout of the node after the switch.
+	Layout string
+	// Pending is a switch planned before for the pod which the node didn't report
+	// yet, it is not to be applied again.
+	Pending bool
+}
+
+// pendingMIGCards drops the switches the nodes reported and returns the profiles
+// of the cards of the others by node.
+func (d *DealerImpl) pendingMIGCards() map[string]map[int]string {
+	ans := make(map[string]map[int]string)
+	for uid, r := range d.MIGPending {
+		ni, ok := d.NodeMaps[r.Node]
+		if !ok || ni.MIG == nil || ni.MIG.profileOf(r.Card) == r.Profile && !ni.MIG.Reconfiguring(r.Card) {
+			delete(d.MIGPending, uid)
+			continue
+		}
+		if ans[r.Node] == nil {
+			ans[r.Node] = make(map[int]string)
+		}
+		ans[r.Node][r.Card] = r.Profile
+	}
+	return ans
+}
+
+// MIGReconfigurePlan returns how to repartition an idle card so that a pending MIG
+// pod fits, nil when the pod fits already or no idle card supports its profile. The
+// switch planned for the pod is returned again until its node reports it.
+func (d *DealerImpl) MIGReconfigurePlan(pod *v1.Pod) *MIGReconfiguration {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+
+	profile := GetMIGProfileOfPod(pod)
+	if profile == "" {
+		return nil
+	}
+	pending := d.pendingMIGCards()
+	if r, ok := d.MIGPending[pod.UID]; ok {
+		ans := *r
+		ans.Pending = true
+		return &ans
+	}
+	demand := NewDemandFromPod(pod)
+	total := 0
+	for _, r := range demand {
+		total += r.Percent
+	}
+	names := make([]string, 0, len(d.NodeMaps))
+	for name := range d.NodeMaps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var candidate *MIGReconfiguration
+	for _, name := range names {
+		ni := d.NodeMaps[name]
+		if ni.MIG == nil || checkMIG(ni.MIG, pod) != nil {
+			continue
+		}
+		excluded := d.ExcludedCards(name, pod)
+		if _, err := ni.Rater.Choose(ni.GPUs.WithoutCards(excluded), demand); err == nil {
+			// fits the current layout, the scheduler will place it
+			return nil
+		}
+		if candidate != nil || total > schetypes.GPUPercentEachCard {
+			continue
+		}
+		for card, g := range ni.GPUs {
+			if _, ok := pending[name][card]; ok || card >= ni.Capacity || g.Percent != g.PercentTotal || ni.MIG.Reconfiguring(card) {
+				continue
+			}
+			if _, unhealthy := d.GetUnhealthyCards(name)[card]; unhealthy {
+				continue
+			}
+			// the switches planned for other pods may not be on the node yet
+			switches := map[int]string{card: profile}
+			for c, p := range pending[name] {
+				switches[c] = p
+			}
+			candidate = &MIGReconfiguration{
+				Node:    name,
+				Card:    card,
+				Profile: profile,
+				Layout:  ni.MIG.WithProfiles(len(ni.GPUs), switches),
+			}
+			break
+		}
+	}
+	if candidate != nil {
+		d.MIGPending[pod.UID] = candidate
+	}
+	return candidate
+}
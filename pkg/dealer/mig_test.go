@@ -0,0 +1,73 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func MockMIGNode(layout, desired string) *v1.Node {
+	node := MockNode("n1", 2)
+	node.Annotations = map[string]string{
+		types.AnnotationMIGProfiles:      "1g.10gb:7,3g.40gb:2",
+		types.AnnotationMIGLayout:        layout,
+		types.AnnotationMIGLayoutDesired: desired,
+	}
+	return node
+}
+
+func MockMIGPod(name, profile string, percent int) *v1.Pod {
+	pod := MockQuotaPod("a", name, percent)
+	pod.Annotations[types.AnnotationMIGProfile] = profile
+	return pod
+}
+
+func TestCheckMIG(t *testing.T) {
+	state, err := ParseMIGState(MockMIGNode("1g.10gb,", ""))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"1g.10gb", ""}, state.Layout)
+
+	assert.Nil(t, checkMIG(state, MockMIGPod("p", "3g.40gb", 50)))
+	assert.Nil(t, checkMIG(state, MockMIGPod("p", "1g.10gb", 14)))
+	assert.NotNil(t, checkMIG(state, MockMIGPod("p", "3g.40gb", 30)))
+	assert.NotNil(t, checkMIG(state, MockMIGPod("p", "7g.80gb", 100)))
+	assert.NotNil(t, checkMIG(nil, MockMIGPod("p", "3g.40gb", 50)))
+	assert.Nil(t, checkMIG(nil, MockQuotaPod("a", "p", 50)))
+}
+
+func TestMIGReconfigurePlan(t *testing.T) {
+	node := MockMIGNode("1g.10gb,", "")
+	d := MockDealer(node)
+	ni := NewNodeInfo("n1", node, d.Rater)
+	d.NodeMaps["n1"] = ni
+
+	// only the idle card 1 can be repartitioned
+	assert.Nil(t, ni.Allocate(&Plan{Demand: Demand{{Percent: 14}}, GPUIndexes: []int{0}}))
+	pod := MockMIGPod("p", "3g.40gb", 50)
+	plan := d.MIGReconfigurePlan(pod)
+	assert.Equal(t, &MIGReconfiguration{Node: "n1", Card: 1, Profile: "3g.40gb", Layout: "1g.10gb,3g.40gb"}, plan)
+
+	// until the node reports it the switch is the pod's, no other pod gets the card
+	plan = d.MIGReconfigurePlan(pod)
+	assert.Equal(t, &MIGReconfiguration{Node: "n1", Card: 1, Profile: "3g.40gb", Layout: "1g.10gb,3g.40gb", Pending: true}, plan)
+	assert.Nil(t, d.MIGReconfigurePlan(MockMIGPod("q", "3g.40gb", 50)))
+
+	// the card takes no pods until the agent applied the layout
+	d.UpdateNode(MockMIGNode("1g.10gb,", "1g.10gb,3g.40gb"))
+	assert.Equal(t, 2, len(d.ExcludedCards("n1", pod)))
+	assert.Equal(t, 2, len(d.ExcludedCards("n1", MockQuotaPod("a", "plain", 50))))
+
+	d.UpdateNode(MockMIGNode("1g.10gb,3g.40gb", "1g.10gb,3g.40gb"))
+	assert.Nil(t, d.MIGReconfigurePlan(pod))
+	demand := NewDemandFromPod(pod)
+	assumed, _ := ni.Assume(demand, pod, d, PolicySpec{}, false)
+	assert.True(t, assumed)
+	assert.Equal(t, []int{1}, ni.PlanCache[planKey(demand, pod)].GPUIndexes)
+
+	// busy cards are never repartitioned
+	assert.Nil(t, ni.Allocate(&Plan{Demand: Demand{{Percent: 50}, {Percent: 50}}, GPUIndexes: []int{1, 1}}))
+	assert.Nil(t, d.MIGReconfigurePlan(MockMIGPod("q", "3g.40gb", 50)))
+}
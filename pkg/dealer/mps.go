@@ -0,0 +1,65 @@
+package dealer
+
+import (
+	"fmt"
+	"strconv"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+var (
+	// MPSSmallPercent is the largest container share of a pod which benefits from
+	// running next to many other pods under MPS.
+	MPSSmallPercent = 25
+	// MPSScoreBonus is added to the score of MPS nodes for small pods, 0 disables it.
+	MPSScoreBonus = 0
+)
+
+func IsMPSNode(node *v1.Node) bool {
+	return node.Labels[schetypes.LabelGPUMPS] == "true"
+}
+
+func isSmallPod(demand Demand) bool {
+	small := false
+	for _, r := range demand {
+		if r.Percent > MPSSmallPercent {
+			return false
+		}
+		if r.Percent > 0 {
+			small = true
+		}
+	}
+	return small
+}
+
+// mpsBonus prefers MPS nodes for pods whose containers all take a small share.
+func mpsBonus(ni *NodeInfo, demand Demand) int {
+	if !ni.MPS || !isSmallPod(demand) {
+		return 0
+	}
+	return MPSScoreBonus
+}
+
+// podWithPlan returns a copy of the pod annotated with the plan, init containers
+// included, and the release finalizer when enabled. On MPS nodes every
+// gpu container also gets the active thread percentage the runtime should set.
+func podWithPlan(pod *v1.Pod, node *v1.Node, plan *Plan) *v1.Pod {
+	newPod := utils.GetUpdatedPodAnnotationSpec(pod, plan.GPUIndexes)
+	annotateInit(newPod, plan)
+	withReleaseFinalizer(newPod)
+	annotateUUIDs(newPod, uuidsOfNode(node), plan)
+	annotatePlan(newPod, plan, uuidsOfNode(node))
+	if !IsMPSNode(node) {
+		return newPod
+	}
+	for i, c := range newPod.Spec.Containers {
+		if i < len(plan.Demand) && plan.Demand[i].Percent > 0 {
+			key := fmt.Sprintf(schetypes.AnnotationMPSThreadPercentage, c.Name)
+			newPod.Annotations[key] = strconv.Itoa(plan.Demand[i].Percent)
+		}
+	}
+	return newPod
+}
@@ -0,0 +1,39 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestPodWithPlan(t *testing.T) {
+	pod := MockPodWithDemand(Demand{{Percent: 20}, {Percent: 0}})
+	pod.Spec.Containers[0].Name = "a"
+	pod.Spec.Containers[1].Name = "b"
+	plan := &Plan{Demand: NewDemandFromPod(pod), GPUIndexes: []int{1, NotNeedGPU}}
+
+	node := MockNode("n1", 2)
+	newPod := podWithPlan(pod, node, plan)
+	assert.Equal(t, "1", newPod.Annotations["nano-gpu/container-a"])
+	assert.NotContains(t, newPod.Annotations, "nano-gpu/mps-active-thread-percentage-a")
+
+	node.Labels = map[string]string{types.LabelGPUMPS: "true"}
+	newPod = podWithPlan(pod, node, plan)
+	assert.Equal(t, "20", newPod.Annotations["nano-gpu/mps-active-thread-percentage-a"])
+	assert.NotContains(t, newPod.Annotations, "nano-gpu/mps-active-thread-percentage-b")
+}
+
+func TestMPSBonus(t *testing.T) {
+	defer func(bonus int) { MPSScoreBonus = bonus }(MPSScoreBonus)
+	MPSScoreBonus = 20
+	node := MockNode("n1", 2)
+	node.Labels = map[string]string{types.LabelGPUMPS: "true"}
+	ni := NewNodeInfo("n1", node, &Binpack{})
+
+	assert.Equal(t, 20, mpsBonus(ni, Demand{{Percent: 10}, {Percent: 25}}))
+	assert.Equal(t, 0, mpsBonus(ni, Demand{{Percent: 10}, {Percent: 50}}))
+	assert.Equal(t, 0, mpsBonus(ni, Demand{{Percent: 0}}))
+	assert.Equal(t, 0, mpsBonus(NewNodeInfo("n2", MockNode("n2", 2), &Binpack{}), Demand{{Percent: 10}}))
+}
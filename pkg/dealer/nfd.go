@@ -0,0 +1,38 @@
+package dealer
+
+import (
+	"strconv"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// CardAttributes describe the cards of a node from the labels gpu feature discovery
+// publishes through node feature discovery, zero when a label is missing. The cards
+// of a node are assumed to be of a single model.
+type CardAttributes struct {
+	Model     string `json:"model,omitempty"`
+	MemoryMiB int    `json:"memoryMiB,omitempty"`
+	// ComputeCapability is major*10+minor.
+	ComputeCapability int  `json:"computeCapability,omitempty"`
+	MIGCapable        bool `json:"migCapable,omitempty"`
+	// Count is the number of cards found on the node, the capacity still comes from
+	// the device plugin.
+	Count int `json:"count,omitempty"`
+}
+
+func cardAttributesOf(node *v1.Node) CardAttributes {
+	labels := node.Labels
+	attrs := CardAttributes{
+		Model:      labels[schetypes.LabelGPUProduct],
+		MIGCapable: labels[schetypes.LabelMIGCapable] == "true",
+	}
+	attrs.MemoryMiB, _ = strconv.Atoi(labels[schetypes.LabelGPUMemory])
+	attrs.Count, _ = strconv.Atoi(labels[schetypes.LabelGPUCount])
+	if major, err := strconv.Atoi(labels[schetypes.LabelGPUComputeMajor]); err == nil {
+		minor, _ := strconv.Atoi(labels[schetypes.LabelGPUComputeMinor])
+		attrs.ComputeCapability = major*10 + minor
+	}
+	return attrs
+}
@@ -0,0 +1,32 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestCardAttributes(t *testing.T) {
+	node := MockNode("n1", 2)
+	assert.Equal(t, CardAttributes{}, NewNodeInfo("n1", node, &Binpack{}).Attributes)
+
+	node.Labels = map[string]string{
+		types.LabelGPUProduct:      "NVIDIA-A100-SXM4-40GB",
+		types.LabelGPUMemory:       "40960",
+		types.LabelGPUCount:        "2",
+		types.LabelGPUComputeMajor: "8",
+		types.LabelGPUComputeMinor: "0",
+		types.LabelMIGCapable:      "true",
+	}
+	d := MockDealer(node)
+	d.NodeMaps["n1"] = NewNodeInfo("n1", MockNode("n1", 2), d.Rater)
+	d.UpdateNode(node)
+	ni := d.NodeMaps["n1"]
+	assert.Equal(t, CardAttributes{Model: "NVIDIA-A100-SXM4-40GB", MemoryMiB: 40960, ComputeCapability: 80, MIGCapable: true, Count: 2}, ni.Attributes)
+
+	vars := d.ruleVars(ni, MockQuotaPod("a", "p0", 40), Demand{{Percent: 40}}, PolicySpec{}, false)
+	assert.Equal(t, "NVIDIA-A100-SXM4-40GB", vars["node.model"])
+	assert.Equal(t, 80.0, vars["node.capability"])
+}
@@ -2,15 +2,16 @@ package dealer
 
 import (
 	"fmt"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/accelerator"
 	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
 	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
 	v1 "k8s.io/api/core/v1"
 )
 
 type NodeInterface interface {
-	Assume(demand Demand) (bool, error)
-	Score(demand Demand) int
-	Bind(demand Demand) (*Plan, error)
+	Assume(demand Demand, pod *v1.Pod) (bool, error)
+	Score(demand Demand, pod *v1.Pod) int
+	Bind(demand Demand, pod *v1.Pod) (*Plan, error)
 	Allocate(plan *Plan) error
 	Release(plan *Plan) error
 }
@@ -18,10 +19,55 @@ type NodeInterface interface {
 type NodeInfo struct {
 	Rater       Rater
 	Name        string
+	Pool        string
+	MPS         bool
+	// Profiles maps the vGPU profiles offered by the node to their gpu percent.
+	Profiles    map[string]int
+	MIG         *MIGState
+	// QoS is the gpu percent of every QoS class by card.
+	QoS         map[int]map[QoSClass]int
+	// Capacity is the number of cards advertised by the node.
+	Capacity    int
+	// UUIDs are the uuids of the cards by index, empty when unknown.
+	UUIDs       []string
+	// SystemReserved is the percent of every card kept off the schedulable capacity.
+	SystemReserved int
+	// Excluded are the cards the node excludes from allocation.
+	Excluded    []int
+	// Labels are the labels of the node.
+	Labels      map[string]string
+	// Vendor is the gpu vendor of the node, the accelerator its share is taken from.
+	Vendor      string
+	// Attributes describe the cards from the labels of node feature discovery.
+	Attributes  CardAttributes
 	GPUs        GPUs
 	PlanCache   map[string]*Plan
 }
 
+// snapshot returns a copy of the node info which stays the same when the node info
+// changes under the lock.
+func (ni *NodeInfo) snapshot() *NodeInfo {
+	c := *ni
+	c.GPUs = ni.GPUs.Clone()
+	c.QoS = make(map[int]map[QoSClass]int, len(ni.QoS))
+	for card, classes := range ni.QoS {
+		c.QoS[card] = make(map[QoSClass]int, len(classes))
+		for class, percent := range classes {
+			c.QoS[card][class] = percent
+		}
+	}
+	c.PlanCache = make(map[string]*Plan, len(ni.PlanCache))
+	for key, plan := range ni.PlanCache {
+		p := *plan
+		c.PlanCache[key] = &p
+	}
+	if ni.MIG != nil {
+		mig := *ni.MIG
+		c.MIG = &mig
+	}
+	return &c
+}
+
 func NewNodeInfo(name string, node *v1.Node, rater Rater) *NodeInfo {
 	var (
 		count     = utils.GetGPUDeviceCountOfNode(node)
@@ -33,22 +79,34 @@ func NewNodeInfo(name string, node *v1.Node, rater Rater) *NodeInfo {
 			PercentTotal: schetypes.GPUPercentEachCard,
 		}
 	}
+	attrs := cardAttributesOf(node)
 	return &NodeInfo{
 		Rater:     rater,
 		Name:      name,
+		Pool:      node.Labels[schetypes.LabelGPUPool],
+		MPS:       IsMPSNode(node),
+		Profiles:  vgpuProfilesOfNode(node),
+		MIG:       migOfNode(node),
+		QoS:       make(map[int]map[QoSClass]int),
+		Capacity:  count,
+		UUIDs:     uuidsOfNode(node),
+		Excluded:  excludedOfNode(node),
+		Labels:    node.Labels,
+		Vendor:    accelerator.VendorOfNode(node),
+		Attributes: attrs,
+		SystemReserved: systemReservedOf(node, attrs),
 		GPUs:      resources,
 		PlanCache: make(map[string]*Plan),
 	}
 }
 
-func (ni *NodeInfo) Assume(demand Demand, d Dealer, policySpec PolicySpec, isLoadSchedule bool) (bool, error) {
-	key := demand.Hash()
+func (ni *NodeInfo) Assume(demand Demand, pod *v1.Pod, d Dealer, policySpec PolicySpec, isLoadSchedule bool) (bool, error) {
+	key := planKey(demand, pod)
 
 	if _, ok := ni.PlanCache[key]; ok {
 		return true, nil
 	}
-
-	plan, err := ni.GPUs.Choose(demand, ni.Rater, d, policySpec, ni.Name, isLoadSchedule)
+	plan, err := ni.plan(demand, pod, d, policySpec, isLoadSchedule)
 	if err != nil {
 		return false, err
 	}
@@ -56,22 +114,73 @@ func (ni *NodeInfo) Assume(demand Demand, d Dealer, policySpec PolicySpec, isLoa
 	return true, nil
 }
 
-func (ni *NodeInfo) Score(demands Demand, d Dealer, policySpec PolicySpec, isLoadSchedule bool) int {
-	key := demands.Hash()
+// plan chooses the cards of the pod on the node without caching the plan.
+func (ni *NodeInfo) plan(demand Demand, pod *v1.Pod, d Dealer, policySpec PolicySpec, isLoadSchedule bool) (*Plan, error) {
+	gpus, rater := latencyAware(ni.schedulableGPUs(pod, d), raterOf(pod, policySpec, ni.Rater), pod, d, policySpec, ni.Name, isLoadSchedule)
+	plan, err := gpus.Choose(demand, rater, d, policySpec, ni.Name, isLoadSchedule)
+	if err != nil {
+		return nil, err
+	}
+	if card, ok := preferredCard(ni, pod); ok {
+		// the cache of a restarted pod may still be warm on the card it ran on
+		if preferred, err := gpus.onlyCard(card).Choose(demand, rater, d, policySpec, ni.Name, isLoadSchedule); err == nil {
+			preferred.Score = plan.Score
+			plan = preferred
+		}
+	}
+	if err := gpus.placeOpportunistic(plan, pod); err != nil {
+		return nil, err
+	}
+	if percent := utils.GetGPUPercentFromInitContainers(pod); percent > 0 {
+		if err := gpus.placeInit(plan, percent); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// schedulableGPUs returns the cards of the node as a new plan of the pod sees them,
+// without the excluded cards and the reserved share.
+func (ni *NodeInfo) schedulableGPUs(pod *v1.Pod, d Dealer) GPUs {
+	gpus := ni.GPUs
+	if removed := ni.removedCards(); len(removed) > 0 {
+		gpus = gpus.WithoutCards(removed)
+	}
+	if d != nil {
+		if excluded := d.ExcludedCards(ni.Name, pod); len(excluded) > 0 {
+			gpus = gpus.WithoutCards(excluded)
+		}
+	}
+	if d != nil {
+		if share := d.SoonFreeShare(ni.Name); len(share) > 0 {
+			gpus = gpus.WithShare(share)
+		}
+	}
+	if ni.SystemReserved > 0 {
+		gpus = gpus.WithHeadroom(ni.SystemReserved)
+	}
+	if reserved := ReservedHeadroom.PercentFor(pod); reserved > 0 {
+		gpus = gpus.WithHeadroom(reserved)
+	}
+	return gpus
+}
+
+func (ni *NodeInfo) Score(demands Demand, pod *v1.Pod, d Dealer, policySpec PolicySpec, isLoadSchedule bool) int {
+	key := planKey(demands, pod)
 	_, ok := ni.PlanCache[key]
 	if !ok {
-		if assumed, _ := ni.Assume(demands, d, policySpec, isLoadSchedule); !assumed {
+		if assumed, _ := ni.Assume(demands, pod, d, policySpec, isLoadSchedule); !assumed {
 			return ScoreMin
 		}
 	}
 	return ni.PlanCache[key].Score
 }
 
-func (ni *NodeInfo) Bind(demands Demand, d Dealer, policySpec PolicySpec, isLoadSchedule bool) (*Plan, error) {
-	key := demands.Hash()
+func (ni *NodeInfo) Bind(demands Demand, pod *v1.Pod, d Dealer, policySpec PolicySpec, isLoadSchedule bool) (*Plan, error) {
+	key := planKey(demands, pod)
 	_, ok := ni.PlanCache[key]
 	if !ok {
-		if assumed, _ := ni.Assume(demands, d, policySpec, isLoadSchedule); !assumed {
+		if assumed, _ := ni.Assume(demands, pod, d, policySpec, isLoadSchedule); !assumed {
 			return nil, fmt.Errorf("assume %s on %s failed", demands, ni.GPUs)
 		}
 	}
@@ -85,12 +194,24 @@ func (ni *NodeInfo) Bind(demands Demand, d Dealer, policySpec PolicySpec, isLoad
 
 func (ni *NodeInfo) Allocate(plan *Plan) error {
 	ni.cleanPlan()
+	// pods may still run on cards the node no longer advertises
+	ni.ensureCards(planCards(plan))
 	return ni.GPUs.Allocate(plan)
 }
 
 func (ni *NodeInfo) Release(plan *Plan) error {
 	ni.cleanPlan()
-	return ni.GPUs.Release(plan)
+	if err := ni.GPUs.Release(plan); err != nil {
+		return err
+	}
+	ni.trim()
+	return nil
+}
+
+// planKey identifies a plan of a pod, pods with the same demand may still get
+// different plans because of pod level constraints.
+func planKey(demand Demand, pod *v1.Pod) string {
+	return string(pod.UID) + "/" + demand.Hash()
 }
 
 func (ni *NodeInfo) cleanPlan() {
@@ -1,9 +1,8 @@
 package dealer
 
 import (
-	"errors"
+	"fmt"
 	"k8s.io/klog"
-	"strconv"
 	"time"
 )
 
@@ -70,42 +69,60 @@ func (d *DealerImpl) AddMemoryUsage(nodeName string)  {
 func (d *DealerImpl) UpdateCoreUsage(nodeName, coreUsage, updateTime string, cardNum int)  {
 	d.Lock.Lock()
 	defer d.Lock.Unlock()
+	d.updateCoreUsage(nodeName, coreUsage, updateTime, cardNum)
+}
+
+func (d *DealerImpl) updateCoreUsage(nodeName, coreUsage, updateTime string, cardNum int) {
+	if _, ok := d.CoreUsage[nodeName]; !ok {
+		d.CoreUsage[nodeName] = make(map[int]GPUCoreUsage)
+	}
+	d.recordCoreUsage(nodeName, cardNum, coreUsage)
+	d.recordHistory(nodeName, GPUCoreUsagePriority, cardNum, coreUsage)
+	exportUsage(nodeName, GPUCoreUsagePriority, cardNum, coreUsage)
+	coreUsage = d.smoothUsage(GPUCoreUsagePriority, nodeName, cardNum, coreUsage)
 	d.CoreUsage[nodeName][cardNum] = NewGPUCoreUsage(coreUsage, updateTime)
+	d.cacheUsage(nodeName, GPUCoreUsagePriority, cardNum, coreUsage, updateTime)
 }
 
 func (d *DealerImpl) UpdateMemoryUsage(nodeName, memoryUsage, updateTime string, cardNum int)  {
 	d.Lock.Lock()
 	defer d.Lock.Unlock()
+	d.updateMemoryUsage(nodeName, memoryUsage, updateTime, cardNum)
+}
+
+func (d *DealerImpl) updateMemoryUsage(nodeName, memoryUsage, updateTime string, cardNum int) {
+	if _, ok := d.MemoryUsage[nodeName]; !ok {
+		d.MemoryUsage[nodeName] = make(map[int]GPUMemoryUsage)
+	}
+	d.recordHistory(nodeName, GPUMemoryUsagePriority, cardNum, memoryUsage)
+	exportUsage(nodeName, GPUMemoryUsagePriority, cardNum, memoryUsage)
+	memoryUsage = d.smoothUsage(GPUMemoryUsagePriority, nodeName, cardNum, memoryUsage)
 	d.MemoryUsage[nodeName][cardNum] = NewGPUMemoryUsage(memoryUsage, updateTime)
+	d.cacheUsage(nodeName, GPUMemoryUsagePriority, cardNum, memoryUsage, updateTime)
+}
+
+func (d *DealerImpl) GetUsageLock(nodeName, key string, card int, activeDuration time.Duration) (bool, float64, error) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	return d.GetUsage(nodeName, key, card, activeDuration)
 }
 
+// GetUsage reads the cached usage of a card, it is stale once older than the
+// active duration.
 func (d *DealerImpl) GetUsage(nodeName, key string, card int, activeDuration time.Duration) (bool, float64, error) {
-	var usage, time string
-	if key == GPUCoreUsagePriority {
-		_, exist :=  d.GetCoreUsage(nodeName)
-		if !exist {
-			return exist, 0, nil
-		}
-		usage = d.CoreUsage[nodeName][card].CoreUsage
-		time = d.CoreUsage[nodeName][card].UpdateTime
-	} else {
-		_, exist :=  d.GetMemoryUsage(nodeName)
-		if !exist {
-			return exist, 0, nil
-		}
-		usage = d.MemoryUsage[nodeName][card].MemoryUsage
-		time = d.MemoryUsage[nodeName][card].UpdateTime
+	c, exist := d.cachedUsageOf(nodeName, key, card)
+	if !exist {
+		return exist, 0, nil
 	}
-	if !inUpdateTimePeriod(time, activeDuration) {
-		return true, 0, errors.New(key + "not in update period")
+	if _, ok := d.Faults.fire(FaultStaleUsage, nodeName); ok {
+		return true, 0, fmt.Errorf("%s %w", key, ErrUsageStale)
 	}
-	UsedValue, err := strconv.ParseFloat(usage, 64)
-	if err != nil {
-		return true, 0, errors.New(key + "strconv.ParseFloat error")
+	if !time.Now().Before(c.Time.Add(activeDuration)) {
+		return true, 0, fmt.Errorf("%s %w", key, ErrUsageStale)
 	}
-	if UsedValue < 0 || UsedValue > 1 {
-		klog.Info("UsedValue:",UsedValue)
-		return true, 0, errors.New(key + " usage < 0 || usage > 1")
+	if c.err != nil {
+		klog.Info("UsedValue:", c.Usage)
+		return true, 0, c.err
 	}
-	return true, UsedValue, nil
+	return true, c.Usage, nil
 }
@@ -0,0 +1,46 @@
+package dealer
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	log "k8s.io/klog/v2"
+)
+
+// KnownPodUIDs returns the pods the dealer accounts share for.
+func (d *DealerImpl) KnownPodUIDs() []types.UID {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	ans := make([]types.UID, 0, len(d.PodMaps))
+	for uid := range d.PodMaps {
+		ans = append(ans, uid)
+	}
+	return ans
+}
+
+// ReleaseOrphans releases the candidates which are no longer live, they were deleted
+// while the delete event got lost. The candidates have to be taken before listing
+// the live pods so that pods bound meanwhile are not taken for orphans.
+func (d *DealerImpl) ReleaseOrphans(candidates []types.UID, live map[types.UID]struct{}) []*v1.Pod {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	ans := make([]*v1.Pod, 0)
+	for _, uid := range candidates {
+		if _, ok := live[uid]; ok {
+			continue
+		}
+		pod, ok := d.PodMaps[uid]
+		if !ok {
+			continue
+		}
+		log.Warningf("release orphaned pod %s/%s on %s", pod.Namespace, pod.Name, pod.Spec.NodeName)
+		if err := d.release(pod); err != nil {
+			log.Errorf("release orphaned pod %s/%s failed: %s", pod.Namespace, pod.Name, err.Error())
+			continue
+		}
+		// no delete event is coming to forget it
+		delete(d.ReleasedPodMap, uid)
+		d.forgetPending(uid)
+		ans = append(ans, pod)
+	}
+	return ans
+}
@@ -0,0 +1,36 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+)
+
+func TestReleaseOrphans(t *testing.T) {
+	node := MockNode("n1", 1)
+	d := MockDealer(node)
+	ni := NewNodeInfo("n1", node, d.Rater)
+	d.NodeMaps["n1"] = ni
+	for _, name := range []string{"live", "orphan"} {
+		pod := MockQuotaPod("a", name, 30)
+		pod.Spec.NodeName = "n1"
+		assert.Nil(t, d.Allocate(utils.GetUpdatedPodAnnotationSpec(pod, []int{0})))
+	}
+	candidates := d.KnownPodUIDs()
+	assert.Len(t, candidates, 2)
+
+	// bound after the candidates were taken
+	late := MockQuotaPod("a", "late", 30)
+	late.Spec.NodeName = "n1"
+	assert.Nil(t, d.Allocate(utils.GetUpdatedPodAnnotationSpec(late, []int{0})))
+
+	released := d.ReleaseOrphans(candidates, map[k8stypes.UID]struct{}{"a/live": {}})
+	assert.Len(t, released, 1)
+	assert.Equal(t, "orphan", released[0].Name)
+	assert.Equal(t, 40, ni.GPUs[0].Percent)
+	assert.Len(t, d.PodMaps, 2)
+	assert.Empty(t, d.ReleasedPodMap)
+}
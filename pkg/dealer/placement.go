@@ -0,0 +1,90 @@
+package dealer
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// ContainerPlacement is the card of a container with its gpu percent.
+type ContainerPlacement struct {
+	Container string `json:"container"`
+	Card      int    `json:"card"`
+	Percent   int    `json:"percent"`
+}
+
+// PodPlacement is where the containers of a pod are placed, containers without
+// gpu are left out.
+type PodPlacement struct {
+	Namespace  string               `json:"namespace"`
+	Name       string               `json:"name"`
+	Node       string               `json:"node"`
+	Containers []ContainerPlacement `json:"containers"`
+}
+
+func placementOf(pod *v1.Pod, plan *Plan) PodPlacement {
+	p := PodPlacement{Namespace: pod.Namespace, Name: pod.Name, Node: pod.Spec.NodeName, Containers: make([]ContainerPlacement, 0)}
+	for i, c := range pod.Spec.Containers {
+		if plan.GPUIndexes[i] == NotNeedGPU {
+			continue
+		}
+		p.Containers = append(p.Containers, ContainerPlacement{Container: c.Name, Card: plan.GPUIndexes[i], Percent: plan.Demand[i].Percent})
+	}
+	return p
+}
+
+// PodPlacement returns the cards of a known pod.
+func (d *DealerImpl) PodPlacement(namespace, name string) (*PodPlacement, error) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	pod := d.knownPod(namespace, name)
+	if pod == nil {
+		return nil, fmt.Errorf("pod %s/%s is not placed on any gpu", namespace, name)
+	}
+	plan, err := NewPlanFromPod(pod)
+	if err != nil {
+		return nil, err
+	}
+	p := placementOf(pod, plan)
+	return &p, nil
+}
+
+// knownPod returns the known pod of the name, nil when there is none.
+func (d *DealerImpl) knownPod(namespace, name string) *v1.Pod {
+	for _, pod := range d.PodMaps {
+		if pod.Namespace == namespace && pod.Name == name {
+			return pod
+		}
+	}
+	return nil
+}
+
+// CardPlacements returns the pods on a card with only their containers on it.
+func (d *DealerImpl) CardPlacements(nodeName string, card int) []PodPlacement {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	ans := make([]PodPlacement, 0)
+	for _, pod := range d.podsOnCard(nodeName, card) {
+		plan, err := NewPlanFromPod(pod)
+		if err != nil {
+			continue
+		}
+		p := placementOf(pod, plan)
+		containers := make([]ContainerPlacement, 0, len(p.Containers))
+		for _, c := range p.Containers {
+			if c.Card == card {
+				containers = append(containers, c)
+			}
+		}
+		p.Containers = containers
+		ans = append(ans, p)
+	}
+	sort.Slice(ans, func(i, j int) bool {
+		if ans[i].Namespace != ans[j].Namespace {
+			return ans[i].Namespace < ans[j].Namespace
+		}
+		return ans[i].Name < ans[j].Name
+	})
+	return ans
+}
@@ -0,0 +1,34 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlacements(t *testing.T) {
+	node := MockNode("n1", 2)
+	d := MockDealer(node)
+	d.NodeMaps["n1"] = NewNodeInfo("n1", node, d.Rater)
+	pod := MockPodWithDemand(Demand{{Percent: 30}, {Percent: 20}, {Percent: 0}})
+	pod.Namespace, pod.Name, pod.UID = "a", "p0", "a/p0"
+	pod.Spec.Containers[0].Name = "train"
+	pod.Spec.Containers[1].Name = "eval"
+	pod.Spec.Containers[2].Name = "sidecar"
+	pod.Spec.NodeName = "n1"
+	pod = utils.GetUpdatedPodAnnotationSpec(pod, []int{0, 1, NotNeedGPU})
+	assert.Nil(t, d.Allocate(pod))
+
+	p, err := d.PodPlacement("a", "p0")
+	assert.NoError(t, err)
+	assert.Equal(t, "n1", p.Node)
+	assert.Equal(t, []ContainerPlacement{{Container: "train", Card: 0, Percent: 30}, {Container: "eval", Card: 1, Percent: 20}}, p.Containers)
+	_, err = d.PodPlacement("a", "p1")
+	assert.Error(t, err)
+
+	onCard := d.CardPlacements("n1", 1)
+	assert.Len(t, onCard, 1)
+	assert.Equal(t, []ContainerPlacement{{Container: "eval", Card: 1, Percent: 20}}, onCard[0].Containers)
+	assert.Empty(t, d.CardPlacements("n1", 2))
+}
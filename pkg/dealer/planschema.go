@@ -0,0 +1,112 @@
+package dealer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+	log "k8s.io/klog/v2"
+)
+
+// PlanVersionV1 is the version of the plan annotation written by this scheduler.
+const PlanVersionV1 = "v1"
+
+// PlanAnnotation is the structured plan of a pod. The index annotation of every
+// container stays the contract with the device plugin and wins over the index here,
+// the plan annotation carries what does not fit in it.
+type PlanAnnotation struct {
+	Version    string          `json:"version"`
+	Containers []ContainerPlan `json:"containers"`
+}
+
+type ContainerPlan struct {
+	Name    string `json:"name"`
+	Index   int    `json:"index"`
+	UUID    string `json:"uuid,omitempty"`
+	Percent int    `json:"percent"`
+	QoS     string `json:"qos,omitempty"`
+}
+
+func newPlanAnnotation(pod *v1.Pod, plan *Plan, uuids []string) *PlanAnnotation {
+	pa := &PlanAnnotation{Version: PlanVersionV1, Containers: make([]ContainerPlan, 0, len(pod.Spec.Containers))}
+	for i, c := range pod.Spec.Containers {
+		if i >= len(plan.GPUIndexes) {
+			break
+		}
+		cp := ContainerPlan{Name: c.Name, Index: plan.GPUIndexes[i], Percent: plan.Demand[i].Percent}
+		if cp.Index >= 0 {
+			cp.QoS = string(GetQoSClass(pod))
+			if cp.Index < len(uuids) {
+				cp.UUID = uuids[cp.Index]
+			}
+		}
+		pa.Containers = append(pa.Containers, cp)
+	}
+	return pa
+}
+
+// annotatePlan writes the plan annotation of the pod.
+func annotatePlan(pod *v1.Pod, plan *Plan, uuids []string) {
+	data, err := json.Marshal(newPlanAnnotation(pod, plan, uuids))
+	if err != nil {
+		log.Errorf("marshal plan of pod %s/%s failed: %v", pod.Namespace, pod.Name, err)
+		return
+	}
+	pod.Annotations[schetypes.AnnotationGPUPlan] = string(data)
+}
+
+// structuredPlan parses the plan annotation of the pod, false when it has none.
+func structuredPlan(pod *v1.Pod) (*PlanAnnotation, bool, error) {
+	value, ok := pod.Annotations[schetypes.AnnotationGPUPlan]
+	if !ok {
+		return nil, false, nil
+	}
+	pa := &PlanAnnotation{}
+	if err := json.Unmarshal([]byte(value), pa); err != nil {
+		return nil, true, err
+	}
+	if pa.Version != PlanVersionV1 {
+		return nil, true, fmt.Errorf("plan version %s is not supported", pa.Version)
+	}
+	return pa, true, nil
+}
+
+func (pa *PlanAnnotation) container(name string) (ContainerPlan, bool) {
+	for _, cp := range pa.Containers {
+		if cp.Name == name {
+			return cp, true
+		}
+	}
+	return ContainerPlan{}, false
+}
+
+// PlanAnnotationOf returns the structured plan of an assumed pod, pods planned
+// before it existed get theirs converted from the legacy annotations.
+func PlanAnnotationOf(pod *v1.Pod) (*PlanAnnotation, error) {
+	if !utils.IsAssumed(pod) {
+		return nil, fmt.Errorf("pod %s/%s is not assumed", pod.Namespace, pod.Name)
+	}
+	pa, ok, err := structuredPlan(pod)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := NewPlanFromPod(pod)
+	if err != nil {
+		return nil, err
+	}
+	converted := newPlanAnnotation(pod, plan, nil)
+	for i := range converted.Containers {
+		cp := &converted.Containers[i]
+		if ok {
+			if structured, found := pa.container(cp.Name); found {
+				cp.UUID = structured.UUID
+			}
+		} else if cp.Index >= 0 {
+			cp.UUID = pod.Annotations[fmt.Sprintf(schetypes.AnnotationGPUContainerUUID, cp.Name)]
+		}
+	}
+	return converted, nil
+}
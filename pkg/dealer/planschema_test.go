@@ -0,0 +1,44 @@
+package dealer
+
+import (
+	"fmt"
+	"testing"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanAnnotation(t *testing.T) {
+	node := MockNode("n1", 2)
+	node.Annotations = map[string]string{schetypes.AnnotationGPUUUIDs: "GPU-a,GPU-b"}
+	pod := MockPodWithDemand(Demand{{Percent: 30}, {Percent: 0}})
+	pod.Spec.Containers[0].Name = "main"
+	pod.Spec.Containers[1].Name = "sidecar"
+	planned := podWithPlan(pod, node, &Plan{Demand: Demand{{Percent: 30}, {Percent: 0}}, GPUIndexes: []int{1, NotNeedGPU}})
+
+	pa, err := PlanAnnotationOf(planned)
+	assert.NoError(t, err)
+	assert.Equal(t, PlanVersionV1, pa.Version)
+	assert.Equal(t, ContainerPlan{Name: "main", Index: 1, UUID: "GPU-b", Percent: 30, QoS: string(GetQoSClass(pod))}, pa.Containers[0])
+	assert.Equal(t, NotNeedGPU, pa.Containers[1].Index)
+
+	// pods planned before the plan annotation are converted
+	legacy := utils.GetUpdatedPodAnnotationSpec(pod, []int{0, NotNeedGPU})
+	pa, err = PlanAnnotationOf(legacy)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pa.Containers[0].Index)
+	assert.Empty(t, pa.Containers[0].UUID)
+
+	// the plan annotation alone is enough to read the plan
+	for _, c := range []string{"main", "sidecar"} {
+		delete(planned.Annotations, fmt.Sprintf(schetypes.AnnotationGPUContainerOn, c))
+	}
+	plan, err := NewPlanFromPod(planned)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, NotNeedGPU}, plan.GPUIndexes)
+
+	planned.Annotations[schetypes.AnnotationGPUPlan] = `{"version":"v9"}`
+	_, err = PlanAnnotationOf(planned)
+	assert.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package dealer
+
+import (
+	"context"
+	"encoding/json"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	log "k8s.io/klog/v2"
+)
+
+// PlanWriteStats counts the patches writing the plans of the pods and the conflicts
+// they met.
+type PlanWriteStats struct {
+	Writes    int
+	Conflicts int
+}
+
+// planPatch returns the merge patch of the metadata the plan changes on the pod. The
+// labels and the annotations are merged key by key, the finalizers are replaced as a
+// whole so their patch is guarded by the version of the pod it is built on.
+func planPatch(pod, newPod *v1.Pod) ([]byte, error) {
+	meta := map[string]interface{}{
+		"labels":      changedKeys(pod.Labels, newPod.Labels),
+		"annotations": changedKeys(pod.Annotations, newPod.Annotations),
+	}
+	if len(newPod.Finalizers) != len(pod.Finalizers) {
+		meta["finalizers"] = newPod.Finalizers
+		meta["resourceVersion"] = pod.ResourceVersion
+	}
+	return json.Marshal(map[string]interface{}{"metadata": meta})
+}
+
+func changedKeys(old, new map[string]string) map[string]string {
+	ans := make(map[string]string)
+	for k, v := range new {
+		if ov, ok := old[k]; !ok || ov != v {
+			ans[k] = v
+		}
+	}
+	return ans
+}
+
+// writePlan patches the plan onto the pod, so that changes other controllers make
+// to the pod meanwhile are kept. A patch conflicting on the finalizers is built again
+// on the latest pod. It returns the pod with the plan.
+func (d *DealerImpl) writePlan(ctx context.Context, pod *v1.Pod, node *v1.Node, plan *Plan) (*v1.Pod, error) {
+	newPod := podWithPlan(pod, node, plan)
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		patch, err := planPatch(pod, newPod)
+		if err != nil {
+			return err
+		}
+		d.PlanWrites.Writes++
+		_, err = d.Client.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+		if !apierrors.IsConflict(d.observe(err)) {
+			return err
+		}
+		d.PlanWrites.Conflicts++
+		log.V(2).Infof("write plan of pod %s/%s conflicts, retry on the latest pod", pod.Namespace, pod.Name)
+		latest, gerr := d.Client.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+		if d.observe(gerr) != nil {
+			return gerr
+		}
+		pod, newPod = latest, podWithPlan(latest, node, plan)
+		return err
+	})
+	return newPod, err
+}
+
+// PlanWriteStatus returns the plan writes and their conflicts so far.
+func (d *DealerImpl) PlanWriteStatus() PlanWriteStats {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	return d.PlanWrites
+}
@@ -0,0 +1,43 @@
+package dealer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestPlanPatch(t *testing.T) {
+	pod := MockQuotaPod("a", "p0", 40)
+	pod.ResourceVersion = "7"
+	pod.Annotations = map[string]string{"owner": "team-a", schetypes.AnnotationGPUAssume: "true"}
+	newPod := podWithPlan(pod, MockNode("n1", 2), &Plan{Demand: Demand{{Percent: 40}}, GPUIndexes: []int{1}})
+
+	patch, err := planPatch(pod, newPod)
+	assert.NoError(t, err)
+	var got struct {
+		Metadata map[string]json.RawMessage `json:"metadata"`
+	}
+	assert.NoError(t, json.Unmarshal(patch, &got))
+	var annotations map[string]string
+	assert.NoError(t, json.Unmarshal(got.Metadata["annotations"], &annotations))
+	// unchanged annotations are left to the pod
+	assert.NotContains(t, annotations, "owner")
+	assert.NotContains(t, annotations, schetypes.AnnotationGPUAssume)
+	assert.NotEmpty(t, annotations)
+	assert.Contains(t, string(got.Metadata["labels"]), schetypes.LabelGPUAssume)
+	assert.NotContains(t, got.Metadata, "finalizers")
+	assert.NotContains(t, got.Metadata, "resourceVersion")
+
+	// the finalizers are replaced as a whole, so the patch is guarded by the version
+	ReleaseFinalizer = true
+	defer func() { ReleaseFinalizer = false }()
+	newPod = podWithPlan(pod, MockNode("n1", 2), &Plan{Demand: Demand{{Percent: 40}}, GPUIndexes: []int{1}})
+	patch, err = planPatch(pod, newPod)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(patch, &got))
+	assert.Equal(t, `"7"`, string(got.Metadata["resourceVersion"]))
+	assert.Contains(t, string(got.Metadata["finalizers"]), schetypes.FinalizerGPURelease)
+}
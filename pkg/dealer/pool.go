@@ -0,0 +1,127 @@
+package dealer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// PoolStatus summarizes gpu percent capacity of the nodes labeled with the same pool.
+type PoolStatus struct {
+	Nodes int `json:"nodes"`
+	Total int `json:"total"`
+	Free  int `json:"free"`
+}
+
+// StatusSectionPrefix starts the keys of the sections of the status which are not
+// nodes, no node name starts with it.
+const StatusSectionPrefix = "_"
+
+// Status is the snapshot reported by Dealer.Status, the nodes are copies which are
+// read without the lock.
+type Status struct {
+	Nodes         map[string]*NodeInfo
+	Pools         map[string]*PoolStatus
+	Fragmentation *FragmentationReport
+	// Unhealthy maps node to the unhealthy cards and the reason.
+	Unhealthy map[string]map[int]string
+	// Continue is the Continue of the query of the next page, empty on the last.
+	Continue string
+}
+
+// MarshalJSON keeps the nodes by name at the top level as /status always served
+// them, the other sections are added alongside under the section prefix.
+func (s *Status) MarshalJSON() ([]byte, error) {
+	ans := make(map[string]interface{}, len(s.Nodes)+4)
+	for name, ni := range s.Nodes {
+		ans[name] = ni
+	}
+	ans[StatusSectionPrefix+"pools"] = s.Pools
+	ans[StatusSectionPrefix+"fragmentation"] = s.Fragmentation
+	ans[StatusSectionPrefix+"unhealthy"] = s.Unhealthy
+	if s.Continue != "" {
+		ans[StatusSectionPrefix+"continue"] = s.Continue
+	}
+	return json.Marshal(ans)
+}
+
+func GetPoolOfNode(node *v1.Node) string {
+	return node.Labels[schetypes.LabelGPUPool]
+}
+
+func GetPoolOfPod(pod *v1.Pod) string {
+	return pod.Annotations[schetypes.AnnotationGPUPool]
+}
+
+// checkPool enforces pool isolation: a pooled node only accepts pods which target
+// its pool, and only from the namespaces the pool grants access to.
+func checkPool(node *v1.Node, pod *v1.Pod) error {
+	pool, target := GetPoolOfNode(node), GetPoolOfPod(pod)
+	if pool != target {
+		if target == "" {
+			return fmt.Errorf("node %s belongs to gpu pool %s", node.Name, pool)
+		}
+		return fmt.Errorf("node %s is not in gpu pool %s", node.Name, target)
+	}
+	if pool == "" {
+		return nil
+	}
+	allowed, ok := node.Annotations[schetypes.AnnotationGPUPoolNamespaces]
+	if !ok {
+		return nil
+	}
+	for _, ns := range strings.Split(allowed, ",") {
+		if strings.TrimSpace(ns) == pod.Namespace {
+			return nil
+		}
+	}
+	return fmt.Errorf("namespace %s has no access to gpu pool %s", pod.Namespace, pool)
+}
+
+func (d *DealerImpl) checkNodePool(ni *NodeInfo, pod *v1.Pod) error {
+	node, err := d.NodeLister.Get(ni.Name)
+	if err != nil {
+		return err
+	}
+	ni.Pool = GetPoolOfNode(node)
+	return checkPool(node, pod)
+}
+
+// poolStatus reports capacity of every pool, nodes which were never used by the
+// dealer are accounted as fully free.
+func (d *DealerImpl) poolStatus() (map[string]*PoolStatus, error) {
+	pools := make(map[string]*PoolStatus)
+	nodes, err := d.NodeLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		pool := GetPoolOfNode(node)
+		if pool == "" {
+			continue
+		}
+		ps, ok := pools[pool]
+		if !ok {
+			ps = &PoolStatus{}
+			pools[pool] = ps
+		}
+		total := utils.GetGPUDeviceCountOfNode(node) * schetypes.GPUPercentEachCard
+		free := total
+		if ni, ok := d.NodeMaps[node.Name]; ok {
+			free, _ = ni.GPUs.PercentAvailableAndFreeGpuCount()
+			excludedTotal, excludedFree := ni.excludedPercent()
+			total -= excludedTotal
+			free -= excludedFree
+		}
+		ps.Nodes++
+		ps.Total += total
+		ps.Free += free
+	}
+	return pools, nil
+}
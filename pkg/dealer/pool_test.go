@@ -0,0 +1,65 @@
+package dealer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestCheckPool(t *testing.T) {
+	plain := MockNode("plain", 1)
+	prod := MockNode("prod", 1)
+	prod.Labels = map[string]string{types.LabelGPUPool: "prod"}
+	restricted := MockNode("restricted", 1)
+	restricted.Labels = map[string]string{types.LabelGPUPool: "prod"}
+	restricted.Annotations = map[string]string{types.AnnotationGPUPoolNamespaces: "serving, infra"}
+
+	pod := MockQuotaPod("serving", "p", 10)
+	assert.Nil(t, checkPool(plain, pod))
+	assert.NotNil(t, checkPool(prod, pod))
+
+	pod.Annotations[types.AnnotationGPUPool] = "prod"
+	assert.NotNil(t, checkPool(plain, pod))
+	assert.Nil(t, checkPool(prod, pod))
+	assert.Nil(t, checkPool(restricted, pod))
+
+	other := MockQuotaPod("research", "p", 10)
+	other.Annotations[types.AnnotationGPUPool] = "prod"
+	assert.NotNil(t, checkPool(restricted, other))
+}
+
+func TestPoolStatus(t *testing.T) {
+	n1, n2 := MockNode("n1", 2), MockNode("n2", 1)
+	n1.Labels = map[string]string{types.LabelGPUPool: "prod"}
+	n2.Labels = map[string]string{types.LabelGPUPool: "prod"}
+	d := MockDealer(n1, n2, MockNode("n3", 4))
+	d.NodeMaps["n1"] = NewNodeInfo("n1", n1, d.Rater)
+	d.NodeMaps["n1"].GPUs[0].Percent = 40
+
+	pools, err := d.poolStatus()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(pools))
+	assert.Equal(t, &PoolStatus{Nodes: 2, Total: 300, Free: 240}, pools["prod"])
+}
+
+func TestStatusKeepsNodesAtTopLevel(t *testing.T) {
+	n1 := MockNode("n1", 1)
+	d := MockDealer(n1)
+	d.NodeMaps["n1"] = NewNodeInfo("n1", n1, d.Rater)
+
+	status, err := d.Status()
+	assert.Nil(t, err)
+	d.NodeMaps["n1"].GPUs[0].Percent = 40
+	assert.Equal(t, 100, status.Nodes["n1"].GPUs[0].Percent)
+
+	data, err := json.Marshal(status)
+	assert.Nil(t, err)
+	sections := map[string]json.RawMessage{}
+	assert.Nil(t, json.Unmarshal(data, &sections))
+	assert.Contains(t, sections, "n1")
+	assert.Contains(t, sections, StatusSectionPrefix+"pools")
+	assert.NotContains(t, sections, "nodes")
+}
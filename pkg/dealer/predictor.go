@@ -0,0 +1,71 @@
+package dealer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PredictorPassthrough is the predictor taking the last reported usage as is.
+const PredictorPassthrough = "passthrough"
+
+// Predictor forecasts the usage of a card, the load-aware path schedules on the
+// prediction instead of the last reported usage.
+type Predictor interface {
+	// PredictUsage returns the usage in [0, 1] of the metric expected on the card
+	// horizon from now given the last reported usage.
+	PredictUsage(node, metric string, card int, last float64, horizon time.Duration) float64
+}
+
+// Passthrough predicts the last reported usage.
+type Passthrough struct{}
+
+func (Passthrough) PredictUsage(node, metric string, card int, last float64, horizon time.Duration) float64 {
+	return last
+}
+
+var (
+	// UsagePredictor is consulted by the load-aware path.
+	UsagePredictor Predictor = Passthrough{}
+	// PredictionHorizon is how far ahead the usage is predicted, about how long a
+	// pod takes from binding to running.
+	PredictionHorizon time.Duration
+
+	predictorsLock sync.Mutex
+	predictors     = map[string]func() Predictor{
+		PredictorPassthrough: func() Predictor { return Passthrough{} },
+	}
+)
+
+// RegisterPredictor makes a predictor selectable by name, the factory is called
+// once the flags are parsed.
+func RegisterPredictor(name string, factory func() Predictor) {
+	predictorsLock.Lock()
+	defer predictorsLock.Unlock()
+	predictors[name] = factory
+}
+
+func NewPredictor(name string) (Predictor, error) {
+	predictorsLock.Lock()
+	defer predictorsLock.Unlock()
+	factory, ok := predictors[name]
+	if !ok {
+		names := make([]string, 0, len(predictors))
+		for name := range predictors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("predictor %s is not supported, use one of %v", name, names)
+	}
+	return factory(), nil
+}
+
+// predictUsage returns the usage the load-aware path schedules on, clamped to [0, 1].
+func predictUsage(node, metric string, card int, last float64) float64 {
+	if UsagePredictor == nil {
+		return last
+	}
+	return math.Max(0, math.Min(1, UsagePredictor.PredictUsage(node, metric, card, last, PredictionHorizon)))
+}
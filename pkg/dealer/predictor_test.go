@@ -0,0 +1,46 @@
+package dealer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type trendPredictor struct {
+	horizon time.Duration
+}
+
+func (p *trendPredictor) PredictUsage(node, metric string, card int, last float64, horizon time.Duration) float64 {
+	p.horizon = horizon
+	return last * 3
+}
+
+func TestPredictor(t *testing.T) {
+	defer func(p Predictor, h time.Duration) { UsagePredictor, PredictionHorizon = p, h }(UsagePredictor, PredictionHorizon)
+	_, err := NewPredictor("oracle")
+	assert.Error(t, err)
+	trend := &trendPredictor{}
+	RegisterPredictor("trend", func() Predictor { return trend })
+	defer delete(predictors, "trend")
+
+	d := MockDealer(MockNode("n1", 2))
+	now := time.Now().In(loc).Format(timeFormat)
+	d.CoreUsage["n1"] = map[int]GPUCoreUsage{
+		0: NewGPUCoreUsage("0.2", now),
+		1: NewGPUCoreUsage("0.5", now),
+	}
+	gpus := NewNodeInfo("n1", MockNode("n1", 2), d.Rater).GPUs
+	spec := PolicySpec{SyncPeriod: []Period{{Name: GPUCoreUsagePriority, Period: time.Minute}}}
+
+	usage, ok := measuredCardsUsage(gpus, d, GPUCoreUsagePriority, spec, "n1")
+	assert.True(t, ok)
+	assert.InDelta(t, 0.35, usage, 1e-9)
+
+	UsagePredictor, err = NewPredictor("trend")
+	assert.NoError(t, err)
+	PredictionHorizon = time.Minute
+	usage, _ = measuredCardsUsage(gpus, d, GPUCoreUsagePriority, spec, "n1")
+	assert.InDelta(t, 0.8, usage, 1e-9)
+	assert.Equal(t, time.Minute, trend.horizon)
+}
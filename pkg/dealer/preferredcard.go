@@ -0,0 +1,64 @@
+package dealer
+
+import (
+	"strconv"
+	"strings"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// PreferredCardBonus is added to the score of the node holding the preferred card of a
+// pod when its plan uses that card, 0 disables the bonus while the card is still
+// preferred within the node.
+var PreferredCardBonus = 50
+
+// preferredCard returns the card of the node the pod prefers, false when it prefers
+// none of its cards.
+func preferredCard(ni *NodeInfo, pod *v1.Pod) (int, bool) {
+	value, ok := pod.Annotations[schetypes.AnnotationPreferredCard]
+	if !ok {
+		return 0, false
+	}
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 || parts[0] != ni.Name {
+		return 0, false
+	}
+	card, err := strconv.Atoi(parts[1])
+	if err != nil {
+		card = ni.indexOfUUID(parts[1])
+	}
+	if card < 0 || card >= len(ni.GPUs) {
+		return 0, false
+	}
+	return card, true
+}
+
+// onlyCard keeps the share of a single card.
+func (g GPUs) onlyCard(card int) GPUs {
+	others := make(map[int]string)
+	for i := range g {
+		if i != card {
+			others[i] = ""
+		}
+	}
+	return g.WithoutCards(others)
+}
+
+func planUsesCard(plan *Plan, card int) bool {
+	for _, idx := range plan.GPUIndexes {
+		if idx == card {
+			return true
+		}
+	}
+	return false
+}
+
+// preferredCardBonus rewards the plans placing the pod on its preferred card.
+func preferredCardBonus(ni *NodeInfo, pod *v1.Pod, plan *Plan) int {
+	if card, ok := preferredCard(ni, pod); ok && planUsesCard(plan, card) {
+		return PreferredCardBonus
+	}
+	return 0
+}
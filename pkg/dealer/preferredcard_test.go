@@ -0,0 +1,48 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestPreferredCard(t *testing.T) {
+	d := MockDealer(MockNode("n1", 3))
+	ni := NewNodeInfo("n1", MockNode("n1", 3), d.Rater)
+	ni.UUIDs = []string{"GPU-a", "GPU-b", "GPU-c"}
+	d.NodeMaps["n1"] = ni
+	ni.GPUs[0].Percent = 50
+
+	pod := MockQuotaPod("a", "p0", 40)
+	demand := NewDemandFromPod(pod)
+	ok, err := ni.Assume(demand, pod, d, PolicySpec{}, false)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	plan := ni.PlanCache[planKey(demand, pod)]
+	assert.Equal(t, []int{0}, plan.GPUIndexes)
+	assert.Equal(t, 0, preferredCardBonus(ni, pod, plan))
+
+	for _, hint := range []string{"n1/2", "n1/GPU-c"} {
+		pod.Annotations = map[string]string{types.AnnotationPreferredCard: hint}
+		ni.cleanPlan()
+		ok, err = ni.Assume(demand, pod, d, PolicySpec{}, false)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		plan = ni.PlanCache[planKey(demand, pod)]
+		assert.Equal(t, []int{2}, plan.GPUIndexes, hint)
+		assert.Equal(t, PreferredCardBonus, preferredCardBonus(ni, pod, plan))
+	}
+
+	// a full preferred card falls back to the rater, hints of other nodes are ignored
+	ni.GPUs[2].Percent = 20
+	ni.cleanPlan()
+	ok, err = ni.Assume(demand, pod, d, PolicySpec{}, false)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []int{0}, ni.PlanCache[planKey(demand, pod)].GPUIndexes)
+	pod.Annotations[types.AnnotationPreferredCard] = "n2/2"
+	_, ok = preferredCard(ni, pod)
+	assert.False(t, ok)
+}
@@ -0,0 +1,84 @@
+package dealer
+
+import (
+	"k8s.io/klog"
+)
+
+const (
+	GPUTemperaturePriority     = "gpu_temperature"
+	GPUPowerUsagePriority      = "gpu_power_usage"
+	GPUMemoryBandwidthPriority = "gpu_memory_bandwidth_usage"
+
+	// PressureScore is the score taken from a node whose cards all report full
+	// pressure with weight 1.
+	PressureScore = 100
+)
+
+// GPUUsage is a reported usage of a metric other than core and memory, the value is
+// normalized to [0, 1] by the prometheus query.
+type GPUUsage struct {
+	Usage      string
+	UpdateTime string
+}
+
+// IsPressureMetric reports whether a metric measures how hot or power capped a card
+// is rather than how much of it is used. Pressure metrics only lower the score.
+func IsPressureMetric(name string) bool {
+	switch name {
+	case GPUTemperaturePriority, GPUPowerUsagePriority, GPUMemoryBandwidthPriority:
+		return true
+	}
+	return false
+}
+
+// Weight of a metric in load aware scoring, metrics without priority policy weigh 1.
+func (ps PolicySpec) Weight(name string) float64 {
+	for _, p := range ps.Priority {
+		if p.Name == name {
+			return p.Weight
+		}
+	}
+	return 1
+}
+
+func (d *DealerImpl) UpdateMetricUsage(nodeName, key, usage, updateTime string, cardNum int) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	usage = d.smoothUsage(key, nodeName, cardNum, usage)
+	if d.MetricUsage[key] == nil {
+		d.MetricUsage[key] = make(map[string]map[int]GPUUsage)
+	}
+	if d.MetricUsage[key][nodeName] == nil {
+		d.MetricUsage[key][nodeName] = make(map[int]GPUUsage)
+	}
+	d.MetricUsage[key][nodeName][cardNum] = GPUUsage{Usage: usage, UpdateTime: updateTime}
+	d.cacheUsage(nodeName, key, cardNum, usage, updateTime)
+}
+
+// Pressure is the weighted pressure of the gpus averaged over the cards.
+func (g GPUs) Pressure(d Dealer, policySpec PolicySpec, nodeName string) float64 {
+	if len(g) == 0 {
+		return 0
+	}
+	pressure := 0.0
+	for _, period := range policySpec.SyncPeriod {
+		if !IsPressureMetric(period.Name) || period.Period == 0 {
+			continue
+		}
+		weight := policySpec.Weight(period.Name)
+		for i := range g {
+			exist, usage, err := d.GetUsage(nodeName, period.Name, i, ActiveDuration(period.Period))
+			if !exist || err != nil {
+				continue
+			}
+			pressure += weight * usage
+		}
+	}
+	return pressure / float64(len(g))
+}
+
+func (g GPUs) pressurePenalty(d Dealer, policySpec PolicySpec, nodeName string) int {
+	penalty := int(g.Pressure(d, policySpec, nodeName) * PressureScore)
+	klog.V(5).Infof("pressure penalty of node %s: %d", nodeName, penalty)
+	return penalty
+}
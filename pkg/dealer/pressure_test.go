@@ -0,0 +1,32 @@
+package dealer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPressure(t *testing.T) {
+	d := MockDealer(MockNode("n1", 2))
+	now := time.Now().In(loc).Format(timeFormat)
+	d.UpdateMetricUsage("n1", GPUTemperaturePriority, "0.8", now, 0)
+	d.UpdateMetricUsage("n1", GPUTemperaturePriority, "0.4", now, 1)
+	d.UpdateMetricUsage("n1", GPUPowerUsagePriority, "1", now, 0)
+	gpus := GPUs{{Percent: 100, PercentTotal: 100}, {Percent: 100, PercentTotal: 100}}
+
+	spec := PolicySpec{
+		SyncPeriod: []Period{
+			{Name: GPUTemperaturePriority, Period: time.Minute},
+			{Name: GPUPowerUsagePriority, Period: time.Minute},
+		},
+		Priority: []PriorityPolicy{{Name: GPUPowerUsagePriority, Weight: 0.5}},
+	}
+	// (0.8 + 0.4 + 0.5 * 1) / 2 cards
+	assert.InDelta(t, 0.85, gpus.Pressure(d, spec, "n1"), 1e-9)
+	assert.Equal(t, 85, gpus.pressurePenalty(d, spec, "n1"))
+
+	// pressure metrics never count as load
+	assert.Equal(t, 0.0, gpus[0].LoadUsage(d, 0, spec, "n1"))
+	assert.Equal(t, 0.0, gpus.Pressure(d, spec, "n2"))
+}
@@ -0,0 +1,113 @@
+package dealer
+
+import (
+	"math"
+	"time"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// PriorityContentionPenalty is subtracted from the score of a node which is the
+	// best fit of a pending pod with higher priority and can't hold both pods.
+	PriorityContentionPenalty = 50
+	pendingPodExpiration      = 10 * time.Minute
+)
+
+var (
+	PriorityAware     bool
+	StarvationTimeout = 5 * time.Minute
+)
+
+// pendingPod is a gpu pod which was filtered but not bound yet.
+type pendingPod struct {
+	Pod       *v1.Pod
+	Percent   int
+	FirstSeen time.Time
+	LastSeen  time.Time
+	BestNode  string
+}
+
+// EffectivePriority returns the pod priority, pods pending longer than
+// StarvationTimeout are promoted so they can't be starved by newer pods.
+func (p *pendingPod) EffectivePriority(now time.Time) int32 {
+	if now.Sub(p.FirstSeen) >= StarvationTimeout {
+		return math.MaxInt32
+	}
+	return podPriority(p.Pod)
+}
+
+func podPriority(pod *v1.Pod) int32 {
+	if pod.Spec.Priority == nil {
+		return 0
+	}
+	return *pod.Spec.Priority
+}
+
+// trackPending records the pod as pending together with the node of its best plan.
+func (d *DealerImpl) trackPending(pod *v1.Pod, nodeInfos []*NodeInfo, assumed []bool) {
+	if !PriorityAware {
+		return
+	}
+	now := time.Now()
+	pp, ok := d.PendingPods[pod.UID]
+	if !ok {
+		pp = &pendingPod{FirstSeen: now}
+		d.PendingPods[pod.UID] = pp
+	}
+	pp.Pod = pod
+	pp.Percent = int(utils.GetGPUPercentFromPodResource(pod))
+	pp.LastSeen = now
+	pp.BestNode = ""
+	best := math.MinInt32
+	for i, ni := range nodeInfos {
+		if ni == nil || !assumed[i] {
+			continue
+		}
+		if plan, ok := ni.PlanCache[planKey(NewDemandFromPod(pod), pod)]; ok && plan.Score > best {
+			best = plan.Score
+			pp.BestNode = ni.Name
+		}
+	}
+	for uid, p := range d.PendingPods {
+		if now.Sub(p.LastSeen) > pendingPodExpiration {
+			delete(d.PendingPods, uid)
+		}
+	}
+}
+
+func (d *DealerImpl) forgetPending(uid types.UID) {
+	delete(d.PendingPods, uid)
+	delete(d.Queued, uid)
+	delete(d.Hints, uid)
+}
+
+// contentionPenalty penalizes nodes which are the best fit of a pending pod with
+// higher effective priority when the node can't hold both pods.
+func (d *DealerImpl) contentionPenalty(pod *v1.Pod, ni *NodeInfo) int {
+	if !PriorityAware {
+		return 0
+	}
+	now := time.Now()
+	priority := podPriority(pod)
+	if pp, ok := d.PendingPods[pod.UID]; ok {
+		priority = pp.EffectivePriority(now)
+	}
+	percent := int(utils.GetGPUPercentFromPodResource(pod))
+	available, _ := ni.GPUs.PercentAvailableAndFreeGpuCount()
+	for uid, other := range d.PendingPods {
+		if uid == pod.UID || other.BestNode != ni.Name {
+			continue
+		}
+		if other.EffectivePriority(now) <= priority {
+			continue
+		}
+		if available < percent+other.Percent {
+			return PriorityContentionPenalty
+		}
+	}
+	return 0
+}
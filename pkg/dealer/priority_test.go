@@ -0,0 +1,36 @@
+package dealer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentionPenalty(t *testing.T) {
+	defer func() { PriorityAware = false }()
+	PriorityAware = true
+
+	d := MockDealer(MockNode("n1", 1))
+	ni := NewNodeInfo("n1", MockNode("n1", 1), d.Rater)
+	ni.GPUs[0].Percent = 60
+	d.NodeMaps["n1"] = ni
+
+	high, low := int32(100), int32(10)
+	important := MockQuotaPod("ns", "important", 50)
+	important.Spec.Priority = &high
+	d.PendingPods[important.UID] = &pendingPod{Pod: important, Percent: 50, FirstSeen: time.Now(), LastSeen: time.Now(), BestNode: "n1"}
+
+	pod := MockQuotaPod("ns", "pod", 20)
+	pod.Spec.Priority = &low
+	assert.Equal(t, PriorityContentionPenalty, d.contentionPenalty(pod, ni))
+
+	// enough room for both pods
+	small := MockQuotaPod("ns", "small", 10)
+	small.Spec.Priority = &low
+	assert.Equal(t, 0, d.contentionPenalty(small, ni))
+
+	// starving pods are promoted over the high priority one
+	d.PendingPods[pod.UID] = &pendingPod{Pod: pod, Percent: 20, FirstSeen: time.Now().Add(-StarvationTimeout), LastSeen: time.Now()}
+	assert.Equal(t, 0, d.contentionPenalty(pod, ni))
+}
@@ -0,0 +1,60 @@
+package dealer
+
+import (
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	log "k8s.io/klog/v2"
+)
+
+// WorkloadProfile is the policy of the pods labeled with its name, like spread and
+// load-aware for inference and binpack by request for training. It replaces the
+// scoring, the rules and the cost of the policy for them.
+type WorkloadProfile struct {
+	// Priority is the priority algorithm of the pods, the global one when empty.
+	Priority string `yaml:"priority"`
+	// LoadAware schedules the pods by their measured load, it only takes effect when
+	// the usage is synced with isLoadSchedule. The global setting holds when unset.
+	LoadAware *bool          `yaml:"loadAware"`
+	Scoring   ScoringWeights `yaml:"scoring"`
+	Rules     Rules          `yaml:"rules"`
+	Cost      CostPolicy     `yaml:"cost"`
+}
+
+// CompileProfiles compiles the rules of the profiles and their priority algorithms,
+// profiles with an unknown one keep the global algorithm.
+func (ps *PolicySpec) CompileProfiles() {
+	raters := make(map[string]Rater)
+	for name, profile := range ps.Profiles {
+		profile.Rules.Compile()
+		ps.Profiles[name] = profile
+		if profile.Priority == "" {
+			continue
+		}
+		rater, err := NewRater(profile.Priority)
+		if err != nil {
+			log.Errorf("profile %s: %v", name, err)
+			continue
+		}
+		raters[name] = rater
+	}
+	ps.ProfileRaters = raters
+}
+
+// ResolveProfile returns the policy and the load awareness of the pod, those of its
+// profile when it is labeled with one and else those of its experiment arm.
+func ResolveProfile(pod *v1.Pod, policySpec PolicySpec, isLoadSchedule bool) (PolicySpec, bool) {
+	policySpec = experimentSpec(pod, policySpec)
+	profile, ok := policySpec.Profiles[pod.Labels[schetypes.LabelWorkloadProfile]]
+	if !ok {
+		return policySpec, isLoadSchedule
+	}
+	resolved := policySpec
+	resolved.Scoring = profile.Scoring
+	resolved.Rules = profile.Rules
+	resolved.Cost = profile.Cost
+	if profile.LoadAware != nil {
+		isLoadSchedule = isLoadSchedule && *profile.LoadAware
+	}
+	return resolved, isLoadSchedule
+}
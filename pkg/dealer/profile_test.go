@@ -0,0 +1,56 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestResolveProfile(t *testing.T) {
+	policy := &Policy{}
+	assert.Nil(t, yaml.Unmarshal([]byte(`
+spec:
+  cost:
+    weight: 20
+  profiles:
+    inference:
+      priority: spread
+      loadAware: true
+      rules:
+        filters:
+          - name: cool
+            expression: usage.core < 0.5
+    training:
+      priority: binpack
+      loadAware: false
+`), policy))
+	policy.Spec.CompileProfiles()
+
+	pod := MockQuotaPod("a", "p0", 50)
+	spec, load := ResolveProfile(pod, policy.Spec, true)
+	assert.Equal(t, 20, spec.Cost.Weight)
+	assert.True(t, load)
+	assert.IsType(t, &Binpack{}, raterOf(pod, policy.Spec, &Binpack{}))
+
+	pod.Labels = map[string]string{schetypes.LabelWorkloadProfile: "inference"}
+	spec, load = ResolveProfile(pod, policy.Spec, true)
+	assert.Equal(t, 0, spec.Cost.Weight)
+	assert.Len(t, spec.Rules.Filters, 1)
+	assert.True(t, load)
+	// the usage isn't synced
+	_, load = ResolveProfile(pod, policy.Spec, false)
+	assert.False(t, load)
+	assert.IsType(t, &Spread{}, raterOf(pod, policy.Spec, &Binpack{}))
+	// a reload compiles a new policy and leaves the one in use alone
+	reloaded := &PolicySpec{}
+	reloaded.CompileProfiles()
+	assert.IsType(t, &Spread{}, raterOf(pod, policy.Spec, &Binpack{}))
+	assert.IsType(t, &Binpack{}, raterOf(pod, *reloaded, &Binpack{}))
+
+	pod.Labels[schetypes.LabelWorkloadProfile] = "training"
+	_, load = ResolveProfile(pod, policy.Spec, true)
+	assert.False(t, load)
+}
@@ -0,0 +1,70 @@
+package dealer
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ErrStaleSequence rejects a pushed usage report older than one already taken.
+var ErrStaleSequence = errors.New("stale usage report sequence")
+
+// UsageReport is the usage of the cards of a node pushed by its agent. Seq grows
+// with every report of a session, a restarted agent starts a new session.
+type UsageReport struct {
+	Node    string      `json:"node"`
+	Session string      `json:"session"`
+	Seq     uint64      `json:"seq"`
+	Cards   []CardUsage `json:"cards"`
+}
+
+// CardUsage is the usage in [0, 1] of a card, unreported metrics are kept.
+type CardUsage struct {
+	Card   int      `json:"card"`
+	Core   *float64 `json:"core,omitempty"`
+	Memory *float64 `json:"memory,omitempty"`
+}
+
+type pushState struct {
+	session string
+	seq     uint64
+}
+
+// PushUsage takes a usage report pushed by a node agent as if the usage had been
+// synced from prometheus.
+func (d *DealerImpl) PushUsage(report UsageReport) error {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	if report.Node == "" {
+		return fmt.Errorf("usage report without node")
+	}
+	ni, ok := d.NodeMaps[report.Node]
+	if !ok {
+		return fmt.Errorf("usage report of unknown node %s", report.Node)
+	}
+	if last, ok := d.Pushes[report.Node]; ok && last.session == report.Session && report.Seq <= last.seq {
+		return fmt.Errorf("%w %d of node %s, last is %d", ErrStaleSequence, report.Seq, report.Node, last.seq)
+	}
+	for _, c := range report.Cards {
+		if c.Card < 0 || c.Card >= ni.Capacity {
+			return fmt.Errorf("card %d of node %s is out of its %d cards", c.Card, report.Node, ni.Capacity)
+		}
+		for _, u := range []*float64{c.Core, c.Memory} {
+			if u != nil && (*u < 0 || *u > 1) {
+				return fmt.Errorf("usage %f of card %d of node %s is out of [0, 1]", *u, c.Card, report.Node)
+			}
+		}
+	}
+	d.Pushes[report.Node] = pushState{session: report.Session, seq: report.Seq}
+	now := time.Now().In(loc).Format(timeFormat)
+	for _, c := range report.Cards {
+		if c.Core != nil {
+			d.updateCoreUsage(report.Node, strconv.FormatFloat(*c.Core, 'f', -1, 64), now, c.Card)
+		}
+		if c.Memory != nil {
+			d.updateMemoryUsage(report.Node, strconv.FormatFloat(*c.Memory, 'f', -1, 64), now, c.Card)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package dealer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPushUsage(t *testing.T) {
+	d := MockDealer(MockNode("n1", 2))
+	d.NodeMaps["n1"] = NewNodeInfo("n1", MockNode("n1", 2), d.Rater)
+	core, memory := 0.4, 0.7
+	report := UsageReport{Node: "n1", Session: "boot-1", Seq: 1, Cards: []CardUsage{
+		{Card: 0, Core: &core, Memory: &memory},
+		{Card: 1, Core: &memory},
+	}}
+	assert.NoError(t, d.PushUsage(report))
+
+	exist, usage, err := d.GetUsage("n1", GPUCoreUsagePriority, 0, time.Minute)
+	assert.True(t, exist)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.4, usage)
+	_, usage, _ = d.GetUsage("n1", GPUMemoryUsagePriority, 0, time.Minute)
+	assert.Equal(t, 0.7, usage)
+
+	err = d.PushUsage(report)
+	assert.True(t, errors.Is(err, ErrStaleSequence))
+	report.Session = "boot-2"
+	assert.NoError(t, d.PushUsage(report))
+
+	bad := 1.5
+	assert.Error(t, d.PushUsage(UsageReport{Node: "n1", Session: "boot-2", Seq: 2, Cards: []CardUsage{{Core: &bad}}}))
+	assert.Error(t, d.PushUsage(UsageReport{}))
+
+	// unknown nodes and cards are rejected without leaving any state
+	assert.Error(t, d.PushUsage(UsageReport{Node: "n2", Session: "boot-1", Seq: 1, Cards: []CardUsage{{Core: &core}}}))
+	assert.Error(t, d.PushUsage(UsageReport{Node: "n1", Session: "boot-2", Seq: 3, Cards: []CardUsage{{Card: 2, Core: &core}}}))
+	assert.NotContains(t, d.Pushes, "n2")
+	assert.NotContains(t, d.CoreUsage, "n2")
+	assert.Equal(t, uint64(1), d.Pushes["n1"].seq)
+}
@@ -0,0 +1,129 @@
+package dealer
+
+import (
+	"fmt"
+	"strconv"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+type QoSClass string
+
+const (
+	QoSGuaranteed QoSClass = "guaranteed"
+	QoSBurstable  QoSClass = "burstable"
+	QoSBestEffort QoSClass = "best-effort"
+)
+
+// QoSReservation reserves only the core request of burstable and best-effort pods,
+// it only makes sense with load-aware scheduling watching the real usage.
+var QoSReservation = false
+
+// coreRequest returns the core request of the container, its gpu percent is the
+// limit and the request defaults to it.
+func coreRequest(pod *v1.Pod, c *v1.Container) int {
+	limit := utils.GetGPUPercentFromContainer(c)
+	if pod.Annotations == nil {
+		return limit
+	}
+	val, ok := pod.Annotations[fmt.Sprintf(schetypes.AnnotationGPUCoreRequest, c.Name)]
+	if !ok {
+		return limit
+	}
+	request, err := strconv.Atoi(val)
+	if err != nil || request < 0 || request > limit {
+		return limit
+	}
+	return request
+}
+
+// GetQoSClass derives the class from the core request and limit of the gpu
+// containers like kubelet does for cpu and memory.
+func GetQoSClass(pod *v1.Pod) QoSClass {
+	requested, limited, guaranteed := 0, 0, true
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+		limit := utils.GetGPUPercentFromContainer(c)
+		if limit == 0 {
+			continue
+		}
+		request := coreRequest(pod, c)
+		requested += request
+		limited += limit
+		if request != limit {
+			guaranteed = false
+		}
+	}
+	switch {
+	case limited > 0 && requested == 0:
+		return QoSBestEffort
+	case guaranteed:
+		return QoSGuaranteed
+	default:
+		return QoSBurstable
+	}
+}
+
+// placeOpportunistic puts the containers reserving nothing on the card with the
+// most room, counting the load when it is known.
+func (g GPUs) placeOpportunistic(plan *Plan, pod *v1.Pod) error {
+	for i := range pod.Spec.Containers {
+		if i >= len(plan.Demand) || plan.Demand[i].Percent > 0 || utils.GetGPUPercentFromContainer(&pod.Spec.Containers[i]) == 0 {
+			continue
+		}
+		best := -1
+		for j, r := range g {
+			if r.Percent <= 0 {
+				continue
+			}
+			if best < 0 || r.Percent+r.RemainLoad*50 > g[best].Percent+g[best].RemainLoad*50 {
+				best = j
+			}
+		}
+		if best < 0 {
+			return fmt.Errorf("no card for best-effort container %s on %s", pod.Spec.Containers[i].Name, g)
+		}
+		plan.GPUIndexes[i] = best
+	}
+	return nil
+}
+
+// addQoS tracks the gpu percent of every class on the cards of the plan, sign is 1
+// on allocation and -1 on release.
+func (ni *NodeInfo) addQoS(pod *v1.Pod, plan *Plan, sign int) {
+	class := GetQoSClass(pod)
+	if ni.QoS == nil {
+		ni.QoS = make(map[int]map[QoSClass]int)
+	}
+	for i, idx := range plan.GPUIndexes {
+		if idx < 0 || i >= len(pod.Spec.Containers) {
+			continue
+		}
+		percent := utils.GetGPUPercentFromContainer(&pod.Spec.Containers[i])
+		if percent == 0 {
+			continue
+		}
+		if ni.QoS[idx] == nil {
+			ni.QoS[idx] = make(map[QoSClass]int)
+		}
+		ni.QoS[idx][class] += sign * percent
+		if ni.QoS[idx][class] <= 0 {
+			delete(ni.QoS[idx], class)
+		}
+		if len(ni.QoS[idx]) == 0 {
+			delete(ni.QoS, idx)
+		}
+	}
+}
+
+// coreReserved returns the core request of the container when QoS reservation is on,
+// its gpu percent otherwise.
+func coreReserved(pod *v1.Pod, c *v1.Container) int {
+	if QoSReservation {
+		return coreRequest(pod, c)
+	}
+	return utils.GetGPUPercentFromContainer(c)
+}
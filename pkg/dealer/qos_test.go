@@ -0,0 +1,59 @@
+package dealer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+)
+
+func MockQoSPod(name string, percent int, request string) *v1.Pod {
+	pod := MockQuotaPod("a", name, percent)
+	pod.Spec.NodeName = "n1"
+	pod.Spec.Containers[0].Name = "main"
+	if request != "" {
+		pod.Annotations[fmt.Sprintf(types.AnnotationGPUCoreRequest, "main")] = request
+	}
+	return pod
+}
+
+func TestGetQoSClass(t *testing.T) {
+	assert.Equal(t, QoSGuaranteed, GetQoSClass(MockQoSPod("p", 50, "")))
+	assert.Equal(t, QoSGuaranteed, GetQoSClass(MockQoSPod("p", 50, "80")))
+	assert.Equal(t, QoSBurstable, GetQoSClass(MockQoSPod("p", 50, "20")))
+	assert.Equal(t, QoSBestEffort, GetQoSClass(MockQoSPod("p", 50, "0")))
+}
+
+func TestQoSReservation(t *testing.T) {
+	node := MockNode("n1", 2)
+	d := MockDealer(node)
+	ni := NewNodeInfo("n1", node, d.Rater)
+	d.NodeMaps["n1"] = ni
+
+	burstable := MockQoSPod("b", 50, "20")
+	assert.Equal(t, Demand{{Percent: 50}}, NewDemandFromPod(burstable))
+	QoSReservation = true
+	defer func() { QoSReservation = false }()
+	assert.Equal(t, Demand{{Percent: 20}}, NewDemandFromPod(burstable))
+	assert.Nil(t, d.Allocate(utils.GetUpdatedPodAnnotationSpec(burstable, []int{0})))
+	assert.Equal(t, 80, ni.GPUs[0].Percent)
+
+	// best-effort pods reserve nothing and go to the card with the most room
+	be := MockQoSPod("e", 50, "0")
+	demand := NewDemandFromPod(be)
+	assumed, err := ni.Assume(demand, be, d, PolicySpec{}, false)
+	assert.True(t, assumed)
+	assert.Nil(t, err)
+	plan := ni.PlanCache[planKey(demand, be)]
+	assert.Equal(t, []int{1}, plan.GPUIndexes)
+	assert.Nil(t, d.Allocate(utils.GetUpdatedPodAnnotationSpec(be, plan.GPUIndexes)))
+	assert.Equal(t, 100, ni.GPUs[1].Percent)
+	assert.Equal(t, map[int]map[QoSClass]int{0: {QoSBurstable: 50}, 1: {QoSBestEffort: 50}}, ni.QoS)
+
+	assert.Nil(t, d.Release(utils.GetUpdatedPodAnnotationSpec(be, plan.GPUIndexes)))
+	assert.Equal(t, map[int]map[QoSClass]int{0: {QoSBurstable: 50}}, ni.QoS)
+}
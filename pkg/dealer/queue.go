@@ -0,0 +1,144 @@
+package dealer
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// QueuedPod is a gpu pod which passed the constraints of some nodes but found no
+// cards to fit on any of them, it waits for gpu capacity rather than anything else.
+type QueuedPod struct {
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	UID       types.UID `json:"uid"`
+	// Demand is the gpu percent of each container asking for a share.
+	Demand    []int     `json:"demand"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+	// ClosestNode is the node missing the least gpu percent, Shortfall how much.
+	ClosestNode string `json:"closestNode,omitempty"`
+	Shortfall   int    `json:"shortfall"`
+	// Attempts counts the filterings the pod failed on gpu capacity, Limit is what
+	// limited the last one and Limits how many each dimension limited.
+	Attempts  int            `json:"attempts"`
+	Limit     string         `json:"limit"`
+	Limits    map[string]int `json:"limits,omitempty"`
+	Exhausted bool           `json:"exhausted,omitempty"`
+	Reported  bool           `json:"-"`
+}
+
+// trackQueued records the pod when it failed on the cards of every node that passed
+// its constraints, it is dropped from the queue when it fits or no node failed it
+// on its cards.
+func (d *DealerImpl) trackQueued(pod *v1.Pod, demand Demand, nodeInfos []*NodeInfo, assumed []bool, res []error, policySpec PolicySpec, isLoadSchedule bool) {
+	now := time.Now()
+	for uid, q := range d.Queued {
+		if now.Sub(q.LastSeen) > pendingPodExpiration {
+			delete(d.Queued, uid)
+		}
+	}
+	closest, shortfall := "", -1
+	var full []*NodeInfo
+	for i, ni := range nodeInfos {
+		if ni == nil {
+			continue
+		}
+		if assumed[i] {
+			delete(d.Queued, pod.UID)
+			return
+		}
+		if !errors.Is(res[i], ErrNoCards) {
+			continue
+		}
+		full = append(full, ni)
+		missing := cardShortfall(ni.schedulableGPUs(pod, d), demand)
+		if shortfall < 0 || missing < shortfall || missing == shortfall && ni.Name < closest {
+			closest, shortfall = ni.Name, missing
+		}
+	}
+	if shortfall < 0 {
+		delete(d.Queued, pod.UID)
+		return
+	}
+	q, ok := d.Queued[pod.UID]
+	if !ok {
+		q = &QueuedPod{Namespace: pod.Namespace, Name: pod.Name, UID: pod.UID, FirstSeen: now}
+		d.Queued[pod.UID] = q
+	}
+	q.Demand = q.Demand[:0]
+	for _, r := range demand {
+		if r.Percent > 0 {
+			q.Demand = append(q.Demand, r.Percent)
+		}
+	}
+	q.LastSeen = now
+	q.ClosestNode, q.Shortfall = closest, shortfall
+	countAttempt(q, d.classifyQueued(pod, demand, full, shortfall, policySpec, isLoadSchedule))
+}
+
+// cardShortfall returns the gpu percent the cards miss to hold the shares, placing
+// the largest shares first on the freest cards.
+func cardShortfall(gpus GPUs, demand Demand) int {
+	free := make([]int, len(gpus))
+	for i, g := range gpus {
+		free[i] = g.Percent
+	}
+	shares := make([]int, 0, len(demand))
+	for _, r := range demand {
+		if r.Percent > 0 {
+			shares = append(shares, r.Percent)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(shares)))
+	missing := 0
+	for _, share := range shares {
+		freest := -1
+		for i := range free {
+			if freest < 0 || free[i] > free[freest] {
+				freest = i
+			}
+		}
+		if freest < 0 {
+			missing += share
+			continue
+		}
+		if free[freest] < share {
+			missing += share - free[freest]
+			free[freest] = 0
+			continue
+		}
+		free[freest] -= share
+	}
+	return missing
+}
+
+func (q *QueuedPod) copy() QueuedPod {
+	c := *q
+	c.Demand = append([]int(nil), q.Demand...)
+	c.Limits = make(map[string]int, len(q.Limits))
+	for limit, n := range q.Limits {
+		c.Limits[limit] = n
+	}
+	return c
+}
+
+// QueuedPods returns the pods waiting for gpu capacity, the longest waiting first.
+func (d *DealerImpl) QueuedPods() []QueuedPod {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	ans := make([]QueuedPod, 0, len(d.Queued))
+	for _, q := range d.Queued {
+		ans = append(ans, q.copy())
+	}
+	sort.Slice(ans, func(i, j int) bool {
+		if !ans[i].FirstSeen.Equal(ans[j].FirstSeen) {
+			return ans[i].FirstSeen.Before(ans[j].FirstSeen)
+		}
+		return ans[i].UID < ans[j].UID
+	})
+	return ans
+}
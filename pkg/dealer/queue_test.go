@@ -0,0 +1,58 @@
+package dealer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestQueuedPods(t *testing.T) {
+	n1, n2 := MockNode("n1", 1), MockNode("n2", 2)
+	d := MockDealer(n1, n2)
+	d.NodeMaps["n1"] = NewNodeInfo("n1", n1, d.Rater)
+	d.NodeMaps["n2"] = NewNodeInfo("n2", n2, d.Rater)
+	for name, used := range map[string][]int{"n1": {70}, "n2": {70, 20}} {
+		for card, percent := range used {
+			assert.NoError(t, d.NodeMaps[name].Allocate(&Plan{Demand: Demand{{Percent: percent}}, GPUIndexes: []int{card}}))
+		}
+	}
+
+	// n1 misses 60 on its only card, n2 10 on its second one
+	pod := MockQuotaPod("a", "p0", 90)
+	ans, _ := d.Assume([]string{"n1", "n2"}, pod, PolicySpec{}, false)
+	assert.Equal(t, []bool{false, false}, ans)
+	queued := d.QueuedPods()
+	assert.Len(t, queued, 1)
+	assert.Equal(t, []int{90}, queued[0].Demand)
+	assert.Equal(t, "n2", queued[0].ClosestNode)
+	assert.Equal(t, 10, queued[0].Shortfall)
+
+	// the shortfall is on the cards as the pod sees them
+	d.NodeMaps["n2"].SystemReserved = 10
+	d.Assume([]string{"n1", "n2"}, pod, PolicySpec{}, false)
+	d.NodeMaps["n2"].SystemReserved = 0
+	queued = d.QueuedPods()
+	assert.Equal(t, "n2", queued[0].ClosestNode)
+	assert.Equal(t, 20, queued[0].Shortfall)
+
+	// a pod failing its plan for another reason than the cards doesn't wait either
+	d.trackQueued(pod, NewDemandFromPod(pod), []*NodeInfo{d.NodeMaps["n1"]}, []bool{false}, []error{errors.New("init containers")}, PolicySpec{}, false)
+	assert.Empty(t, d.QueuedPods())
+	d.Assume([]string{"n1", "n2"}, pod, PolicySpec{}, false)
+
+	// a pod failing other constraints doesn't wait for gpu capacity
+	ShardSelector = labels.SelectorFromSet(labels.Set{"shard": "a"})
+	ans, _ = d.Assume([]string{"n1", "n2"}, pod, PolicySpec{}, false)
+	ShardSelector = nil
+	assert.Equal(t, []bool{false, false}, ans)
+	assert.Empty(t, d.QueuedPods())
+
+	small := MockQuotaPod("a", "p1", 20)
+	d.Assume([]string{"n1"}, MockQuotaPod("a", "p2", 40), PolicySpec{}, false)
+	assert.Len(t, d.QueuedPods(), 1)
+	ans, _ = d.Assume([]string{"n1"}, small, PolicySpec{}, false)
+	assert.Equal(t, []bool{true}, ans)
+	assert.Len(t, d.QueuedPods(), 1)
+}
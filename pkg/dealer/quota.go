@@ -0,0 +1,184 @@
+package dealer
+
+import (
+	"fmt"
+	"sort"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	log "k8s.io/klog/v2"
+)
+
+// ElasticQuota is the in-memory view of an ElasticGPUQuota object. Min and Max
+// are expressed in gpu percent, the same unit as nano-gpu/gpu-percent.
+type ElasticQuota struct {
+	Name       string
+	Namespaces []string
+	Min        int
+	Max        int
+	Used       int
+}
+
+func (d *DealerImpl) UpdateQuota(quota *ElasticQuota) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	d.Quotas[quota.Name] = quota
+}
+
+func (d *DealerImpl) DeleteQuota(name string) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	delete(d.Quotas, name)
+}
+
+// QuotaStatus returns a snapshot of all quotas with their current usage.
+func (d *DealerImpl) QuotaStatus() []ElasticQuota {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	usage := d.quotaUsage()
+	ans := make([]ElasticQuota, 0, len(d.Quotas))
+	for name, q := range d.Quotas {
+		snapshot := *q
+		snapshot.Used = usage[name]
+		ans = append(ans, snapshot)
+	}
+	sort.Slice(ans, func(i, j int) bool { return ans[i].Name < ans[j].Name })
+	return ans
+}
+
+// ReclaimCandidates picks pods of borrowing quotas which should be evicted so that
+// quota lender gets back the given amount of gpu percent. Pods created last are
+// chosen first and no borrower is pushed below its min. The share of borrowed pods
+// being deleted already counts as reclaimed.
+func (d *DealerImpl) ReclaimCandidates(lender string, amount int) []*v1.Pod {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	usage := d.quotaUsage()
+	borrowed := make(map[string]int)
+	for name, q := range d.Quotas {
+		if name == lender {
+			continue
+		}
+		if used := usage[name]; used > q.Min {
+			borrowed[name] = used - q.Min
+		}
+	}
+
+	pods := make([]*v1.Pod, 0)
+	freed := 0
+	for _, pod := range d.PodMaps {
+		q := d.quotaOfNamespace(pod.Namespace)
+		if q == nil || borrowed[q.Name] <= 0 {
+			continue
+		}
+		if _, terminating := d.Terminating[pod.UID]; terminating || pod.DeletionTimestamp != nil {
+			percent := int(utils.GetGPUPercentFromPodResource(pod))
+			borrowed[q.Name] -= percent
+			freed += percent
+			continue
+		}
+		pods = append(pods, pod)
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[j].CreationTimestamp.Before(&pods[i].CreationTimestamp)
+	})
+
+	ans := make([]*v1.Pod, 0)
+	for _, pod := range pods {
+		if freed >= amount {
+			break
+		}
+		q := d.quotaOfNamespace(pod.Namespace)
+		percent := int(utils.GetGPUPercentFromPodResource(pod))
+		if percent == 0 || borrowed[q.Name] < percent {
+			continue
+		}
+		borrowed[q.Name] -= percent
+		freed += percent
+		ans = append(ans, pod)
+	}
+	return ans
+}
+
+// checkQuota verifies the pod fits into the elastic quota of its namespace. Share up
+// to min is guaranteed, share between min and max may only be borrowed from capacity
+// which is neither used nor guaranteed to other quotas.
+func (d *DealerImpl) checkQuota(pod *v1.Pod) error {
+	q := d.quotaOfNamespace(pod.Namespace)
+	if q == nil {
+		return nil
+	}
+	demand := int(utils.GetGPUPercentFromPodResource(pod))
+	usage := d.quotaUsage()
+	used := usage[q.Name]
+	if used+demand > q.Max {
+		return fmt.Errorf("elastic quota %s exceeded: used %d + demand %d > max %d", q.Name, used, demand, q.Max)
+	}
+	if used+demand <= q.Min {
+		return nil
+	}
+
+	capacity, err := d.clusterCapacity()
+	if err != nil {
+		return err
+	}
+	reserved := 0
+	for name, other := range d.Quotas {
+		if name == q.Name {
+			continue
+		}
+		if usage[name] < other.Min {
+			reserved += other.Min - usage[name]
+		}
+	}
+	idle := capacity - d.clusterUsed() - reserved
+	if demand > idle {
+		return fmt.Errorf("elastic quota %s can't borrow %d: only %d idle share left", q.Name, demand, idle)
+	}
+	return nil
+}
+
+func (d *DealerImpl) quotaOfNamespace(namespace string) *ElasticQuota {
+	for _, q := range d.Quotas {
+		for _, ns := range q.Namespaces {
+			if ns == namespace {
+				return q
+			}
+		}
+	}
+	return nil
+}
+
+func (d *DealerImpl) quotaUsage() map[string]int {
+	usage := make(map[string]int)
+	for _, pod := range d.PodMaps {
+		if q := d.quotaOfNamespace(pod.Namespace); q != nil {
+			usage[q.Name] += int(utils.GetGPUPercentFromPodResource(pod))
+		}
+	}
+	return usage
+}
+
+func (d *DealerImpl) clusterUsed() int {
+	used := 0
+	for _, pod := range d.PodMaps {
+		used += int(utils.GetGPUPercentFromPodResource(pod))
+	}
+	return used
+}
+
+func (d *DealerImpl) clusterCapacity() (int, error) {
+	nodes, err := d.NodeLister.List(labels.Everything())
+	if err != nil {
+		log.Errorf("list nodes failed: %s", err.Error())
+		return 0, err
+	}
+	capacity := 0
+	for _, node := range nodes {
+		capacity += utils.GetGPUDeviceCountOfNode(node) * schetypes.GPUPercentEachCard
+	}
+	return capacity, nil
+}
@@ -0,0 +1,118 @@
+package dealer
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func MockNode(name string, cards int) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: v1.NodeStatus{
+			Capacity: v1.ResourceList{
+				types.ResourceGPUPercent: resource.MustParse(strconv.Itoa(cards * types.GPUPercentEachCard)),
+			},
+		},
+	}
+}
+
+func MockDealer(nodes ...*v1.Node) *DealerImpl {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, node := range nodes {
+		indexer.Add(node)
+	}
+	return newDealerImpl(nil, corelisters.NewNodeLister(indexer), nil, &Binpack{})
+}
+
+func MockQuotaPod(namespace, name string, percent int) *v1.Pod {
+	pod := MockPodWithDemand(Demand{{Percent: percent}})
+	pod.Namespace = namespace
+	pod.Name = name
+	pod.UID = k8stypes.UID(namespace + "/" + name)
+	return pod
+}
+
+func TestCheckQuota(t *testing.T) {
+	d := MockDealer(MockNode("n1", 4))
+	d.Quotas["a"] = &ElasticQuota{Name: "a", Namespaces: []string{"a"}, Min: 100, Max: 300}
+	d.Quotas["b"] = &ElasticQuota{Name: "b", Namespaces: []string{"b"}, Min: 200, Max: 400}
+
+	// within min
+	assert.Nil(t, d.checkQuota(MockQuotaPod("a", "p0", 100)))
+	// namespace without quota is not limited
+	assert.Nil(t, d.checkQuota(MockQuotaPod("c", "p0", 400)))
+	// above max
+	assert.NotNil(t, d.checkQuota(MockQuotaPod("a", "p0", 400)))
+
+	// a borrows 100 beyond min: 400 capacity - 200 reserved for b
+	p1 := MockQuotaPod("a", "p1", 100)
+	d.PodMaps[p1.UID] = p1
+	assert.Nil(t, d.checkQuota(MockQuotaPod("a", "p2", 100)))
+	p2 := MockQuotaPod("a", "p2", 100)
+	d.PodMaps[p2.UID] = p2
+	// only b's guaranteed share is left
+	assert.NotNil(t, d.checkQuota(MockQuotaPod("a", "p3", 100)))
+	assert.Nil(t, d.checkQuota(MockQuotaPod("b", "p0", 200)))
+}
+
+func TestReclaimCandidates(t *testing.T) {
+	d := MockDealer(MockNode("n1", 4))
+	d.Quotas["a"] = &ElasticQuota{Name: "a", Namespaces: []string{"a"}, Min: 100, Max: 400}
+	d.Quotas["b"] = &ElasticQuota{Name: "b", Namespaces: []string{"b"}, Min: 200, Max: 400}
+	for i := 0; i < 3; i++ {
+		p := MockQuotaPod("a", strconv.Itoa(i), 100)
+		p.CreationTimestamp = metav1.Unix(int64(i), 0)
+		d.PodMaps[p.UID] = p
+	}
+
+	victims := d.ReclaimCandidates("b", 200)
+	assert.Equal(t, 2, len(victims))
+	assert.Equal(t, "2", victims[0].Name)
+	assert.Equal(t, "1", victims[1].Name)
+
+	// lender never reclaims more than borrowed
+	victims = d.ReclaimCandidates("b", 300)
+	assert.Equal(t, 2, len(victims))
+}
+
+func TestReclaimCandidatesOfSeveralBorrowers(t *testing.T) {
+	d := MockDealer(MockNode("n1", 4))
+	d.Quotas["a"] = &ElasticQuota{Name: "a", Namespaces: []string{"a"}, Min: 100, Max: 400}
+	d.Quotas["b"] = &ElasticQuota{Name: "b", Namespaces: []string{"b"}, Min: 200, Max: 400}
+	d.Quotas["c"] = &ElasticQuota{Name: "c", Namespaces: []string{"c"}, Min: 0, Max: 400}
+	for i, ns := range []string{"a", "a", "a", "c", "c"} {
+		p := MockQuotaPod(ns, strconv.Itoa(i), 50)
+		p.CreationTimestamp = metav1.Unix(int64(i), 0)
+		d.PodMaps[p.UID] = p
+	}
+
+	// the newest pods of any borrower go first, no more than the deficit
+	victims := d.ReclaimCandidates("b", 100)
+	assert.Equal(t, 2, len(victims))
+	assert.Equal(t, "4", victims[0].Name)
+	assert.Equal(t, "3", victims[1].Name)
+
+	// a pod being evicted already covers its share of the deficit
+	deleted := d.PodMaps["c/4"].DeepCopy()
+	now := metav1.Now()
+	deleted.DeletionTimestamp = &now
+	d.MarkTerminating(deleted)
+	victims = d.ReclaimCandidates("b", 100)
+	assert.Equal(t, 1, len(victims))
+	assert.Equal(t, "3", victims[0].Name)
+
+	// a is left with its min
+	victims = d.ReclaimCandidates("b", 400)
+	assert.Equal(t, 2, len(victims))
+	assert.Equal(t, "2", victims[1].Name)
+}
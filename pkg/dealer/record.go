@@ -0,0 +1,347 @@
+package dealer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	log "k8s.io/klog/v2"
+)
+
+// RecordPath is the file the extender requests and the state changes of the dealer
+// are appended to for replay, empty disables recording.
+var RecordPath string
+
+const (
+	RecordOpSnapshot    = "snapshot"
+	RecordOpFilter      = "filter"
+	RecordOpScore       = "score"
+	RecordOpBind        = "bind"
+	RecordOpAllocate    = "allocate"
+	RecordOpRelease     = "release"
+	RecordOpForget      = "forget"
+	RecordOpCoreUsage   = "core-usage"
+	RecordOpMemoryUsage = "memory-usage"
+	RecordOpMetricUsage = "metric-usage"
+	RecordOpNode        = "node"
+	RecordOpNodeDelete  = "node-delete"
+	RecordOpOrphans     = "orphans"
+)
+
+// RecordEntry is a line of a recording. The first line is the snapshot of the cluster
+// the later ones apply to; requests carry their outcome so a replay can compare.
+type RecordEntry struct {
+	Time       time.Time       `json:"time"`
+	Op         string          `json:"op"`
+	Snapshot   json.RawMessage `json:"snapshot,omitempty"`
+	Pod        *v1.Pod         `json:"pod,omitempty"`
+	NodeObject *v1.Node        `json:"nodeObject,omitempty"`
+	Nodes      []string        `json:"nodes,omitempty"`
+	Node       string          `json:"node,omitempty"`
+	LoadAware  bool            `json:"loadAware,omitempty"`
+	Metric     string          `json:"metric,omitempty"`
+	Usage      string          `json:"usage,omitempty"`
+	Card       int             `json:"card,omitempty"`
+	Candidates []types.UID     `json:"candidates,omitempty"`
+	Live       []types.UID     `json:"live,omitempty"`
+	Fits       []bool          `json:"fits,omitempty"`
+	Errors     []string        `json:"errors,omitempty"`
+	Scores     []int           `json:"scores,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// RecordingDealer appends every extender request and every change of the dealer
+// state from the informers and the usage sync to a file. Calls overlapping in time
+// are recorded in the order they return.
+type RecordingDealer struct {
+	Dealer
+	impl *DealerImpl
+	once sync.Once
+	lock sync.Mutex
+	enc  *json.Encoder
+}
+
+func NewRecordingDealer(d *DealerImpl, path string) (*RecordingDealer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &RecordingDealer{Dealer: d, impl: d, enc: json.NewEncoder(f)}, nil
+}
+
+// begin records the snapshot before the first change, when the informers are synced.
+func (r *RecordingDealer) begin() {
+	r.once.Do(func() {
+		snapshot, err := r.impl.snapshotJSON()
+		if err != nil {
+			log.Errorf("record snapshot failed: %v", err)
+			return
+		}
+		r.record(&RecordEntry{Op: RecordOpSnapshot, Snapshot: snapshot})
+	})
+}
+
+func (r *RecordingDealer) record(e *RecordEntry) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	e.Time = time.Now()
+	if err := r.enc.Encode(e); err != nil {
+		log.Errorf("record %s failed: %v", e.Op, err)
+	}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func errorStrings(errs []error) []string {
+	ans := make([]string, len(errs))
+	for i, err := range errs {
+		ans[i] = errorString(err)
+	}
+	return ans
+}
+
+func (r *RecordingDealer) Assume(nodes []string, pod *v1.Pod, policySpec PolicySpec, isLoadSchedule bool) ([]bool, []error) {
+	r.begin()
+	fits, errs := r.Dealer.Assume(nodes, pod, policySpec, isLoadSchedule)
+	r.record(&RecordEntry{Op: RecordOpFilter, Pod: pod, Nodes: nodes, LoadAware: isLoadSchedule, Fits: fits, Errors: errorStrings(errs)})
+	return fits, errs
+}
+
+func (r *RecordingDealer) Score(nodes []string, pod *v1.Pod, policySpec PolicySpec, isLoadSchedule bool) []int {
+	r.begin()
+	scores := r.Dealer.Score(nodes, pod, policySpec, isLoadSchedule)
+	r.record(&RecordEntry{Op: RecordOpScore, Pod: pod, Nodes: nodes, LoadAware: isLoadSchedule, Scores: scores})
+	return scores
+}
+
+func (r *RecordingDealer) Bind(node string, pod *v1.Pod, policySpec PolicySpec, isLoadSchedule bool) error {
+	r.begin()
+	err := r.Dealer.Bind(node, pod, policySpec, isLoadSchedule)
+	r.record(&RecordEntry{Op: RecordOpBind, Pod: pod, Node: node, LoadAware: isLoadSchedule, Error: errorString(err)})
+	return err
+}
+
+func (r *RecordingDealer) Allocate(pod *v1.Pod) error {
+	r.begin()
+	err := r.Dealer.Allocate(pod)
+	r.record(&RecordEntry{Op: RecordOpAllocate, Pod: pod, Error: errorString(err)})
+	return err
+}
+
+func (r *RecordingDealer) Release(pod *v1.Pod) error {
+	r.begin()
+	err := r.Dealer.Release(pod)
+	r.record(&RecordEntry{Op: RecordOpRelease, Pod: pod, Error: errorString(err)})
+	return err
+}
+
+func (r *RecordingDealer) Forget(pod *v1.Pod) error {
+	r.begin()
+	err := r.Dealer.Forget(pod)
+	r.record(&RecordEntry{Op: RecordOpForget, Pod: pod, Error: errorString(err)})
+	return err
+}
+
+func (r *RecordingDealer) UpdateCoreUsage(nodeName, coreUsage, updateTime string, cardNum int) {
+	r.begin()
+	r.Dealer.UpdateCoreUsage(nodeName, coreUsage, updateTime, cardNum)
+	r.record(&RecordEntry{Op: RecordOpCoreUsage, Node: nodeName, Usage: coreUsage, Card: cardNum})
+}
+
+func (r *RecordingDealer) UpdateMemoryUsage(nodeName, memoryUsage, updateTime string, cardNum int) {
+	r.begin()
+	r.Dealer.UpdateMemoryUsage(nodeName, memoryUsage, updateTime, cardNum)
+	r.record(&RecordEntry{Op: RecordOpMemoryUsage, Node: nodeName, Usage: memoryUsage, Card: cardNum})
+}
+
+func (r *RecordingDealer) UpdateMetricUsage(nodeName, key, usage, updateTime string, cardNum int) {
+	r.begin()
+	r.Dealer.UpdateMetricUsage(nodeName, key, usage, updateTime, cardNum)
+	r.record(&RecordEntry{Op: RecordOpMetricUsage, Node: nodeName, Metric: key, Usage: usage, Card: cardNum})
+}
+
+func (r *RecordingDealer) UpdateNode(node *v1.Node) {
+	r.begin()
+	r.Dealer.UpdateNode(node)
+	r.record(&RecordEntry{Op: RecordOpNode, NodeObject: node})
+}
+
+func (r *RecordingDealer) DeleteNode(name string) {
+	r.begin()
+	r.Dealer.DeleteNode(name)
+	r.record(&RecordEntry{Op: RecordOpNodeDelete, Node: name})
+}
+
+func (r *RecordingDealer) ReleaseOrphans(candidates []types.UID, live map[types.UID]struct{}) []*v1.Pod {
+	r.begin()
+	released := r.Dealer.ReleaseOrphans(candidates, live)
+	e := &RecordEntry{Op: RecordOpOrphans, Candidates: candidates, Live: make([]types.UID, 0, len(live))}
+	for uid := range live {
+		e.Live = append(e.Live, uid)
+	}
+	r.record(e)
+	return released
+}
+
+// snapshotJSON returns the cluster as the dealer sees it: the nodes, the assumed
+// pods and the usage of the cards.
+func (d *DealerImpl) snapshotJSON() (json.RawMessage, error) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	nodes, err := d.NodeLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	assumed, err := d.PodLister.List(labels.SelectorFromSet(labels.Set{schetypes.GPUAssume: "true"}))
+	if err != nil {
+		return nil, err
+	}
+	pods := make(map[types.UID]*v1.Pod)
+	for _, pod := range assumed {
+		if pod.Spec.NodeName != "" && !utils.IsCompletedPod(pod) {
+			pods[pod.UID] = pod
+		}
+	}
+	for _, restored := range d.Restored {
+		for _, pod := range restored {
+			pods[pod.UID] = pod
+		}
+	}
+	for uid, pod := range d.PodMaps {
+		pods[uid] = pod
+	}
+	snapshot := &ClusterSnapshot{
+		Nodes: nodes,
+		Checkpoint: Checkpoint{
+			Time:        time.Now(),
+			Pods:        make([]*v1.Pod, 0, len(pods)),
+			CoreUsage:   d.CoreUsage,
+			MemoryUsage: d.MemoryUsage,
+			MetricUsage: d.MetricUsage,
+			Health:      d.Health,
+		},
+	}
+	for _, pod := range pods {
+		snapshot.Pods = append(snapshot.Pods, pod)
+	}
+	return json.Marshal(snapshot)
+}
+
+// Divergence is a recorded decision the replay came to differently.
+type Divergence struct {
+	Entry    int    `json:"entry"`
+	Op       string `json:"op"`
+	Pod      string `json:"pod"`
+	Recorded string `json:"recorded"`
+	Replayed string `json:"replayed"`
+}
+
+type ReplayReport struct {
+	Entries     int          `json:"entries"`
+	Decisions   int          `json:"decisions"`
+	Divergences []Divergence `json:"divergences"`
+}
+
+// Replay plays a recording on a dealer of its snapshot and compares the filter, score
+// and bind decisions with the recorded ones. Bindings are accounted in memory only and
+// usage samples are taken as current when they are played.
+func Replay(r io.Reader, policySpec PolicySpec, rater Rater) (*ReplayReport, error) {
+	dec := json.NewDecoder(r)
+	report := &ReplayReport{Divergences: make([]Divergence, 0)}
+	var d *DealerImpl
+	var nodes cache.Indexer
+	for i := 0; ; i++ {
+		e := &RecordEntry{}
+		if err := dec.Decode(e); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("entry %d: %v", i, err)
+		}
+		report.Entries++
+		if e.Op == RecordOpSnapshot {
+			snapshot := &ClusterSnapshot{}
+			if err := json.Unmarshal(e.Snapshot, snapshot); err != nil {
+				return nil, fmt.Errorf("entry %d: %v", i, err)
+			}
+			d, nodes = newSimulatedDealer(snapshot, rater)
+			continue
+		}
+		if d == nil {
+			return nil, fmt.Errorf("entry %d: the recording doesn't start with a snapshot", i)
+		}
+		diverge := func(recorded, replayed interface{}) {
+			if reflect.DeepEqual(recorded, replayed) {
+				return
+			}
+			div := Divergence{Entry: i, Op: e.Op, Recorded: fmt.Sprint(recorded), Replayed: fmt.Sprint(replayed)}
+			if e.Pod != nil {
+				div.Pod = e.Pod.Namespace + "/" + e.Pod.Name
+			}
+			report.Divergences = append(report.Divergences, div)
+		}
+		now := time.Now().In(loc).Format(timeFormat)
+		var spec PolicySpec
+		if e.Pod != nil {
+			spec, _ = ResolveProfile(e.Pod, policySpec, e.LoadAware)
+		}
+		switch e.Op {
+		case RecordOpFilter:
+			report.Decisions++
+			fits, errs := d.Assume(e.Nodes, e.Pod, spec, e.LoadAware)
+			diverge(e.Fits, fits)
+			diverge(e.Errors, errorStrings(errs))
+		case RecordOpScore:
+			report.Decisions++
+			diverge(e.Scores, d.Score(e.Nodes, e.Pod, spec, e.LoadAware))
+		case RecordOpBind:
+			report.Decisions++
+			_, err := d.bindInMemory(e.Node, e.Pod, spec, e.LoadAware)
+			diverge(e.Error, errorString(err))
+		case RecordOpAllocate:
+			d.Allocate(e.Pod)
+		case RecordOpRelease:
+			d.Release(e.Pod)
+		case RecordOpForget:
+			d.Forget(e.Pod)
+		case RecordOpCoreUsage:
+			d.UpdateCoreUsage(e.Node, e.Usage, now, e.Card)
+		case RecordOpMemoryUsage:
+			d.UpdateMemoryUsage(e.Node, e.Usage, now, e.Card)
+		case RecordOpMetricUsage:
+			d.UpdateMetricUsage(e.Node, e.Metric, e.Usage, now, e.Card)
+		case RecordOpNode:
+			nodes.Update(e.NodeObject)
+			d.UpdateNode(e.NodeObject)
+		case RecordOpNodeDelete:
+			if node, ok, _ := nodes.GetByKey(e.Node); ok {
+				nodes.Delete(node)
+			}
+			d.DeleteNode(e.Node)
+		case RecordOpOrphans:
+			live := make(map[types.UID]struct{}, len(e.Live))
+			for _, uid := range e.Live {
+				live[uid] = struct{}{}
+			}
+			d.ReleaseOrphans(e.Candidates, live)
+		default:
+			return nil, fmt.Errorf("entry %d: unknown op %s", i, e.Op)
+		}
+	}
+	return report, nil
+}
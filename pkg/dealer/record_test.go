@@ -0,0 +1,63 @@
+package dealer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+)
+
+func TestRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "record")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "record.jsonl")
+
+	d := MockDealer(MockNode("n1", 2), MockNode("n2", 1))
+	d.PodLister = corelisters.NewPodLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}))
+	running := utils.GetUpdatedPodAnnotationSpec(MockQuotaPod("a", "running", 50), []int{1})
+	running.Spec.NodeName = "n1"
+	assert.Nil(t, d.Allocate(running))
+	r, err := NewRecordingDealer(d, path)
+	assert.Nil(t, err)
+
+	nodes := []string{"n1", "n2"}
+	pod := MockQuotaPod("a", "p0", 40)
+	r.Assume(nodes, pod, PolicySpec{}, false)
+	r.Score(nodes, pod, PolicySpec{}, false)
+	assert.Nil(t, r.Release(running))
+	big := MockQuotaPod("a", "p1", 90)
+	fits, _ := r.Assume(nodes, big, PolicySpec{}, false)
+	assert.Equal(t, []bool{true, true}, fits)
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	report, err := Replay(bytes.NewReader(data), PolicySpec{}, &Binpack{})
+	assert.Nil(t, err)
+	assert.Equal(t, 5, report.Entries)
+	assert.Equal(t, 3, report.Decisions)
+	assert.Empty(t, report.Divergences)
+
+	// a decision the dealer no longer comes to
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	e := &RecordEntry{}
+	assert.Nil(t, json.Unmarshal(lines[2], e))
+	assert.Equal(t, RecordOpScore, e.Op)
+	e.Scores = []int{0, 0}
+	lines[2], _ = json.Marshal(e)
+	report, err = Replay(bytes.NewReader(bytes.Join(lines, []byte("\n"))), PolicySpec{}, &Binpack{})
+	assert.Nil(t, err)
+	assert.Len(t, report.Divergences, 1)
+	assert.Equal(t, "a/p0", report.Divergences[0].Pod)
+
+	_, err = Replay(bytes.NewReader(lines[1]), PolicySpec{}, &Binpack{})
+	assert.NotNil(t, err)
+}
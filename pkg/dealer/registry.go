@@ -0,0 +1,96 @@
+package dealer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+var (
+	ratersLock sync.Mutex
+	raters     = map[string]func() Rater{
+		schetypes.PriorityBinPack:                  func() Rater { return &Binpack{} },
+		schetypes.PrioritySpread:                   func() Rater { return &Spread{} },
+		schetypes.PriorityRequestedToCapacityRatio: func() Rater { return &RequestedToCapacityRatio{Shape: RTCRShape} },
+		schetypes.PriorityConsolidation:            func() Rater { return &Consolidation{} },
+		schetypes.PriorityBalanced:                 func() Rater { return &Balanced{} },
+		schetypes.PriorityCardSpread:               func() Rater { return &CardSpread{} },
+	}
+
+	// NamespaceRaters override the rater of the pods of a namespace.
+	NamespaceRaters = map[string]Rater{}
+
+	// SchedulerRaters override the rater of the pods of a scheduler profile, those an
+	// extender profile serves.
+	SchedulerRaters = map[string]Rater{}
+)
+
+// RegisterRater makes a rater selectable by name, the factory is called once the
+// flags are parsed.
+func RegisterRater(name string, factory func() Rater) {
+	ratersLock.Lock()
+	defer ratersLock.Unlock()
+	raters[name] = factory
+}
+
+func NewRater(name string) (Rater, error) {
+	ratersLock.Lock()
+	defer ratersLock.Unlock()
+	factory, ok := raters[name]
+	if !ok {
+		return nil, fmt.Errorf("priority algorithm %s is not supported, use one of %v", name, raterNames())
+	}
+	return factory(), nil
+}
+
+func raterNames() []string {
+	names := make([]string, 0, len(raters))
+	for name := range raters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseNamespaceRaters parses raters by namespace like "ml=card-spread,batch=binpack".
+func ParseNamespaceRaters(s string) (map[string]Rater, error) {
+	ans := make(map[string]Rater)
+	if strings.TrimSpace(s) == "" {
+		return ans, nil
+	}
+	for _, item := range strings.Split(s, ",") {
+		parts := strings.Split(strings.TrimSpace(item), "=")
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("namespace rater %q is not namespace=priority", item)
+		}
+		rater, err := NewRater(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		ans[parts[0]] = rater
+	}
+	return ans, nil
+}
+
+// raterOf returns the rater of the workload profile of the policy, of the scheduler
+// profile or else of the namespace of the pod, the fallback otherwise.
+func raterOf(pod *v1.Pod, policySpec PolicySpec, fallback Rater) Rater {
+	if r, ok := policySpec.ProfileRaters[pod.Labels[schetypes.LabelWorkloadProfile]]; ok {
+		return r
+	}
+	if r, ok := SchedulerRaters[pod.Spec.SchedulerName]; ok {
+		return r
+	}
+	if Experiment != nil && Experiment.Rater != nil && ArmOf(pod) == ArmExperiment {
+		return Experiment.Rater
+	}
+	if r, ok := NamespaceRaters[pod.Namespace]; ok {
+		return r
+	}
+	return fallback
+}
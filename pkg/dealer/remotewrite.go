@@ -0,0 +1,161 @@
+package dealer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	log "k8s.io/klog/v2"
+)
+
+var (
+	// RemoteWriteURL is the prometheus remote-write endpoint the reported usage is
+	// forwarded to, empty disables it.
+	RemoteWriteURL string
+	// RemoteWriteInterval is the period of sending the buffered samples.
+	RemoteWriteInterval = 15 * time.Second
+	// RemoteWriteBuffer is the number of samples buffered between sends, the oldest
+	// are dropped once full.
+	RemoteWriteBuffer = 10000
+
+	remoteWriter = &usageWriter{client: &http.Client{Timeout: 10 * time.Second}}
+)
+
+type remoteSample struct {
+	node   string
+	metric string
+	card   int
+	value  float64
+	time   time.Time
+}
+
+type usageWriter struct {
+	lock    sync.Mutex
+	pending []remoteSample
+	client  *http.Client
+}
+
+// exportUsage buffers a reported usage for the remote-write endpoint, malformed
+// values are dropped.
+func exportUsage(nodeName, metric string, card int, usage string) {
+	if RemoteWriteURL == "" {
+		return
+	}
+	value, err := strconv.ParseFloat(usage, 64)
+	if err != nil || value < 0 || value > 1 {
+		return
+	}
+	w := remoteWriter
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.pending = append(w.pending, remoteSample{node: nodeName, metric: metric, card: card, value: value, time: time.Now()})
+	if over := len(w.pending) - RemoteWriteBuffer; over > 0 {
+		w.pending = w.pending[over:]
+	}
+}
+
+// FlushRemoteWrite sends the buffered samples, they are kept for the next flush
+// when the endpoint fails.
+func FlushRemoteWrite() {
+	w := remoteWriter
+	w.lock.Lock()
+	samples := w.pending
+	w.pending = nil
+	w.lock.Unlock()
+	if len(samples) == 0 {
+		return
+	}
+	if err := w.send(samples); err != nil {
+		log.Warningf("remote write of %d usage samples failed: %v", len(samples), err)
+		w.lock.Lock()
+		w.pending = append(samples, w.pending...)
+		if over := len(w.pending) - RemoteWriteBuffer; over > 0 {
+			w.pending = w.pending[over:]
+		}
+		w.lock.Unlock()
+	}
+}
+
+func (w *usageWriter) send(samples []remoteSample) error {
+	req, err := http.NewRequest(http.MethodPost, RemoteWriteURL, bytes.NewReader(snappyBlock(writeRequest(samples))))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	return nil
+}
+
+// writeRequest encodes the samples as a prometheus WriteRequest protobuf, one time
+// series by sample as the samples of a card are rarely batched.
+func writeRequest(samples []remoteSample) []byte {
+	var buf []byte
+	for _, s := range samples {
+		var series []byte
+		for _, l := range [][2]string{
+			{"__name__", "nano_gpu_" + s.metric},
+			{"card", strconv.Itoa(s.card)},
+			{"node", s.node},
+		} {
+			var label []byte
+			label = appendBytesField(label, 1, []byte(l[0]))
+			label = appendBytesField(label, 2, []byte(l[1]))
+			series = appendBytesField(series, 1, label)
+		}
+		sample := []byte{1<<3 | 1}
+		sample = append(sample, make([]byte, 8)...)
+		binary.LittleEndian.PutUint64(sample[1:], math.Float64bits(s.value))
+		sample = append(sample, 2<<3)
+		sample = appendUvarint(sample, uint64(s.time.UnixNano()/int64(time.Millisecond)))
+		series = appendBytesField(series, 2, sample)
+		buf = appendBytesField(buf, 1, series)
+	}
+	return buf
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	return append(buf, tmp[:binary.PutUvarint(tmp[:], v)]...)
+}
+
+func appendBytesField(buf []byte, field int, value []byte) []byte {
+	buf = appendUvarint(buf, uint64(field)<<3|2)
+	buf = appendUvarint(buf, uint64(len(value)))
+	return append(buf, value...)
+}
+
+// snappyBlock frames the data as a snappy block of literals, which every snappy
+// decoder accepts, the payload being small compression is not worth a dependency.
+func snappyBlock(data []byte) []byte {
+	buf := appendUvarint(nil, uint64(len(data)))
+	for len(data) > 0 {
+		n := len(data)
+		if n > 1<<16 {
+			n = 1 << 16
+		}
+		if n <= 60 {
+			buf = append(buf, byte(n-1)<<2)
+		} else if n <= 1<<8 {
+			buf = append(buf, 60<<2, byte(n-1))
+		} else {
+			buf = append(buf, 61<<2, byte(n-1), byte((n-1)>>8))
+		}
+		buf = append(buf, data[:n]...)
+		data = data[n:]
+	}
+	return buf
+}
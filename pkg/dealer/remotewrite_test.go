@@ -0,0 +1,68 @@
+package dealer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// unsnappyLiterals decodes snappy blocks made of literals only.
+func unsnappyLiterals(t *testing.T, block []byte) []byte {
+	n, k := binary.Uvarint(block)
+	block = block[k:]
+	var data []byte
+	for len(block) > 0 {
+		tag := int(block[0] >> 2)
+		block = block[1:]
+		switch tag {
+		case 60:
+			tag, block = int(block[0]), block[1:]
+		case 61:
+			tag, block = int(block[0])|int(block[1])<<8, block[2:]
+		}
+		data = append(data, block[:tag+1]...)
+		block = block[tag+1:]
+	}
+	assert.Equal(t, int(n), len(data))
+	return data
+}
+
+func TestRemoteWrite(t *testing.T) {
+	defer func(url string) { RemoteWriteURL = url }(RemoteWriteURL)
+	status := http.StatusInternalServerError
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+		body, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	RemoteWriteURL = server.URL
+
+	d := MockDealer(MockNode("n1", 2))
+	d.AddCoreUsage("n1")
+	d.AddMemoryUsage("n1")
+	now := time.Now().In(loc).Format(timeFormat)
+	d.UpdateCoreUsage("n1", "0.25", now, 1)
+	d.UpdateMemoryUsage("n1", "bad", now, 0)
+
+	FlushRemoteWrite()
+	assert.Len(t, remoteWriter.pending, 1)
+
+	status = http.StatusNoContent
+	FlushRemoteWrite()
+	assert.Empty(t, remoteWriter.pending)
+	data := unsnappyLiterals(t, body)
+	for _, s := range []string{"nano_gpu_" + GPUCoreUsagePriority, "card", "1", "node", "n1"} {
+		assert.True(t, bytes.Contains(data, []byte(s)), s)
+	}
+
+	large := bytes.Repeat([]byte("x"), 70000)
+	assert.Equal(t, large, unsnappyLiterals(t, snappyBlock(large)))
+}
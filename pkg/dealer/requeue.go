@@ -0,0 +1,65 @@
+package dealer
+
+import (
+	"sort"
+)
+
+// RequeueThreshold is the gpu percent a node must free within a requeue period for
+// the pods waiting for gpu capacity to be signaled, 0 disables it.
+var RequeueThreshold int
+
+// RequeueStats counts the pods signaled to retry on freed capacity.
+type RequeueStats struct {
+	Signals int
+}
+
+// trackFreed adds the share of the plan released on the node.
+func (d *DealerImpl) trackFreed(node string, plan *Plan) {
+	if RequeueThreshold <= 0 {
+		return
+	}
+	for i, r := range plan.Demand {
+		if i < len(plan.GPUIndexes) && plan.GPUIndexes[i] >= 0 {
+			d.Freed[node] += r.Percent
+		}
+	}
+}
+
+// RequeueCandidates returns the pods waiting for gpu capacity which fit on a node
+// that freed at least RequeueThreshold since the last call, and starts a new period.
+func (d *DealerImpl) RequeueCandidates() []QueuedPod {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	nodes := make([]string, 0)
+	for name, freed := range d.Freed {
+		if _, ok := d.NodeMaps[name]; ok && freed >= RequeueThreshold {
+			nodes = append(nodes, name)
+		}
+		delete(d.Freed, name)
+	}
+	sort.Strings(nodes)
+	ans := make([]QueuedPod, 0)
+	for _, q := range d.Queued {
+		demand := make(Demand, 0, len(q.Demand))
+		for _, percent := range q.Demand {
+			demand = append(demand, GPUResource{Percent: percent})
+		}
+		for _, name := range nodes {
+			if cardShortfall(d.NodeMaps[name].GPUs, demand) == 0 {
+				c := q.copy()
+				c.ClosestNode, c.Shortfall = name, 0
+				ans = append(ans, c)
+				break
+			}
+		}
+	}
+	sort.Slice(ans, func(i, j int) bool { return ans[i].FirstSeen.Before(ans[j].FirstSeen) })
+	d.Requeues.Signals += len(ans)
+	return ans
+}
+
+func (d *DealerImpl) RequeueStatus() RequeueStats {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	return d.Requeues
+}
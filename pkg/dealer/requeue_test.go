@@ -0,0 +1,37 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+)
+
+func TestRequeueCandidates(t *testing.T) {
+	RequeueThreshold = 50
+	defer func() { RequeueThreshold = 0 }()
+	node := MockNode("n1", 1)
+	d := MockDealer(node)
+	d.NodeMaps["n1"] = NewNodeInfo("n1", node, d.Rater)
+	running := MockQuotaPod("a", "run", 80)
+	running.Spec.NodeName = "n1"
+	running = utils.GetUpdatedPodAnnotationSpec(running, []int{0})
+	assert.NoError(t, d.Allocate(running))
+
+	waiting := MockQuotaPod("a", "p0", 60)
+	ans, _ := d.Assume([]string{"n1"}, waiting, PolicySpec{}, false)
+	assert.Equal(t, []bool{false}, ans)
+	assert.Empty(t, d.RequeueCandidates())
+
+	assert.NoError(t, d.Release(running))
+	candidates := d.RequeueCandidates()
+	assert.Len(t, candidates, 1)
+	assert.Equal(t, types.UID("a/p0"), candidates[0].UID)
+	assert.Equal(t, "n1", candidates[0].ClosestNode)
+	assert.Equal(t, 1, d.RequeueStatus().Signals)
+
+	// the freed capacity is signaled once
+	assert.Empty(t, d.RequeueCandidates())
+}
@@ -0,0 +1,112 @@
+package dealer
+
+import (
+	"errors"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// The dimensions limiting a pod waiting for gpu capacity.
+const (
+	// LimitCapacity is no node having the gpu percent of the pod free.
+	LimitCapacity = "capacity"
+	// LimitFragmentation is a node having the percent free but not on cards the
+	// shares of the pod fit on.
+	LimitFragmentation = "fragmentation"
+	// LimitLoad is the cards holding the shares being too loaded.
+	LimitLoad = "load"
+	// LimitStaleLoad is the cards holding the shares being rejected on usage which
+	// wasn't reported in time.
+	LimitStaleLoad = "stale-load"
+)
+
+// RetryBudget is the number of attempts a pod waiting for gpu capacity gets before
+// it is reported as never fitting, 0 disables it.
+var RetryBudget int
+
+// classifyQueued returns what kept the pod off the nodes which failed it on their
+// cards, judged by the node missing the least. The load only limits pods scheduled
+// on the usage of the cards.
+func (d *DealerImpl) classifyQueued(pod *v1.Pod, demand Demand, nodeInfos []*NodeInfo, shortfall int, policySpec PolicySpec, isLoadSchedule bool) string {
+	if shortfall == 0 && isLoadSchedule {
+		for _, ni := range nodeInfos {
+			if cardShortfall(ni.schedulableGPUs(pod, d), demand) == 0 && d.usageStale(ni, policySpec) {
+				return LimitStaleLoad
+			}
+		}
+		return LimitLoad
+	}
+	total := 0
+	for _, r := range demand {
+		total += r.Percent
+	}
+	for _, ni := range nodeInfos {
+		if available, _ := ni.schedulableGPUs(pod, d).PercentAvailableAndFreeGpuCount(); available >= total {
+			return LimitFragmentation
+		}
+	}
+	return LimitCapacity
+}
+
+// usageStale determines if the usage of a card of the node scored by the policy
+// wasn't reported in time.
+func (d *DealerImpl) usageStale(ni *NodeInfo, policySpec PolicySpec) bool {
+	for _, p := range policySpec.SyncPeriod {
+		if IsPressureMetric(p.Name) {
+			continue
+		}
+		activeDuration, err := getActiveDuration(policySpec.SyncPeriod, p.Name)
+		if err != nil || activeDuration == 0 {
+			continue
+		}
+		for card := range ni.GPUs {
+			if _, _, err := d.GetUsage(ni.Name, p.Name, card, activeDuration); errors.Is(err, ErrUsageStale) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// countAttempt adds an attempt limited by the dimension to the pod and marks it
+// exhausted once it used up its retry budget.
+func countAttempt(q *QueuedPod, limit string) {
+	q.Attempts++
+	q.Limit = limit
+	if q.Limits == nil {
+		q.Limits = make(map[string]int)
+	}
+	q.Limits[limit]++
+	if RetryBudget > 0 && q.Attempts >= RetryBudget {
+		q.Exhausted = true
+	}
+}
+
+// limitingDimension is the dimension which limited most attempts of the pod.
+func (q *QueuedPod) limitingDimension() string {
+	ans := q.Limit
+	for _, limit := range []string{LimitCapacity, LimitFragmentation, LimitLoad, LimitStaleLoad} {
+		if q.Limits[limit] > q.Limits[ans] {
+			ans = limit
+		}
+	}
+	return ans
+}
+
+// ExhaustedPods returns the pods waiting for gpu capacity which used up their retry
+// budget since the last call, with the dimension which limited them most.
+func (d *DealerImpl) ExhaustedPods() []QueuedPod {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	ans := make([]QueuedPod, 0)
+	for _, q := range d.Queued {
+		if !q.Exhausted || q.Reported {
+			continue
+		}
+		q.Reported = true
+		c := q.copy()
+		c.Limit = q.limitingDimension()
+		ans = append(ans, c)
+	}
+	return ans
+}
@@ -0,0 +1,45 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryBudget(t *testing.T) {
+	RetryBudget = 3
+	defer func() { RetryBudget = 0 }()
+	n1, n2 := MockNode("n1", 1), MockNode("n2", 2)
+	d := MockDealer(n1, n2)
+	d.NodeMaps["n1"] = NewNodeInfo("n1", n1, d.Rater)
+	d.NodeMaps["n2"] = NewNodeInfo("n2", n2, d.Rater)
+	assert.NoError(t, d.NodeMaps["n1"].Allocate(&Plan{Demand: Demand{{Percent: 70}}, GPUIndexes: []int{0}}))
+	assert.NoError(t, d.NodeMaps["n2"].Allocate(&Plan{Demand: Demand{{Percent: 50}, {Percent: 50}}, GPUIndexes: []int{0, 1}}))
+
+	// n2 has 100 free but on two cards
+	pod := MockQuotaPod("a", "p0", 90)
+	d.Assume([]string{"n1", "n2"}, pod, PolicySpec{}, false)
+	assert.Equal(t, LimitFragmentation, d.QueuedPods()[0].Limit)
+
+	// without n2 no node has the percent free
+	d.Assume([]string{"n1"}, pod, PolicySpec{}, false)
+	assert.Equal(t, LimitCapacity, d.QueuedPods()[0].Limit)
+	assert.Empty(t, d.ExhaustedPods())
+
+	d.Assume([]string{"n1", "n2"}, pod, PolicySpec{}, false)
+	exhausted := d.ExhaustedPods()
+	assert.Len(t, exhausted, 1)
+	assert.Equal(t, 3, exhausted[0].Attempts)
+	assert.Equal(t, LimitFragmentation, exhausted[0].Limit)
+	assert.Equal(t, map[string]int{LimitFragmentation: 2, LimitCapacity: 1}, exhausted[0].Limits)
+
+	// the pod is reported once
+	d.Assume([]string{"n1", "n2"}, pod, PolicySpec{}, false)
+	assert.Empty(t, d.ExhaustedPods())
+
+	// a pod the cards have room for is only limited by the load when scheduled on it
+	small := MockQuotaPod("a", "p1", 20)
+	full := []*NodeInfo{d.NodeMaps["n1"]}
+	assert.Equal(t, LimitFragmentation, d.classifyQueued(small, NewDemandFromPod(small), full, 0, PolicySpec{}, false))
+	assert.Equal(t, LimitLoad, d.classifyQueued(small, NewDemandFromPod(small), full, 0, PolicySpec{}, true))
+}
@@ -0,0 +1,97 @@
+package dealer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ShapePoint maps a utilization to a score, both in [0, 100].
+type ShapePoint struct {
+	Utilization int
+	Score       int
+}
+
+// RTCRShape is the shape of the requested-to-capacity-ratio rater, the default
+// favours fuller nodes like binpack.
+var RTCRShape = []ShapePoint{{0, 0}, {100, 100}}
+
+// ParseShape parses points like "0:0,70:100,100:0" sorted by utilization.
+func ParseShape(s string) ([]ShapePoint, error) {
+	ans := make([]ShapePoint, 0)
+	for _, item := range strings.Split(s, ",") {
+		parts := strings.Split(strings.TrimSpace(item), ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("shape point %q is not utilization:score", item)
+		}
+		u, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		score, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		if u < 0 || u > 100 || score < 0 || score > 100 {
+			return nil, fmt.Errorf("shape point %q is out of [0, 100]", item)
+		}
+		ans = append(ans, ShapePoint{Utilization: u, Score: score})
+	}
+	sort.Slice(ans, func(i, j int) bool { return ans[i].Utilization < ans[j].Utilization })
+	for i := 1; i < len(ans); i++ {
+		if ans[i].Utilization == ans[i-1].Utilization {
+			return nil, fmt.Errorf("utilization %d appears twice in the shape", ans[i].Utilization)
+		}
+	}
+	return ans, nil
+}
+
+// RequestedToCapacityRatio scores nodes like the kube-scheduler plugin of the same
+// name: the core and memory utilization the node would have with the pod are mapped
+// through the shape and averaged. A shape peaking at 70 keeps nodes around 70%.
+type RequestedToCapacityRatio struct {
+	Binpack
+	Shape []ShapePoint
+}
+
+func (r *RequestedToCapacityRatio) Rate(gpus GPUs, p *Plan, d Dealer, policySpec PolicySpec, nodeName string, isLoadSchedule bool) int {
+	total, reserved := 0, 0
+	for _, g := range gpus {
+		total += g.PercentTotal
+		reserved += g.PercentTotal - g.Percent
+	}
+	for _, c := range p.Demand {
+		reserved += c.Percent
+	}
+	if total == 0 {
+		return ScoreMin
+	}
+	core := 100 * reserved / total
+	memory := core
+	if isLoadSchedule {
+		if usage, ok := measuredCardsUsage(gpus, d, GPUMemoryUsagePriority, policySpec, nodeName); ok {
+			memory = int(100 * usage)
+		}
+	}
+	return (r.score(core) + r.score(memory)) / 2
+}
+
+// score interpolates the shape linearly, utilization beyond the points takes the
+// score of the closest one.
+func (r *RequestedToCapacityRatio) score(utilization int) int {
+	shape := r.Shape
+	if len(shape) == 0 {
+		return ScoreMin
+	}
+	if utilization <= shape[0].Utilization {
+		return shape[0].Score
+	}
+	for i := 1; i < len(shape); i++ {
+		if utilization <= shape[i].Utilization {
+			lo, hi := shape[i-1], shape[i]
+			return lo.Score + (hi.Score-lo.Score)*(utilization-lo.Utilization)/(hi.Utilization-lo.Utilization)
+		}
+	}
+	return shape[len(shape)-1].Score
+}
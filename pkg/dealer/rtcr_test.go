@@ -0,0 +1,36 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseShape(t *testing.T) {
+	shape, err := ParseShape("100:0, 0:0,70:100")
+	assert.NoError(t, err)
+	assert.Equal(t, []ShapePoint{{0, 0}, {70, 100}, {100, 0}}, shape)
+
+	for _, s := range []string{"", "0:0,0:10", "0:101", "a:1"} {
+		_, err := ParseShape(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestRequestedToCapacityRatioRate(t *testing.T) {
+	shape, _ := ParseShape("0:0,70:100,100:0")
+	r, err := NewRater("requested-to-capacity-ratio")
+	assert.NoError(t, err)
+	r.(*RequestedToCapacityRatio).Shape = shape
+
+	gpus := GPUs{{Percent: 100, PercentTotal: 100}, {Percent: 60, PercentTotal: 100}}
+	// 40 + 100 of 200 reaches the target
+	assert.Equal(t, 100, r.Rate(gpus, &Plan{Demand: Demand{{Percent: 100}}}, nil, PolicySpec{}, "n1", false))
+	// 40 + 20 of 200
+	assert.Equal(t, 42, r.Rate(gpus, &Plan{Demand: Demand{{Percent: 20}}}, nil, PolicySpec{}, "n1", false))
+	// 40 + 160 of 200
+	assert.Equal(t, 0, r.Rate(gpus, &Plan{Demand: Demand{{Percent: 80}, {Percent: 80}}}, nil, PolicySpec{}, "n1", false))
+
+	_, err = NewRater("unknown")
+	assert.Error(t, err)
+}
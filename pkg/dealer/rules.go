@@ -0,0 +1,146 @@
+package dealer
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	log "k8s.io/klog/v2"
+)
+
+// Rules are custom predicates and score terms of the policy, written in the
+// expression language of CompileExpr over the variables of ruleVars. They are
+// reloaded along with the policy file.
+type Rules struct {
+	// Filters keep a node only when every expression is true.
+	Filters []Rule `yaml:"filters"`
+	// Scores add the weighted value of every expression to the node score.
+	Scores []Rule `yaml:"scores"`
+}
+
+type Rule struct {
+	Name       string  `yaml:"name"`
+	Expression string  `yaml:"expression"`
+	Weight     float64 `yaml:"weight"`
+	expr       Expr
+}
+
+// Compile compiles the rules, broken ones are dropped.
+func (rs *Rules) Compile() {
+	rs.Filters = compileRules(rs.Filters)
+	rs.Scores = compileRules(rs.Scores)
+}
+
+func compileRules(rules []Rule) []Rule {
+	ans := make([]Rule, 0, len(rules))
+	for _, r := range rules {
+		e, err := CompileExpr(r.Expression)
+		if err != nil {
+			log.Errorf("drop rule %s: %v", r.Name, err)
+			continue
+		}
+		r.expr = e
+		ans = append(ans, r)
+	}
+	return ans
+}
+
+// ruleVars are the variables rules are evaluated with, usage is the average measured
+// usage of the cards in [0, 1] and 0 when unknown.
+func (d *DealerImpl) ruleVars(ni *NodeInfo, pod *v1.Pod, demand Demand, policySpec PolicySpec, isLoadSchedule bool) map[string]interface{} {
+	free, freeCards, total := 0, 0, 0
+	for _, g := range ni.GPUs {
+		free += g.Percent
+		total += g.PercentTotal
+		if g.Percent == g.PercentTotal {
+			freeCards++
+		}
+	}
+	percent := 0
+	for _, r := range demand {
+		percent += r.Percent
+	}
+	vars := map[string]interface{}{
+		"node.name":         ni.Name,
+		"node.pool":         ni.Pool,
+		"node.mps":          ni.MPS,
+		"node.cards":        float64(len(ni.GPUs)),
+		"node.freeCards":    float64(freeCards),
+		"node.freePercent":  float64(free),
+		"node.totalPercent": float64(total),
+		"node.model":        ni.Attributes.Model,
+		"node.memoryMiB":    float64(ni.Attributes.MemoryMiB),
+		"node.capability":   float64(ni.Attributes.ComputeCapability),
+		"node.migCapable":   ni.Attributes.MIGCapable,
+		"pod.namespace":     pod.Namespace,
+		"pod.name":          pod.Name,
+		"demand.percent":    float64(percent),
+		"demand.containers": float64(len(demand)),
+		"usage.core":        0.0,
+		"usage.memory":      0.0,
+	}
+	if isLoadSchedule {
+		if usage, ok := measuredCardsUsage(ni.GPUs, d, GPUCoreUsagePriority, policySpec, ni.Name); ok {
+			vars["usage.core"] = usage
+		}
+		if usage, ok := measuredCardsUsage(ni.GPUs, d, GPUMemoryUsagePriority, policySpec, ni.Name); ok {
+			vars["usage.memory"] = usage
+		}
+	}
+	return vars
+}
+
+// checkRules fails the node when a filter is false, filters failing to evaluate
+// are ignored.
+func (d *DealerImpl) checkRules(ni *NodeInfo, pod *v1.Pod, demand Demand, policySpec PolicySpec, isLoadSchedule bool) error {
+	if len(policySpec.Rules.Filters) == 0 {
+		return nil
+	}
+	vars := d.ruleVars(ni, pod, demand, policySpec, isLoadSchedule)
+	for _, r := range policySpec.Rules.Filters {
+		if r.expr == nil {
+			continue
+		}
+		v, err := r.expr.Eval(vars)
+		if err != nil {
+			log.Warningf("ignore filter %s on node %s: %v", r.Name, ni.Name, err)
+			continue
+		}
+		if pass, ok := v.(bool); !ok {
+			log.Warningf("ignore filter %s on node %s: %v is not a bool", r.Name, ni.Name, v)
+		} else if !pass {
+			return fmt.Errorf("node %s is filtered by rule %s", ni.Name, r.Name)
+		}
+	}
+	return nil
+}
+
+// ruleScore returns the weighted sum of the score terms, terms failing to evaluate
+// count 0.
+func (d *DealerImpl) ruleScore(ni *NodeInfo, pod *v1.Pod, demand Demand, policySpec PolicySpec, isLoadSchedule bool) int {
+	if len(policySpec.Rules.Scores) == 0 {
+		return 0
+	}
+	vars := d.ruleVars(ni, pod, demand, policySpec, isLoadSchedule)
+	sum := 0.0
+	for _, r := range policySpec.Rules.Scores {
+		if r.expr == nil {
+			continue
+		}
+		v, err := r.expr.Eval(vars)
+		if err != nil {
+			log.Warningf("ignore score %s on node %s: %v", r.Name, ni.Name, err)
+			continue
+		}
+		f, ok := v.(float64)
+		if !ok {
+			log.Warningf("ignore score %s on node %s: %v is not a number", r.Name, ni.Name, v)
+			continue
+		}
+		weight := r.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		sum += weight * f
+	}
+	return int(sum)
+}
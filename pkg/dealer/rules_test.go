@@ -0,0 +1,60 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileExpr(t *testing.T) {
+	vars := map[string]interface{}{"node.cards": 4.0, "pod.namespace": "ml"}
+	for src, want := range map[string]interface{}{
+		"1 + 2 * 3":                       7.0,
+		"(1 + 2) * 3":                     9.0,
+		"-node.cards / 2":                 -2.0,
+		"max(1, node.cards, 3) - abs(-1)": 3.0,
+		"node.cards >= 4 && pod.namespace == 'ml'": true,
+		"!(node.cards < 2) || unknown":             true,
+		"pod.namespace + '-x'":                     "ml-x",
+	} {
+		e, err := CompileExpr(src)
+		assert.NoError(t, err, src)
+		v, err := e.Eval(vars)
+		assert.NoError(t, err, src)
+		assert.Equal(t, want, v, src)
+	}
+	for _, src := range []string{"1 +", "(1", "foo(1)", "1 = 2", "'a"} {
+		_, err := CompileExpr(src)
+		assert.Error(t, err, src)
+	}
+	e, _ := CompileExpr("unknown > 1")
+	_, err := e.Eval(vars)
+	assert.Error(t, err)
+}
+
+func TestRules(t *testing.T) {
+	d := MockDealer(MockNode("n1", 2))
+	ni := NewNodeInfo("n1", MockNode("n1", 2), d.Rater)
+	ni.GPUs[0].Percent = 0
+	pod := MockQuotaPod("ml", "p0", 20)
+	demand := Demand{{Percent: 20}}
+
+	policy := PolicySpec{Rules: Rules{
+		Filters: []Rule{
+			{Name: "free", Expression: "node.freeCards >= 1"},
+			{Name: "broken", Expression: "1 +"},
+			{Name: "unknown", Expression: "nope"},
+		},
+		Scores: []Rule{
+			{Name: "cards", Expression: "node.freeCards * 10", Weight: 2},
+			{Name: "namespace", Expression: "pod.namespace == 'ml'"},
+		},
+	}}
+	policy.Rules.Compile()
+	assert.Len(t, policy.Rules.Filters, 2)
+	assert.NoError(t, d.checkRules(ni, pod, demand, policy, false))
+	assert.Equal(t, 20, d.ruleScore(ni, pod, demand, policy, false))
+
+	ni.GPUs[1].Percent = 0
+	assert.Error(t, d.checkRules(ni, pod, demand, policy, false))
+}
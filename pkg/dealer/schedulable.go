@@ -0,0 +1,46 @@
+package dealer
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// tolerates reports whether a toleration of the pod tolerates the taint.
+func tolerates(pod *v1.Pod, taint *v1.Taint) bool {
+	for i := range pod.Spec.Tolerations {
+		if pod.Spec.Tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNodeSchedulable fails the cordoned and not ready nodes and the nodes with
+// NoSchedule or NoExecute taints the pod doesn't tolerate, from the live node as the
+// scheduler would reject them anyway. Cordoned nodes are taken by pods tolerating the
+// unschedulable taint.
+func (d *DealerImpl) checkNodeSchedulable(ni *NodeInfo, pod *v1.Pod) error {
+	node, err := d.NodeLister.Get(ni.Name)
+	if err != nil {
+		return err
+	}
+	if node.Spec.Unschedulable && !tolerates(pod, &v1.Taint{Key: v1.TaintNodeUnschedulable, Effect: v1.TaintEffectNoSchedule}) {
+		return fmt.Errorf("node %s is cordoned", ni.Name)
+	}
+	for _, c := range node.Status.Conditions {
+		if c.Type == v1.NodeReady && c.Status != v1.ConditionTrue {
+			return fmt.Errorf("node %s is not ready", ni.Name)
+		}
+	}
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect == v1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if !tolerates(pod, taint) {
+			return fmt.Errorf("node %s has taint %s=%s:%s the pod doesn't tolerate", ni.Name, taint.Key, taint.Value, taint.Effect)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestCheckNodeSchedulable(t *testing.T) {
+	ready, cordoned, notReady, tainted := MockNode("ready", 1), MockNode("cordoned", 1), MockNode("not-ready", 1), MockNode("tainted", 1)
+	ready.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}
+	cordoned.Spec.Unschedulable = true
+	notReady.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionUnknown}}
+	tainted.Spec.Taints = []v1.Taint{
+		{Key: "gpu", Value: "reserved", Effect: v1.TaintEffectNoSchedule},
+		{Key: "soft", Effect: v1.TaintEffectPreferNoSchedule},
+	}
+	d := MockDealer(ready, cordoned, notReady, tainted)
+	ni := func(node *v1.Node) *NodeInfo { return NewNodeInfo(node.Name, node, d.Rater) }
+
+	pod := MockQuotaPod("a", "p0", 30)
+	assert.NoError(t, d.checkNodeSchedulable(ni(ready), pod))
+	assert.EqualError(t, d.checkNodeSchedulable(ni(cordoned), pod), "node cordoned is cordoned")
+	assert.EqualError(t, d.checkNodeSchedulable(ni(notReady), pod), "node not-ready is not ready")
+	assert.Error(t, d.checkNodeSchedulable(ni(tainted), pod))
+
+	pod.Spec.Tolerations = []v1.Toleration{
+		{Key: "gpu", Operator: v1.TolerationOpEqual, Value: "reserved", Effect: v1.TaintEffectNoSchedule},
+		{Key: v1.TaintNodeUnschedulable, Operator: v1.TolerationOpExists},
+	}
+	assert.NoError(t, d.checkNodeSchedulable(ni(tainted), pod))
+	assert.NoError(t, d.checkNodeSchedulable(ni(cordoned), pod))
+}
@@ -0,0 +1,43 @@
+package dealer
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// SchedulerNames are the schedulers the extender serves, the pods of the other
+// schedulers are neither assumed nor bound so that schedulers sharing the extender
+// config don't account the same pods twice. Empty serves the pods of all schedulers.
+var SchedulerNames = map[string]struct{}{}
+
+// ParseSchedulerNames parses comma separated scheduler names.
+func ParseSchedulerNames(s string) map[string]struct{} {
+	ans := make(map[string]struct{})
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			ans[name] = struct{}{}
+		}
+	}
+	return ans
+}
+
+// Serves determines if the pod targets a scheduler the extender serves.
+func Serves(pod *v1.Pod) bool {
+	if len(SchedulerNames) == 0 {
+		return true
+	}
+	name := pod.Spec.SchedulerName
+	if name == "" {
+		name = v1.DefaultSchedulerName
+	}
+	_, ok := SchedulerNames[name]
+	return ok
+}
+
+// ForeignSchedulerError is returned when binding a pod of a scheduler the extender
+// doesn't serve.
+func ForeignSchedulerError(pod *v1.Pod) error {
+	return fmt.Errorf("pod %s/%s targets scheduler %s which this extender doesn't serve", pod.Namespace, pod.Name, pod.Spec.SchedulerName)
+}
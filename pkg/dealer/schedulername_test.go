@@ -0,0 +1,23 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServes(t *testing.T) {
+	pod := MockQuotaPod("a", "p0", 40)
+	assert.True(t, Serves(pod))
+
+	SchedulerNames = ParseSchedulerNames(" default-scheduler, gpu-spread ,")
+	defer func() { SchedulerNames = map[string]struct{}{} }()
+	assert.Len(t, SchedulerNames, 2)
+	// pods without a scheduler name go to the default scheduler
+	assert.True(t, Serves(pod))
+	pod.Spec.SchedulerName = "gpu-spread"
+	assert.True(t, Serves(pod))
+	pod.Spec.SchedulerName = "volcano"
+	assert.False(t, Serves(pod))
+	assert.Contains(t, ForeignSchedulerError(pod).Error(), "volcano")
+}
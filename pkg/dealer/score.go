@@ -0,0 +1,128 @@
+package dealer
+
+var (
+	// NormalizeScores scales the scores of every prioritize call to the score range,
+	// the best node gets ScoreRangeMax and the worst ScoreRangeMin, so that the raw
+	// rater scores don't outweigh the other priorities of the scheduler.
+	NormalizeScores = false
+	ScoreRangeMin   = 0
+	ScoreRangeMax   = 100
+)
+
+// SubScores are what a node score is made of, in percent.
+type SubScores struct {
+	// CoreFit is the gpu share of the node reserved once the pod is placed.
+	CoreFit float64 `json:"coreFit"`
+	// MemoryFit is the measured memory usage of the node, the reserved share when
+	// it isn't measured as memory is reserved along with the share.
+	MemoryFit float64 `json:"memoryFit"`
+	// Load is the measured core usage of the node.
+	Load float64 `json:"load"`
+}
+
+// ScoringWeights weigh the sub-scores of a node in place of the rater score, so that
+// clusters bound by memory can favour memory fit over core fit. All zero keeps the
+// rater score.
+type ScoringWeights struct {
+	CoreFit   float64 `yaml:"coreFit"`
+	MemoryFit float64 `yaml:"memoryFit"`
+	Load      float64 `yaml:"load"`
+}
+
+func (w ScoringWeights) Enabled() bool {
+	return w.CoreFit+w.MemoryFit+w.Load > 0
+}
+
+// Score returns the weighted average of the sub-scores in [0, 100]. Fuller nodes score
+// higher unless the rater spreads, less loaded nodes always score higher.
+func (w ScoringWeights) Score(s SubScores, spread bool) int {
+	core, memory := s.CoreFit, s.MemoryFit
+	if spread {
+		core, memory = 100-core, 100-memory
+	}
+	sum := w.CoreFit*core + w.MemoryFit*memory + w.Load*(100-s.Load)
+	return int(sum / (w.CoreFit + w.MemoryFit + w.Load))
+}
+
+func spreads(r Rater) bool {
+	switch r.(type) {
+	case *Spread, *ThermalSpread:
+		return true
+	}
+	return false
+}
+
+func (d *DealerImpl) subScores(ni *NodeInfo, demand Demand, policySpec PolicySpec, isLoadSchedule bool) SubScores {
+	total, reserved := 0, 0
+	for _, g := range ni.GPUs {
+		total += g.PercentTotal
+		reserved += g.PercentTotal - g.Percent
+	}
+	for _, r := range demand {
+		reserved += r.Percent
+	}
+	ans := SubScores{}
+	if total > 0 && reserved < total {
+		ans.CoreFit = 100 * float64(reserved) / float64(total)
+	} else if total > 0 {
+		ans.CoreFit = 100
+	}
+	ans.MemoryFit = ans.CoreFit
+	if !isLoadSchedule {
+		return ans
+	}
+	if usage, ok := measuredCardsUsage(ni.GPUs, d, GPUMemoryUsagePriority, policySpec, ni.Name); ok {
+		ans.MemoryFit = 100 * usage
+	}
+	if usage, ok := measuredCardsUsage(ni.GPUs, d, GPUCoreUsagePriority, policySpec, ni.Name); ok {
+		ans.Load = 100 * usage
+	}
+	return ans
+}
+
+// measuredCardsUsage returns the average fresh usage of the cards of the node.
+func measuredCardsUsage(gpus GPUs, d Dealer, key string, policySpec PolicySpec, nodeName string) (float64, bool) {
+	activeDuration, err := getActiveDuration(policySpec.SyncPeriod, key)
+	if err != nil {
+		return 0, false
+	}
+	sum, n := 0.0, 0
+	for i := range gpus {
+		exist, usage, err := d.GetUsage(nodeName, key, i, activeDuration)
+		if !exist || err != nil {
+			continue
+		}
+		sum += predictUsage(nodeName, key, i, usage)
+		n++
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return sum / float64(n), true
+}
+
+// normalizeScores scales the scores linearly to the score range, equal scores all
+// get ScoreRangeMax.
+func normalizeScores(scores []int) []int {
+	if len(scores) == 0 {
+		return scores
+	}
+	lo, hi := scores[0], scores[0]
+	for _, s := range scores {
+		if s < lo {
+			lo = s
+		}
+		if s > hi {
+			hi = s
+		}
+	}
+	ans := make([]int, len(scores))
+	for i, s := range scores {
+		if hi == lo {
+			ans[i] = ScoreRangeMax
+			continue
+		}
+		ans[i] = ScoreRangeMin + (s-lo)*(ScoreRangeMax-ScoreRangeMin)/(hi-lo)
+	}
+	return ans
+}
@@ -0,0 +1,51 @@
+package dealer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeScores(t *testing.T) {
+	lo, hi := ScoreRangeMin, ScoreRangeMax
+	defer func() { ScoreRangeMin, ScoreRangeMax = lo, hi }()
+	ScoreRangeMin, ScoreRangeMax = 0, 10
+
+	assert.Equal(t, []int{0, 5, 10}, normalizeScores([]int{180, 230, 280}))
+	assert.Equal(t, []int{10, 10}, normalizeScores([]int{42, 42}))
+	assert.Empty(t, normalizeScores(nil))
+}
+
+func TestSubScores(t *testing.T) {
+	d := MockDealer(MockNode("n1", 2))
+	ni := NewNodeInfo("n1", MockNode("n1", 2), d.Rater)
+	ni.GPUs[0].Percent = 50
+	spec := PolicySpec{SyncPeriod: []Period{{Name: GPUCoreUsagePriority, Period: time.Minute}}}
+
+	s := d.subScores(ni, Demand{{Percent: 50}}, spec, false)
+	assert.Equal(t, SubScores{CoreFit: 50, MemoryFit: 50}, s)
+
+	now := time.Now().In(loc).Format(timeFormat)
+	d.CoreUsage["n1"] = map[int]GPUCoreUsage{
+		0: NewGPUCoreUsage("0.4", now),
+		1: NewGPUCoreUsage("0.2", now),
+	}
+	s = d.subScores(ni, Demand{{Percent: 50}}, spec, true)
+	assert.Equal(t, 50.0, s.MemoryFit)
+	assert.InDelta(t, 30, s.Load, 1e-9)
+}
+
+func TestScoringWeights(t *testing.T) {
+	s := SubScores{CoreFit: 40, MemoryFit: 80, Load: 20}
+	assert.False(t, ScoringWeights{}.Enabled())
+
+	memoryBound := ScoringWeights{CoreFit: 1, MemoryFit: 3}
+	assert.Equal(t, 70, memoryBound.Score(s, false))
+	assert.Equal(t, 30, memoryBound.Score(s, true))
+
+	loadOnly := ScoringWeights{Load: 1}
+	assert.Equal(t, 80, loadOnly.Score(s, false))
+	assert.True(t, spreads(&ThermalSpread{}))
+	assert.False(t, spreads(&Binpack{}))
+}
@@ -0,0 +1,96 @@
+package dealer
+
+import (
+	"reflect"
+
+	v1 "k8s.io/api/core/v1"
+	log "k8s.io/klog/v2"
+)
+
+// Shadow is a policy evaluated next to the active one without affecting bindings,
+// nil disables the shadow mode. Every scored pod gets the node and the cards the
+// shadow policy would pick, which are compared with the real binding.
+var Shadow *ShadowPolicy
+
+type ShadowPolicy struct {
+	Spec  PolicySpec
+	Rater Rater
+}
+
+// ShadowStats counts the bindings the shadow policy was compared on and how many it
+// agreed with, on the node and on the node and the cards.
+type ShadowStats struct {
+	Decisions      int `json:"decisions"`
+	NodeAgreements int `json:"nodeAgreements"`
+	CardAgreements int `json:"cardAgreements"`
+}
+
+type shadowChoice struct {
+	Node       string
+	GPUIndexes []int
+}
+
+// shadowScore records the node and the cards the shadow policy picks among the nodes
+// the active policy finds feasible. Plans are chosen on copies of the cards.
+func (d *DealerImpl) shadowScore(pod *v1.Pod, nodes []string, demand Demand, isLoadSchedule bool) {
+	if Shadow == nil {
+		return
+	}
+	var best *shadowChoice
+	bestScore := 0
+	for _, name := range nodes {
+		ni, ok := d.NodeMaps[name]
+		if !ok {
+			continue
+		}
+		if _, feasible := ni.PlanCache[planKey(demand, pod)]; !feasible {
+			continue
+		}
+		if err := d.checkRules(ni, pod, demand, Shadow.Spec, isLoadSchedule); err != nil {
+			continue
+		}
+		plan, err := ni.schedulableGPUs(pod, d).Clone().Choose(demand, Shadow.Rater, d, Shadow.Spec, name, isLoadSchedule)
+		if err != nil {
+			continue
+		}
+		score := plan.Score + d.ruleScore(ni, pod, demand, Shadow.Spec, isLoadSchedule) + costScore(ni, Shadow.Spec.Cost)
+		if best == nil || score > bestScore {
+			best, bestScore = &shadowChoice{Node: name, GPUIndexes: plan.GPUIndexes}, score
+		}
+	}
+	if best == nil {
+		delete(d.ShadowChoices, pod.UID)
+		return
+	}
+	d.ShadowChoices[pod.UID] = best
+}
+
+// compareShadow counts the binding of the pod against the choice of the shadow
+// policy and logs the placement diff when they disagree.
+func (d *DealerImpl) compareShadow(pod *v1.Pod, node string, plan *Plan) {
+	choice, ok := d.ShadowChoices[pod.UID]
+	if !ok {
+		return
+	}
+	delete(d.ShadowChoices, pod.UID)
+	d.ShadowStats.Decisions++
+	if choice.Node != node {
+		log.Infof("shadow: pod %s/%s bound on %s %v, shadow policy picks %s %v",
+			pod.Namespace, pod.Name, node, plan.GPUIndexes, choice.Node, choice.GPUIndexes)
+		return
+	}
+	d.ShadowStats.NodeAgreements++
+	if !reflect.DeepEqual(choice.GPUIndexes, plan.GPUIndexes) {
+		log.Infof("shadow: pod %s/%s bound on cards %v of %s, shadow policy picks %v",
+			pod.Namespace, pod.Name, plan.GPUIndexes, node, choice.GPUIndexes)
+		return
+	}
+	d.ShadowStats.CardAgreements++
+}
+
+// ShadowStatus returns the agreement of the shadow policy so far.
+func (d *DealerImpl) ShadowStatus() ShadowStats {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	return d.ShadowStats
+}
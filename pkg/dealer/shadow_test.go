@@ -0,0 +1,39 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShadow(t *testing.T) {
+	d := MockDealer(MockNode("n1", 2))
+	n1 := NewNodeInfo("n1", MockNode("n1", 2), d.Rater)
+	n1.GPUs[0].Percent = 60
+	d.NodeMaps["n1"] = n1
+	pod := MockQuotaPod("a", "p0", 30)
+
+	// disabled, nothing is recorded
+	d.score([]string{"n1"}, pod, PolicySpec{}, false)
+	assert.Empty(t, d.ShadowChoices)
+
+	Shadow = &ShadowPolicy{Rater: &Spread{}}
+	defer func() { Shadow = nil }()
+	_, plans := d.score([]string{"n1"}, pod, PolicySpec{}, false)
+	assert.Equal(t, []int{0}, plans[0])
+	assert.Equal(t, &shadowChoice{Node: "n1", GPUIndexes: []int{1}}, d.ShadowChoices[pod.UID])
+
+	// same node, other card
+	d.compareShadow(pod, "n1", &Plan{GPUIndexes: plans[0]})
+	assert.Equal(t, ShadowStats{Decisions: 1, NodeAgreements: 1}, d.ShadowStatus())
+	assert.Empty(t, d.ShadowChoices)
+
+	Shadow.Rater = &Binpack{}
+	d.score([]string{"n1"}, pod, PolicySpec{}, false)
+	d.compareShadow(pod, "n1", &Plan{GPUIndexes: plans[0]})
+	assert.Equal(t, ShadowStats{Decisions: 2, NodeAgreements: 2, CardAgreements: 1}, d.ShadowStatus())
+
+	// not scored, not compared
+	d.compareShadow(pod, "n1", &Plan{GPUIndexes: plans[0]})
+	assert.Equal(t, 2, d.ShadowStatus().Decisions)
+}
@@ -0,0 +1,41 @@
+package dealer
+
+import (
+	"errors"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ShardSelector selects the gpu nodes of the shard the scheduler owns when several
+// schedulers split the nodes of a cluster, nil owns all nodes. The nodes of the other
+// shards are neither cached nor scheduled on.
+var ShardSelector labels.Selector
+
+// OwnsNode determines if the node is in the shard of the scheduler.
+func OwnsNode(node *v1.Node) bool {
+	return ShardSelector == nil || ShardSelector.Matches(labels.Set(node.Labels))
+}
+
+// ErrNodeNotOwned is a node being out of the shard of the scheduler.
+var ErrNodeNotOwned = errors.New("node is out of the shard")
+
+func errNodeNotOwned(name string) error {
+	return fmt.Errorf("%w %s: %s", ErrNodeNotOwned, ShardSelector.String(), name)
+}
+
+// SetShardHeld records whether the scheduler holds the Lease of its shard, gpu pods
+// are only placed while it does so that two schedulers never share the nodes.
+func (d *DealerImpl) SetShardHeld(held bool) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	d.ShardHeld = held
+}
+
+func (d *DealerImpl) checkShard() error {
+	if ShardSelector != nil && !d.ShardHeld {
+		return fmt.Errorf("the lease of the shard %s isn't held by this scheduler", ShardSelector.String())
+	}
+	return nil
+}
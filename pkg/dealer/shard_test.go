@@ -0,0 +1,40 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestShard(t *testing.T) {
+	ShardSelector = labels.SelectorFromSet(labels.Set{"shard": "a"})
+	defer func() { ShardSelector = nil }()
+	owned, other := MockNode("n1", 2), MockNode("n2", 2)
+	owned.Labels = map[string]string{"shard": "a"}
+	d := MockDealer(owned, other)
+	assert.True(t, OwnsNode(owned))
+	assert.False(t, OwnsNode(other))
+
+	// nodes of other shards are not cached
+	d.UpdateNode(other)
+	assert.NotContains(t, d.NodeMaps, "n2")
+	d.NodeMaps["n1"] = NewNodeInfo("n1", owned, d.Rater)
+
+	// nothing is placed before the lease of the shard is held
+	pod := MockQuotaPod("a", "p0", 40)
+	ans, errs := d.Assume([]string{"n1", "n2"}, pod, PolicySpec{}, false)
+	assert.Equal(t, []bool{false, false}, ans)
+	assert.Contains(t, errs[0].Error(), "lease")
+
+	d.SetShardHeld(true)
+	ans, errs = d.Assume([]string{"n1", "n2"}, pod, PolicySpec{}, false)
+	assert.Equal(t, []bool{true, false}, ans)
+	assert.Contains(t, errs[1].Error(), "out of the shard")
+
+	// a node relabeled out of the shard is dropped
+	relabeled := owned.DeepCopy()
+	relabeled.Labels["shard"] = "b"
+	d.UpdateNode(relabeled)
+	assert.NotContains(t, d.NodeMaps, "n1")
+}
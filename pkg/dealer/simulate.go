@@ -0,0 +1,186 @@
+package dealer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ClusterSnapshot is a cluster as the simulator sees it: the gpu nodes and a dealer
+// checkpoint holding the assumed pods and the usage of the cards.
+type ClusterSnapshot struct {
+	Nodes []*v1.Node `json:"nodes"`
+	Checkpoint
+}
+
+// NewSimulatedDealer returns a dealer of the snapshot without an apiserver, pods are
+// placed in memory only. The usage of the snapshot is taken as current.
+func NewSimulatedDealer(snapshot *ClusterSnapshot, rater Rater) *DealerImpl {
+	d, _ := newSimulatedDealer(snapshot, rater)
+	return d
+}
+
+// newSimulatedDealer also returns the store of the nodes, so that node updates can
+// be played.
+func newSimulatedDealer(snapshot *ClusterSnapshot, rater Rater) (*DealerImpl, cache.Indexer) {
+	nodes := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, node := range snapshot.Nodes {
+		nodes.Add(node)
+	}
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	d := newDealerImpl(nil, corelisters.NewNodeLister(nodes), corelisters.NewPodLister(pods), rater)
+	d.restoreCheckpoint(&snapshot.Checkpoint)
+	now := time.Now().In(loc).Format(timeFormat)
+	for _, cards := range d.CoreUsage {
+		for idx, usage := range cards {
+			usage.UpdateTime = now
+			cards[idx] = usage
+		}
+	}
+	for _, cards := range d.MemoryUsage {
+		for idx, usage := range cards {
+			usage.UpdateTime = now
+			cards[idx] = usage
+		}
+	}
+	for _, metrics := range d.MetricUsage {
+		for _, cards := range metrics {
+			for idx, usage := range cards {
+				usage.UpdateTime = now
+				cards[idx] = usage
+			}
+		}
+	}
+	return d, nodes
+}
+
+// SimulatedPlacement is the outcome of placing a pod, Reason tells why no node fits it.
+type SimulatedPlacement struct {
+	Pod        string `json:"pod"`
+	Node       string `json:"node,omitempty"`
+	GPUIndexes []int  `json:"gpuIndexes,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+type SimulationReport struct {
+	Placements []SimulatedPlacement `json:"placements"`
+	Placed     int                  `json:"placed"`
+	Unplaced   int                  `json:"unplaced"`
+	// Utilization is the allocated share of all cards after the placements.
+	Utilization   float64              `json:"utilization"`
+	Fragmentation *FragmentationReport `json:"fragmentation"`
+}
+
+// Simulate places the pods in order as the extender would schedule them one after
+// the other, every placed pod is accounted before the next one is filtered.
+func (d *DealerImpl) Simulate(pods []*v1.Pod, policySpec PolicySpec, isLoadSchedule bool) (*SimulationReport, error) {
+	nodes, err := d.NodeLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	sort.Strings(names)
+	report := &SimulationReport{Placements: make([]SimulatedPlacement, 0, len(pods))}
+	for _, pod := range pods {
+		spec, load := ResolveProfile(pod, policySpec, isLoadSchedule)
+		p := SimulatedPlacement{Pod: pod.Namespace + "/" + pod.Name}
+		node, plan, err := d.place(names, pod, spec, load)
+		if err != nil {
+			p.Reason = err.Error()
+			report.Unplaced++
+		} else {
+			p.Node, p.GPUIndexes = node, plan.GPUIndexes
+			report.Placed++
+		}
+		report.Placements = append(report.Placements, p)
+	}
+
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	total, free := 0, 0
+	for _, name := range names {
+		ni, err := d.getNodeInfo(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range ni.GPUs {
+			total += g.PercentTotal
+			free += g.Percent
+		}
+	}
+	if total > 0 {
+		report.Utilization = 1 - float64(free)/float64(total)
+	}
+	report.Fragmentation = d.fragmentation()
+	return report, nil
+}
+
+// place filters and scores the nodes for the pod and accounts it on the best one
+// like a binding does, without writing the pod anywhere.
+func (d *DealerImpl) place(nodes []string, pod *v1.Pod, policySpec PolicySpec, isLoadSchedule bool) (string, *Plan, error) {
+	ok, errs := d.Assume(nodes, pod, policySpec, isLoadSchedule)
+	feasible := make([]string, 0)
+	reasons := make([]string, 0)
+	for i, node := range nodes {
+		if ok[i] {
+			feasible = append(feasible, node)
+		} else if errs[i] != nil {
+			reasons = append(reasons, fmt.Sprintf("%s: %v", node, errs[i]))
+		}
+	}
+	if len(feasible) == 0 {
+		return "", nil, fmt.Errorf("no node fits: %s", strings.Join(reasons, "; "))
+	}
+	scores := d.Score(feasible, pod, policySpec, isLoadSchedule)
+	best := 0
+	for i := range scores {
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+	plan, err := d.bindInMemory(feasible[best], pod, policySpec, isLoadSchedule)
+	if err != nil {
+		return "", nil, err
+	}
+	return feasible[best], plan, nil
+}
+
+// bindInMemory accounts the pod on the node like a binding does, without writing the
+// pod anywhere.
+func (d *DealerImpl) bindInMemory(name string, pod *v1.Pod, policySpec PolicySpec, isLoadSchedule bool) (*Plan, error) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	ni, err := d.getNodeInfo(name)
+	if err != nil {
+		return nil, err
+	}
+	node, err := d.NodeLister.Get(ni.Name)
+	if err != nil {
+		return nil, err
+	}
+	demand := NewDemandFromPod(pod)
+	d.unreserveGang(pod.UID)
+	plan, err := ni.Bind(demand, pod, d, policySpec, isLoadSchedule)
+	if err != nil {
+		return nil, err
+	}
+	newPod := podWithPlan(pod, node, plan)
+	newPod.Spec.NodeName = ni.Name
+	ni.addQoS(newPod, plan, 1)
+	d.PodMaps[pod.UID] = newPod
+	d.trackAntiAffinity(newPod)
+	d.recordAllocation(ni.Name, newPod, plan, AllocationActionAllocate)
+	d.forgetPending(pod.UID)
+	d.forgetJobReplica(pod, false)
+	d.recordShape(newPod)
+	return plan, nil
+}
@@ -0,0 +1,38 @@
+package dealer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+)
+
+func TestSimulate(t *testing.T) {
+	running := utils.GetUpdatedPodAnnotationSpec(MockQuotaPod("a", "running", 60), []int{0})
+	running.Spec.NodeName = "n1"
+	data, err := json.Marshal(&ClusterSnapshot{
+		Nodes:      []*v1.Node{MockNode("n1", 1), MockNode("n2", 1)},
+		Checkpoint: Checkpoint{Pods: []*v1.Pod{running}},
+	})
+	assert.Nil(t, err)
+	snapshot := &ClusterSnapshot{}
+	assert.Nil(t, json.Unmarshal(data, snapshot))
+	assert.Len(t, snapshot.Pods, 1)
+
+	d := NewSimulatedDealer(snapshot, &Binpack{})
+	pods := []*v1.Pod{MockQuotaPod("a", "p0", 40), MockQuotaPod("a", "p1", 80), MockQuotaPod("a", "p2", 50)}
+	report, err := d.Simulate(pods, PolicySpec{}, false)
+	assert.Nil(t, err)
+	// binpack fills the card of the running pod first
+	assert.Equal(t, SimulatedPlacement{Pod: "a/p0", Node: "n1", GPUIndexes: []int{0}}, report.Placements[0])
+	assert.Equal(t, "n2", report.Placements[1].Node)
+	assert.Equal(t, "", report.Placements[2].Node)
+	assert.NotEmpty(t, report.Placements[2].Reason)
+	assert.Equal(t, 2, report.Placed)
+	assert.Equal(t, 1, report.Unplaced)
+	assert.InDelta(t, 0.9, report.Utilization, 0.001)
+	assert.Equal(t, 20, report.Fragmentation.Cluster.Free)
+}
@@ -0,0 +1,86 @@
+package dealer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// spikeWindow is the number of recent samples a new sample is compared against.
+const spikeWindow = 5
+
+var (
+	// UsageSmoothingAlpha is the weight of the newest sample in the exponentially
+	// weighted moving average of usage, 1 keeps the raw reported value.
+	UsageSmoothingAlpha = 1.0
+	// UsageSpikeThreshold rejects a sample deviating more than the threshold from
+	// the median of the recent samples, 0 disables spike filtering. A sustained
+	// change is accepted once it dominates the window.
+	UsageSpikeThreshold = 0.0
+)
+
+type usageFilter struct {
+	samples []float64
+	ewma    float64
+	primed  bool
+}
+
+func (f *usageFilter) median() float64 {
+	sorted := append([]float64(nil), f.samples...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// filter rejects spikes and returns the smoothed usage after taking the sample.
+func (f *usageFilter) filter(value float64) float64 {
+	accepted := value
+	if UsageSpikeThreshold > 0 && len(f.samples) >= spikeWindow/2+1 {
+		if m := f.median(); math.Abs(value-m) > UsageSpikeThreshold {
+			accepted = m
+		}
+	}
+	f.samples = append(f.samples, value)
+	if len(f.samples) > spikeWindow {
+		f.samples = f.samples[len(f.samples)-spikeWindow:]
+	}
+	if !f.primed || UsageSmoothingAlpha >= 1 || UsageSmoothingAlpha <= 0 {
+		f.ewma = accepted
+		f.primed = true
+	} else {
+		f.ewma = UsageSmoothingAlpha*accepted + (1-UsageSmoothingAlpha)*f.ewma
+	}
+	return f.ewma
+}
+
+// dropUsageFilters drops the filters of every metric and card of the node, the
+// keys are metric/node/card.
+func (d *DealerImpl) dropUsageFilters(nodeName string) {
+	for k := range d.UsageFilters {
+		parts := strings.Split(k, "/")
+		if len(parts) >= 3 && parts[len(parts)-2] == nodeName {
+			delete(d.UsageFilters, k)
+		}
+	}
+}
+
+// smoothUsage returns the value stored for GetUsage, malformed values are kept as
+// is so that GetUsage still reports them.
+func (d *DealerImpl) smoothUsage(key, nodeName string, card int, usage string) string {
+	value, err := strconv.ParseFloat(usage, 64)
+	if err != nil || value < 0 || value > 1 {
+		return usage
+	}
+	fk := fmt.Sprintf("%s/%s/%d", key, nodeName, card)
+	f, ok := d.UsageFilters[fk]
+	if !ok {
+		f = &usageFilter{}
+		d.UsageFilters[fk] = f
+	}
+	return strconv.FormatFloat(f.filter(value), 'f', -1, 64)
+}
@@ -0,0 +1,37 @@
+package dealer
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSmoothUsage(t *testing.T) {
+	defer func(alpha, threshold float64) {
+		UsageSmoothingAlpha, UsageSpikeThreshold = alpha, threshold
+	}(UsageSmoothingAlpha, UsageSpikeThreshold)
+	d := MockDealer()
+	smooth := func(usage string) float64 {
+		v, err := strconv.ParseFloat(d.smoothUsage(GPUCoreUsagePriority, "n1", 0, usage), 64)
+		assert.Nil(t, err)
+		return v
+	}
+
+	// defaults keep the raw value
+	assert.Equal(t, 0.2, smooth("0.2"))
+	assert.Equal(t, 0.9, smooth("0.9"))
+	assert.Equal(t, "bad", d.smoothUsage(GPUCoreUsagePriority, "n1", 1, "bad"))
+
+	UsageSmoothingAlpha, UsageSpikeThreshold = 0.5, 0.3
+	d = MockDealer()
+	assert.InDelta(t, 0.2, smooth("0.2"), 1e-9)
+	assert.InDelta(t, 0.2, smooth("0.2"), 1e-9)
+	assert.InDelta(t, 0.3, smooth("0.4"), 1e-9)
+	// a single spike is replaced by the median
+	assert.InDelta(t, 0.25, smooth("1"), 1e-9)
+	// a sustained change is accepted once it dominates the window
+	assert.InDelta(t, 0.275, smooth("1"), 1e-9)
+	assert.InDelta(t, 0.3375, smooth("1"), 1e-9)
+	assert.InDelta(t, 0.66875, smooth("1"), 1e-9)
+}
@@ -0,0 +1,67 @@
+package dealer
+
+import (
+	"fmt"
+	"strings"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// SpotScoreWeight is added to the score of spot nodes for fault-tolerant pods and
+// taken off it for long-running pods, 0 disables it.
+var SpotScoreWeight = 0
+
+// spotLabels are the labels and values cloud providers and provisioners mark spot
+// nodes with.
+var spotLabels = map[string]string{
+	schetypes.LabelSpot:                     "true",
+	"node.kubernetes.io/lifecycle":          "spot",
+	"karpenter.sh/capacity-type":            "spot",
+	"eks.amazonaws.com/capacityType":        "SPOT",
+	"cloud.google.com/gke-spot":             "true",
+	"cloud.google.com/gke-preemptible":      "true",
+	"kubernetes.azure.com/scalesetpriority": "spot",
+}
+
+// IsSpotNode reports whether the node is a spot or preemptible node.
+func IsSpotNode(labels map[string]string) bool {
+	for key, value := range spotLabels {
+		if strings.EqualFold(labels[key], value) {
+			return true
+		}
+	}
+	return false
+}
+
+func isFaultTolerant(pod *v1.Pod) bool {
+	return pod.Annotations[schetypes.AnnotationFaultTolerant] == "true"
+}
+
+// checkTerminationNotice fails the nodes about to be reclaimed.
+func (d *DealerImpl) checkTerminationNotice(ni *NodeInfo) error {
+	node, err := d.NodeLister.Get(ni.Name)
+	if err != nil {
+		return err
+	}
+	if notice, ok := node.Annotations[schetypes.AnnotationTerminationNotice]; ok {
+		return fmt.Errorf("node %s got a termination notice %s", ni.Name, notice)
+	}
+	return nil
+}
+
+// spotScore prefers spot nodes for fault-tolerant pods and avoids them for long-running
+// pods, short pods don't mind.
+func spotScore(ni *NodeInfo, pod *v1.Pod) int {
+	if SpotScoreWeight == 0 || !IsSpotNode(ni.Labels) {
+		return 0
+	}
+	if isFaultTolerant(pod) {
+		return SpotScoreWeight
+	}
+	if _, short := expectedDuration(pod); !short {
+		return -SpotScoreWeight
+	}
+	return 0
+}
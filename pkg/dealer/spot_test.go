@@ -0,0 +1,41 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestSpotScore(t *testing.T) {
+	defer func(w int) { SpotScoreWeight = w }(SpotScoreWeight)
+	SpotScoreWeight = 20
+	spot, onDemand := MockNode("spot", 1), MockNode("on-demand", 1)
+	spot.Labels = map[string]string{"eks.amazonaws.com/capacityType": "SPOT"}
+	onDemand.Labels = map[string]string{"karpenter.sh/capacity-type": "on-demand"}
+	spotNI, onDemandNI := NewNodeInfo("spot", spot, &Binpack{}), NewNodeInfo("on-demand", onDemand, &Binpack{})
+
+	training := MockQuotaPod("a", "training", 100)
+	assert.Equal(t, -20, spotScore(spotNI, training))
+	assert.Equal(t, 0, spotScore(onDemandNI, training))
+
+	tolerant := MockQuotaPod("a", "tolerant", 100)
+	tolerant.Annotations = map[string]string{types.AnnotationFaultTolerant: "true"}
+	assert.Equal(t, 20, spotScore(spotNI, tolerant))
+
+	controller := true
+	job := MockQuotaPod("a", "job", 100)
+	job.OwnerReferences = []metav1.OwnerReference{{Kind: "Job", Name: "j", Controller: &controller}}
+	assert.Equal(t, 0, spotScore(spotNI, job))
+}
+
+func TestCheckTerminationNotice(t *testing.T) {
+	node := MockNode("spot", 1)
+	d := MockDealer(node)
+	ni := NewNodeInfo("spot", node, d.Rater)
+	assert.NoError(t, d.checkTerminationNotice(ni))
+	node.Annotations = map[string]string{types.AnnotationTerminationNotice: "2026-10-20T02:00:00Z"}
+	assert.EqualError(t, d.checkTerminationNotice(ni), "node spot got a termination notice 2026-10-20T02:00:00Z")
+}
@@ -0,0 +1,81 @@
+package dealer
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	log "k8s.io/klog/v2"
+)
+
+// SpreadWeight is the score of the least crowded topology domain of a pod with
+// topology spread constraints over the most crowded one, 0 disables it. Only the gpu
+// pods known to the dealer are counted.
+var SpreadWeight = 0
+
+// spreadScores scores the nodes by how few pods matching the topology spread constraints
+// of the pod their domains hold, averaged over the constraints. Nodes without the
+// topology key score 0.
+func (d *DealerImpl) spreadScores(nodes []string, pod *v1.Pod) []int {
+	scores := make([]int, len(nodes))
+	constraints := pod.Spec.TopologySpreadConstraints
+	if SpreadWeight == 0 || len(constraints) == 0 {
+		return scores
+	}
+	nodeLabels := make(map[string]labels.Set)
+	labelsOf := func(name string) labels.Set {
+		if l, ok := nodeLabels[name]; ok {
+			return l
+		}
+		var l labels.Set
+		if node, err := d.NodeLister.Get(name); err == nil {
+			l = node.Labels
+		}
+		nodeLabels[name] = l
+		return l
+	}
+	sums := make([]float64, len(nodes))
+	for _, c := range constraints {
+		selector, err := metav1.LabelSelectorAsSelector(c.LabelSelector)
+		if err != nil {
+			log.Warningf("ignore topology spread constraint of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+			continue
+		}
+		counts := make(map[string]int)
+		for _, p := range d.PodMaps {
+			if p.Namespace != pod.Namespace || p.UID == pod.UID || !selector.Matches(labels.Set(p.Labels)) {
+				continue
+			}
+			if domain, ok := labelsOf(p.Spec.NodeName)[c.TopologyKey]; ok {
+				counts[domain]++
+			}
+		}
+		first := true
+		min, max := 0, 0
+		for _, name := range nodes {
+			domain, ok := labelsOf(name)[c.TopologyKey]
+			if !ok {
+				continue
+			}
+			n := counts[domain]
+			if first || n < min {
+				min = n
+			}
+			if first || n > max {
+				max = n
+			}
+			first = false
+		}
+		if max == min {
+			continue
+		}
+		for i, name := range nodes {
+			if domain, ok := labelsOf(name)[c.TopologyKey]; ok {
+				sums[i] += float64(max-counts[domain]) / float64(max-min)
+			}
+		}
+	}
+	for i := range scores {
+		scores[i] = int(float64(SpreadWeight) * sums[i] / float64(len(constraints)))
+	}
+	return scores
+}
@@ -0,0 +1,54 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+)
+
+func TestSpreadScores(t *testing.T) {
+	defer func(w int) { SpreadWeight = w }(SpreadWeight)
+	SpreadWeight = 20
+	zones := map[string]string{"n1": "z1", "n2": "z1", "n3": "z2", "n4": ""}
+	nodes := make([]*v1.Node, 0)
+	for _, name := range []string{"n1", "n2", "n3", "n4"} {
+		node := MockNode(name, 1)
+		if zones[name] != "" {
+			node.Labels = map[string]string{"zone": zones[name]}
+		}
+		nodes = append(nodes, node)
+	}
+	d := MockDealer(nodes...)
+	for i, node := range []string{"n1", "n2", "n3"} {
+		p := MockQuotaPod("a", string(rune('a'+i)), 10)
+		p.Labels = map[string]string{"app": "infer"}
+		p.Spec.NodeName = node
+		d.PodMaps[p.UID] = p
+	}
+	other := MockQuotaPod("b", "x", 10)
+	other.Labels = map[string]string{"app": "infer"}
+	other.Spec.NodeName = "n3"
+	d.PodMaps[other.UID] = other
+
+	pod := MockQuotaPod("a", "new", 10)
+	names := []string{"n1", "n2", "n3", "n4"}
+	assert.Equal(t, []int{0, 0, 0, 0}, d.spreadScores(names, pod))
+
+	pod.Spec.TopologySpreadConstraints = []v1.TopologySpreadConstraint{{
+		MaxSkew:           1,
+		TopologyKey:       "zone",
+		WhenUnsatisfiable: v1.ScheduleAnyway,
+		LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "infer"}},
+	}}
+	assert.Equal(t, []int{0, 0, 20, 0}, d.spreadScores(names, pod))
+
+	// a balanced spread adds nothing
+	p := MockQuotaPod("a", "d", 10)
+	p.Labels = map[string]string{"app": "infer"}
+	p.Spec.NodeName = "n3"
+	d.PodMaps[k8stypes.UID("a/d")] = p
+	assert.Equal(t, []int{0, 0, 0, 0}, d.spreadScores(names, pod))
+}
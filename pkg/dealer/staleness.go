@@ -0,0 +1,53 @@
+package dealer
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// StalePolicyFailOpen treats a card without fresh usage as idle.
+	StalePolicyFailOpen = "fail-open"
+	// StalePolicyFailClosed treats a card without fresh usage as fully loaded.
+	StalePolicyFailClosed = "fail-closed"
+	// StalePolicyRequest estimates the usage of a card from the requested share.
+	StalePolicyRequest = "request"
+)
+
+var ErrUsageStale = errors.New("usage not in update period")
+
+var (
+	// StalenessWindow is added to the sync period of a metric to decide how long a
+	// reported usage stays valid.
+	StalenessWindow = ExtenderAtivePeriod
+	StalePolicy     = StalePolicyFailOpen
+)
+
+func ValidateStalePolicy(policy string) error {
+	switch policy {
+	case StalePolicyFailOpen, StalePolicyFailClosed, StalePolicyRequest:
+		return nil
+	}
+	return fmt.Errorf("stale policy %s is not supported", policy)
+}
+
+// ActiveDuration is how long a usage synced every period stays valid.
+func ActiveDuration(period time.Duration) time.Duration {
+	return period + StalenessWindow
+}
+
+// staleUsage is the usage assumed for a card whose reported usage is stale, ok is
+// false when the card should be ignored.
+func (g *GPUResource) staleUsage() (usage float64, ok bool) {
+	switch StalePolicy {
+	case StalePolicyFailClosed:
+		return 1, true
+	case StalePolicyRequest:
+		if g.PercentTotal == 0 {
+			return 0, true
+		}
+		return float64(g.PercentTotal-g.Percent) / float64(g.PercentTotal), true
+	}
+	return 0, false
+}
@@ -0,0 +1,32 @@
+package dealer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStalePolicy(t *testing.T) {
+	defer func(policy string) { StalePolicy = policy }(StalePolicy)
+	d := MockDealer(MockNode("n1", 1))
+	d.AddCoreUsage("n1")
+	stale := time.Now().In(loc).Add(-time.Hour).Format(timeFormat)
+	d.UpdateCoreUsage("n1", "0.1", stale, 0)
+	spec := PolicySpec{SyncPeriod: []Period{{Name: GPUCoreUsagePriority, Period: time.Minute}}}
+	g := &GPUResource{Percent: 40, PercentTotal: 100}
+
+	_, _, err := d.GetUsage("n1", GPUCoreUsagePriority, 0, ActiveDuration(time.Minute))
+	assert.True(t, errors.Is(err, ErrUsageStale))
+
+	StalePolicy = StalePolicyFailOpen
+	assert.Equal(t, 0.0, g.LoadUsage(d, 0, spec, "n1"))
+	StalePolicy = StalePolicyFailClosed
+	assert.Equal(t, 1.0, g.LoadUsage(d, 0, spec, "n1"))
+	StalePolicy = StalePolicyRequest
+	assert.InDelta(t, 0.6, g.LoadUsage(d, 0, spec, "n1"), 1e-9)
+
+	assert.Nil(t, ValidateStalePolicy(StalePolicyRequest))
+	assert.NotNil(t, ValidateStalePolicy("ignore"))
+}
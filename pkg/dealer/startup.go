@@ -0,0 +1,76 @@
+package dealer
+
+import (
+	"context"
+	"fmt"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	log "k8s.io/klog/v2"
+)
+
+// StartupListPageSize is the number of pods listed per request on startup.
+var StartupListPageSize int64 = 500
+
+// startupListOptions selects the bound and unfinished assumed pods.
+func startupListOptions() metav1.ListOptions {
+	return metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", schetypes.GPUAssume, "true"),
+		FieldSelector: fields.AndSelectors(
+			fields.OneTermNotEqualSelector(schetypes.NodeNameField, ""),
+			fields.OneTermNotEqualSelector("status.phase", string(v1.PodSucceeded)),
+			fields.OneTermNotEqualSelector("status.phase", string(v1.PodFailed)),
+		).String(),
+		Limit: StartupListPageSize,
+	}
+}
+
+// loadAssumedPods accounts the assumed pods page by page so that the cache is
+// built without holding the whole cluster in a single response.
+func (d *DealerImpl) loadAssumedPods() error {
+	opts := startupListOptions()
+	for pages := 1; ; pages++ {
+		list, err := d.Client.CoreV1().Pods(metav1.NamespaceAll).List(context.Background(), opts)
+		if err != nil {
+			return err
+		}
+		d.accountPods(list.Items)
+		if list.Continue == "" {
+			log.Infof("loaded %d pods on %d nodes from %d pages", len(d.PodMaps), len(d.NodeMaps), pages)
+			return nil
+		}
+		opts.Continue = list.Continue
+	}
+}
+
+func (d *DealerImpl) accountPods(pods []v1.Pod) {
+	for i := range pods {
+		pod := &pods[i]
+		ni, ok := d.NodeMaps[pod.Spec.NodeName]
+		if !ok {
+			node, err := d.getNode(pod.Spec.NodeName)
+			if err != nil {
+				log.Errorf("get node %s failed: %s", pod.Spec.NodeName, err.Error())
+				continue
+			}
+			if !OwnsNode(node) {
+				continue
+			}
+			ni = NewNodeInfo(node.Name, node, d.Rater)
+			d.NodeMaps[node.Name] = ni
+		}
+		d.accountPod(ni, pod)
+	}
+}
+
+// getNode falls back to the apiserver as the node informer may not have synced yet
+// on startup.
+func (d *DealerImpl) getNode(name string) (*v1.Node, error) {
+	if node, err := d.NodeLister.Get(name); err == nil {
+		return node, nil
+	}
+	return d.Client.CoreV1().Nodes().Get(context.Background(), name, metav1.GetOptions{})
+}
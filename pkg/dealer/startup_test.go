@@ -0,0 +1,56 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+)
+
+func TestStartupListOptions(t *testing.T) {
+	opts := startupListOptions()
+	assert.Equal(t, "nano-gpu/assume=true", opts.LabelSelector)
+	assert.Equal(t, "spec.nodeName!=,status.phase!=Succeeded,status.phase!=Failed", opts.FieldSelector)
+	assert.Equal(t, StartupListPageSize, opts.Limit)
+}
+
+func TestAccountPods(t *testing.T) {
+	d := MockDealer(MockNode("n1", 2))
+	pods := make([]v1.Pod, 0)
+	for i, name := range []string{"p0", "p1"} {
+		pod := MockQuotaPod("a", name, 40)
+		pod.Spec.NodeName = "n1"
+		pods = append(pods, *utils.GetUpdatedPodAnnotationSpec(pod, []int{i}))
+	}
+	d.accountPods(pods[:1])
+	d.accountPods(pods[1:])
+	assert.Len(t, d.PodMaps, 2)
+	assert.Equal(t, "p0", d.PodMaps["a/p0"].Name)
+	assert.Equal(t, 60, d.NodeMaps["n1"].GPUs[0].Percent)
+	assert.Equal(t, 60, d.NodeMaps["n1"].GPUs[1].Percent)
+}
+
+func TestPodsOfNodeFromLister(t *testing.T) {
+	d := MockDealer()
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	d.PodLister = corelisters.NewPodLister(indexer)
+	for i, name := range []string{"p0", "p1", "p2"} {
+		pod := utils.GetUpdatedPodAnnotationSpec(MockQuotaPod("a", name, 40), []int{0})
+		pod.Spec.NodeName = "n1"
+		if i == 1 {
+			pod.Spec.NodeName = "n2"
+		}
+		if i == 2 {
+			pod.Status.Phase = v1.PodSucceeded
+		}
+		assert.NoError(t, indexer.Add(pod))
+	}
+	pods, err := d.podsOfNode("n1")
+	assert.NoError(t, err)
+	assert.Len(t, pods, 1)
+	assert.Equal(t, "p0", pods[0].Name)
+}
@@ -23,42 +23,34 @@ func GetPolicyFromFile(path string) *Policy {
 	if err != nil {
 		klog.Errorf("Unmarshal policy yaml error: %v", err)
 	}
+	policy.Spec.Rules.Compile()
+	policy.Spec.CompileProfiles()
 
 	return policy
 }
 
-func inUpdateTimePeriod(updatetimeStr string, activeDuration time.Duration) bool {
-	klog.V(6).Infof("updatetimeStr %s", updatetimeStr)
-	if len(updatetimeStr) < 5 {
-		klog.Errorf("updatetimeStr len < 5")
-		return false
-	}
-	loc, err := time.LoadLocation("Asia/Shanghai") //设置时区
-	if err != nil {
-		loc = time.FixedZone("CST", 8*3600)
-	}
-
-	nodeStateUpdateTime, err := time.ParseInLocation(timeFormat, updatetimeStr, loc)
+// GetAlternatePolicyFromFile reads a policy evaluated next to the active one, its
+// profiles are dropped as the pods keep the profiles of the active policy.
+func GetAlternatePolicyFromFile(path string) PolicySpec {
+	yamlFile, err := ioutil.ReadFile(path)
 	if err != nil {
-		klog.Errorf("nodeStateUpdateTime ParseInLocation error")
-		return false
+		klog.Errorf("read policy yaml %s error: %v", path, err)
+		panic("read alternate policy yaml error")
 	}
-
-	now := time.Now().In(loc)
-	updatetime := nodeStateUpdateTime.Add(activeDuration)
-	klog.V(6).Infof("now %v , updatetime %v", now, updatetime)
-	if now.Before(updatetime) {
-		return true
+	policy := new(Policy)
+	if err := yaml.Unmarshal(yamlFile, policy); err != nil {
+		klog.Errorf("Unmarshal policy yaml error: %v", err)
 	}
-	klog.Info("Updatetime is %v and now is %v",updatetime, now)
-	return false
+	policy.Spec.Rules.Compile()
+	policy.Spec.Profiles = nil
+	return policy.Spec
 }
 
 func getActiveDuration(syncPeriodList []Period, name string) (time.Duration, error) {
 	for _, period := range syncPeriodList {
 		if period.Name == name {
 			if period.Period != 0 {
-				return period.Period + ExtenderAtivePeriod, nil
+				return ActiveDuration(period.Period), nil
 			}
 		}
 	}
@@ -0,0 +1,92 @@
+package dealer
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// StatusQuery narrows the nodes of a status, the zero query selects every node.
+type StatusQuery struct {
+	// Selector matches the labels of the nodes, nil matches every node.
+	Selector labels.Selector
+	Pool     string
+	// MinAllocated and MaxAllocated bound the allocated gpu percent of a node in
+	// [0, 100], MaxAllocated 0 leaves it unbounded.
+	MinAllocated int
+	MaxAllocated int
+	// MinCoreUsage is the least measured core usage in [0, 1] averaged over the
+	// cards, nodes without usage only match 0.
+	MinCoreUsage float64
+	// Limit is the number of nodes of a page sorted by name, 0 returns all, the
+	// page after is queried with Continue set to the Continue of the status.
+	Limit    int
+	Continue string
+}
+
+func (q StatusQuery) matches(d *DealerImpl, ni *NodeInfo) bool {
+	if q.Pool != "" && ni.Pool != q.Pool {
+		return false
+	}
+	if q.Selector != nil && !q.Selector.Empty() {
+		node, err := d.NodeLister.Get(ni.Name)
+		if err != nil || !q.Selector.Matches(labels.Set(node.Labels)) {
+			return false
+		}
+	}
+	if len(ni.GPUs) > 0 {
+		allocated := int(100 * ni.GPUs.Usage())
+		if allocated < q.MinAllocated || (q.MaxAllocated > 0 && allocated > q.MaxAllocated) {
+			return false
+		}
+	}
+	if q.MinCoreUsage > 0 {
+		sum, n := 0.0, 0
+		for i := range ni.GPUs {
+			if sample, ok := d.GetUsageSample(ni.Name, GPUCoreUsagePriority, i); ok {
+				sum += sample.Usage
+				n++
+			}
+		}
+		if n == 0 || sum/float64(n) < q.MinCoreUsage {
+			return false
+		}
+	}
+	return true
+}
+
+// QueryStatus returns the status of the nodes matching the query, pools and
+// fragmentation stay cluster wide.
+func (d *DealerImpl) QueryStatus(q StatusQuery) (*Status, error) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	pools, err := d.poolStatus()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(d.NodeMaps))
+	for name, ni := range d.NodeMaps {
+		if name > q.Continue && q.matches(d, ni) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	status := &Status{
+		Nodes:         make(map[string]*NodeInfo),
+		Pools:         pools,
+		Fragmentation: d.fragmentation(),
+		Unhealthy:     make(map[string]map[int]string),
+	}
+	if q.Limit > 0 && len(names) > q.Limit {
+		names = names[:q.Limit]
+		status.Continue = names[q.Limit-1]
+	}
+	unhealthy := d.unhealthy()
+	for _, name := range names {
+		status.Nodes[name] = d.NodeMaps[name].snapshot()
+		if cards, ok := unhealthy[name]; ok {
+			status.Unhealthy[name] = cards
+		}
+	}
+	return status, nil
+}
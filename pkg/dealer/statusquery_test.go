@@ -0,0 +1,48 @@
+package dealer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestQueryStatus(t *testing.T) {
+	nodes := make([]*v1.Node, 0)
+	for _, name := range []string{"n1", "n2", "n3"} {
+		node := MockNode(name, 2)
+		node.Labels = map[string]string{"zone": "a"}
+		nodes = append(nodes, node)
+	}
+	nodes[2].Labels["zone"] = "b"
+	d := MockDealer(nodes...)
+	for _, node := range nodes {
+		d.NodeMaps[node.Name] = NewNodeInfo(node.Name, node, d.Rater)
+	}
+	d.NodeMaps["n2"].GPUs[0].Percent = 0
+	d.UpdateCoreUsage("n2", "0.9", time.Now().In(loc).Format(timeFormat), 0)
+
+	status, err := d.QueryStatus(StatusQuery{Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, status.Nodes, 2)
+	assert.Equal(t, "n2", status.Continue)
+	status, _ = d.QueryStatus(StatusQuery{Limit: 2, Continue: status.Continue})
+	assert.Len(t, status.Nodes, 1)
+	assert.Contains(t, status.Nodes, "n3")
+	assert.Empty(t, status.Continue)
+
+	selector, _ := labels.Parse("zone=a")
+	status, _ = d.QueryStatus(StatusQuery{Selector: selector})
+	assert.Len(t, status.Nodes, 2)
+	status, _ = d.QueryStatus(StatusQuery{MinAllocated: 50})
+	assert.Len(t, status.Nodes, 1)
+	assert.Contains(t, status.Nodes, "n2")
+	status, _ = d.QueryStatus(StatusQuery{MaxAllocated: 10})
+	assert.Len(t, status.Nodes, 2)
+	status, _ = d.QueryStatus(StatusQuery{MinCoreUsage: 0.5})
+	assert.Len(t, status.Nodes, 1)
+	status, _ = d.QueryStatus(StatusQuery{Pool: "train"})
+	assert.Empty(t, status.Nodes)
+}
@@ -0,0 +1,144 @@
+package dealer
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UsagePattern generates the usage of the cards over time, so that load-aware
+// scheduling can be exercised without gpus or a metrics pipeline.
+type UsagePattern interface {
+	// At returns the usage in [0, 1] of the card at the offset from the start.
+	At(card int, offset time.Duration) float64
+}
+
+// ConstantUsage keeps every card at the same usage.
+type ConstantUsage float64
+
+func (c ConstantUsage) At(int, time.Duration) float64 {
+	return float64(c)
+}
+
+// SawtoothUsage ramps the usage from Min to Max every Period, the ramps of the cards
+// are shifted by an eighth of the period from one card to the next.
+type SawtoothUsage struct {
+	Min, Max float64
+	Period   time.Duration
+}
+
+func (s SawtoothUsage) At(card int, offset time.Duration) float64 {
+	if s.Period <= 0 {
+		return s.Min
+	}
+	phase := (offset + time.Duration(card)*s.Period/8) % s.Period
+	return s.Min + (s.Max-s.Min)*float64(phase)/float64(s.Period)
+}
+
+// BurstyUsage keeps the cards at Base and bursts a card to Peak for an Interval with
+// the Chance. Bursts are drawn from Seed, card and interval, so they repeat.
+type BurstyUsage struct {
+	Base, Peak float64
+	Chance     float64
+	Interval   time.Duration
+	Seed       int64
+}
+
+func (b BurstyUsage) At(card int, offset time.Duration) float64 {
+	step := int64(0)
+	if b.Interval > 0 {
+		step = int64(offset / b.Interval)
+	}
+	r := rand.New(rand.NewSource(b.Seed ^ int64(card)<<32 ^ step))
+	if r.Float64() < b.Chance {
+		return b.Peak
+	}
+	return b.Base
+}
+
+// ParseUsagePattern parses a pattern like constant:0.5, sawtooth:0.1:0.9:10m for
+// min, max and period or bursty:0.1:0.9:0.2:1m for base, peak, chance and interval.
+func ParseUsagePattern(s string) (UsagePattern, error) {
+	parts := strings.Split(s, ":")
+	floats := func(args []string) ([]float64, error) {
+		ans := make([]float64, len(args))
+		for i, arg := range args {
+			v, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return nil, fmt.Errorf("usage pattern %q: %v", s, err)
+			}
+			ans[i] = v
+		}
+		return ans, nil
+	}
+	switch {
+	case parts[0] == "constant" && len(parts) == 2:
+		v, err := floats(parts[1:])
+		if err != nil {
+			return nil, err
+		}
+		return ConstantUsage(v[0]), nil
+	case parts[0] == "sawtooth" && len(parts) == 4:
+		v, err := floats(parts[1:3])
+		if err != nil {
+			return nil, err
+		}
+		period, err := time.ParseDuration(parts[3])
+		if err != nil {
+			return nil, fmt.Errorf("usage pattern %q: %v", s, err)
+		}
+		return SawtoothUsage{Min: v[0], Max: v[1], Period: period}, nil
+	case parts[0] == "bursty" && len(parts) == 5:
+		v, err := floats(parts[1:4])
+		if err != nil {
+			return nil, err
+		}
+		interval, err := time.ParseDuration(parts[4])
+		if err != nil {
+			return nil, fmt.Errorf("usage pattern %q: %v", s, err)
+		}
+		return BurstyUsage{Base: v[0], Peak: v[1], Chance: v[2], Interval: interval}, nil
+	}
+	return nil, fmt.Errorf("usage pattern %q is not constant:usage, sawtooth:min:max:period or bursty:base:peak:chance:interval", s)
+}
+
+// FakeNodes returns count gpu nodes named prefix-i with cards cards each.
+func FakeNodes(prefix string, count, cards int) []*v1.Node {
+	nodes := make([]*v1.Node, 0, count)
+	for i := 0; i < count; i++ {
+		nodes = append(nodes, &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-%d", prefix, i)},
+			Status: v1.NodeStatus{
+				Capacity: v1.ResourceList{
+					schetypes.ResourceGPUPercent: *resource.NewQuantity(int64(cards*schetypes.GPUPercentEachCard), resource.DecimalSI),
+				},
+			},
+		})
+	}
+	return nodes
+}
+
+// FillUsage reports the core and the memory usage of the cards of the nodes, by node
+// name, from the patterns at the offset as a usage sync would, a nil pattern leaves
+// its metric alone.
+func FillUsage(d Dealer, cards map[string]int, core, memory UsagePattern, offset time.Duration) {
+	now := time.Now().In(loc).Format(timeFormat)
+	for node, count := range cards {
+		for card := 0; card < count; card++ {
+			if core != nil {
+				d.UpdateCoreUsage(node, strconv.FormatFloat(core.At(card, offset), 'f', 4, 64), now, card)
+			}
+			if memory != nil {
+				d.UpdateMemoryUsage(node, strconv.FormatFloat(memory.At(card, offset), 'f', 4, 64), now, card)
+			}
+		}
+	}
+}
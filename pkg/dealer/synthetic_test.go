@@ -0,0 +1,59 @@
+package dealer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+)
+
+func TestUsagePatterns(t *testing.T) {
+	p, err := ParseUsagePattern("constant:0.5")
+	assert.Nil(t, err)
+	assert.Equal(t, 0.5, p.At(3, time.Hour))
+
+	p, err = ParseUsagePattern("sawtooth:0.2:1:8m")
+	assert.Nil(t, err)
+	assert.InDelta(t, 0.2, p.At(0, 0), 0.001)
+	assert.InDelta(t, 0.6, p.At(0, 4*time.Minute), 0.001)
+	assert.InDelta(t, 0.2, p.At(0, 8*time.Minute), 0.001)
+	// the next card is an eighth of the period ahead
+	assert.InDelta(t, 0.3, p.At(1, 0), 0.001)
+
+	p, err = ParseUsagePattern("bursty:0.1:0.9:0.3:1m")
+	assert.Nil(t, err)
+	bursts := 0
+	for step := 0; step < 1000; step++ {
+		usage := p.At(0, time.Duration(step)*time.Minute)
+		assert.Contains(t, []float64{0.1, 0.9}, usage)
+		if usage == 0.9 {
+			bursts++
+		}
+		// the same within an interval
+		assert.Equal(t, usage, p.At(0, time.Duration(step)*time.Minute+30*time.Second))
+	}
+	assert.InDelta(t, 300, bursts, 60)
+
+	for _, bad := range []string{"constant", "sawtooth:0.1:0.9", "bursty:a:0.9:0.1:1m", "linear:1"} {
+		_, err := ParseUsagePattern(bad)
+		assert.NotNil(t, err, bad)
+	}
+}
+
+func TestFillUsage(t *testing.T) {
+	nodes := FakeNodes("gpu", 2, 4)
+	assert.Equal(t, "gpu-1", nodes[1].Name)
+	assert.Equal(t, 4, utils.GetGPUDeviceCountOfNode(nodes[0]))
+	d := MockDealer(nodes...)
+	FillUsage(d, map[string]int{"gpu-0": 4, "gpu-1": 4}, ConstantUsage(0.7), SawtoothUsage{Min: 0, Max: 1, Period: time.Minute}, 0)
+	for _, node := range []string{"gpu-0", "gpu-1"} {
+		_, usage, err := d.GetUsage(node, GPUCoreUsagePriority, 3, time.Minute)
+		assert.Nil(t, err)
+		assert.Equal(t, 0.7, usage)
+	}
+	_, usage, err := d.GetUsage("gpu-0", GPUMemoryUsagePriority, 2, time.Minute)
+	assert.Nil(t, err)
+	assert.InDelta(t, 0.25, usage, 0.001)
+}
@@ -0,0 +1,81 @@
+package dealer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	log "k8s.io/klog/v2"
+)
+
+// SystemReserved is the share of every card kept off the schedulable capacity of the
+// nodes without the system reserved annotation, in its format. Empty reserves nothing.
+var SystemReserved = ""
+
+// ParseSystemReserved returns the percent of a card to reserve for core=5,memory=1Gi.
+// A share takes the same percent of core and memory, so the larger one is reserved,
+// memory quantities need the memory of the card in MiB.
+func ParseSystemReserved(value string, memoryMiB int) (int, error) {
+	reserved := 0
+	for _, kv := range strings.Split(value, ",") {
+		kv = strings.TrimSpace(kv)
+		if kv == "" {
+			continue
+		}
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			return 0, fmt.Errorf("invalid system reserved %q", kv)
+		}
+		key, val := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		var percent int
+		switch {
+		case key == "core", key == "memory" && strings.HasSuffix(val, "%"):
+			p, err := strconv.Atoi(strings.TrimSuffix(val, "%"))
+			if err != nil {
+				return 0, fmt.Errorf("invalid system reserved %q", kv)
+			}
+			percent = p
+		case key == "memory":
+			q, err := resource.ParseQuantity(val)
+			if err != nil {
+				return 0, fmt.Errorf("invalid system reserved %q: %v", kv, err)
+			}
+			if memoryMiB <= 0 {
+				return 0, fmt.Errorf("system reserved %q needs the gpu memory of the node", kv)
+			}
+			mib := q.Value() / (1 << 20)
+			percent = int((mib*int64(schetypes.GPUPercentEachCard) + int64(memoryMiB) - 1) / int64(memoryMiB))
+		default:
+			return 0, fmt.Errorf("unknown system reserved %q", key)
+		}
+		if percent < 0 || percent >= schetypes.GPUPercentEachCard {
+			return 0, fmt.Errorf("system reserved %q out of range [0, %d)", kv, schetypes.GPUPercentEachCard)
+		}
+		if percent > reserved {
+			reserved = percent
+		}
+	}
+	return reserved, nil
+}
+
+// systemReservedOf returns the percent of every card of the node to reserve, from its
+// annotation or else the default.
+func systemReservedOf(node *v1.Node, attrs CardAttributes) int {
+	value, ok := node.Annotations[schetypes.AnnotationSystemReserved]
+	if !ok {
+		value = SystemReserved
+	}
+	if value == "" {
+		return 0
+	}
+	reserved, err := ParseSystemReserved(value, attrs.MemoryMiB)
+	if err != nil {
+		log.Errorf("node %s: %v", node.Name, err)
+		return 0
+	}
+	return reserved
+}
@@ -0,0 +1,53 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestParseSystemReserved(t *testing.T) {
+	for value, want := range map[string]int{"": 0, "core=5": 5, "core=5,memory=1Gi": 7, "memory=10%": 10, " core=3 , memory=512Mi ": 4} {
+		reserved, err := ParseSystemReserved(value, 15360)
+		assert.NoError(t, err, value)
+		assert.Equal(t, want, reserved, value)
+	}
+	for _, value := range []string{"core", "gpu=5", "core=x", "core=100", "memory=1Gi"} {
+		memory := 15360
+		if value == "memory=1Gi" {
+			memory = 0
+		}
+		_, err := ParseSystemReserved(value, memory)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestSystemReserved(t *testing.T) {
+	node := MockNode("n1", 2)
+	node.Labels = map[string]string{types.LabelGPUPool: "p"}
+	node.Annotations = map[string]string{types.AnnotationSystemReserved: "core=10"}
+	d := MockDealer(node)
+	ni := NewNodeInfo("n1", node, d.Rater)
+	d.NodeMaps["n1"] = ni
+	assert.Equal(t, 10, ni.SystemReserved)
+
+	whole := MockQuotaPod("a", "whole", 100)
+	ok, _ := ni.Assume(NewDemandFromPod(whole), whole, d, PolicySpec{}, false)
+	assert.False(t, ok)
+	pod := MockQuotaPod("a", "p0", 90)
+	ok, err := ni.Assume(NewDemandFromPod(pod), pod, d, PolicySpec{}, false)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	status, err := d.Status()
+	assert.NoError(t, err)
+	assert.Equal(t, &PoolStatus{Nodes: 1, Total: 180, Free: 180}, status.Pools["p"])
+
+	defer func(value string) { SystemReserved = value }(SystemReserved)
+	SystemReserved = "core=5"
+	delete(node.Annotations, types.AnnotationSystemReserved)
+	d.UpdateNode(node)
+	assert.Equal(t, 5, ni.SystemReserved)
+}
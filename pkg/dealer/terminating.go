@@ -0,0 +1,112 @@
+package dealer
+
+import (
+	"time"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// TerminatingGrace keeps the share of terminating pods until their containers are
+// gone, while plans may already count on the share of pods due within the grace.
+// Binding such a plan waits for the release. 0 releases terminating pods at once.
+var TerminatingGrace time.Duration
+
+// ShouldRelease determines if the share of the pod has to be released.
+func ShouldRelease(pod *v1.Pod) bool {
+	if TerminatingGrace <= 0 {
+		return utils.IsCompletedPod(pod)
+	}
+	if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+		return true
+	}
+	return pod.DeletionTimestamp != nil && utils.IsContainersTerminated(pod)
+}
+
+// MarkTerminating records when a known terminating pod is due.
+func (d *DealerImpl) MarkTerminating(pod *v1.Pod) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	if _, ok := d.PodMaps[pod.UID]; !ok || pod.DeletionTimestamp == nil {
+		return
+	}
+	if _, ok := d.Terminating[pod.UID]; !ok {
+		d.Terminating[pod.UID] = pod.DeletionTimestamp.Time
+		d.cleanPlanOfNode(pod.Spec.NodeName)
+	}
+}
+
+// pruneTerminating forgets the terminating pods which are no longer known, it runs
+// before the cards are planned as SoonFreeShare is read by the parallel workers.
+func (d *DealerImpl) pruneTerminating() {
+	for uid := range d.Terminating {
+		if _, ok := d.PodMaps[uid]; !ok {
+			delete(d.Terminating, uid)
+		}
+	}
+}
+
+// SoonFreeShare returns the share by card of the known pods on the node which are
+// due within the grace, it doesn't change the dealer.
+func (d *DealerImpl) SoonFreeShare(nodeName string) map[int]int {
+	ans := make(map[int]int)
+	if TerminatingGrace <= 0 {
+		return ans
+	}
+	horizon := time.Now().Add(TerminatingGrace)
+	for uid, due := range d.Terminating {
+		pod, ok := d.PodMaps[uid]
+		if !ok {
+			continue
+		}
+		if pod.Spec.NodeName != nodeName || due.After(horizon) {
+			continue
+		}
+		plan, err := NewPlanFromPod(pod)
+		if err != nil {
+			continue
+		}
+		for i, idx := range plan.GPUIndexes {
+			if idx >= 0 {
+				ans[idx] += plan.Demand[i].Percent
+			}
+		}
+	}
+	return ans
+}
+
+// WithShare returns a copy of the cards with the share given back.
+func (g GPUs) WithShare(share map[int]int) GPUs {
+	ans := g.Clone()
+	for card, percent := range share {
+		if card < 0 || card >= len(ans) {
+			continue
+		}
+		ans[card].Percent += percent
+		if ans[card].Percent > ans[card].PercentTotal {
+			ans[card].Percent = ans[card].PercentTotal
+		}
+	}
+	return ans
+}
+
+// waitForRelease holds the bind of a plan counting on terminating pods until their
+// share is released, at most for the grace. The lock is left while waiting.
+func (d *DealerImpl) waitForRelease(ni *NodeInfo, demand Demand, pod *v1.Pod, policySpec PolicySpec, isLoadSchedule bool) {
+	if TerminatingGrace <= 0 {
+		return
+	}
+	deadline := time.Now().Add(TerminatingGrace)
+	for time.Now().Before(deadline) {
+		if assumed, _ := ni.Assume(demand, pod, d, policySpec, isLoadSchedule); !assumed {
+			return
+		}
+		if ni.GPUs.Clone().Allocate(ni.PlanCache[planKey(demand, pod)]) == nil {
+			return
+		}
+		d.Lock.Unlock()
+		time.Sleep(time.Second)
+		d.Lock.Lock()
+	}
+}
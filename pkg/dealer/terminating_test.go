@@ -0,0 +1,80 @@
+package dealer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+)
+
+func TestShouldRelease(t *testing.T) {
+	pod := MockQuotaPod("a", "p", 50)
+	now := metav1.Now()
+	pod.DeletionTimestamp = &now
+	pod.Status.ContainerStatuses = []v1.ContainerStatus{{State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}}}
+	assert.True(t, ShouldRelease(pod))
+
+	TerminatingGrace = time.Minute
+	defer func() { TerminatingGrace = 0 }()
+	assert.False(t, ShouldRelease(pod))
+	pod.Status.ContainerStatuses[0].State = v1.ContainerState{Terminated: &v1.ContainerStateTerminated{}}
+	assert.True(t, ShouldRelease(pod))
+}
+
+func TestSoonFreeShare(t *testing.T) {
+	TerminatingGrace = 30 * time.Second
+	defer func() { TerminatingGrace = 0 }()
+	node := MockNode("n1", 1)
+	d := MockDealer(node)
+	ni := NewNodeInfo("n1", node, d.Rater)
+	d.NodeMaps["n1"] = ni
+
+	old := MockQuotaPod("a", "old", 80)
+	old.Spec.NodeName = "n1"
+	old = utils.GetUpdatedPodAnnotationSpec(old, []int{0})
+	assert.Nil(t, d.Allocate(old))
+
+	pod := MockQuotaPod("a", "new", 50)
+	demand := NewDemandFromPod(pod)
+	assumed, _ := ni.Assume(demand, pod, d, PolicySpec{}, false)
+	assert.False(t, assumed)
+
+	// due later than the grace
+	due := metav1.NewTime(time.Now().Add(time.Minute))
+	old.DeletionTimestamp = &due
+	d.MarkTerminating(old)
+	assumed, _ = ni.Assume(demand, pod, d, PolicySpec{}, false)
+	assert.False(t, assumed)
+
+	d.Terminating[old.UID] = time.Now().Add(10 * time.Second)
+	ni.cleanPlan()
+	assumed, _ = ni.Assume(demand, pod, d, PolicySpec{}, false)
+	assert.True(t, assumed)
+	assert.Equal(t, 20, ni.GPUs[0].Percent)
+
+	// the bind waits until the terminating pod is released
+	done := make(chan struct{})
+	d.Lock.Lock()
+	go func() {
+		d.Lock.Lock()
+		assert.Nil(t, d.release(old))
+		d.Lock.Unlock()
+		close(done)
+	}()
+	d.waitForRelease(ni, demand, pod, PolicySpec{}, false)
+	d.Lock.Unlock()
+	<-done
+	assert.Nil(t, ni.GPUs.Clone().Allocate(ni.PlanCache[planKey(demand, pod)]))
+	assert.Empty(t, d.Terminating)
+
+	// the workers only read the terminating pods, unknown ones are pruned before
+	d.Terminating["a/gone"] = time.Now()
+	d.SoonFreeShare("n1")
+	assert.Len(t, d.Terminating, 1)
+	d.Assume([]string{"n1"}, pod, PolicySpec{}, false)
+	assert.Empty(t, d.Terminating)
+}
@@ -0,0 +1,126 @@
+package dealer
+
+import (
+	"fmt"
+	"sort"
+)
+
+// NodeAwareRater is implemented by raters which need the reported state of the node
+// to pick cards, GPUs.Choose prefers it over Rater.Choose.
+type NodeAwareRater interface {
+	ChooseOnNode(gpus GPUs, demand Demand, d Dealer, policySpec PolicySpec, nodeName string) ([]int, error)
+}
+
+// ThermalSpread spreads containers like Spread, keeps them off cards reported above
+// Threshold and prefers cards whose neighbours are idle, so that busy cards alternate
+// on dense nodes where adjacent cards heat each other.
+type ThermalSpread struct {
+	Spread
+	// Threshold is the normalized temperature above which a card is hot.
+	Threshold float64
+}
+
+// Rate scores like Spread and takes the share of hot cards off the score.
+func (ts *ThermalSpread) Rate(gpus GPUs, p *Plan, d Dealer, policySpec PolicySpec, nodeName string, isLoadSchedule bool) int {
+	score := ts.Spread.Rate(gpus, p, d, policySpec, nodeName, isLoadSchedule)
+	if len(gpus) == 0 {
+		return score
+	}
+	hot := 0
+	for _, h := range ts.hotCards(gpus, d, policySpec, nodeName) {
+		if h {
+			hot++
+		}
+	}
+	return score - ScoreMax*hot/len(gpus)
+}
+
+func (ts *ThermalSpread) Choose(gpus GPUs, demand Demand) ([]int, error) {
+	return chooseThermal(gpus, demand, make([]bool, len(gpus)))
+}
+
+func (ts *ThermalSpread) ChooseOnNode(gpus GPUs, demand Demand, d Dealer, policySpec PolicySpec, nodeName string) ([]int, error) {
+	return chooseThermal(gpus, demand, ts.hotCards(gpus, d, policySpec, nodeName))
+}
+
+// hotCards marks the cards whose fresh temperature is above the threshold, cards
+// without temperature are never hot.
+func (ts *ThermalSpread) hotCards(gpus GPUs, d Dealer, policySpec PolicySpec, nodeName string) []bool {
+	hot := make([]bool, len(gpus))
+	if d == nil {
+		return hot
+	}
+	for _, period := range policySpec.SyncPeriod {
+		if period.Name != GPUTemperaturePriority || period.Period == 0 {
+			continue
+		}
+		for i := range gpus {
+			exist, temperature, err := d.GetUsage(nodeName, period.Name, i, ActiveDuration(period.Period))
+			if exist && err == nil && temperature > ts.Threshold {
+				hot[i] = true
+			}
+		}
+	}
+	return hot
+}
+
+// chooseThermal places the larger containers first, on a cool card with the fewest
+// busy neighbours and the most free share.
+func chooseThermal(gpus GPUs, demand Demand, hot []bool) ([]int, error) {
+	free := make([]int, len(gpus))
+	for i, g := range gpus {
+		free[i] = g.Percent
+	}
+	busy := func(i int) bool {
+		return i >= 0 && i < len(gpus) && free[i] < gpus[i].PercentTotal
+	}
+	busyNeighbours := func(i int) int {
+		n := 0
+		if busy(i - 1) {
+			n++
+		}
+		if busy(i + 1) {
+			n++
+		}
+		return n
+	}
+
+	order := make([]int, len(demand))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return demand[order[a]].Percent > demand[order[b]].Percent })
+
+	indexes := make([]int, len(demand))
+	for _, j := range order {
+		if demand[j].Percent == 0 {
+			indexes[j] = NotNeedGPU
+			continue
+		}
+		best := -1
+		for i := range gpus {
+			if free[i] < demand[j].Percent {
+				continue
+			}
+			if best == -1 || thermalLess(i, best, hot, busyNeighbours, free) {
+				best = i
+			}
+		}
+		if best == -1 {
+			return nil, fmt.Errorf("can't allocate %s on %s", demand, gpus)
+		}
+		free[best] -= demand[j].Percent
+		indexes[j] = best
+	}
+	return indexes, nil
+}
+
+func thermalLess(a, b int, hot []bool, busyNeighbours func(int) int, free []int) bool {
+	if hot[a] != hot[b] {
+		return !hot[a]
+	}
+	if na, nb := busyNeighbours(a), busyNeighbours(b); na != nb {
+		return na < nb
+	}
+	return free[a] > free[b]
+}
@@ -0,0 +1,45 @@
+package dealer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func idleGPUs(n int) GPUs {
+	gpus := make(GPUs, n)
+	for i := range gpus {
+		gpus[i] = &GPUResource{Percent: 100, PercentTotal: 100}
+	}
+	return gpus
+}
+
+func TestThermalSpreadChoose(t *testing.T) {
+	ts := &ThermalSpread{Threshold: 0.85}
+
+	// busy cards alternate
+	indexes, err := ts.Choose(idleGPUs(4), Demand{{Percent: 30}, {Percent: 30}})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 2}, indexes)
+
+	d := MockDealer(MockNode("n1", 4))
+	now := time.Now().In(loc).Format(timeFormat)
+	d.UpdateMetricUsage("n1", GPUTemperaturePriority, "0.9", now, 2)
+	spec := PolicySpec{SyncPeriod: []Period{{Name: GPUTemperaturePriority, Period: time.Minute}}}
+	indexes, err = ts.ChooseOnNode(idleGPUs(4), Demand{{Percent: 30}, {Percent: 30}}, d, spec, "n1")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 3}, indexes)
+
+	// a hot card is used when nothing else fits
+	gpus := idleGPUs(4)
+	gpus[0].Percent, gpus[1].Percent, gpus[3].Percent = 0, 0, 0
+	indexes, err = ts.ChooseOnNode(gpus, Demand{{Percent: 50}}, d, spec, "n1")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2}, indexes)
+
+	assert.Equal(t, ts.Spread.Rate(idleGPUs(4), nil, d, spec, "n1", false)-25, ts.Rate(idleGPUs(4), nil, d, spec, "n1", false))
+
+	_, err = ts.Choose(idleGPUs(1), Demand{{Percent: 60}, {Percent: 60}})
+	assert.NotNil(t, err)
+}
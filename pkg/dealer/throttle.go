@@ -0,0 +1,140 @@
+package dealer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	log "k8s.io/klog/v2"
+)
+
+var (
+	// ThrottleThreshold is the number of consecutive throttled or timed out apiserver
+	// calls which switch the dealer to the degraded mode, 0 disables it.
+	ThrottleThreshold = 0
+	// DegradedPeriod is how long the degraded mode lasts after the last throttled call.
+	DegradedPeriod = time.Minute
+)
+
+// DeferredWrite is the metadata of a pod written once the dealer leaves the degraded
+// mode, writes of the same pod are merged.
+type DeferredWrite struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// throttle has its own lock as Bind holds the dealer lock during apiserver calls.
+type throttle struct {
+	sync.Mutex
+	failures int
+	until    time.Time
+	deferred map[k8stypes.NamespacedName]*DeferredWrite
+}
+
+func isThrottled(err error) bool {
+	return apierrors.IsTooManyRequests(err) || apierrors.IsTimeout(err) || apierrors.IsServerTimeout(err) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// observe records the result of an apiserver call and returns its error.
+func (d *DealerImpl) observe(err error) error {
+	if ThrottleThreshold <= 0 {
+		return err
+	}
+	t := &d.Throttle
+	t.Lock()
+	defer t.Unlock()
+	if !isThrottled(err) {
+		t.failures = 0
+		return err
+	}
+	t.failures++
+	if t.failures >= ThrottleThreshold {
+		if !time.Now().Before(t.until) {
+			log.Warningf("apiserver throttles the scheduler, enter degraded mode: %v", err)
+		}
+		t.until = time.Now().Add(DegradedPeriod)
+	}
+	return err
+}
+
+// Degraded determines if the apiserver throttles the scheduler so that writes which
+// can wait are deferred.
+func (d *DealerImpl) Degraded() bool {
+	d.Throttle.Lock()
+	defer d.Throttle.Unlock()
+	return time.Now().Before(d.Throttle.until)
+}
+
+// DeferWrite queues labels and annotations of the pod until the degraded mode is over.
+func (d *DealerImpl) DeferWrite(namespace, name string, labels, annotations map[string]string) {
+	d.Throttle.Lock()
+	defer d.Throttle.Unlock()
+	if d.Throttle.deferred == nil {
+		d.Throttle.deferred = make(map[k8stypes.NamespacedName]*DeferredWrite)
+	}
+	key := k8stypes.NamespacedName{Namespace: namespace, Name: name}
+	w, ok := d.Throttle.deferred[key]
+	if !ok {
+		w = &DeferredWrite{Labels: map[string]string{}, Annotations: map[string]string{}}
+		d.Throttle.deferred[key] = w
+	}
+	for k, v := range labels {
+		w.Labels[k] = v
+	}
+	for k, v := range annotations {
+		w.Annotations[k] = v
+	}
+}
+
+// FlushDeferred patches the deferred writes unless the dealer is degraded, writes
+// failing on throttling are queued again.
+func (d *DealerImpl) FlushDeferred() {
+	if d.Degraded() {
+		return
+	}
+	d.Throttle.Lock()
+	deferred := d.Throttle.deferred
+	d.Throttle.deferred = nil
+	d.Throttle.Unlock()
+	for key, w := range deferred {
+		patch, err := json.Marshal(map[string]interface{}{"metadata": w})
+		if err != nil {
+			log.Errorf("marshal deferred write of pod %s failed: %v", key, err)
+			continue
+		}
+		ctx, cancel := apiContext()
+		_, err = d.Client.CoreV1().Pods(key.Namespace).Patch(ctx, key.Name, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+		cancel()
+		switch {
+		case err == nil, apierrors.IsNotFound(err):
+		case isThrottled(d.observe(err)):
+			d.DeferWrite(key.Namespace, key.Name, w.Labels, w.Annotations)
+		default:
+			log.Errorf("flush deferred write of pod %s failed: %v", key, err)
+		}
+	}
+}
+
+// bindDegraded binds the pod in a single call, the apiserver copies the annotations
+// of the binding to the pod while the labels are deferred.
+func (d *DealerImpl) bindDegraded(ctx context.Context, newPod *v1.Pod, node string) error {
+	err := d.Client.CoreV1().Pods(newPod.Namespace).Bind(ctx, &v1.Binding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: newPod.Namespace, Name: newPod.Name, UID: newPod.UID, Annotations: newPod.Annotations},
+		Target: v1.ObjectReference{
+			Kind: "Node",
+			Name: node,
+		},
+	}, metav1.CreateOptions{})
+	if d.observe(err) != nil {
+		return err
+	}
+	d.DeferWrite(newPod.Namespace, newPod.Name, newPod.Labels, nil)
+	return nil
+}
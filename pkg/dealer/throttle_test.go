@@ -0,0 +1,39 @@
+package dealer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+)
+
+func TestDegradedMode(t *testing.T) {
+	threshold, period := ThrottleThreshold, DegradedPeriod
+	defer func() { ThrottleThreshold, DegradedPeriod = threshold, period }()
+	ThrottleThreshold, DegradedPeriod = 2, time.Minute
+
+	d := MockDealer()
+	throttled := apierrors.NewTooManyRequests("slow down", 1)
+	assert.Equal(t, throttled, d.observe(throttled))
+	d.observe(nil)
+	d.observe(throttled)
+	assert.False(t, d.Degraded())
+	d.observe(errors.New("not found"))
+	assert.False(t, d.Degraded())
+	d.observe(throttled)
+	d.observe(apierrors.NewServerTimeout(schema.GroupResource{Resource: "pods"}, "patch", 1))
+	assert.True(t, d.Degraded())
+
+	d.DeferWrite("a", "p", map[string]string{"l": "1"}, nil)
+	d.DeferWrite("a", "p", nil, map[string]string{"a": "2"})
+	w := d.Throttle.deferred[k8stypes.NamespacedName{Namespace: "a", Name: "p"}]
+	assert.Equal(t, &DeferredWrite{Labels: map[string]string{"l": "1"}, Annotations: map[string]string{"a": "2"}}, w)
+
+	// nothing is flushed while degraded
+	d.FlushDeferred()
+	assert.Len(t, d.Throttle.deferred, 1)
+}
@@ -20,6 +20,14 @@ type Policy struct {
 type PolicySpec struct {
 	SyncPeriod []Period          `yaml:"syncPeriod"`
 	Priority   []PriorityPolicy  `yaml:"priority"`
+	Scoring    ScoringWeights    `yaml:"scoring"`
+	Rules      Rules             `yaml:"rules"`
+	Cost       CostPolicy        `yaml:"cost"`
+	// Profiles are the policies of the pods by their profile label.
+	Profiles map[string]WorkloadProfile `yaml:"profiles"`
+	// ProfileRaters override the rater of the pods of a profile, they are compiled
+	// with the policy so that a reload doesn't change them under a running plan.
+	ProfileRaters map[string]Rater `yaml:"-"`
 }
 
 type Period struct {
@@ -0,0 +1,94 @@
+package dealer
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// cachedUsage is a reported usage parsed once when it is taken, raw is what it was
+// parsed from so usage stored by other means is noticed.
+type cachedUsage struct {
+	UsageSample
+	raw string
+	err error
+}
+
+func usageRaw(usage, updateTime string) string {
+	return usage + "@" + updateTime
+}
+
+func parseUsage(key, usage, updateTime string) cachedUsage {
+	c := cachedUsage{raw: usageRaw(usage, updateTime)}
+	t, err := time.ParseInLocation(timeFormat, updateTime, loc)
+	if err != nil {
+		c.err = fmt.Errorf("%s %w", key, ErrUsageStale)
+		return c
+	}
+	value, err := strconv.ParseFloat(usage, 64)
+	if err != nil {
+		c.err = errors.New(key + "strconv.ParseFloat error")
+	} else if value < 0 || value > 1 {
+		c.err = errors.New(key + " usage < 0 || usage > 1")
+	}
+	c.UsageSample = UsageSample{Time: t, Usage: value}
+	return c
+}
+
+// cacheUsage parses a reported usage so that reading it is a map lookup.
+func (d *DealerImpl) cacheUsage(nodeName, key string, card int, usage, updateTime string) {
+	d.usageCacheLock.Lock()
+	defer d.usageCacheLock.Unlock()
+	d.storeUsage(nodeName, key, card, usage, updateTime)
+}
+
+func (d *DealerImpl) storeUsage(nodeName, key string, card int, usage, updateTime string) cachedUsage {
+	k := historyKey(nodeName, key)
+	if d.UsageCache[k] == nil {
+		d.UsageCache[k] = make(map[int]cachedUsage)
+	}
+	c := parseUsage(key, usage, updateTime)
+	d.UsageCache[k][card] = c
+	return c
+}
+
+// rawUsage returns the usage and the time stored for a card.
+func (d *DealerImpl) rawUsage(nodeName, key string, card int) (string, string, bool) {
+	if key == GPUCoreUsagePriority {
+		cards, exist := d.CoreUsage[nodeName]
+		return cards[card].CoreUsage, cards[card].UpdateTime, exist
+	} else if IsPressureMetric(key) {
+		cards, exist := d.MetricUsage[key][nodeName]
+		return cards[card].Usage, cards[card].UpdateTime, exist
+	}
+	cards, exist := d.MemoryUsage[nodeName]
+	return cards[card].MemoryUsage, cards[card].UpdateTime, exist
+}
+
+// cachedUsageOf returns the parsed usage of a card, false if the node never
+// reported the metric. Usage stored without going through the cache, like a warm
+// started one, is parsed on the first read.
+func (d *DealerImpl) cachedUsageOf(nodeName, key string, card int) (cachedUsage, bool) {
+	k := historyKey(nodeName, key)
+	usage, updateTime, exist := d.rawUsage(nodeName, key, card)
+	if !exist {
+		return cachedUsage{}, false
+	}
+	d.usageCacheLock.Lock()
+	defer d.usageCacheLock.Unlock()
+	if c, ok := d.UsageCache[k][card]; ok && c.raw == usageRaw(usage, updateTime) {
+		return c, true
+	}
+	return d.storeUsage(nodeName, key, card, usage, updateTime), true
+}
+
+// GetUsageSample returns the last usage of a card with the time it was reported,
+// false if the node never reported the metric or the usage is malformed.
+func (d *DealerImpl) GetUsageSample(nodeName, key string, card int) (UsageSample, bool) {
+	c, ok := d.cachedUsageOf(nodeName, key, card)
+	if !ok || c.err != nil {
+		return UsageSample{}, false
+	}
+	return c.UsageSample, true
+}
@@ -0,0 +1,63 @@
+package dealer
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsageCache(t *testing.T) {
+	d := MockDealer(MockNode("n1", 2))
+	reported := time.Now().Add(-time.Minute)
+	d.UpdateCoreUsage("n1", "0.3", reported.In(loc).Format(timeFormat), 0)
+	d.UpdateCoreUsage("n1", "2", time.Now().In(loc).Format(timeFormat), 1)
+
+	sample, ok := d.GetUsageSample("n1", GPUCoreUsagePriority, 0)
+	assert.True(t, ok)
+	assert.Equal(t, 0.3, sample.Usage)
+	assert.Equal(t, reported.Unix(), sample.Time.Unix())
+	_, ok = d.GetUsageSample("n1", GPUCoreUsagePriority, 1)
+	assert.False(t, ok)
+	_, ok = d.GetUsageSample("n2", GPUCoreUsagePriority, 0)
+	assert.False(t, ok)
+
+	exist, usage, err := d.GetUsage("n1", GPUCoreUsagePriority, 0, 2*time.Minute)
+	assert.True(t, exist)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.3, usage)
+	_, _, err = d.GetUsage("n1", GPUCoreUsagePriority, 0, 30*time.Second)
+	assert.True(t, errors.Is(err, ErrUsageStale))
+	_, _, err = d.GetUsage("n1", GPUCoreUsagePriority, 1, time.Minute)
+	assert.Error(t, err)
+
+	// usage stored without the cache, like a warm started one, is parsed on read
+	d.MemoryUsage["n1"] = map[int]GPUMemoryUsage{0: NewGPUMemoryUsage("0.6", time.Now().In(loc).Format(timeFormat))}
+	_, usage, err = d.GetUsage("n1", GPUMemoryUsagePriority, 0, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.6, usage)
+	assert.Len(t, d.UsageCache[historyKey("n1", GPUMemoryUsagePriority)], 1)
+}
+
+func TestUsageCacheParallel(t *testing.T) {
+	d := MockDealer(MockNode("n1", 8))
+	d.MemoryUsage["n1"] = make(map[int]GPUMemoryUsage)
+	for card := 0; card < 8; card++ {
+		d.MemoryUsage["n1"][card] = NewGPUMemoryUsage("0.5", time.Now().In(loc).Format(timeFormat))
+	}
+	// the cards of the nodes are planned by parallel workers which all read usage
+	wg := sync.WaitGroup{}
+	for card := 0; card < 8; card++ {
+		wg.Add(1)
+		go func(card int) {
+			defer wg.Done()
+			_, usage, err := d.GetUsage("n1", GPUMemoryUsagePriority, card, time.Minute)
+			assert.NoError(t, err)
+			assert.Equal(t, 0.5, usage)
+		}(card)
+	}
+	wg.Wait()
+	assert.Len(t, d.UsageCache[historyKey("n1", GPUMemoryUsagePriority)], 8)
+}
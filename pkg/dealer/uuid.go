@@ -0,0 +1,128 @@
+package dealer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/accelerator"
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	log "k8s.io/klog/v2"
+)
+
+// uuidsOfNode returns the uuid of every card of the node by index, nil when the
+// device plugin does not publish them.
+func uuidsOfNode(node *v1.Node) []string {
+	value := node.Annotations[schetypes.AnnotationGPUUUIDs]
+	if value == "" {
+		return nil
+	}
+	uuids := strings.Split(value, ",")
+	for i := range uuids {
+		uuids[i] = strings.TrimSpace(uuids[i])
+	}
+	return uuids
+}
+
+func (ni *NodeInfo) indexOfUUID(uuid string) int {
+	for i, u := range ni.UUIDs {
+		if u == uuid {
+			return i
+		}
+	}
+	return -1
+}
+
+// annotateUUIDs records the uuid of the card of every container next to its index.
+func annotateUUIDs(pod *v1.Pod, uuids []string, plan *Plan) {
+	for i, c := range pod.Spec.Containers {
+		if i < len(plan.GPUIndexes) && plan.GPUIndexes[i] >= 0 && plan.GPUIndexes[i] < len(uuids) {
+			pod.Annotations[fmt.Sprintf(schetypes.AnnotationGPUContainerUUID, c.Name)] = uuids[plan.GPUIndexes[i]]
+		}
+	}
+}
+
+// resolveUUIDs returns the pod with the index of every container following the
+// uuid of its card, the pod itself when nothing moved. Cards whose uuid left the
+// node keep their index.
+func resolveUUIDs(ni *NodeInfo, pod *v1.Pod) *v1.Pod {
+	if len(ni.UUIDs) == 0 {
+		return pod
+	}
+	resolved := pod
+	a := accelerator.ForPod(pod)
+	structured, _, _ := structuredPlan(pod)
+	for _, c := range pod.Spec.Containers {
+		uuid := pod.Annotations[fmt.Sprintf(schetypes.AnnotationGPUContainerUUID, c.Name)]
+		if structured != nil {
+			if cp, ok := structured.container(c.Name); ok && cp.UUID != "" {
+				uuid = cp.UUID
+			}
+		}
+		if uuid == "" {
+			continue
+		}
+		idx := ni.indexOfUUID(uuid)
+		if idx < 0 {
+			log.Warningf("card %s of pod %s/%s is gone from node %s", uuid, pod.Namespace, pod.Name, ni.Name)
+			continue
+		}
+		key := a.ContainerAnnotation(c.Name)
+		if pod.Annotations[key] == strconv.Itoa(idx) {
+			continue
+		}
+		if resolved == pod {
+			resolved = pod.DeepCopy()
+		}
+		resolved.Annotations[key] = strconv.Itoa(idx)
+	}
+	return resolved
+}
+
+// remapUUIDs moves the accounting of the cards of a node whose indexes changed,
+// like after the driver enumerated them again, and follows with the known pods.
+func (d *DealerImpl) remapUUIDs(ni *NodeInfo, uuids []string) {
+	old := ni.UUIDs
+	ni.UUIDs = uuids
+	if len(old) == 0 || len(uuids) == 0 {
+		return
+	}
+	moves := make(map[int]int)
+	for from, uuid := range old {
+		if to := ni.indexOfUUID(uuid); to >= 0 && to != from && from < len(ni.GPUs) {
+			moves[from] = to
+		}
+	}
+	if len(moves) == 0 {
+		return
+	}
+	log.Infof("cards of node %s are enumerated again, move %v", ni.Name, moves)
+	ni.ensureCards(len(uuids))
+	gpus := append(GPUs(nil), ni.GPUs...)
+	qos := make(map[int]map[QoSClass]int)
+	for idx, classes := range ni.QoS {
+		if _, moved := moves[idx]; !moved {
+			qos[idx] = classes
+		}
+	}
+	for from := range moves {
+		gpus[from] = &GPUResource{Percent: schetypes.GPUPercentEachCard, PercentTotal: schetypes.GPUPercentEachCard}
+		delete(qos, from)
+	}
+	for from, to := range moves {
+		gpus[to] = ni.GPUs[from]
+		if classes, ok := ni.QoS[from]; ok {
+			qos[to] = classes
+		}
+	}
+	ni.GPUs = gpus
+	ni.QoS = qos
+	for uid, pod := range d.PodMaps {
+		if pod.Spec.NodeName == ni.Name {
+			d.PodMaps[uid] = resolveUUIDs(ni, pod)
+		}
+	}
+	ni.cleanPlan()
+}
@@ -0,0 +1,43 @@
+package dealer
+
+import (
+	"fmt"
+	"testing"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUUIDPlans(t *testing.T) {
+	node := MockNode("n1", 3)
+	node.Annotations = map[string]string{schetypes.AnnotationGPUUUIDs: "GPU-a,GPU-b,GPU-c"}
+	d := MockDealer(node)
+	ni := NewNodeInfo("n1", node, d.Rater)
+	d.NodeMaps["n1"] = ni
+	assert.Equal(t, []string{"GPU-a", "GPU-b", "GPU-c"}, ni.UUIDs)
+
+	pod := MockQuotaPod("a", "p0", 30)
+	pod.Spec.NodeName = "n1"
+	pod = podWithPlan(pod, node, &Plan{Demand: Demand{{Percent: 30}}, GPUIndexes: []int{1}})
+	assert.Equal(t, "GPU-b", pod.Annotations[fmt.Sprintf(schetypes.AnnotationGPUContainerUUID, "")])
+	assert.Nil(t, d.Allocate(pod))
+	assert.Equal(t, 70, ni.GPUs[1].Percent)
+
+	// the driver enumerates GPU-b as card 2 and GPU-c as card 1
+	moved := node.DeepCopy()
+	moved.Annotations[schetypes.AnnotationGPUUUIDs] = "GPU-a,GPU-c,GPU-b"
+	d.UpdateNode(moved)
+	assert.Equal(t, 100, ni.GPUs[1].Percent)
+	assert.Equal(t, 70, ni.GPUs[2].Percent)
+	assert.Equal(t, []int{2}, utils.GetGPUIDFromAnnotation(d.PodMaps[pod.UID]))
+
+	assert.Nil(t, d.Release(pod))
+	for _, g := range ni.GPUs {
+		assert.Equal(t, 100, g.Percent)
+	}
+
+	// a pod listed with its stale index is accounted on the card of its uuid
+	assert.Nil(t, d.Allocate(pod))
+	assert.Equal(t, 70, ni.GPUs[2].Percent)
+}
@@ -0,0 +1,43 @@
+package dealer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/accelerator"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// demandVendors returns the gpu vendors whose share the containers of the pod request.
+func demandVendors(pod *v1.Pod) []string {
+	seen := make(map[string]struct{})
+	for _, c := range pod.Spec.Containers {
+		for name := range c.Resources.Limits {
+			if a, ok := accelerator.ForResource(name); ok {
+				seen[a.Vendor()] = struct{}{}
+			}
+		}
+	}
+	ans := make([]string, 0, len(seen))
+	for vendor := range seen {
+		ans = append(ans, vendor)
+	}
+	sort.Strings(ans)
+	return ans
+}
+
+// checkNodeVendor fails the nodes of another gpu vendor than the share the pod
+// requests, ROCm pods don't run on NVIDIA cards and the reverse.
+func checkNodeVendor(ni *NodeInfo, pod *v1.Pod) error {
+	vendors := demandVendors(pod)
+	switch {
+	case len(vendors) == 0:
+		return nil
+	case len(vendors) > 1:
+		return fmt.Errorf("pod requests the gpu share of several vendors %v", vendors)
+	case vendors[0] != ni.Vendor:
+		return fmt.Errorf("pod requests %s gpu share, node %s has %s gpus", vendors[0], ni.Name, ni.Vendor)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func TestAMDNode(t *testing.T) {
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "amd", Labels: map[string]string{types.LabelGPUVendor: types.GPUVendorAMD}},
+		Status: v1.NodeStatus{
+			Capacity: v1.ResourceList{types.ResourceAMDGPU: resource.MustParse("2")},
+		},
+	}
+	ni := NewNodeInfo("amd", node, &Binpack{})
+	assert.Equal(t, 2, len(ni.GPUs))
+
+	node.Status.Capacity[types.ResourceAMDGPUPercent] = resource.MustParse("300")
+	assert.Equal(t, 3, len(NewNodeInfo("amd", node, &Binpack{}).GPUs))
+
+	// without the vendor label the node is read as nvidia
+	node.Labels[types.LabelGPUVendor] = ""
+	assert.Equal(t, 0, len(NewNodeInfo("amd", node, &Binpack{}).GPUs))
+
+	pod := &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{{
+		Resources: v1.ResourceRequirements{
+			Limits: v1.ResourceList{types.ResourceAMDGPUPercent: resource.MustParse("40")},
+		},
+	}}}}
+	assert.Equal(t, Demand{{Percent: 40}}, NewDemandFromPod(pod))
+}
+
+func TestCheckNodeVendor(t *testing.T) {
+	share := func(name v1.ResourceName) *v1.Pod {
+		return &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{{
+			Resources: v1.ResourceRequirements{Limits: v1.ResourceList{name: resource.MustParse("40")}},
+		}}}}
+	}
+	amdNode := MockNode("amd", 1)
+	amdNode.Labels = map[string]string{types.LabelGPUVendor: types.GPUVendorAMD}
+	amd := NewNodeInfo("amd", amdNode, &Binpack{})
+	nvidia := NewNodeInfo("nvidia", MockNode("nvidia", 1), &Binpack{})
+
+	rocm, cuda := share(types.ResourceAMDGPUPercent), share(types.ResourceGPUPercent)
+	assert.NoError(t, checkNodeVendor(amd, rocm))
+	assert.Error(t, checkNodeVendor(nvidia, rocm))
+	assert.NoError(t, checkNodeVendor(nvidia, cuda))
+	assert.Error(t, checkNodeVendor(amd, cuda))
+
+	// the shares of several vendors fit no node and are not summed
+	both := share(types.ResourceGPUPercent)
+	both.Spec.Containers[0].Resources.Limits[types.ResourceAMDGPUPercent] = resource.MustParse("30")
+	assert.Error(t, checkNodeVendor(amd, both))
+	assert.Error(t, checkNodeVendor(nvidia, both))
+	assert.Equal(t, 1, len(NewDemandFromPod(both)))
+	assert.Equal(t, 40, NewDemandFromPod(both)[0].Percent)
+}
@@ -0,0 +1,139 @@
+package dealer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+
+	v1 "k8s.io/api/core/v1"
+	log "k8s.io/klog/v2"
+)
+
+// ParseVGPUProfiles reads the vGPU profiles offered by a node and the gpu percent
+// each profile takes, e.g. "a100-10c:25,a100-20c:50". Nil means no vGPU.
+func ParseVGPUProfiles(node *v1.Node) (map[string]int, error) {
+	value := node.Annotations[schetypes.AnnotationVGPUProfiles]
+	if value == "" {
+		return nil, nil
+	}
+	profiles := make(map[string]int)
+	for _, item := range strings.Split(value, ",") {
+		kv := strings.SplitN(strings.TrimSpace(item), ":", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid vgpu profile %q", item)
+		}
+		percent, err := strconv.Atoi(kv[1])
+		if err != nil || percent <= 0 || percent > schetypes.GPUPercentEachCard {
+			return nil, fmt.Errorf("invalid percent of vgpu profile %q", item)
+		}
+		profiles[kv[0]] = percent
+	}
+	return profiles, nil
+}
+
+func vgpuProfilesOfNode(node *v1.Node) map[string]int {
+	profiles, err := ParseVGPUProfiles(node)
+	if err != nil {
+		log.Errorf("node %s: %s", node.Name, err.Error())
+	}
+	return profiles
+}
+
+func GetVGPUProfileOfPod(pod *v1.Pod) string {
+	return pod.Annotations[schetypes.AnnotationVGPUProfile]
+}
+
+// checkVGPU only lets pods requesting an offered profile onto vGPU nodes, every gpu
+// container must request exactly the share of the profile.
+func checkVGPU(profiles map[string]int, pod *v1.Pod) error {
+	profile := GetVGPUProfileOfPod(pod)
+	if profiles == nil {
+		if profile != "" {
+			return fmt.Errorf("node doesn't offer vgpu profile %s", profile)
+		}
+		return nil
+	}
+	if profile == "" {
+		return fmt.Errorf("node only runs vgpu profiles")
+	}
+	percent, ok := profiles[profile]
+	if !ok {
+		return fmt.Errorf("node doesn't offer vgpu profile %s", profile)
+	}
+	for _, r := range NewDemandFromPod(pod) {
+		if r.Percent != 0 && r.Percent != percent {
+			return fmt.Errorf("vgpu profile %s takes %d gpu percent on node, container requests %d", profile, percent, r.Percent)
+		}
+	}
+	return nil
+}
+
+func (d *DealerImpl) checkNodeVGPU(ni *NodeInfo, pod *v1.Pod) error {
+	node, err := d.NodeLister.Get(ni.Name)
+	if err != nil {
+		return err
+	}
+	ni.Profiles = vgpuProfilesOfNode(node)
+	return checkVGPU(ni.Profiles, pod)
+}
+
+// cardProfiles returns the profile hosted by every busy card of a vGPU node.
+func (d *DealerImpl) cardProfiles(nodeName string) map[int]string {
+	ans := make(map[int]string)
+	for _, pod := range d.PodMaps {
+		profile := GetVGPUProfileOfPod(pod)
+		if pod.Spec.NodeName != nodeName || profile == "" {
+			continue
+		}
+		plan, err := NewPlanFromPod(pod)
+		if err != nil {
+			continue
+		}
+		for i, idx := range plan.GPUIndexes {
+			if idx >= 0 && plan.Demand[i].Percent > 0 {
+				ans[idx] = profile
+			}
+		}
+	}
+	return ans
+}
+
+// ExcludedCards returns the cards of a node a new plan of the pod must not use: the
+// cards the node excludes, the unhealthy cards, the cards taken by whole gpu pods, the cards whose MIG geometry
+// doesn't match the pod, the cards of the pods it has a card anti-affinity with, the
+// cards other than the one of the pod it has a card affinity with, the cards not
+// planned for the pod as a replica of a planned job and, as a physical gpu only hosts
+// vGPUs of a single profile, the cards hosting another profile.
+func (d *DealerImpl) ExcludedCards(nodeName string, pod *v1.Pod) map[int]string {
+	ans := d.GetUnhealthyCards(nodeName)
+	if ni, ok := d.NodeMaps[nodeName]; ok {
+		for card, reason := range ni.excludedCards() {
+			ans[card] = reason
+		}
+	}
+	for card, reason := range migExcludedCards(d.NodeMaps[nodeName], pod) {
+		ans[card] = reason
+	}
+	for card, reason := range d.wholeGPUExcludedCards(nodeName) {
+		ans[card] = reason
+	}
+	for card, reason := range d.antiAffinityExcludedCards(nodeName, pod) {
+		ans[card] = reason
+	}
+	for card, reason := range d.affinityExcludedCards(nodeName, pod) {
+		ans[card] = reason
+	}
+	for card, reason := range d.jobPlanExcludedCards(nodeName, pod) {
+		ans[card] = reason
+	}
+	if profile := GetVGPUProfileOfPod(pod); profile != "" {
+		for card, other := range d.cardProfiles(nodeName) {
+			if other != profile {
+				ans[card] = "hosts vgpu profile " + other
+			}
+		}
+	}
+	return ans
+}
@@ -0,0 +1,65 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+)
+
+func MockVGPUPod(name, profile string, percent int) *v1.Pod {
+	pod := MockQuotaPod("a", name, percent)
+	pod.Annotations[types.AnnotationVGPUProfile] = profile
+	return pod
+}
+
+func TestParseVGPUProfiles(t *testing.T) {
+	node := MockNode("n1", 2)
+	profiles, err := ParseVGPUProfiles(node)
+	assert.Nil(t, err)
+	assert.Nil(t, profiles)
+
+	node.Annotations = map[string]string{types.AnnotationVGPUProfiles: "a100-10c:25, a100-20c:50"}
+	profiles, err = ParseVGPUProfiles(node)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"a100-10c": 25, "a100-20c": 50}, profiles)
+
+	node.Annotations[types.AnnotationVGPUProfiles] = "a100-10c:200"
+	_, err = ParseVGPUProfiles(node)
+	assert.NotNil(t, err)
+}
+
+func TestVGPUHomogeneity(t *testing.T) {
+	node := MockNode("n1", 2)
+	node.Annotations = map[string]string{types.AnnotationVGPUProfiles: "a100-10c:25,a100-20c:50"}
+	d := MockDealer(node)
+	ni := NewNodeInfo("n1", node, d.Rater)
+	d.NodeMaps["n1"] = ni
+
+	assert.NotNil(t, checkVGPU(ni.Profiles, MockQuotaPod("a", "plain", 25)))
+	assert.NotNil(t, checkVGPU(ni.Profiles, MockVGPUPod("p", "a100-40c", 100)))
+	assert.NotNil(t, checkVGPU(ni.Profiles, MockVGPUPod("p", "a100-10c", 50)))
+	assert.Nil(t, checkVGPU(ni.Profiles, MockVGPUPod("p", "a100-10c", 25)))
+	assert.NotNil(t, checkVGPU(nil, MockVGPUPod("p", "a100-10c", 25)))
+
+	resident := utils.GetUpdatedPodAnnotationSpec(MockVGPUPod("r", "a100-10c", 25), []int{0})
+	resident.Spec.NodeName = "n1"
+	d.PodMaps[resident.UID] = resident
+	assert.Nil(t, ni.Allocate(&Plan{Demand: Demand{{Percent: 25}}, GPUIndexes: []int{0}}))
+
+	// binpack would prefer the busy card, but it hosts another profile
+	pod := MockVGPUPod("p", "a100-20c", 50)
+	demand := NewDemandFromPod(pod)
+	assumed, _ := ni.Assume(demand, pod, d, PolicySpec{}, false)
+	assert.True(t, assumed)
+	assert.Equal(t, []int{1}, ni.PlanCache[planKey(demand, pod)].GPUIndexes)
+
+	same := MockVGPUPod("s", "a100-10c", 25)
+	demand = NewDemandFromPod(same)
+	assumed, _ = ni.Assume(demand, same, d, PolicySpec{}, false)
+	assert.True(t, assumed)
+	assert.Equal(t, []int{0}, ni.PlanCache[planKey(demand, same)].GPUIndexes)
+}
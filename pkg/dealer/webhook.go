@@ -0,0 +1,92 @@
+package dealer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	log "k8s.io/klog/v2"
+)
+
+var (
+	// ScoreWebhookURL receives the feasible plans of every prioritize call and returns
+	// score adjustments by node, empty disables it.
+	ScoreWebhookURL = ""
+	// ScoreWebhookTimeout bounds a webhook call, the scores are kept unadjusted when
+	// the webhook fails or times out.
+	ScoreWebhookTimeout = 500 * time.Millisecond
+)
+
+// ScoreWebhookCandidate is a node the pod fits on with the cards it would get.
+type ScoreWebhookCandidate struct {
+	Node       string `json:"node"`
+	GPUIndexes []int  `json:"gpuIndexes"`
+	Score      int    `json:"score"`
+}
+
+type ScoreWebhookRequest struct {
+	Namespace  string                  `json:"namespace"`
+	Name       string                  `json:"name"`
+	UID        string                  `json:"uid"`
+	Candidates []ScoreWebhookCandidate `json:"candidates"`
+}
+
+type ScoreWebhookResponse struct {
+	// Adjustments are added to the score of the nodes.
+	Adjustments map[string]int `json:"adjustments"`
+}
+
+// adjustScores adds the adjustments of the webhook to the scores of the feasible
+// nodes, failing open.
+func adjustScores(pod *v1.Pod, nodes []string, plans [][]int, scores []int) []int {
+	req := ScoreWebhookRequest{Namespace: pod.Namespace, Name: pod.Name, UID: string(pod.UID)}
+	for i, plan := range plans {
+		if plan != nil {
+			req.Candidates = append(req.Candidates, ScoreWebhookCandidate{Node: nodes[i], GPUIndexes: plan, Score: scores[i]})
+		}
+	}
+	if len(req.Candidates) == 0 {
+		return scores
+	}
+	resp, err := callScoreWebhook(&req)
+	if err != nil {
+		log.Warningf("score webhook failed for pod %s/%s, keep the scores: %v", pod.Namespace, pod.Name, err)
+		return scores
+	}
+	ans := make([]int, len(scores))
+	for i, score := range scores {
+		ans[i] = score
+		if plans[i] == nil {
+			continue
+		}
+		ans[i] += resp.Adjustments[nodes[i]]
+		if ans[i] < ScoreMin {
+			ans[i] = ScoreMin
+		}
+	}
+	return ans
+}
+
+func callScoreWebhook(req *ScoreWebhookRequest) (*ScoreWebhookResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	client := http.Client{Timeout: ScoreWebhookTimeout}
+	r, err := client.Post(ScoreWebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %s", r.Status)
+	}
+	resp := &ScoreWebhookResponse{}
+	if err := json.NewDecoder(r.Body).Decode(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,33 @@
+package dealer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdjustScores(t *testing.T) {
+	defer func(url string, timeout time.Duration) { ScoreWebhookURL, ScoreWebhookTimeout = url, timeout }(ScoreWebhookURL, ScoreWebhookTimeout)
+	var got ScoreWebhookRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		json.NewEncoder(w).Encode(ScoreWebhookResponse{Adjustments: map[string]int{"n1": 10, "n2": -100, "n3": 50}})
+	}))
+	defer server.Close()
+	ScoreWebhookURL = server.URL
+
+	pod := MockQuotaPod("a", "p0", 20)
+	nodes := []string{"n1", "n2", "n3"}
+	plans := [][]int{{0}, {1}, nil}
+	assert.Equal(t, []int{40, 0, 0}, adjustScores(pod, nodes, plans, []int{30, 20, 0}))
+	assert.Equal(t, []ScoreWebhookCandidate{{Node: "n1", GPUIndexes: []int{0}, Score: 30}, {Node: "n2", GPUIndexes: []int{1}, Score: 20}}, got.Candidates)
+
+	// fail open
+	ScoreWebhookURL = server.URL + "/missing"
+	server.Config.Handler = http.NotFoundHandler()
+	assert.Equal(t, []int{30, 20, 0}, adjustScores(pod, nodes, plans, []int{30, 20, 0}))
+}
@@ -0,0 +1,83 @@
+package dealer
+
+import (
+	"sort"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/utils"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const reasonWholeGPU = "taken by whole gpu pods"
+
+type wholeGPUPod struct {
+	Node  string
+	Cards int
+}
+
+// UpdateWholeGPUPod tracks pods of the nvidia device plugin which take whole cards
+// on nodes shared by the dealer, so that shared pods never land next to them.
+func (d *DealerImpl) UpdateWholeGPUPod(pod *v1.Pod) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	cards := utils.GetWholeGPUCountFromPodResource(pod)
+	if pod.Spec.NodeName == "" || cards == 0 || utils.IsCompletedPod(pod) {
+		d.forgetWholeGPUPod(pod.UID)
+		return
+	}
+	d.WholeGPUPods[pod.UID] = &wholeGPUPod{Node: pod.Spec.NodeName, Cards: cards}
+	d.cleanPlanOfNode(pod.Spec.NodeName)
+}
+
+func (d *DealerImpl) ForgetWholeGPUPod(pod *v1.Pod) {
+	d.Lock.Lock()
+	defer d.Lock.Unlock()
+	d.forgetWholeGPUPod(pod.UID)
+}
+
+func (d *DealerImpl) forgetWholeGPUPod(uid types.UID) {
+	if p, ok := d.WholeGPUPods[uid]; ok {
+		delete(d.WholeGPUPods, uid)
+		d.cleanPlanOfNode(p.Node)
+	}
+}
+
+func (d *DealerImpl) cleanPlanOfNode(nodeName string) {
+	if ni, ok := d.NodeMaps[nodeName]; ok {
+		ni.cleanPlan()
+	}
+}
+
+func (d *DealerImpl) wholeGPUCards(nodeName string) int {
+	n := 0
+	for _, p := range d.WholeGPUPods {
+		if p.Node == nodeName {
+			n += p.Cards
+		}
+	}
+	return n
+}
+
+// wholeGPUExcludedCards treats as many cards as the whole gpu pods take as fully
+// consumed. The device plugin doesn't tell which cards it handed out, the cards
+// without shared pods are taken first, highest index first.
+func (d *DealerImpl) wholeGPUExcludedCards(nodeName string) map[int]string {
+	ans := make(map[int]string)
+	n := d.wholeGPUCards(nodeName)
+	ni, ok := d.NodeMaps[nodeName]
+	if n == 0 || !ok {
+		return ans
+	}
+	cards := make([]int, len(ni.GPUs))
+	for i := range cards {
+		cards[len(cards)-1-i] = i
+	}
+	sort.SliceStable(cards, func(a, b int) bool {
+		return ni.GPUs[cards[a]].Percent == ni.GPUs[cards[a]].PercentTotal && ni.GPUs[cards[b]].Percent != ni.GPUs[cards[b]].PercentTotal
+	})
+	for i := 0; i < n && i < len(cards); i++ {
+		ans[cards[i]] = reasonWholeGPU
+	}
+	return ans
+}
@@ -0,0 +1,52 @@
+package dealer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+)
+
+func MockWholeGPUPod(name string, cards int64) *v1.Pod {
+	pod := &v1.Pod{}
+	pod.Name, pod.UID, pod.Spec.NodeName = name, k8stypes.UID(name), "n1"
+	pod.Spec.Containers = []v1.Container{{
+		Resources: v1.ResourceRequirements{
+			Limits: v1.ResourceList{types.ResourceNvidiaGPU: *resource.NewQuantity(cards, resource.DecimalSI)},
+		},
+	}}
+	return pod
+}
+
+func TestWholeGPUPods(t *testing.T) {
+	node := MockNode("n1", 4)
+	d := MockDealer(node)
+	ni := NewNodeInfo("n1", node, d.Rater)
+	d.NodeMaps["n1"] = ni
+	assert.Nil(t, ni.Allocate(&Plan{Demand: Demand{{Percent: 50}}, GPUIndexes: []int{3}}))
+
+	whole := MockWholeGPUPod("w", 2)
+	d.UpdateWholeGPUPod(whole)
+	// idle cards are taken first, highest index first
+	excluded := d.ExcludedCards("n1", MockQuotaPod("a", "p", 50))
+	assert.Equal(t, map[int]string{2: reasonWholeGPU, 1: reasonWholeGPU}, excluded)
+
+	pod := MockQuotaPod("a", "p", 100)
+	demand := NewDemandFromPod(pod)
+	assumed, _ := ni.Assume(demand, pod, d, PolicySpec{}, false)
+	assert.True(t, assumed)
+	assert.Equal(t, []int{0}, ni.PlanCache[planKey(demand, pod)].GPUIndexes)
+
+	whole.Status.Phase = v1.PodSucceeded
+	d.UpdateWholeGPUPod(whole)
+	assert.Empty(t, d.ExcludedCards("n1", pod))
+
+	d.UpdateWholeGPUPod(MockWholeGPUPod("w", 4))
+	assert.Len(t, d.ExcludedCards("n1", pod), 4)
+	d.ForgetWholeGPUPod(whole)
+	assert.Empty(t, d.WholeGPUPods)
+}
@@ -0,0 +1,166 @@
+package metrics
+
+import (
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "nano_gpu"
+
+var (
+	nodeFragmentationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "node", "fragmentation"),
+		"Share of free gpu percent on the node which can't be used by a single card demand.",
+		[]string{"node"}, nil,
+	)
+	clusterFragmentationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cluster", "fragmentation"),
+		"Share of free gpu percent in the cluster which can't be used by a single card demand.",
+		nil, nil,
+	)
+	clusterFreeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cluster", "free_percent"),
+		"Free gpu percent of all nodes known to the dealer.",
+		nil, nil,
+	)
+	usageBreakerOpenDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "node", "usage_breaker_open"),
+		"Whether the node is scheduled by request as its usage can't be trusted.",
+		[]string{"node"}, nil,
+	)
+	usageBreakerTripsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "node", "usage_breaker_trips_total"),
+		"Times the usage breaker of the node tripped.",
+		[]string{"node"}, nil,
+	)
+	shadowDecisionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "shadow", "decisions_total"),
+		"Bindings the shadow policy was compared on.",
+		nil, nil,
+	)
+	shadowAgreementsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "shadow", "agreements_total"),
+		"Bindings the shadow policy agreed with, on the node or on the node and the cards.",
+		[]string{"level"}, nil,
+	)
+	planWritesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bind", "plan_writes_total"),
+		"Patches writing the gpu plan of a pod on binding.",
+		nil, nil,
+	)
+	planWriteConflictsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bind", "plan_write_conflicts_total"),
+		"Plan patches which conflicted with a change of the pod and were retried.",
+		nil, nil,
+	)
+	queuedPodsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "queue", "pods"),
+		"Gpu pods waiting for gpu capacity.",
+		nil, nil,
+	)
+	requeueSignalsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "queue", "requeue_signals_total"),
+		"Pods waiting for gpu capacity signaled to retry as a node freed enough for them.",
+		nil, nil,
+	)
+	hintSkippedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "queue", "hint_skipped_plans_total"),
+		"Card plans skipped as the pod failed on the unchanged node in an earlier filtering.",
+		nil, nil,
+	)
+	armPodsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "experiment", "pods"),
+		"Bound gpu pods of the experiment arm.",
+		[]string{"arm"}, nil,
+	)
+	armBindingsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "experiment", "bindings_total"),
+		"Pods of the experiment arm bound since the start.",
+		[]string{"arm"}, nil,
+	)
+	armLatencyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "experiment", "scheduling_latency_seconds_total"),
+		"Time from the first filter to the binding summed over the bound pods of the experiment arm.",
+		[]string{"arm"}, nil,
+	)
+	armUtilizationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "experiment", "utilization"),
+		"Allocated share of the cards the pods of the experiment arm run on.",
+		[]string{"arm"}, nil,
+	)
+	armFragmentationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "experiment", "fragmentation"),
+		"Fragmentation of the free share on the cards the pods of the experiment arm run on.",
+		[]string{"arm"}, nil,
+	)
+)
+
+// DealerCollector exports the dealer state on every scrape, so the values are
+// never older than the dealer cache.
+type DealerCollector struct {
+	Dealer dealer.Dealer
+}
+
+func (c *DealerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- nodeFragmentationDesc
+	ch <- clusterFragmentationDesc
+	ch <- clusterFreeDesc
+	ch <- usageBreakerOpenDesc
+	ch <- usageBreakerTripsDesc
+	ch <- shadowDecisionsDesc
+	ch <- shadowAgreementsDesc
+	ch <- planWritesDesc
+	ch <- planWriteConflictsDesc
+	ch <- queuedPodsDesc
+	ch <- requeueSignalsDesc
+	ch <- hintSkippedDesc
+	ch <- armPodsDesc
+	ch <- armBindingsDesc
+	ch <- armLatencyDesc
+	ch <- armUtilizationDesc
+	ch <- armFragmentationDesc
+}
+
+func (c *DealerCollector) Collect(ch chan<- prometheus.Metric) {
+	report := c.Dealer.Fragmentation()
+	for node, f := range report.Nodes {
+		ch <- prometheus.MustNewConstMetric(nodeFragmentationDesc, prometheus.GaugeValue, f.Score, node)
+	}
+	ch <- prometheus.MustNewConstMetric(clusterFragmentationDesc, prometheus.GaugeValue, report.Cluster.Score)
+	ch <- prometheus.MustNewConstMetric(clusterFreeDesc, prometheus.GaugeValue, float64(report.Cluster.Free))
+	for node, b := range c.Dealer.UsageBreakers() {
+		open := 0.0
+		if b.Open {
+			open = 1
+		}
+		ch <- prometheus.MustNewConstMetric(usageBreakerOpenDesc, prometheus.GaugeValue, open, node)
+		ch <- prometheus.MustNewConstMetric(usageBreakerTripsDesc, prometheus.CounterValue, float64(b.Trips), node)
+	}
+	writes := c.Dealer.PlanWriteStatus()
+	ch <- prometheus.MustNewConstMetric(planWritesDesc, prometheus.CounterValue, float64(writes.Writes))
+	ch <- prometheus.MustNewConstMetric(planWriteConflictsDesc, prometheus.CounterValue, float64(writes.Conflicts))
+	ch <- prometheus.MustNewConstMetric(queuedPodsDesc, prometheus.GaugeValue, float64(len(c.Dealer.QueuedPods())))
+	ch <- prometheus.MustNewConstMetric(requeueSignalsDesc, prometheus.CounterValue, float64(c.Dealer.RequeueStatus().Signals))
+	ch <- prometheus.MustNewConstMetric(hintSkippedDesc, prometheus.CounterValue, float64(c.Dealer.HintStatus().Skipped))
+	if dealer.Shadow != nil {
+		shadow := c.Dealer.ShadowStatus()
+		ch <- prometheus.MustNewConstMetric(shadowDecisionsDesc, prometheus.CounterValue, float64(shadow.Decisions))
+		ch <- prometheus.MustNewConstMetric(shadowAgreementsDesc, prometheus.CounterValue, float64(shadow.NodeAgreements), "node")
+		ch <- prometheus.MustNewConstMetric(shadowAgreementsDesc, prometheus.CounterValue, float64(shadow.CardAgreements), "card")
+	}
+	if dealer.Experiment != nil {
+		for arm, stats := range c.Dealer.ArmStatus() {
+			ch <- prometheus.MustNewConstMetric(armPodsDesc, prometheus.GaugeValue, float64(stats.Pods), arm)
+			ch <- prometheus.MustNewConstMetric(armBindingsDesc, prometheus.CounterValue, float64(stats.Bindings), arm)
+			ch <- prometheus.MustNewConstMetric(armLatencyDesc, prometheus.CounterValue, stats.LatencySeconds, arm)
+			ch <- prometheus.MustNewConstMetric(armUtilizationDesc, prometheus.GaugeValue, stats.Utilization, arm)
+			ch <- prometheus.MustNewConstMetric(armFragmentationDesc, prometheus.GaugeValue, stats.Fragmentation, arm)
+		}
+	}
+}
+
+// Register registers all collectors of the scheduler to the default registry.
+func Register(d dealer.Dealer) {
+	prometheus.MustRegister(&DealerCollector{Dealer: d})
+}
@@ -82,3 +82,8 @@ func (p *PromConfig) QueryLasterData(nodeName, key, cardNum string) (metricValue
 	return val, nil
 }
 
+//QueryRawData query the latest value of a card as reported, without normalizing
+func (p *PromConfig) QueryRawData(nodeName, key, cardNum string) (metricValue string, err error) {
+	label := fmt.Sprintf("node=~\"%s\",card=\"%s\"", nodeName, cardNum)
+	return p.queryDataHelper(key + "{" + label + "}")
+}
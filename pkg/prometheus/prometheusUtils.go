@@ -7,6 +7,7 @@ import (
 //PromAPIS prometheus apis
 type PromAPIS interface {
 	QueryLasterData(nodeName, key, cardNum string) (metricValue string, err error)
+	QueryRawData(nodeName, key, cardNum string) (metricValue string, err error)
 }
 
 //PromConfig prometheus config
@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+)
+
+const faultsPrefix = "/debug/faults"
+
+// AddFaults serves the fault injection api: GET lists the armed faults, POST arms the
+// fault of the body and DELETE clears the fault of the point query, all by default.
+func AddFaults(router *httprouter.Router, d dealer.Dealer) {
+	router.GET(faultsPrefix, DebugLogging(FaultsRoute(d), faultsPrefix))
+	router.POST(faultsPrefix, DebugLogging(FaultsRoute(d), faultsPrefix))
+	router.DELETE(faultsPrefix, DebugLogging(FaultsRoute(d), faultsPrefix))
+}
+
+func FaultsRoute(d dealer.Dealer) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodPost:
+			fault := dealer.Fault{}
+			if r.Body == nil || json.NewDecoder(r.Body).Decode(&fault) != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte("{'error':'the body must be a fault'}"))
+				return
+			}
+			if err := d.InjectFault(fault); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(fmt.Sprintf("{'error':'%s'}", err.Error())))
+				return
+			}
+		case http.MethodDelete:
+			d.ClearFaults(r.URL.Query().Get("point"))
+		}
+		resultBody, err := json.Marshal(d.ArmedFaults())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(fmt.Sprintf("{'error':'%s'}", err.Error())))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(resultBody)
+	}
+}
@@ -0,0 +1,82 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+)
+
+const (
+	federationPrefix    = "/federation"
+	federationClusters  = federationPrefix + "/clusters"
+	federationPlacement = federationPrefix + "/placement"
+)
+
+// AddFederation serves the federation hub: the clusters POST their capacity to and
+// GET lists /federation/clusters, /federation/placement answers which clusters fit a
+// demand like /capacity does. The clusters must be allowed to post to the path by
+// the authorizer.
+func AddFederation(router *httprouter.Router, hub *dealer.FederationHub, a *SARAuthorizer) {
+	router.GET(federationClusters, DebugLogging(FederationClustersRoute(hub), federationClusters))
+	post := DebugLogging(FederationClustersRoute(hub), federationClusters)
+	router.Handler(http.MethodPost, federationClusters, Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		post(w, r, nil)
+	}), a))
+	router.GET(federationPlacement, DebugLogging(FederationPlacementRoute(hub), federationPlacement))
+}
+
+func FederationClustersRoute(hub *dealer.FederationHub) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			summary := &dealer.ClusterSummary{}
+			if r.Body == nil || json.NewDecoder(r.Body).Decode(summary) != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte("{'error':'the body must be a cluster summary'}"))
+				return
+			}
+			if err := hub.Update(summary); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(fmt.Sprintf("{'error':'%s'}", err.Error())))
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		resultBody, err := json.Marshal(hub.Clusters())
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(fmt.Sprintf("{'error':'%s'}", err.Error())))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(resultBody)
+	}
+}
+
+// FederationPlacementRoute answers which clusters fit the demand and how many of its
+// replicas, e.g. /federation/placement?percent=50&percent=20&max=10.
+func FederationPlacementRoute(hub *dealer.FederationHub) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		demand, maxReplicas, err := demandOfQuery(r.URL.Query(), 1)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf("{'error':'%s'}", err.Error())))
+			return
+		}
+		resultBody, err := json.Marshal(hub.Place(demand, maxReplicas, time.Now()))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(fmt.Sprintf("{'error':'%s'}", err.Error())))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(resultBody)
+	}
+}
@@ -0,0 +1,75 @@
+package routes
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+
+	log "k8s.io/klog/v2"
+)
+
+const usagePushPrefix = "/usage/push"
+
+// PushResult sums up a stream of pushed usage reports.
+type PushResult struct {
+	Accepted  int    `json:"accepted"`
+	Rejected  int    `json:"rejected"`
+	LastError string `json:"lastError,omitempty"`
+}
+
+// AddUsagePush lets node agents push their usage with the bearer token instead of
+// the usage being synced from prometheus.
+func AddUsagePush(router *httprouter.Router, d dealer.Dealer, token string) {
+	router.POST(usagePushPrefix, UsagePushRoute(d, token))
+}
+
+// UsagePushRoute takes a stream of newline delimited usage reports, an agent may
+// keep a single request open and write a report every interval.
+func UsagePushRoute(d dealer.Dealer, token string) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		bearer := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("{'error':'unauthorized'}"))
+			return
+		}
+		result := PushResult{}
+		decoder := json.NewDecoder(r.Body)
+		for {
+			var report dealer.UsageReport
+			err := decoder.Decode(&report)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(fmt.Sprintf("{'error':'invalid usage report: %s'}", err.Error())))
+				return
+			}
+			if err := d.PushUsage(report); err != nil {
+				log.V(4).Infof("reject usage report: %v", err)
+				result.Rejected++
+				result.LastError = err.Error()
+				continue
+			}
+			result.Accepted++
+		}
+		if resultBody, err := json.Marshal(result); err != nil {
+			log.Warning("failed due to ", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			errMsg := fmt.Sprintf("{'error':'%s'}", err.Error())
+			w.Write([]byte(errMsg))
+		} else {
+			w.WriteHeader(http.StatusOK)
+			w.Write(resultBody)
+		}
+	}
+}
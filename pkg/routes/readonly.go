@@ -0,0 +1,138 @@
+package routes
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	log "k8s.io/klog/v2"
+)
+
+// authorizationTTL is how long a decision on a token and path is reused.
+const authorizationTTL = 30 * time.Second
+
+// NewReadOnlyRouter serves the status and usage endpoints without the extender
+// ones, so that it can be exposed to people who must not schedule.
+func NewReadOnlyRouter(d dealer.Dealer) *httprouter.Router {
+	router := httprouter.New()
+	router.GET(statusPrefix, DebugLogging(StatusRoute(d), statusPrefix))
+	AddQuotaStatus(router, d)
+	AddCapacity(router, d)
+	AddBurstStatus(router, d)
+	AddHistory(router, d)
+	AddHottest(router, d)
+	AddHeatmap(router, d)
+	AddPlacements(router, d)
+	AddQueue(router, d)
+	AddAllocationHistory(router, d)
+	AddMetrics(router)
+	return router
+}
+
+type authorization struct {
+	allowed bool
+	until   time.Time
+}
+
+// SARAuthorizer authenticates the bearer token of a request with a TokenReview and
+// authorizes its method on its path with a SubjectAccessReview, like the apiserver
+// does for non-resource urls such as /metrics.
+type SARAuthorizer struct {
+	client kubernetes.Interface
+	lock   sync.Mutex
+	cache  map[string]authorization
+}
+
+func NewSARAuthorizer(client kubernetes.Interface) *SARAuthorizer {
+	return &SARAuthorizer{client: client, cache: make(map[string]authorization)}
+}
+
+// Authorize serves the requests the authorizer allows, others are rejected.
+func Authorize(h http.Handler, a *SARAuthorizer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || token == r.Header.Get("Authorization") {
+			http.Error(w, "bearer token required", http.StatusUnauthorized)
+			return
+		}
+		allowed, err := a.allowed(r.Context(), token, strings.ToLower(r.Method), r.URL.Path)
+		if err != nil {
+			log.Warningf("authorize %s failed: %v", r.URL.Path, err)
+			http.Error(w, "authorization failed", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (a *SARAuthorizer) allowed(ctx context.Context, token, verb, path string) (bool, error) {
+	sum := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(sum[:]) + verb + path
+	now := time.Now()
+	a.lock.Lock()
+	if c, ok := a.cache[key]; ok && now.Before(c.until) {
+		a.lock.Unlock()
+		return c.allowed, nil
+	}
+	a.lock.Unlock()
+
+	allowed, err := a.review(ctx, token, verb, path)
+	if err != nil {
+		return false, err
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	for k, c := range a.cache {
+		if !now.Before(c.until) {
+			delete(a.cache, k)
+		}
+	}
+	a.cache[key] = authorization{allowed: allowed, until: now.Add(authorizationTTL)}
+	return allowed, nil
+}
+
+func (a *SARAuthorizer) review(ctx context.Context, token, verb, path string) (bool, error) {
+	tr, err := a.client.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	if !tr.Status.Authenticated {
+		return false, nil
+	}
+	user := tr.Status.User
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	sar, err := a.client.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{Path: path, Verb: verb},
+			User:                  user.Username,
+			UID:                   user.UID,
+			Groups:                user.Groups,
+			Extra:                 extra,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return sar.Status.Allowed, nil
+}
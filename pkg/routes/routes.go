@@ -6,12 +6,20 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
 	"github.com/nano-gpu/nano-gpu-scheduler/pkg/scheduler"
+	schetypes "github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
 
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	log "k8s.io/klog/v2"
 	extender "k8s.io/kube-scheduler/extender/v1"
 )
@@ -23,7 +31,26 @@ const (
 	predicatesPrefix = apiPrefix + "/filter"
 	prioritiesPrefix = apiPrefix + "/priorities"
 
-	statusPrefix = "/status"
+	metricsPath       = "/metrics"
+	statusPrefix      = "/status"
+	quotaStatusPrefix = statusPrefix + "/quota"
+	burstStatusPrefix = statusPrefix + "/burst"
+	auditPrefix       = statusPrefix + "/audit"
+	historyPrefix     = statusPrefix + "/history"
+	hottestPrefix     = statusPrefix + "/hottest"
+	heatmapPrefix     = statusPrefix + "/heatmap"
+	queuePrefix       = statusPrefix + "/queue"
+	podCardsPath      = statusPrefix + "/pods/:namespace/:name"
+	cardPodsPath      = statusPrefix + "/nodes/:node/cards/:card"
+	cardHistoryPath   = cardPodsPath + "/history"
+	capacityPrefix    = "/capacity"
+	dryRunPrefix      = "/dryrun"
+
+	defaultCapacityReplicas = 1000
+	maxCapacityReplicas     = 1000
+	defaultHistoryWindow    = time.Hour
+	defaultHottestCards     = 10
+	defaultCardHistory      = 24 * time.Hour
 )
 
 var (
@@ -186,11 +213,11 @@ func DebugLogging(h httprouter.Handle, path string) httprouter.Handle {
 }
 
 func AddPredicate(router *httprouter.Router, predicate *scheduler.Predicate) {
-	router.POST(predicatesPrefix, DebugLogging(PredicateRoute(predicate), predicatesPrefix))
+	router.POST(predicatesPrefix, DebugLogging(Compress(PredicateRoute(predicate)), predicatesPrefix))
 }
 
 func AddPrioritize(router *httprouter.Router, prioritize *scheduler.Prioritize) {
-	router.POST(prioritiesPrefix, DebugLogging(PrioritizeRoute(prioritize), prioritiesPrefix))
+	router.POST(prioritiesPrefix, DebugLogging(Compress(PrioritizeRoute(prioritize)), prioritiesPrefix))
 }
 
 func AddBind(router *httprouter.Router, bind *scheduler.Bind) {
@@ -201,18 +228,48 @@ func AddBind(router *httprouter.Router, bind *scheduler.Bind) {
 	}
 }
 
+// AddExtender serves the filter, prioritize and bind endpoints of an extender profile
+// under the prefix, the default prefix when empty.
+func AddExtender(router *httprouter.Router, prefix string, predicate *scheduler.Predicate, prioritize *scheduler.Prioritize, bind *scheduler.Bind) {
+	if prefix == "" {
+		prefix = apiPrefix
+	}
+	filterPath, prioritizePath, bindPath := prefix+"/filter", prefix+"/priorities", prefix+"/bind"
+	router.POST(filterPath, DebugLogging(Compress(PredicateRoute(predicate)), filterPath))
+	router.POST(prioritizePath, DebugLogging(Compress(PrioritizeRoute(prioritize)), prioritizePath))
+	router.POST(bindPath, DebugLogging(BindRoute(bind), bindPath))
+}
+
 func AddStatus(router *httprouter.Router, d dealer.Dealer) {
 	if handle, _, _ := router.Lookup("GET", statusPrefix); handle != nil {
 		log.Warning("AddBind was called more then once!")
 	} else {
 		router.POST(statusPrefix, DebugLogging(StatusRoute(d), statusPrefix))
+		router.GET(statusPrefix, DebugLogging(StatusRoute(d), statusPrefix))
 	}
 }
 
+// StatusRoute returns the whole status, or with a query the page of the matching
+// nodes with the selected fields, see parseStatusQuery and selectFields.
 func StatusRoute(d dealer.Dealer) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-		nodeMaps, err := d.Status()
+		var (
+			status *dealer.Status
+			err    error
+		)
+		query := r.URL.Query()
 		w.Header().Set("Content-Type", "application/json")
+		if len(query) == 0 {
+			status, err = d.Status()
+		} else {
+			q, perr := parseStatusQuery(query)
+			if perr != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(fmt.Sprintf("{'error':'%s'}", perr.Error())))
+				return
+			}
+			status, err = d.QueryStatus(q)
+		}
 		if err != nil {
 			log.Warningf("failed to get status: %v", err)
 
@@ -222,7 +279,7 @@ func StatusRoute(d dealer.Dealer) httprouter.Handle {
 			return
 		}
 
-		if resultBody, err := json.Marshal(nodeMaps); err != nil {
+		if resultBody, err := selectFields(status, query.Get("fields"), query.Get("nodeFields")); err != nil {
 			log.Warning("failed due to ", err)
 			// panic(err)
 			w.Header().Set("Content-Type", "application/json")
@@ -238,3 +295,412 @@ func StatusRoute(d dealer.Dealer) httprouter.Handle {
 
 	}
 }
+
+func AddMetrics(router *httprouter.Router) {
+	router.Handler("GET", metricsPath, promhttp.Handler())
+}
+
+func AddQuotaStatus(router *httprouter.Router, d dealer.Dealer) {
+	router.GET(quotaStatusPrefix, DebugLogging(QuotaStatusRoute(d), quotaStatusPrefix))
+}
+
+func QuotaStatusRoute(d dealer.Dealer) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		if resultBody, err := json.Marshal(d.QuotaStatus()); err != nil {
+			log.Warning("failed due to ", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			errMsg := fmt.Sprintf("{'error':'%s'}", err.Error())
+			w.Write([]byte(errMsg))
+		} else {
+			w.WriteHeader(http.StatusOK)
+			w.Write(resultBody)
+		}
+	}
+}
+
+func AddBurstStatus(router *httprouter.Router, d dealer.Dealer) {
+	router.GET(burstStatusPrefix, DebugLogging(BurstStatusRoute(d), burstStatusPrefix))
+}
+
+// BurstStatusRoute exposes the burst pressure of every card so the runtime knows
+// where to reclaim ballooned memory.
+func BurstStatusRoute(d dealer.Dealer) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		if resultBody, err := json.Marshal(d.BurstPressure()); err != nil {
+			log.Warning("failed due to ", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			errMsg := fmt.Sprintf("{'error':'%s'}", err.Error())
+			w.Write([]byte(errMsg))
+		} else {
+			w.WriteHeader(http.StatusOK)
+			w.Write(resultBody)
+		}
+	}
+}
+
+func AddAudit(router *httprouter.Router, d dealer.Dealer) {
+	router.GET(auditPrefix, DebugLogging(AuditRoute(d), auditPrefix))
+}
+
+// AuditRoute diffs the cache against the pod annotations of the cluster.
+func AuditRoute(d dealer.Dealer) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		report, err := d.Audit()
+		if err != nil {
+			log.Warningf("failed to audit: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			errMsg := fmt.Sprintf("{'error':'%s'}", err.Error())
+			w.Write([]byte(errMsg))
+			return
+		}
+		if resultBody, err := json.Marshal(report); err != nil {
+			log.Warning("failed due to ", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			errMsg := fmt.Sprintf("{'error':'%s'}", err.Error())
+			w.Write([]byte(errMsg))
+		} else {
+			w.WriteHeader(http.StatusOK)
+			w.Write(resultBody)
+		}
+	}
+}
+
+func AddCapacity(router *httprouter.Router, d dealer.Dealer) {
+	router.GET(capacityPrefix, DebugLogging(CapacityRoute(d), capacityPrefix))
+}
+
+// demandOfQuery parses the shares of a hypothetical demand and the number of its
+// replicas asked for, e.g. ?percent=50&percent=20&max=10.
+func demandOfQuery(query url.Values, defaultReplicas int) (dealer.Demand, int, error) {
+	demand := dealer.Demand{}
+	for _, p := range query["percent"] {
+		percent, err := strconv.Atoi(p)
+		if err != nil || percent < 1 || percent > schetypes.GPUPercentEachCard {
+			return nil, 0, fmt.Errorf("invalid percent %s", p)
+		}
+		demand = append(demand, dealer.GPUResource{Percent: percent})
+	}
+	if len(demand) == 0 {
+		return nil, 0, fmt.Errorf("percent is required")
+	}
+	maxReplicas := defaultReplicas
+	if m := query.Get("max"); m != "" {
+		v, err := strconv.Atoi(m)
+		if err != nil || v < 1 {
+			return nil, 0, fmt.Errorf("invalid max %s", m)
+		}
+		maxReplicas = v
+	}
+	if maxReplicas > maxCapacityReplicas {
+		maxReplicas = maxCapacityReplicas
+	}
+	return demand, maxReplicas, nil
+}
+
+// CapacityRoute answers how many replicas of a hypothetical demand fit right now,
+// e.g. /capacity?percent=50&percent=20&max=10 for pods with two gpu containers.
+func CapacityRoute(d dealer.Dealer) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		demand, maxReplicas, err := demandOfQuery(r.URL.Query(), defaultCapacityReplicas)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf("{'error':'%s'}", err.Error())))
+			return
+		}
+
+		report, err := d.Capacity(demand, maxReplicas)
+		if err != nil {
+			log.Warningf("failed to get capacity: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			errMsg := fmt.Sprintf("{'error':'%s'}", err.Error())
+			w.Write([]byte(errMsg))
+			return
+		}
+		if resultBody, err := json.Marshal(report); err != nil {
+			log.Warning("failed due to ", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			errMsg := fmt.Sprintf("{'error':'%s'}", err.Error())
+			w.Write([]byte(errMsg))
+		} else {
+			w.WriteHeader(http.StatusOK)
+			w.Write(resultBody)
+		}
+	}
+}
+
+func AddDryRun(router *httprouter.Router, dryRun *scheduler.DryRun) {
+	router.POST(dryRunPrefix, DebugLogging(DryRunRoute(dryRun), dryRunPrefix))
+}
+
+// DryRunRoute filters and scores the pod of the body on the nodes of the query, all
+// nodes by default, e.g. /dryrun?node=n1&node=n2. Nothing is reserved for the pod.
+func DryRunRoute(dryRun *scheduler.DryRun) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		pod := &v1.Pod{}
+		if r.Body == nil || json.NewDecoder(r.Body).Decode(pod) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("{'error':'the body must be a pod'}"))
+			return
+		}
+		if pod.Namespace == "" {
+			pod.Namespace = metav1.NamespaceDefault
+		}
+		if pod.UID == "" {
+			pod.UID = types.UID(dryRunPrefix + "/" + pod.Namespace + "/" + pod.Name)
+		}
+		report, err := dryRun.Func(pod, r.URL.Query()["node"])
+		if err != nil {
+			log.Warningf("failed to dry run pod %s/%s: %v", pod.Namespace, pod.Name, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			errMsg := fmt.Sprintf("{'error':'%s'}", err.Error())
+			w.Write([]byte(errMsg))
+			return
+		}
+		if resultBody, err := json.Marshal(report); err != nil {
+			log.Warning("failed due to ", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			errMsg := fmt.Sprintf("{'error':'%s'}", err.Error())
+			w.Write([]byte(errMsg))
+		} else {
+			w.WriteHeader(http.StatusOK)
+			w.Write(resultBody)
+		}
+	}
+}
+
+func AddHistory(router *httprouter.Router, d dealer.Dealer) {
+	router.GET(historyPrefix, DebugLogging(HistoryRoute(d), historyPrefix))
+}
+
+// HistoryRoute summarizes the recent usage of the cards of a node, e.g.
+// /status/history?node=n1&metric=memory&window=30m&samples=true, the metric is
+// core by default and the window one hour.
+func HistoryRoute(d dealer.Dealer) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		query := r.URL.Query()
+		node := query.Get("node")
+		if node == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("{'error':'node is required'}"))
+			return
+		}
+		metric, ok := usageMetric(query.Get("metric"))
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf("{'error':'invalid metric %s'}", query.Get("metric"))))
+			return
+		}
+		window := defaultHistoryWindow
+		if v := query.Get("window"); v != "" {
+			var err error
+			if window, err = time.ParseDuration(v); err != nil || window <= 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(fmt.Sprintf("{'error':'invalid window %s'}", v)))
+				return
+			}
+		}
+
+		history, err := d.UsageHistory(node, metric, window, query.Get("samples") == "true")
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			errMsg := fmt.Sprintf("{'error':'%s'}", err.Error())
+			w.Write([]byte(errMsg))
+			return
+		}
+		if resultBody, err := json.Marshal(history); err != nil {
+			log.Warning("failed due to ", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			errMsg := fmt.Sprintf("{'error':'%s'}", err.Error())
+			w.Write([]byte(errMsg))
+		} else {
+			w.WriteHeader(http.StatusOK)
+			w.Write(resultBody)
+		}
+	}
+}
+
+// usageMetric maps the metric of a query to the usage metric, core by default.
+func usageMetric(metric string) (string, bool) {
+	switch metric {
+	case "", "core":
+		return dealer.GPUCoreUsagePriority, true
+	case "memory":
+		return dealer.GPUMemoryUsagePriority, true
+	}
+	return "", false
+}
+
+func AddHottest(router *httprouter.Router, d dealer.Dealer) {
+	router.GET(hottestPrefix, DebugLogging(HottestRoute(d), hottestPrefix))
+}
+
+// HottestRoute lists the most used cards of the cluster with their pods, e.g.
+// /status/hottest?metric=memory&k=5, the metric is core by default.
+func HottestRoute(d dealer.Dealer) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		query := r.URL.Query()
+		metric, ok := usageMetric(query.Get("metric"))
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf("{'error':'invalid metric %s'}", query.Get("metric"))))
+			return
+		}
+		k := defaultHottestCards
+		if v := query.Get("k"); v != "" {
+			var err error
+			if k, err = strconv.Atoi(v); err != nil || k < 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(fmt.Sprintf("{'error':'invalid k %s'}", v)))
+				return
+			}
+		}
+
+		if resultBody, err := json.Marshal(d.HottestCards(metric, k)); err != nil {
+			log.Warning("failed due to ", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			errMsg := fmt.Sprintf("{'error':'%s'}", err.Error())
+			w.Write([]byte(errMsg))
+		} else {
+			w.WriteHeader(http.StatusOK)
+			w.Write(resultBody)
+		}
+	}
+}
+
+func AddHeatmap(router *httprouter.Router, d dealer.Dealer) {
+	router.GET(heatmapPrefix, DebugLogging(HeatmapRoute(d), heatmapPrefix))
+}
+
+// HeatmapRoute exposes the allocation and the measured usage of every card by node.
+func HeatmapRoute(d dealer.Dealer) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		if resultBody, err := json.Marshal(d.Heatmap()); err != nil {
+			log.Warning("failed due to ", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			errMsg := fmt.Sprintf("{'error':'%s'}", err.Error())
+			w.Write([]byte(errMsg))
+		} else {
+			w.WriteHeader(http.StatusOK)
+			w.Write(resultBody)
+		}
+	}
+}
+
+func AddQueue(router *httprouter.Router, d dealer.Dealer) {
+	router.GET(queuePrefix, DebugLogging(QueueRoute(d), queuePrefix))
+}
+
+// QueueRoute lists the gpu pods blocked on gpu capacity with their demands and the
+// node closest to fit them, e.g. /status/queue.
+func QueueRoute(d dealer.Dealer) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		if resultBody, err := json.Marshal(d.QueuedPods()); err != nil {
+			log.Warning("failed due to ", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			errMsg := fmt.Sprintf("{'error':'%s'}", err.Error())
+			w.Write([]byte(errMsg))
+		} else {
+			w.WriteHeader(http.StatusOK)
+			w.Write(resultBody)
+		}
+	}
+}
+
+func AddPlacements(router *httprouter.Router, d dealer.Dealer) {
+	router.GET(podCardsPath, DebugLogging(PodCardsRoute(d), podCardsPath))
+	router.GET(cardPodsPath, DebugLogging(CardPodsRoute(d), cardPodsPath))
+}
+
+// PodCardsRoute returns the cards of a pod, e.g. /status/pods/default/train-0.
+func PodCardsRoute(d dealer.Dealer) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		placement, err := d.PodPlacement(ps.ByName("namespace"), ps.ByName("name"))
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			errMsg := fmt.Sprintf("{'error':'%s'}", err.Error())
+			w.Write([]byte(errMsg))
+			return
+		}
+		if resultBody, err := json.Marshal(placement); err != nil {
+			log.Warning("failed due to ", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			errMsg := fmt.Sprintf("{'error':'%s'}", err.Error())
+			w.Write([]byte(errMsg))
+		} else {
+			w.WriteHeader(http.StatusOK)
+			w.Write(resultBody)
+		}
+	}
+}
+
+// CardPodsRoute returns the pods of a card, e.g. /status/nodes/node-1/cards/3.
+func CardPodsRoute(d dealer.Dealer) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		card, err := strconv.Atoi(ps.ByName("card"))
+		if err != nil || card < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf("{'error':'invalid card %s'}", ps.ByName("card"))))
+			return
+		}
+		if resultBody, err := json.Marshal(d.CardPlacements(ps.ByName("node"), card)); err != nil {
+			log.Warning("failed due to ", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			errMsg := fmt.Sprintf("{'error':'%s'}", err.Error())
+			w.Write([]byte(errMsg))
+		} else {
+			w.WriteHeader(http.StatusOK)
+			w.Write(resultBody)
+		}
+	}
+}
+
+func AddAllocationHistory(router *httprouter.Router, d dealer.Dealer) {
+	router.GET(cardHistoryPath, DebugLogging(AllocationHistoryRoute(d), cardHistoryPath))
+}
+
+// AllocationHistoryRoute returns what was allocated and released on a card, e.g.
+// /status/nodes/node-1/cards/3/history?since=2021-06-01T00:00:00Z or since=2h, the
+// last day by default.
+func AllocationHistoryRoute(d dealer.Dealer) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json")
+		card, err := strconv.Atoi(ps.ByName("card"))
+		if err != nil || card < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf("{'error':'invalid card %s'}", ps.ByName("card"))))
+			return
+		}
+		since := time.Now().Add(-defaultCardHistory)
+		if v := r.URL.Query().Get("since"); v != "" {
+			if t, err := time.Parse(time.RFC3339, v); err == nil {
+				since = t
+			} else if ago, err := time.ParseDuration(v); err == nil {
+				since = time.Now().Add(-ago)
+			} else {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(fmt.Sprintf("{'error':'invalid since %s'}", v)))
+				return
+			}
+		}
+		if resultBody, err := json.Marshal(d.AllocationHistory(ps.ByName("node"), card, since)); err != nil {
+			log.Warning("failed due to ", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			errMsg := fmt.Sprintf("{'error':'%s'}", err.Error())
+			w.Write([]byte(errMsg))
+		} else {
+			w.WriteHeader(http.StatusOK)
+			w.Write(resultBody)
+		}
+	}
+}
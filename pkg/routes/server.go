@@ -0,0 +1,93 @@
+package routes
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// CompressResponses gzips the filter and prioritize responses for clients accepting it,
+// they list thousands of nodes on big clusters.
+var CompressResponses = false
+
+// ServerOptions tunes the http server of the extender.
+type ServerOptions struct {
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	KeepAlives   bool
+	// MaxConcurrentRequests bounds the requests handled at once, others wait for a
+	// slot until the client gives up. 0 leaves them unbounded.
+	MaxConcurrentRequests int
+}
+
+func NewServer(addr string, handler http.Handler, opts ServerOptions) *http.Server {
+	if opts.MaxConcurrentRequests > 0 {
+		handler = LimitConcurrency(handler, opts.MaxConcurrentRequests)
+	}
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       opts.ReadTimeout,
+		ReadHeaderTimeout: opts.ReadTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+	}
+	server.SetKeepAlivesEnabled(opts.KeepAlives)
+	return server
+}
+
+func LimitConcurrency(h http.Handler, n int) http.Handler {
+	slots := make(chan struct{}, n)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+			h.ServeHTTP(w, r)
+		case <-r.Context().Done():
+			http.Error(w, "too many concurrent requests", http.StatusServiceUnavailable)
+		}
+	})
+}
+
+var gzipWriters = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz *gzip.Writer
+}
+
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	return w.gz.Write(b)
+}
+
+// Compress gzips the response when enabled and accepted by the client.
+func Compress(h httprouter.Handle) httprouter.Handle {
+	if !CompressResponses {
+		return h
+	}
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			h(w, r, p)
+			return
+		}
+		gz := gzipWriters.Get().(*gzip.Writer)
+		defer gzipWriters.Put(gz)
+		gz.Reset(w)
+		defer gz.Close()
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Add("Vary", "Accept-Encoding")
+		h(&gzipResponseWriter{ResponseWriter: w, gz: gz}, r, p)
+	}
+}
@@ -0,0 +1,86 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// parseStatusQuery reads a status query like
+// ?selector=zone=a&pool=train&minAllocated=50&minCoreUsage=0.8&limit=100&continue=node-9.
+func parseStatusQuery(query url.Values) (dealer.StatusQuery, error) {
+	q := dealer.StatusQuery{Pool: query.Get("pool"), Continue: query.Get("continue")}
+	if v := query.Get("selector"); v != "" {
+		selector, err := labels.Parse(v)
+		if err != nil {
+			return q, fmt.Errorf("invalid selector %s", v)
+		}
+		q.Selector = selector
+	}
+	for name, value := range map[string]*int{"minAllocated": &q.MinAllocated, "maxAllocated": &q.MaxAllocated, "limit": &q.Limit} {
+		if v := query.Get(name); v != "" {
+			i, err := strconv.Atoi(v)
+			if err != nil || i < 0 {
+				return q, fmt.Errorf("invalid %s %s", name, v)
+			}
+			*value = i
+		}
+	}
+	if v := query.Get("minCoreUsage"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 1 {
+			return q, fmt.Errorf("invalid minCoreUsage %s", v)
+		}
+		q.MinCoreUsage = f
+	}
+	return q, nil
+}
+
+// selectFields marshals the status keeping only the sections of fields, like
+// nodes,pools, and the node fields of nodeFields, like GPUs,Pool. Empty keeps all.
+func selectFields(status *dealer.Status, fields, nodeFields string) ([]byte, error) {
+	if fields == "" && nodeFields == "" {
+		return json.Marshal(status)
+	}
+	data, err := json.Marshal(status)
+	if err != nil {
+		return nil, err
+	}
+	sections := map[string]interface{}{}
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return nil, err
+	}
+	keep := map[string]bool{"continue": true}
+	for _, f := range strings.Split(fields, ",") {
+		keep[strings.TrimSpace(f)] = true
+	}
+	keepNode := map[string]bool{}
+	for _, f := range strings.Split(nodeFields, ",") {
+		keepNode[strings.TrimSpace(f)] = true
+	}
+	for key, value := range sections {
+		// the nodes are at the top level, the other sections under the prefix
+		section := "nodes"
+		if strings.HasPrefix(key, dealer.StatusSectionPrefix) {
+			section = strings.TrimPrefix(key, dealer.StatusSectionPrefix)
+		}
+		if fields != "" && !keep[section] {
+			delete(sections, key)
+			continue
+		}
+		if node, ok := value.(map[string]interface{}); ok && section == "nodes" && nodeFields != "" {
+			for f := range node {
+				if !keepNode[f] {
+					delete(node, f)
+				}
+			}
+		}
+	}
+	return json.Marshal(sections)
+}
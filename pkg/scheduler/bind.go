@@ -48,8 +48,14 @@ func NewNanoGPUBind(ctx context.Context, clientset *kubernetes.Clientset, d deal
 				log.Warningf("warn: Failed to handle pod %s in ns %s due to error %v", name, namespace, err)
 				return err
 			}
+			if !dealer.Serves(pod) {
+				err = dealer.ForeignSchedulerError(pod)
+				log.Warningf("warn: Failed to handle pod %s in ns %s due to error %v", name, namespace, err)
+				return err
+			}
 
-			err = d.Bind(node, pod, policySpec, isLoadSchedule)
+			spec, load := dealer.ResolveProfile(pod, policySpec, isLoadSchedule)
+			err = d.Bind(node, pod, spec, load)
 			d.PrintStatus(pod, "bind")
 
 			return err
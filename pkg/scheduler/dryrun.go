@@ -0,0 +1,24 @@
+package scheduler
+
+import (
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/dealer"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// DryRun filters and scores a pod without scheduling it, to tell users why it does
+// or doesn't fit before they submit it.
+type DryRun struct {
+	Name string
+	Func func(pod *v1.Pod, nodeNames []string) (*dealer.DryRunReport, error)
+}
+
+func NewNanoGPUDryRun(d dealer.Dealer, policySpec dealer.PolicySpec, isLoadSchedule bool) *DryRun {
+	return &DryRun{
+		Name: "NanoGPUDryRun",
+		Func: func(pod *v1.Pod, nodeNames []string) (*dealer.DryRunReport, error) {
+			spec, load := dealer.ResolveProfile(pod, policySpec, isLoadSchedule)
+			return d.DryRun(nodeNames, pod, spec, load)
+		},
+	}
+}
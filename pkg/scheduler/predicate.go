@@ -44,9 +44,20 @@ func NewNanoGPUPredicate(ctx context.Context, clientset *kubernetes.Clientset, d
 	return &Predicate{
 		Name: "NanoGPUFilter",
 		Func: func(pod *v1.Pod, nodeNames []string, d dealer.Dealer) ([]bool, []error) {
-
+			if !dealer.Serves(pod) {
+				log.V(4).Infof("pod %s/%s targets scheduler %s, pass all nodes", pod.Namespace, pod.Name, pod.Spec.SchedulerName)
+				can := make([]bool, len(nodeNames))
+				for i := range can {
+					can[i] = true
+				}
+				return can, make([]error, len(nodeNames))
+			}
+			if !dealer.IsGPUPod(pod) {
+				return d.FilterNonGPU(nodeNames, pod)
+			}
 			log.Infof("Check if the pod %s/%s can be scheduled on nodes %v", pod.Namespace, pod.Name, nodeNames)
-			return d.Assume(nodeNames, pod, policySpec, isLoadSchedule)
+			spec, load := dealer.ResolveProfile(pod, policySpec, isLoadSchedule)
+			return d.Assume(nodeNames, pod, spec, load)
 		},
 		Dealer: d,
 	}
@@ -28,7 +28,14 @@ func NewNanoGPUPrioritize(ctx context.Context, clientset *kubernetes.Clientset,
 		Func: func(pod *v1.Pod, nodeNames []string) (*extender.HostPriorityList, error) {
 			var priorityList extender.HostPriorityList
 			priorityList = make([]extender.HostPriority, len(nodeNames))
-			scores := d.Score(nodeNames, pod, policySpec, isLoadSchedule)
+			if !dealer.IsGPUPod(pod) || !dealer.Serves(pod) {
+				for i, name := range nodeNames {
+					priorityList[i] = extender.HostPriority{Host: name}
+				}
+				return &priorityList, nil
+			}
+			spec, load := dealer.ResolveProfile(pod, policySpec, isLoadSchedule)
+			scores := d.Score(nodeNames, pod, spec, load)
 			for i, score := range scores {
 				priorityList[i] = extender.HostPriority{
 					Host:  nodeNames[i],
@@ -9,13 +9,204 @@ const (
 	ResourceGPUPercent v1.ResourceName = "nano-gpu/gpu-percent"
 	GPUPercentEachCard                 = 100
 
+	// ResourceAMDGPUPercent is the share of AMD gpus advertised next to the whole
+	// gpus of the ROCm device plugin.
+	ResourceAMDGPUPercent v1.ResourceName = "nano-gpu/amd-gpu-percent"
+	ResourceAMDGPU        v1.ResourceName = "amd.com/gpu"
+
+	// ResourceNvidiaGPU is the whole gpu of the nvidia device plugin.
+	ResourceNvidiaGPU v1.ResourceName = "nvidia.com/gpu"
+
+	LabelGPUVendor  = "nano-gpu/vendor"
+	GPUVendorNVIDIA = "nvidia"
+	GPUVendorAMD    = "amd"
+
 	GPUAssume                = "nano-gpu/assume"
 	AnnotationGPUAssume      = GPUAssume
 	LabelGPUAssume           = GPUAssume
 	AnnotationGPUContainerOn = "nano-gpu/container-%s"
+	// AnnotationGPUContainerAck is set by the device plugin to the card it really
+	// assigned to the container.
+	AnnotationGPUContainerAck = "nano-gpu/container-ack-%s"
+
+	// FinalizerGPURelease holds the deletion of a pod until its share is released.
+	FinalizerGPURelease = "nano-gpu/release"
+
+	// LabelGPUProduct is the gpu model label of gpu feature discovery.
+	LabelGPUProduct = "nvidia.com/gpu.product"
+	// LabelGPUComputeMajor and LabelGPUComputeMinor are the compute capability labels
+	// of gpu feature discovery.
+	LabelGPUComputeMajor = "nvidia.com/gpu.compute.major"
+	LabelGPUComputeMinor = "nvidia.com/gpu.compute.minor"
+	// LabelGPUMemory is the memory of a card in MiB, LabelGPUCount the number of cards
+	// and LabelMIGCapable whether they support MIG, as published by gpu feature discovery.
+	LabelGPUMemory  = "nvidia.com/gpu.memory"
+	LabelGPUCount   = "nvidia.com/gpu.count"
+	LabelMIGCapable = "nvidia.com/mig.capable"
+	// LabelKarpenterGPUName and LabelKarpenterGPUCount are the gpu model and count
+	// labels of the nodes provisioned by Karpenter.
+	LabelKarpenterGPUName  = "karpenter.k8s.aws/instance-gpu-name"
+	LabelKarpenterGPUCount = "karpenter.k8s.aws/instance-gpu-count"
+	// AnnotationComputeCapabilityMin is the lowest compute capability a pod accepts and
+	// AnnotationComputeCapabilityPreferred the one it prefers, as 8.0, 80 or sm_80.
+	AnnotationComputeCapabilityMin       = "nano-gpu/compute-capability-min"
+	AnnotationComputeCapabilityPreferred = "nano-gpu/compute-capability-preferred"
+	// LabelCUDARuntime* are the cuda version and LabelCUDADriver* the driver version
+	// labels of gpu feature discovery.
+	LabelCUDARuntimeMajor = "nvidia.com/cuda.runtime.major"
+	LabelCUDARuntimeMinor = "nvidia.com/cuda.runtime.minor"
+	LabelCUDADriverMajor  = "nvidia.com/cuda.driver.major"
+	LabelCUDADriverMinor  = "nvidia.com/cuda.driver.minor"
+	LabelCUDADriverRev    = "nvidia.com/cuda.driver.rev"
+	// AnnotationCUDAVersion and AnnotationDriverVersion override the versions of the
+	// labels on a node, on a pod they are the lowest versions it runs with.
+	AnnotationCUDAVersion   = "nano-gpu/cuda-version"
+	AnnotationDriverVersion = "nano-gpu/driver-version"
+
+	GPUPool                     = "nano-gpu/pool"
+	LabelGPUPool                = GPUPool
+	AnnotationGPUPool           = GPUPool
+	AnnotationGPUPoolNamespaces = "nano-gpu/pool-namespaces"
+
+	AnnotationGPUMovable       = "nano-gpu/movable"
+	AnnotationLatencySensitive = "nano-gpu/latency-sensitive"
+	// AnnotationCardAntiAffinity is a label selector of the pods of the namespace a
+	// pod must not share a card with, either way.
+	AnnotationCardAntiAffinity = "nano-gpu/card-anti-affinity"
+	// AnnotationCardAffinity names the pod of the namespace whose card a pod must be
+	// placed on.
+	AnnotationCardAffinity = "nano-gpu/card-affinity"
+	// AnnotationPreferredCard is the node and the card index or uuid a pod ran on before,
+	// as node/1 or node/GPU-<uuid>, to be placed there again while it is free.
+	AnnotationPreferredCard = "nano-gpu/preferred-card"
+
+	// LabelGPUMPS marks nodes running the MPS control daemon.
+	LabelGPUMPS                   = "nano-gpu/mps"
+	AnnotationMPSThreadPercentage = "nano-gpu/mps-active-thread-percentage-%s"
+
+	// AnnotationVGPUProfiles lists the vGPU profiles of a node with their percent.
+	AnnotationVGPUProfiles = "nano-gpu/vgpu-profiles"
+	AnnotationVGPUProfile  = "nano-gpu/vgpu-profile"
+
+	// AnnotationMIGProfiles lists the MIG profiles of the cards of a node with the
+	// instances per card, the layouts list the profile of every card.
+	AnnotationMIGProfiles      = "nano-gpu/mig-profiles"
+	AnnotationMIGLayout        = "nano-gpu/mig-layout"
+	AnnotationMIGLayoutDesired = "nano-gpu/mig-layout-desired"
+	AnnotationMIGProfile       = "nano-gpu/mig-profile"
+
+	// AnnotationGPUMemoryGuaranteed is the guaranteed memory percent of a container,
+	// its gpu percent is the burst ceiling.
+	AnnotationGPUMemoryGuaranteed = "nano-gpu/memory-guaranteed-%s"
+	// AnnotationGPUCoreRequest is the core request of a container, its gpu percent
+	// is the limit.
+	AnnotationGPUCoreRequest = "nano-gpu/core-request-%s"
+
+	// AnnotationGPUUsage is the usage report of a node written by its agent, the
+	// dealer takes it on every change.
+	AnnotationGPUUsage = "nano-gpu/usage"
+
+	// AnnotationMaintenance lists the maintenance windows of a node as start/end or
+	// start/duration in RFC3339, separated by commas.
+	AnnotationMaintenance = "nano-gpu/maintenance"
+	// AnnotationExpectedDuration is how long a pod is expected to run, as 2h. Pods
+	// without it are long-running unless owned by a Job.
+	AnnotationExpectedDuration = "nano-gpu/expected-duration"
+	// LabelSpot marks a spot node when its provider has no known capacity type label.
+	LabelSpot = "nano-gpu/spot"
+	// AnnotationTerminationNotice is set on a spot node by a termination handler once the
+	// node is to be reclaimed.
+	AnnotationTerminationNotice = "nano-gpu/termination-notice"
+	// AnnotationFaultTolerant marks a pod which survives losing its node, it prefers
+	// spot nodes.
+	AnnotationFaultTolerant = "nano-gpu/fault-tolerant"
+	// AnnotationSystemReserved is the share of every card of a node kept off the
+	// schedulable capacity, as core=5,memory=1Gi with the core in percent and the memory
+	// in percent or as a quantity.
+	AnnotationSystemReserved = "nano-gpu/system-reserved"
+	// LabelWorkloadProfile is the workload profile of a pod, like inference or training,
+	// its policy is the one of the profile in the policy config.
+	LabelWorkloadProfile = "nano-gpu/profile"
+	// AnnotationJobPlanning set to true on the replicas of a controller places them all
+	// at once rather than one by one.
+	AnnotationJobPlanning = "nano-gpu/job-planning"
+	// AnnotationMinReplicas and AnnotationMaxReplicas are the replica range of an
+	// elastic training job set on its pods, AnnotationGrantedReplicas the replicas of
+	// the range that fit right now, written back for the operator to start with.
+	AnnotationMinReplicas     = "nano-gpu/min-replicas"
+	AnnotationMaxReplicas     = "nano-gpu/max-replicas"
+	AnnotationGrantedReplicas = "nano-gpu/granted-replicas"
+	// AnnotationVolcanoGroupName is the pod group of a Volcano pod, LabelPodGroup and
+	// LabelPodGroupLegacy the pod group of a pod of the coscheduling plugin.
+	AnnotationVolcanoGroupName = "scheduling.k8s.io/group-name"
+	LabelPodGroup              = "scheduling.x-k8s.io/pod-group"
+	LabelPodGroupLegacy        = "pod-group.scheduling.sigs.k8s.io"
+	// LabelPlaceholder marks the whole gpu pods standing in for the pending gpu share
+	// of unschedulable pods, so that cluster autoscalers add nodes for them.
+	LabelPlaceholder = "nano-gpu/placeholder"
+	// AnnotationPlaceholderHint is the gpu model label, the model and the number of
+	// cards a placeholder pod stands for, as label=model/count.
+	AnnotationPlaceholderHint = "nano-gpu/placeholder-hint"
+	// AnnotationGPUExcluded lists the card indexes of a node never to allocate, as 0,3.
+	AnnotationGPUExcluded = "nano-gpu/excluded-gpus"
+	// AnnotationGPUUUIDs lists the uuid of every card of a node by index, plans
+	// record the uuid of the card of a container so they survive re-enumeration.
+	AnnotationGPUUUIDs         = "nano-gpu/gpu-uuids"
+	AnnotationGPUContainerUUID = "nano-gpu/container-uuid-%s"
+	// AnnotationGPUPlan is the versioned structured plan of a pod.
+	AnnotationGPUPlan = "nano-gpu/plan"
 )
 
 const (
 	PriorityBinPack string = "binpack"
 	PrioritySpread  string = "spread"
+
+	PriorityThermalSpread string = "thermal-spread"
+	// PriorityRequestedToCapacityRatio scores the utilization of nodes through a shape.
+	PriorityRequestedToCapacityRatio string = "requested-to-capacity-ratio"
+	// PriorityConsolidation keeps the number of gpus in use minimal.
+	PriorityConsolidation string = "consolidation"
+	// PriorityBalanced avoids leaving cards with skewed free core and memory.
+	PriorityBalanced string = "balanced"
+	// PriorityCardSpread packs nodes and spreads pods over the cards of a node.
+	PriorityCardSpread string = "card-spread"
 )
+
+const (
+	ElasticQuotaGroup    = "nano-gpu.io"
+	ElasticQuotaVersion  = "v1alpha1"
+	ElasticQuotaResource = "elasticgpuquotas"
+)
+
+const (
+	KueueGroup            = "kueue.x-k8s.io"
+	KueueVersion          = "v1beta1"
+	KueueWorkloadResource = "workloads"
+	KueueFlavorResource   = "resourceflavors"
+	// LabelKueueQueueName is the local queue a pod or its job is submitted to.
+	LabelKueueQueueName = "kueue.x-k8s.io/queue-name"
+	// AnnotationFlavorUsage is the gpu share of the nodes of a ResourceFlavor.
+	AnnotationFlavorUsage = "nano-gpu/flavor-usage"
+)
+
+const (
+	AllocationResource = "nanogpuallocations"
+	AllocationKind     = "NanoGPUAllocation"
+	// LabelAllocationNode is the node of a NanoGPUAllocation.
+	LabelAllocationNode = "nano-gpu/node"
+)
+
+const (
+	// ShardLeasePrefix prefixes the Lease recording the scheduler owning a shard.
+	ShardLeasePrefix = "nano-gpu-shard-"
+	// AnnotationShardSelector is the node selector of the shard on its Lease.
+	AnnotationShardSelector = "nano-gpu/shard-selector"
+	// ShardAssumedSuffix suffixes the ConfigMap of a shard holding the pods in flight,
+	// under the ShardAssumedKey.
+	ShardAssumedSuffix = "-assumed"
+	ShardAssumedKey    = "assumptions"
+)
+
+// AnnotationRequeue is set on a pod waiting for gpu capacity when a node freed enough
+// for it, the update makes the scheduler retry the pod before its backoff expires.
+const AnnotationRequeue = "nano-gpu/requeue"
@@ -1,14 +1,14 @@
 package utils
 
 import (
-	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/accelerator"
 	v1 "k8s.io/api/core/v1"
 )
 
 func GetGPUDeviceCountOfNode(node *v1.Node) int {
-	val, ok := node.Status.Capacity[types.ResourceGPUPercent]
-	if !ok {
+	a := accelerator.ForNode(node)
+	if a == nil {
 		return 0
 	}
-	return int(val.Value()) / types.GPUPercentEachCard
+	return a.DeviceCount(node)
 }
@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/nano-gpu/nano-gpu-scheduler/pkg/accelerator"
 	"github.com/nano-gpu/nano-gpu-scheduler/pkg/types"
 
 	v1 "k8s.io/api/core/v1"
@@ -23,40 +24,76 @@ func IsCompletedPod(pod *v1.Pod) bool {
 	return false
 }
 
+// IsContainersTerminated determines if every container of the pod has terminated
+func IsContainersTerminated(pod *v1.Pod) bool {
+	for _, s := range pod.Status.ContainerStatuses {
+		if s.State.Terminated == nil && s.State.Waiting == nil {
+			return false
+		}
+	}
+	return true
+}
+
 // IsGPUSharingPod determines if it's the pod for GPU sharing
 func IsGPUSharingPod(pod *v1.Pod) bool {
-	return GetGPUPercentFromPodResource(pod) > 0
+	return GetGPUPercentFromPodResource(pod) > 0 || GetGPUPercentFromInitContainers(pod) > 0
 }
 
-// GetGPUIDFromAnnotation gets GPU ID from Annotation
+// GetGPUIDFromAnnotation gets the GPU IDs of all containers from Annotation
 func GetGPUIDFromAnnotation(pod *v1.Pod) (gpuIDs []int) {
-	if len(pod.ObjectMeta.Annotations) > 0 {
-		value, found := pod.ObjectMeta.Annotations[fmt.Sprintf(types.AnnotationGPUContainerOn, pod.Spec.Containers[0].Name)]
-		if found {
-			gpuIDStrs := strings.Split(value, ",")
-			for _, idStr := range gpuIDStrs {
-				id, err := strconv.Atoi(idStr)
-				if err != nil {
-					log.Warningf("warn: Failed due to %v for pod %s in ns %s", err, pod.Name, pod.Namespace)
-				}
-				gpuIDs = append(gpuIDs, id)
-			}
+	if len(pod.ObjectMeta.Annotations) == 0 {
+		return
+	}
+	a := accelerator.ForPod(pod)
+	for _, c := range pod.Spec.Containers {
+		value, found := pod.ObjectMeta.Annotations[a.ContainerAnnotation(c.Name)]
+		if !found {
+			continue
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			log.Warningf("warn: Failed due to %v for pod %s in ns %s", err, pod.Name, pod.Namespace)
+			continue
 		}
+		// containers without gpu
+		if id < 0 {
+			continue
+		}
+		gpuIDs = append(gpuIDs, id)
 	}
-
 	return gpuIDs
 }
 
 func GetGPUPercentFromPodResource(pod *v1.Pod) (gpuPercent uint) {
 	containers := pod.Spec.Containers
-	for _, container := range containers {
-		if val, ok := container.Resources.Limits[types.ResourceGPUPercent]; ok {
-			gpuPercent += uint(val.Value())
-		}
+	for i := range containers {
+		gpuPercent += uint(GetGPUPercentFromContainer(&containers[i]))
 	}
 	return gpuPercent
 }
 
+// GetGPUPercentFromInitContainers returns the share of the largest init container,
+// init containers run one at a time.
+func GetGPUPercentFromInitContainers(pod *v1.Pod) int {
+	percent := 0
+	for i := range pod.Spec.InitContainers {
+		if p := GetGPUPercentFromContainer(&pod.Spec.InitContainers[i]); p > percent {
+			percent = p
+		}
+	}
+	return percent
+}
+
+// IsInitialized determines if all init containers of the pod have completed
+func IsInitialized(pod *v1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == v1.PodInitialized {
+			return c.Status == v1.ConditionTrue
+		}
+	}
+	return pod.Status.Phase == v1.PodRunning || pod.Status.Phase == v1.PodSucceeded
+}
+
 func arrayToString(array []int, delim string) string {
 	return strings.Trim(strings.Join(strings.Fields(fmt.Sprint(array)), ","), "[]")
 }
@@ -70,8 +107,9 @@ func GetUpdatedPodAnnotationSpec(oldPod *v1.Pod, indexes []int) (newPod *v1.Pod)
 	if len(newPod.Annotations) == 0 {
 		newPod.Annotations = map[string]string{}
 	}
+	a := accelerator.ForPod(newPod)
 	for i, container := range newPod.Spec.Containers {
-		newPod.Annotations[fmt.Sprintf(types.AnnotationGPUContainerOn, container.Name)] = strconv.Itoa(indexes[i]) // 1,2,3
+		newPod.Annotations[a.ContainerAnnotation(container.Name)] = strconv.Itoa(indexes[i]) // 1,2,3
 	}
 	newPod.Annotations[types.AnnotationGPUAssume] = "true"
 	newPod.Labels[types.LabelGPUAssume] = "true"
@@ -83,7 +121,7 @@ func IsAssumed(pod *v1.Pod) bool {
 }
 
 func GetContainerAssignIndex(pod *v1.Pod, containerName string) (int, error) {
-	key := fmt.Sprintf(types.AnnotationGPUContainerOn, containerName)
+	key := accelerator.ForPod(pod).ContainerAnnotation(containerName)
 	val, ok := pod.Annotations[key]
 	if !ok {
 		return 0, fmt.Errorf("pod's annotation %v doesn't contain container %s", pod.Annotations, containerName)
@@ -91,10 +129,39 @@ func GetContainerAssignIndex(pod *v1.Pod, containerName string) (int, error) {
 	return strconv.Atoi(val)
 }
 
-func GetGPUPercentFromContainer(container *v1.Container) int {
-	val, ok := container.Resources.Limits[types.ResourceGPUPercent]
+// GetContainerAckIndex returns the card the device plugin acknowledged for the container
+func GetContainerAckIndex(pod *v1.Pod, containerName string) (int, error) {
+	val, ok := pod.Annotations[fmt.Sprintf(types.AnnotationGPUContainerAck, containerName)]
 	if !ok {
-		return 0
+		return 0, fmt.Errorf("pod's annotation %v doesn't contain ack of container %s", pod.Annotations, containerName)
+	}
+	return strconv.Atoi(val)
+}
+
+func GetGPUPercentFromContainer(container *v1.Container) int {
+	// a container requests the share of a single accelerator, the dealer fails pods
+	// asking for several
+	for _, name := range accelerator.PercentResources() {
+		if val, ok := container.Resources.Limits[name]; ok {
+			return int(val.Value())
+		}
+	}
+	return 0
+}
+
+// GetWholeGPUCountFromPodResource returns the whole gpus requested from the nvidia
+// device plugin, the largest init container counts as it runs alone.
+func GetWholeGPUCountFromPodResource(pod *v1.Pod) int {
+	count := 0
+	for _, c := range pod.Spec.Containers {
+		if val, ok := c.Resources.Limits[types.ResourceNvidiaGPU]; ok {
+			count += int(val.Value())
+		}
+	}
+	for _, c := range pod.Spec.InitContainers {
+		if val, ok := c.Resources.Limits[types.ResourceNvidiaGPU]; ok && int(val.Value()) > count {
+			count = int(val.Value())
+		}
 	}
-	return int(val.Value())
+	return count
 }